@@ -61,6 +61,16 @@ func (h *CommandHistory) Execute(cmd EditorCommand, editor *EditorWidget) error
 	return nil
 }
 
+// CanUndo сообщает, есть ли команда для отмены
+func (h *CommandHistory) CanUndo() bool {
+	return h.currentIndex >= 0
+}
+
+// CanRedo сообщает, есть ли команда для повтора
+func (h *CommandHistory) CanRedo() bool {
+	return h.currentIndex < len(h.commands)-1
+}
+
 // Undo отменяет последнюю команду
 func (h *CommandHistory) Undo(editor *EditorWidget) error {
 	if h.currentIndex < 0 {
@@ -83,9 +93,13 @@ func (h *CommandHistory) Redo(editor *EditorWidget) error {
 		return fmt.Errorf("nothing to redo")
 	}
 
+	cmd := h.commands[h.currentIndex+1]
+	if err := cmd.Execute(editor); err != nil {
+		return err
+	}
+
 	h.currentIndex++
-	cmd := h.commands[h.currentIndex]
-	return cmd.Execute(editor)
+	return nil
 }
 
 // InsertTextCommand - команда вставки текста
@@ -230,6 +244,58 @@ func (c *ReplaceTextCommand) GetDescription() string {
 	return fmt.Sprintf("Replace '%s' with '%s'", c.findText, c.replaceText)
 }
 
+// EditTextCommand - замена диапазона текста по смещениям; через нее проходят
+// правки режимов vim и emacs, соседние правки можно слить в один шаг отмены
+type EditTextCommand struct {
+	start   int
+	removed string // текст, который был на месте вставки
+	text    string // вставленный текст
+}
+
+func (c *EditTextCommand) Execute(editor *EditorWidget) error {
+	end := c.start + len(c.removed)
+	if end > len(editor.textContent) || editor.textContent[c.start:end] != c.removed {
+		return fmt.Errorf("text has changed since the edit")
+	}
+	editor.textContent = editor.textContent[:c.start] + c.text + editor.textContent[end:]
+	editor.moveCursorToIndex(c.start + len(c.text))
+	editor.updateDisplay()
+	return nil
+}
+
+func (c *EditTextCommand) Undo(editor *EditorWidget) error {
+	end := c.start + len(c.text)
+	if end > len(editor.textContent) || editor.textContent[c.start:end] != c.text {
+		return fmt.Errorf("text has changed since the edit")
+	}
+	editor.textContent = editor.textContent[:c.start] + c.removed + editor.textContent[end:]
+	editor.moveCursorToIndex(c.start)
+	editor.updateDisplay()
+	return nil
+}
+
+func (c *EditTextCommand) GetDescription() string {
+	if c.text == "" {
+		return fmt.Sprintf("Delete %d characters", len(c.removed))
+	}
+	return fmt.Sprintf("Edit %d characters", len(c.text))
+}
+
+// absorb сливает следующую правку next, если она касается текста этой;
+// content - текст после этой правки и до next
+func (c *EditTextCommand) absorb(next *EditTextCommand, content string) bool {
+	end := c.start + len(c.text)
+	nextEnd := next.start + len(next.removed)
+	if next.start > end || nextEnd < c.start {
+		return false
+	}
+	from, to := min(c.start, next.start), max(end, nextEnd)
+	c.removed = content[from:c.start] + c.removed + content[end:to]
+	c.text = content[from:next.start] + next.text + content[nextEnd:to]
+	c.start = from
+	return true
+}
+
 // FormatCodeCommand - команда форматирования кода
 type FormatCodeCommand struct {
 	language string
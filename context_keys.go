@@ -0,0 +1,234 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ContextKeys - реестр ключей контекста для условий привязок (when).
+// Компоненты публикуют ключи: изменяемые по событию - через Set,
+// вычисляемые из своего состояния - через SetProvider.
+type ContextKeys struct {
+	mutex        sync.RWMutex
+	values       map[string]interface{}
+	providers    map[string]func() interface{}
+	descriptions map[string]string
+}
+
+// NewContextKeys создает пустой реестр ключей контекста
+func NewContextKeys() *ContextKeys {
+	return &ContextKeys{
+		values:       make(map[string]interface{}),
+		providers:    make(map[string]func() interface{}),
+		descriptions: make(map[string]string),
+	}
+}
+
+// Set устанавливает значение ключа (bool, string или число)
+func (ck *ContextKeys) Set(key string, value interface{}) {
+	ck.mutex.Lock()
+	defer ck.mutex.Unlock()
+	ck.values[key] = value
+}
+
+// Reset удаляет значение ключа
+func (ck *ContextKeys) Reset(key string) {
+	ck.mutex.Lock()
+	defer ck.mutex.Unlock()
+	delete(ck.values, key)
+}
+
+// SetProvider регистрирует функцию, вычисляющую значение ключа при проверке условия
+func (ck *ContextKeys) SetProvider(key, description string, provider func() interface{}) {
+	ck.mutex.Lock()
+	defer ck.mutex.Unlock()
+	ck.providers[key] = provider
+	ck.descriptions[key] = description
+}
+
+// Describe добавляет описание ключа, значение которого задается через Set
+func (ck *ContextKeys) Describe(key, description string) {
+	ck.mutex.Lock()
+	defer ck.mutex.Unlock()
+	ck.descriptions[key] = description
+}
+
+// Get возвращает значение ключа; nil - ключ не установлен
+func (ck *ContextKeys) Get(key string) interface{} {
+	ck.mutex.RLock()
+	provider, ok := ck.providers[key]
+	value := ck.values[key]
+	ck.mutex.RUnlock()
+
+	if ok {
+		return provider()
+	}
+	return value
+}
+
+// Known сообщает, публикует ли кто-нибудь этот ключ
+func (ck *ContextKeys) Known(key string) bool {
+	ck.mutex.RLock()
+	defer ck.mutex.RUnlock()
+	_, described := ck.descriptions[key]
+	_, set := ck.values[key]
+	return described || set
+}
+
+// Keys возвращает отсортированный список известных ключей с описаниями
+func (ck *ContextKeys) Keys() []string {
+	ck.mutex.RLock()
+	defer ck.mutex.RUnlock()
+	keys := make([]string, 0, len(ck.descriptions))
+	for key := range ck.descriptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Description возвращает описание ключа
+func (ck *ContextKeys) Description(key string) string {
+	ck.mutex.RLock()
+	defer ck.mutex.RUnlock()
+	return ck.descriptions[key]
+}
+
+// Публикация ключей компонентами
+
+// publishContextKeys публикует состояние редактора: выделение, язык, файл и режим Vim
+func (e *EditorWidget) publishContextKeys(ck *ContextKeys) {
+	ck.SetProvider("editorHasSelection", "Editor has a non-empty selection", func() interface{} {
+		if vh := e.vimHandler; vh != nil && (vh.mode == VimVisual || vh.mode == VimVisualLine || vh.mode == VimVisualBlock) {
+			return true
+		}
+		return e.content != nil && e.content.SelectedText() != ""
+	})
+	ck.SetProvider("editorHasMultipleSelections", "Editor has extra cursors", func() interface{} {
+		return len(e.cursors) > 0
+	})
+	ck.SetProvider("editorIsDirty", "Current file has unsaved changes", func() interface{} {
+		return e.isDirty
+	})
+	ck.SetProvider("editorReadonly", "Editor is read-only", func() interface{} {
+		return e.content != nil && e.content.Disabled()
+	})
+	ck.SetProvider("language", "Language of the current file (go, python, markdown ...)", func() interface{} {
+		return e.language
+	})
+	ck.SetProvider("editorLangId", "Alias of language", func() interface{} {
+		return e.language
+	})
+	ck.SetProvider("resourceFilename", "File name of the current file", func() interface{} {
+		if e.filePath == "" {
+			return ""
+		}
+		return filepath.Base(e.filePath)
+	})
+	ck.SetProvider("resourceExtname", "Extension of the current file, including the dot", func() interface{} {
+		return filepath.Ext(e.filePath)
+	})
+	ck.SetProvider("vimMode", "Vim mode: normal, insert, visual, visual_line, visual_block, command, replace", func() interface{} {
+		if e.vimHandler == nil {
+			return ""
+		}
+		return strings.ToLower(strings.ReplaceAll(e.vimHandler.GetModeString(), "-", "_"))
+	})
+	ck.SetProvider("vimInsert", "Vim is in Insert mode", func() interface{} {
+		return e.vimHandler != nil && e.vimHandler.mode == VimInsert
+	})
+	ck.SetProvider("vimNormal", "Vim is in Normal mode", func() interface{} {
+		return e.vimHandler != nil && e.vimHandler.mode == VimNormal
+	})
+	ck.SetProvider("vimVisual", "Vim is in one of the Visual modes", func() interface{} {
+		vh := e.vimHandler
+		return vh != nil && (vh.mode == VimVisual || vh.mode == VimVisualLine || vh.mode == VimVisualBlock)
+	})
+}
+
+// publishContextKeys публикует видимость боковой панели и выбранный в ней элемент
+func (s *SidebarWidget) publishContextKeys(ck *ContextKeys) {
+	ck.SetProvider("sidebarVisible", "Sidebar is visible", func() interface{} {
+		return s.isVisible
+	})
+	ck.SetProvider("explorerResourceIsFolder", "Item selected in the sidebar is a folder", func() interface{} {
+		if s.selectedFile == "" {
+			return false
+		}
+		info, err := os.Stat(s.selectedFile)
+		return err == nil && info.IsDir()
+	})
+}
+
+// publishContextKeys публикует состояние терминалов
+func (tm *TerminalManager) publishContextKeys(ck *ContextKeys) {
+	ck.SetProvider("terminalVisible", "Terminal is visible", func() interface{} {
+		return tm.IsVisible()
+	})
+	ck.SetProvider("terminalCount", "Number of open terminals", func() interface{} {
+		return len(tm.GetTerminals())
+	})
+}
+
+// publishContextKeys публикует открытые диалоги (оверлеи окна)
+func (dm *DialogManager) publishContextKeys(ck *ContextKeys) {
+	ck.SetProvider("dialogOpen", "A dialog or popup is open", func() interface{} {
+		return dm.mainWindow.Canvas().Overlays().Top() != nil
+	})
+}
+
+// updateFocusKeys обновляет ключи фокуса по виджету, получившему фокус
+func (hm *HotkeyManager) updateFocusKeys(obj fyne.Focusable) {
+	ck := hm.contextKeys
+	_, editorFocus := obj.(*editorEntry)
+	_, entryFocus := obj.(*widget.Entry)
+	_, findFocus := obj.(*findBarEntry)
+	_, sidebarFocus := obj.(*SidebarWidget)
+	_, minimapFocus := obj.(*MinimapWidget)
+
+	ck.Set("editorFocus", editorFocus)
+	ck.Set("editorTextFocus", editorFocus)
+	ck.Set("inputFocus", editorFocus || entryFocus || findFocus)
+	ck.Set("findInputFocus", findFocus)
+	ck.Set("sidebarFocus", sidebarFocus)
+	ck.Set("minimapFocus", minimapFocus)
+	ck.Set("inputMode", map[InputMode]string{ModeVim: "vim", ModeEmacs: "emacs"}[hm.currentMode])
+}
+
+// registerContextKeys описывает ключи менеджера клавиш и подключает компоненты приложения
+func (hm *HotkeyManager) registerContextKeys() {
+	ck := hm.contextKeys
+	ck.Describe("editorFocus", "Editor has keyboard focus")
+	ck.Describe("editorTextFocus", "Alias of editorFocus")
+	ck.Describe("inputFocus", "A text input has keyboard focus")
+	ck.Describe("findInputFocus", "An input of the find bar has keyboard focus")
+	ck.Describe("sidebarFocus", "Sidebar has keyboard focus")
+	ck.Describe("minimapFocus", "Minimap has keyboard focus")
+	ck.Describe("inputMode", "Key binding mode: vim, emacs or empty")
+	hm.updateFocusKeys(nil)
+
+	if hm.app == nil {
+		return
+	}
+	if hm.app.editor != nil {
+		hm.app.editor.publishContextKeys(ck)
+	}
+	if hm.app.findBar != nil {
+		hm.app.findBar.publishContextKeys(ck)
+	}
+	if hm.app.sidebar != nil {
+		hm.app.sidebar.publishContextKeys(ck)
+	}
+	if hm.app.terminalMgr != nil {
+		hm.app.terminalMgr.publishContextKeys(ck)
+	}
+	if hm.app.dialogManager != nil {
+		hm.app.dialogManager.publishContextKeys(ck)
+	}
+}
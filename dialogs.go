@@ -18,6 +18,8 @@ type DialogManager struct {
 	mainWindow fyne.Window
 	editor     *EditorWidget
 	config     *Config
+
+	newShortcutEditor func() *shortcutEditor // редактор горячих клавиш для вкладки настроек
 }
 
 // NewDialogManager создает новый менеджер диалогов
@@ -86,108 +88,69 @@ func (dm *DialogManager) ShowSaveFileDialog(callback func(string)) {
 	fileDialog.Show()
 }
 
-// ShowFindDialog показывает диалог поиска
-func (dm *DialogManager) ShowFindDialog(onFind func(string, bool, bool, bool)) {
-	var searchEntry *widget.Entry
-	var caseSensitiveCheck *widget.Check
-	var wholeWordCheck *widget.Check
-	var regexCheck *widget.Check
-
-	searchEntry = widget.NewEntry()
-	searchEntry.SetPlaceHolder("Enter text to find...")
-
-	caseSensitiveCheck = widget.NewCheck("Case sensitive", nil)
-	wholeWordCheck = widget.NewCheck("Whole words only", nil)
-	regexCheck = widget.NewCheck("Use regular expressions", nil)
-
-	// Загружаем настройки из конфигурации
-	if dm.config != nil {
-		caseSensitiveCheck.SetChecked(dm.config.Editor.SearchCaseSensitive)
-		wholeWordCheck.SetChecked(dm.config.Editor.SearchWholeWord)
-		regexCheck.SetChecked(dm.config.Editor.SearchRegex)
-	}
-
-	content := container.NewVBox(
-		searchEntry,
-		container.NewHBox(
-			caseSensitiveCheck,
-			wholeWordCheck,
-			regexCheck,
-		),
-	)
+// ShowOpenFolderDialog показывает диалог выбора папки
+func (dm *DialogManager) ShowOpenFolderDialog(callback func(string)) {
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, dm.mainWindow)
+			return
+		}
+		if dir == nil {
+			return
+		}
 
-	findDialog := dialog.NewCustomConfirm("Find", "Find", "Cancel", content, func(confirmed bool) {
-		if confirmed && searchEntry.Text != "" {
-			if onFind != nil {
-				onFind(searchEntry.Text,
-					caseSensitiveCheck.Checked,
-					wholeWordCheck.Checked,
-					regexCheck.Checked)
-			}
+		if callback != nil {
+			callback(dir.Path())
 		}
 	}, dm.mainWindow)
-
-	findDialog.Show()
-	AnimateShow(content)
-
-	// Фокус на поле ввода
-	dm.mainWindow.Canvas().Focus(searchEntry)
 }
 
-// ShowReplaceDialog показывает диалог замены
-func (dm *DialogManager) ShowReplaceDialog(onReplace func(string, string, bool, bool, bool, bool)) {
-	var findEntry, replaceEntry *widget.Entry
-	var caseSensitiveCheck, wholeWordCheck, regexCheck, replaceAllCheck *widget.Check
-
-	findEntry = widget.NewEntry()
-	findEntry.SetPlaceHolder("Find what...")
+// ShowOpenFileDialogWithFilter показывает диалог открытия файла с заданными расширениями
+func (dm *DialogManager) ShowOpenFileDialogWithFilter(extensions []string, callback func(string)) {
+	fileDialog := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, dm.mainWindow)
+			return
+		}
+		if file == nil {
+			return
+		}
 
-	replaceEntry = widget.NewEntry()
-	replaceEntry.SetPlaceHolder("Replace with...")
+		path := file.URI().Path()
+		file.Close()
 
-	caseSensitiveCheck = widget.NewCheck("Case sensitive", nil)
-	wholeWordCheck = widget.NewCheck("Whole words only", nil)
-	regexCheck = widget.NewCheck("Use regular expressions", nil)
-	replaceAllCheck = widget.NewCheck("Replace all occurrences", nil)
+		if callback != nil {
+			callback(path)
+		}
+	}, dm.mainWindow)
 
-	// Загружаем настройки
-	if dm.config != nil {
-		caseSensitiveCheck.SetChecked(dm.config.Editor.SearchCaseSensitive)
-		wholeWordCheck.SetChecked(dm.config.Editor.SearchWholeWord)
-		regexCheck.SetChecked(dm.config.Editor.SearchRegex)
+	if len(extensions) > 0 {
+		fileDialog.SetFilter(storage.NewExtensionFileFilter(extensions))
 	}
+	fileDialog.Show()
+}
 
-	content := container.NewVBox(
-		widget.NewLabel("Find:"),
-		findEntry,
-		widget.NewLabel("Replace:"),
-		replaceEntry,
-		container.NewHBox(
-			caseSensitiveCheck,
-			wholeWordCheck,
-		),
-		container.NewHBox(
-			regexCheck,
-			replaceAllCheck,
-		),
-	)
+// ShowSaveFileDialogWithName показывает диалог сохранения с заданным именем файла
+func (dm *DialogManager) ShowSaveFileDialogWithName(fileName string, callback func(string)) {
+	fileDialog := dialog.NewFileSave(func(file fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, dm.mainWindow)
+			return
+		}
+		if file == nil {
+			return
+		}
 
-	replaceDialog := dialog.NewCustomConfirm("Replace", "Replace", "Cancel", content, func(confirmed bool) {
-		if confirmed && findEntry.Text != "" {
-			if onReplace != nil {
-				onReplace(findEntry.Text,
-					replaceEntry.Text,
-					caseSensitiveCheck.Checked,
-					wholeWordCheck.Checked,
-					regexCheck.Checked,
-					replaceAllCheck.Checked)
-			}
+		path := file.URI().Path()
+		file.Close()
+
+		if callback != nil {
+			callback(path)
 		}
 	}, dm.mainWindow)
 
-	replaceDialog.Show()
-	AnimateShow(content)
-	dm.mainWindow.Canvas().Focus(findEntry)
+	fileDialog.SetFileName(fileName)
+	fileDialog.Show()
 }
 
 // ShowGoToLineDialog показывает диалог перехода к строке
@@ -559,102 +522,20 @@ func (dm *DialogManager) createAppearanceSettings(config *Config) fyne.CanvasObj
 	)
 }
 
+// SetShortcutEditor задает конструктор редактора горячих клавиш
+func (dm *DialogManager) SetShortcutEditor(newEditor func() *shortcutEditor) {
+	dm.newShortcutEditor = newEditor
+}
+
 // createKeyBindingsSettings создает вкладку настроек горячих клавиш
 func (dm *DialogManager) createKeyBindingsSettings(config *Config) fyne.CanvasObject {
-	// Создаем список горячих клавиш
-	keyBindings := []struct {
-		Name    string
-		Current string
-		Action  string
-	}{
-		{"New File", config.KeyBindings.NewFile, "new_file"},
-		{"Open File", config.KeyBindings.OpenFile, "open_file"},
-		{"Save File", config.KeyBindings.SaveFile, "save_file"},
-		{"Find", config.KeyBindings.Find, "find"},
-		{"Replace", config.KeyBindings.Replace, "replace"},
-		{"Go to Line", config.KeyBindings.GoToLine, "go_to_line"},
-		{"File Switcher", config.KeyBindings.FileSwitcher, "file_switcher"},
-		{"Toggle Sidebar", config.KeyBindings.ToggleSidebar, "toggle_sidebar"},
-		{"Toggle Minimap", config.KeyBindings.ToggleMinimap, "toggle_minimap"},
-		{"Command Palette", config.KeyBindings.CommandPalette, "command_palette"},
+	var editor *shortcutEditor
+	var editorView fyne.CanvasObject = widget.NewLabel("Key bindings are not loaded yet")
+	if dm.newShortcutEditor != nil {
+		editor = dm.newShortcutEditor()
+		editorView = editor.Widget()
 	}
 
-	list := widget.NewList(
-		func() int { return len(keyBindings) },
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewLabel("Action"),
-				widget.NewButton("Change", nil),
-			)
-		},
-		func(index widget.ListItemID, item fyne.CanvasObject) {
-			box := item.(*fyne.Container)
-			label := box.Objects[0].(*widget.Label)
-			button := box.Objects[1].(*widget.Button)
-
-			kb := &keyBindings[index]
-			label.SetText(fmt.Sprintf("%s: %s", kb.Name, kb.Current))
-			button.OnTapped = func() {
-				entry := widget.NewEntry()
-				entry.SetPlaceHolder("e.g. Ctrl+N")
-				entry.SetText(kb.Current)
-
-				form := dialog.NewForm(
-					fmt.Sprintf("Change %s", kb.Name),
-					"Save",
-					"Cancel",
-					[]*widget.FormItem{
-						widget.NewFormItem("Shortcut", entry),
-					},
-					func(confirmed bool) {
-						if !confirmed {
-							return
-						}
-						newBinding := strings.TrimSpace(entry.Text)
-						if newBinding == "" {
-							return
-						}
-
-						// Validate key binding format using existing parser
-						var hm HotkeyManager
-						if _, err := hm.parseKeyBinding(newBinding); err != nil {
-							dialog.ShowError(err, dm.mainWindow)
-							return
-						}
-
-						kb.Current = newBinding
-						switch kb.Action {
-						case "new_file":
-							config.KeyBindings.NewFile = newBinding
-						case "open_file":
-							config.KeyBindings.OpenFile = newBinding
-						case "save_file":
-							config.KeyBindings.SaveFile = newBinding
-						case "find":
-							config.KeyBindings.Find = newBinding
-						case "replace":
-							config.KeyBindings.Replace = newBinding
-						case "go_to_line":
-							config.KeyBindings.GoToLine = newBinding
-						case "file_switcher":
-							config.KeyBindings.FileSwitcher = newBinding
-						case "toggle_sidebar":
-							config.KeyBindings.ToggleSidebar = newBinding
-						case "toggle_minimap":
-							config.KeyBindings.ToggleMinimap = newBinding
-						case "command_palette":
-							config.KeyBindings.CommandPalette = newBinding
-						}
-						label.SetText(fmt.Sprintf("%s: %s", kb.Name, kb.Current))
-					},
-					dm.mainWindow,
-				)
-				form.Resize(fyne.NewSize(400, 150))
-				form.Show()
-			}
-		},
-	)
-
 	// Режимы клавиш
 	vimBindingsCheck := widget.NewCheck("Enable Vim key bindings", func(checked bool) {
 		config.KeyBindings.EnableVimBindings = checked
@@ -666,18 +547,38 @@ func (dm *DialogManager) createKeyBindingsSettings(config *Config) fyne.CanvasOb
 	})
 	emacsBindingsCheck.SetChecked(config.KeyBindings.EnableEmacsBindings)
 
-	vscodeBindingsCheck := widget.NewCheck("Use VS Code compatible bindings", func(checked bool) {
-		config.KeyBindings.EnableVSCodeBindings = checked
+	// Пресет раскладки применяется сразу, чтобы таблица показывала его привязки
+	var presetTitles []string
+	for _, p := range keymapPresets {
+		presetTitles = append(presetTitles, p.Title)
+	}
+	presetSelect := widget.NewSelect(presetTitles, func(selected string) {
+		for _, p := range keymapPresets {
+			if p.Title != selected || p.ID == config.KeyBindings.KeymapPreset {
+				continue
+			}
+			if err := applyKeymapPreset(&config.KeyBindings, p.ID); err != nil {
+				dialog.ShowError(err, dm.mainWindow)
+				return
+			}
+			if editor != nil {
+				editor.Reload()
+			}
+		}
 	})
-	vscodeBindingsCheck.SetChecked(config.KeyBindings.EnableVSCodeBindings)
+	presetSelect.PlaceHolder = "Custom"
+	if preset := findKeymapPreset(config.KeyBindings.KeymapPreset); preset != nil {
+		presetSelect.Selected = preset.Title
+	}
 
-	return container.NewVBox(
-		widget.NewCard("Key Bindings", "", list),
+	return container.NewBorder(
 		widget.NewCard("Key Binding Modes", "", container.NewVBox(
+			container.NewHBox(widget.NewLabel("Keymap:"), presetSelect),
 			vimBindingsCheck,
 			emacsBindingsCheck,
-			vscodeBindingsCheck,
 		)),
+		nil, nil, nil,
+		editorView,
 	)
 }
 
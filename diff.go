@@ -4,15 +4,233 @@ import (
 	"fmt"
 	"image/color"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
+// Цвета подсветки различий
+var (
+	diffInsertLineColor = color.NRGBA{R: 0, G: 255, B: 0, A: 50}
+	diffDeleteLineColor = color.NRGBA{R: 255, G: 0, B: 0, A: 50}
+	diffInsertWordColor = color.NRGBA{R: 0, G: 255, B: 0, A: 130}
+	diffDeleteWordColor = color.NRGBA{R: 255, G: 0, B: 0, A: 130}
+	diffFillerColor     = color.NRGBA{R: 128, G: 128, B: 128, A: 40}
+	diffGutterColor     = color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+)
+
+// diffRowKind - тип строки в выровненном сравнении
+type diffRowKind int
+
+const (
+	diffRowEqual diffRowKind = iota
+	diffRowChanged
+	diffRowDeleted
+	diffRowInserted
+)
+
+// diffRow - пара строк слева и справа (-1, если строки нет)
+type diffRow struct {
+	kind  diffRowKind
+	left  int
+	right int
+}
+
+// diffHunk - непрерывный блок изменений, строки [start, end)
+type diffHunk struct {
+	start int
+	end   int
+}
+
+// DiffSide - одна из сравниваемых сторон: файл или текст в памяти
+type DiffSide struct {
+	Name string
+	Path string // пустой для текстов без файла
+	Text string
+
+	// Apply применяет измененный текст к источнику в памяти (например, к буферу редактора)
+	Apply func(text string)
+}
+
+// writable возвращает true, если изменения стороны можно сохранить
+func (s DiffSide) writable() bool {
+	return s.Path != "" || s.Apply != nil
+}
+
+// splitDiffLines разбивает текст на строки без завершающего перевода строки
+func splitDiffLines(text string) ([]string, bool) {
+	if text == "" {
+		return nil, false
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	trailing := strings.HasSuffix(text, "\n")
+	if trailing {
+		text = text[:len(text)-1]
+	}
+	return strings.Split(text, "\n"), trailing
+}
+
+// diffLineEnding возвращает перевод строки текста: CRLF, если он встречается, иначе LF
+func diffLineEnding(text string) string {
+	if strings.Contains(text, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// normalizeDiffLine приводит строку к виду для сравнения
+func normalizeDiffLine(line string, ignoreWhitespace bool) string {
+	if ignoreWhitespace {
+		return strings.Join(strings.Fields(line), " ")
+	}
+	return line
+}
+
+// computeDiffRows выравнивает строки двух текстов построчно
+func computeDiffRows(left, right []string, ignoreWhitespace bool) []diffRow {
+	join := func(lines []string) string {
+		var sb strings.Builder
+		for _, l := range lines {
+			sb.WriteString(normalizeDiffLine(l, ignoreWhitespace))
+			sb.WriteByte('\n')
+		}
+		return sb.String()
+	}
+
+	// Каждая строка кодируется одним символом
+	dmp := diffmatchpatch.New()
+	chars1, chars2, _ := dmp.DiffLinesToChars(join(left), join(right))
+	diffs := dmp.DiffMain(chars1, chars2, false)
+
+	var rows []diffRow
+	li, ri := 0, 0
+	var deleted, inserted []int
+
+	flush := func() {
+		n := len(deleted)
+		if len(inserted) > n {
+			n = len(inserted)
+		}
+		for i := 0; i < n; i++ {
+			row := diffRow{left: -1, right: -1}
+			if i < len(deleted) {
+				row.left = deleted[i]
+			}
+			if i < len(inserted) {
+				row.right = inserted[i]
+			}
+			switch {
+			case row.left >= 0 && row.right >= 0:
+				row.kind = diffRowChanged
+			case row.left >= 0:
+				row.kind = diffRowDeleted
+			default:
+				row.kind = diffRowInserted
+			}
+			rows = append(rows, row)
+		}
+		deleted, inserted = deleted[:0], inserted[:0]
+	}
+
+	for _, d := range diffs {
+		count := len([]rune(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			flush()
+			for i := 0; i < count; i++ {
+				rows = append(rows, diffRow{kind: diffRowEqual, left: li, right: ri})
+				li++
+				ri++
+			}
+		case diffmatchpatch.DiffDelete:
+			for i := 0; i < count; i++ {
+				deleted = append(deleted, li)
+				li++
+			}
+		case diffmatchpatch.DiffInsert:
+			for i := 0; i < count; i++ {
+				inserted = append(inserted, ri)
+				ri++
+			}
+		}
+	}
+	flush()
+
+	return rows
+}
+
+// buildDiffHunks группирует измененные строки в блоки
+func buildDiffHunks(rows []diffRow) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(rows); i++ {
+		if rows[i].kind == diffRowEqual {
+			continue
+		}
+		start := i
+		for i < len(rows) && rows[i].kind != diffRowEqual {
+			i++
+		}
+		hunks = append(hunks, diffHunk{start: start, end: i})
+	}
+	return hunks
+}
+
+// intralineRanges возвращает диапазоны (в символах) измененных слов в двух строках
+func intralineRanges(a, b string) ([][2]int, [][2]int) {
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMain(a, b, false)
+	diffs = dmp.DiffCleanupSemantic(diffs)
+
+	var left, right [][2]int
+	lp, rp := 0, 0
+	for _, d := range diffs {
+		n := len([]rune(d.Text))
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			lp += n
+			rp += n
+		case diffmatchpatch.DiffDelete:
+			left = append(left, [2]int{lp, lp + n})
+			lp += n
+		case diffmatchpatch.DiffInsert:
+			right = append(right, [2]int{rp, rp + n})
+			rp += n
+		}
+	}
+	return left, right
+}
+
+// DiffView - окно сравнения двух текстов бок о бок
+type DiffView struct {
+	app    *App
+	window fyne.Window
+
+	left, right             DiffSide
+	leftLines, rightLines   []string
+	leftTrailing            bool
+	rightTrailing           bool
+	leftEOL, rightEOL       string // перевод строки каждой стороны, сохраняемый при записи
+	leftDirty, rightDirty   bool
+	ignoreWhitespace        bool
+	rows                    []diffRow
+	hunks                   []diffHunk
+	current                 int
+	leftGrid, rightGrid     *widget.TextGrid
+	leftScroll, rightScroll *container.Scroll
+	leftHeader, rightHeader *widget.Label
+	statusLabel             *widget.Label
+	saveLeftBtn             *widget.Button
+	saveRightBtn            *widget.Button
+	syncing                 bool
+}
+
 // compareFiles запускает выбор двух файлов и отображает различия между ними
 func (a *App) compareFiles() {
 	if a.dialogManager == nil {
@@ -43,38 +261,533 @@ func (a *App) showDiffWindow(file1, file2 string) {
 		return
 	}
 
-	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain(string(content1), string(content2), false)
+	a.showDiffView("File Diff",
+		DiffSide{Name: file1, Path: file1, Text: string(content1)},
+		DiffSide{Name: file2, Path: file2, Text: string(content2)})
+}
 
-	grid := widget.NewTextGrid()
-	row := 0
-	for _, d := range diffs {
-		lines := strings.Split(d.Text, "\n")
-		for i, line := range lines {
-			if i == len(lines)-1 && line == "" {
-				continue
+// showDiffContents отображает различия между двумя текстами
+func (a *App) showDiffContents(title, name1, text1, name2, text2 string) {
+	a.showDiffView(title, DiffSide{Name: name1, Text: text1}, DiffSide{Name: name2, Text: text2})
+}
+
+// showDiffView открывает окно сравнения двух сторон
+func (a *App) showDiffView(title string, left, right DiffSide) *DiffView {
+	v := &DiffView{app: a, left: left, right: right, current: -1}
+	v.leftLines, v.leftTrailing = splitDiffLines(left.Text)
+	v.rightLines, v.rightTrailing = splitDiffLines(right.Text)
+	v.leftEOL, v.rightEOL = diffLineEnding(left.Text), diffLineEnding(right.Text)
+
+	v.window = a.fyneApp.NewWindow(title)
+	v.window.SetContent(v.build())
+	v.window.Resize(fyne.NewSize(1100, 700))
+	v.recompute()
+	v.window.Show()
+
+	if len(v.hunks) > 0 {
+		v.goToHunk(0)
+	}
+	return v
+}
+
+// build создает интерфейс окна сравнения
+func (v *DiffView) build() fyne.CanvasObject {
+	v.leftGrid = widget.NewTextGrid()
+	v.rightGrid = widget.NewTextGrid()
+	v.leftScroll = container.NewScroll(v.leftGrid)
+	v.rightScroll = container.NewScroll(v.rightGrid)
+
+	// Синхронная прокрутка панелей
+	v.leftScroll.OnScrolled = func(pos fyne.Position) { v.syncScroll(v.rightScroll, pos) }
+	v.rightScroll.OnScrolled = func(pos fyne.Position) { v.syncScroll(v.leftScroll, pos) }
+
+	v.leftHeader = widget.NewLabel("")
+	v.leftHeader.TextStyle = fyne.TextStyle{Monospace: true}
+	v.leftHeader.Truncation = fyne.TextTruncateEllipsis
+	v.rightHeader = widget.NewLabel("")
+	v.rightHeader.TextStyle = fyne.TextStyle{Monospace: true}
+	v.rightHeader.Truncation = fyne.TextTruncateEllipsis
+
+	v.statusLabel = widget.NewLabel("")
+
+	ignoreCheck := widget.NewCheck("Ignore whitespace", func(checked bool) {
+		v.ignoreWhitespace = checked
+		v.recompute()
+		if len(v.hunks) > 0 {
+			v.goToHunk(0)
+		}
+	})
+
+	saveLabel := func(side DiffSide, suffix string) string {
+		if side.Apply != nil {
+			return "Apply " + suffix
+		}
+		return "Save " + suffix
+	}
+	v.saveLeftBtn = widget.NewButtonWithIcon(saveLabel(v.left, "Left"), theme.DocumentSaveIcon(), func() { v.save(true) })
+	v.saveRightBtn = widget.NewButtonWithIcon(saveLabel(v.right, "Right"), theme.DocumentSaveIcon(), func() { v.save(false) })
+	if !v.left.writable() {
+		v.saveLeftBtn.Hide()
+	}
+	if !v.right.writable() {
+		v.saveRightBtn.Hide()
+	}
+
+	toolbar := container.NewHBox(
+		widget.NewButtonWithIcon("Previous", theme.MoveUpIcon(), func() { v.navigate(-1) }),
+		widget.NewButtonWithIcon("Next", theme.MoveDownIcon(), func() { v.navigate(1) }),
+		widget.NewSeparator(),
+		widget.NewButtonWithIcon("Copy to Left", theme.NavigateBackIcon(), func() { v.copyChunk(true) }),
+		widget.NewButtonWithIcon("Copy to Right", theme.NavigateNextIcon(), func() { v.copyChunk(false) }),
+		widget.NewSeparator(),
+		ignoreCheck,
+		widget.NewSeparator(),
+		v.saveLeftBtn,
+		v.saveRightBtn,
+		widget.NewButtonWithIcon("Export Patch", theme.DownloadIcon(), v.exportPatch),
+		layout.NewSpacer(),
+		v.statusLabel,
+	)
+
+	leftPane := container.NewBorder(v.leftHeader, nil, nil, nil, v.leftScroll)
+	rightPane := container.NewBorder(v.rightHeader, nil, nil, nil, v.rightScroll)
+	split := container.NewHSplit(leftPane, rightPane)
+	split.SetOffset(0.5)
+
+	return container.NewBorder(toolbar, nil, nil, nil, split)
+}
+
+// syncScroll переносит смещение прокрутки на другую панель
+func (v *DiffView) syncScroll(target *container.Scroll, pos fyne.Position) {
+	if v.syncing {
+		return
+	}
+	v.syncing = true
+	target.ScrollToOffset(pos)
+	v.syncing = false
+}
+
+// recompute пересчитывает выравнивание и перерисовывает панели
+func (v *DiffView) recompute() {
+	v.rows = computeDiffRows(v.leftLines, v.rightLines, v.ignoreWhitespace)
+	v.hunks = buildDiffHunks(v.rows)
+	if v.current >= len(v.hunks) {
+		v.current = len(v.hunks) - 1
+	}
+	v.render()
+}
+
+// render заполняет обе панели
+func (v *DiffView) render() {
+	v.renderSide(true)
+	v.renderSide(false)
+
+	leftName, rightName := v.left.Name, v.right.Name
+	if v.leftDirty {
+		leftName += " *"
+	}
+	if v.rightDirty {
+		rightName += " *"
+	}
+	v.leftHeader.SetText(leftName)
+	v.rightHeader.SetText(rightName)
+	v.updateStatus()
+}
+
+// renderSide заполняет одну панель с номерами строк и подсветкой
+func (v *DiffView) renderSide(isLeft bool) {
+	grid, lines := v.rightGrid, v.rightLines
+	if isLeft {
+		grid, lines = v.leftGrid, v.leftLines
+	}
+
+	maxLines := len(v.leftLines)
+	if len(v.rightLines) > maxLines {
+		maxLines = len(v.rightLines)
+	}
+	numWidth := len(fmt.Sprint(maxLines))
+
+	currentStart, currentEnd := -1, -1
+	if v.current >= 0 && v.current < len(v.hunks) {
+		currentStart, currentEnd = v.hunks[v.current].start, v.hunks[v.current].end
+	}
+
+	gutterStyle := &widget.CustomTextGridStyle{FGColor: diffGutterColor}
+	gridRows := make([]widget.TextGridRow, len(v.rows))
+
+	for i, row := range v.rows {
+		index := row.right
+		if isLeft {
+			index = row.left
+		}
+
+		marker := ' '
+		if i >= currentStart && i < currentEnd {
+			marker = '▶'
+		}
+
+		var gutter string
+		if index >= 0 {
+			gutter = fmt.Sprintf("%*d", numWidth, index+1)
+		} else {
+			gutter = strings.Repeat(" ", numWidth)
+		}
+		gutter += string(marker) + " "
+
+		var text string
+		if index >= 0 {
+			text = lines[index]
+		}
+		textRunes := []rune(text)
+
+		cells := make([]widget.TextGridCell, 0, len(gutter)+len(textRunes))
+		for _, r := range gutter {
+			cells = append(cells, widget.TextGridCell{Rune: r, Style: gutterStyle})
+		}
+
+		// Пословная подсветка внутри измененных строк
+		var wordRanges [][2]int
+		wordColor := diffInsertWordColor
+		if row.kind == diffRowChanged {
+			leftRanges, rightRanges := intralineRanges(v.leftLines[row.left], v.rightLines[row.right])
+			wordRanges = rightRanges
+			if isLeft {
+				wordRanges = leftRanges
+				wordColor = diffDeleteWordColor
 			}
-			cells := make([]widget.TextGridCell, len([]rune(line)))
-			for j, r := range line {
-				cells[j] = widget.TextGridCell{Rune: r}
+		}
+		wordStyle := &widget.CustomTextGridStyle{BGColor: wordColor}
+
+		for j, r := range textRunes {
+			cell := widget.TextGridCell{Rune: r}
+			for _, rg := range wordRanges {
+				if j >= rg[0] && j < rg[1] {
+					cell.Style = wordStyle
+					break
+				}
 			}
-			grid.SetRow(row, widget.TextGridRow{Cells: cells})
-			style := &widget.CustomTextGridStyle{}
-			switch d.Type {
-			case diffmatchpatch.DiffInsert:
-				style.BGColor = color.NRGBA{R: 0, G: 255, B: 0, A: 100}
-			case diffmatchpatch.DiffDelete:
-				style.BGColor = color.NRGBA{R: 255, G: 0, B: 0, A: 100}
+			cells = append(cells, cell)
+		}
+
+		gridRow := widget.TextGridRow{Cells: cells}
+		switch {
+		case index < 0:
+			gridRow.Style = &widget.CustomTextGridStyle{BGColor: diffFillerColor}
+		case row.kind == diffRowEqual:
+		case isLeft:
+			gridRow.Style = &widget.CustomTextGridStyle{BGColor: diffDeleteLineColor}
+		default:
+			gridRow.Style = &widget.CustomTextGridStyle{BGColor: diffInsertLineColor}
+		}
+		gridRows[i] = gridRow
+	}
+
+	grid.Rows = gridRows
+	grid.Refresh()
+}
+
+// updateStatus обновляет счетчик изменений
+func (v *DiffView) updateStatus() {
+	switch {
+	case len(v.hunks) == 0 && len(v.leftLines) > 0 && len(v.rightLines) > 0 && v.leftTrailing != v.rightTrailing:
+		v.statusLabel.SetText("Only the final newline differs")
+	case len(v.hunks) == 0 && v.leftEOL != v.rightEOL:
+		v.statusLabel.SetText(fmt.Sprintf("Only line endings differ (%s / %s)", diffEOLName(v.leftEOL), diffEOLName(v.rightEOL)))
+	case len(v.hunks) == 0:
+		v.statusLabel.SetText("No differences")
+	case v.current < 0:
+		v.statusLabel.SetText(fmt.Sprintf("%d changes", len(v.hunks)))
+	default:
+		v.statusLabel.SetText(fmt.Sprintf("Change %d of %d", v.current+1, len(v.hunks)))
+	}
+}
+
+// navigate переходит к следующему или предыдущему изменению
+func (v *DiffView) navigate(delta int) {
+	if len(v.hunks) == 0 {
+		return
+	}
+	next := v.current + delta
+	if next < 0 {
+		next = len(v.hunks) - 1
+	} else if next >= len(v.hunks) {
+		next = 0
+	}
+	v.goToHunk(next)
+}
+
+// goToHunk выделяет изменение и прокручивает к нему обе панели
+func (v *DiffView) goToHunk(index int) {
+	v.current = index
+	v.render()
+
+	if len(v.rows) == 0 {
+		return
+	}
+	rowHeight := v.leftGrid.MinSize().Height / float32(len(v.rows))
+	y := float32(v.hunks[index].start)*rowHeight - v.leftScroll.Size().Height/3
+	if y < 0 {
+		y = 0
+	}
+	v.leftScroll.ScrollToOffset(fyne.NewPos(v.leftScroll.Offset.X, y))
+	v.rightScroll.ScrollToOffset(fyne.NewPos(v.rightScroll.Offset.X, y))
+}
+
+// hunkLineRange возвращает диапазон строк стороны, занимаемый блоком
+func (v *DiffView) hunkLineRange(h diffHunk, isLeft bool) (int, int) {
+	index := func(row diffRow) int {
+		if isLeft {
+			return row.left
+		}
+		return row.right
+	}
+
+	start, end := -1, -1
+	for i := h.start; i < h.end; i++ {
+		if idx := index(v.rows[i]); idx >= 0 {
+			if start < 0 {
+				start = idx
 			}
-			if style.BGColor != nil {
-				grid.SetRowStyle(row, style)
+			end = idx + 1
+		}
+	}
+	if start >= 0 {
+		return start, end
+	}
+
+	// Блок без строк на этой стороне - позиция вставки после предыдущей строки
+	for i := h.start - 1; i >= 0; i-- {
+		if idx := index(v.rows[i]); idx >= 0 {
+			return idx + 1, idx + 1
+		}
+	}
+	return 0, 0
+}
+
+// copyChunk переносит текущий блок на левую или правую сторону
+func (v *DiffView) copyChunk(toLeft bool) {
+	if v.current < 0 || v.current >= len(v.hunks) {
+		return
+	}
+	h := v.hunks[v.current]
+	ls, le := v.hunkLineRange(h, true)
+	rs, re := v.hunkLineRange(h, false)
+
+	replace := func(dst []string, ds, de int, src []string) []string {
+		result := make([]string, 0, len(dst)-(de-ds)+len(src))
+		result = append(result, dst[:ds]...)
+		result = append(result, src...)
+		return append(result, dst[de:]...)
+	}
+
+	if toLeft {
+		v.leftLines = replace(v.leftLines, ls, le, v.rightLines[rs:re])
+		v.leftDirty = true
+	} else {
+		v.rightLines = replace(v.rightLines, rs, re, v.leftLines[ls:le])
+		v.rightDirty = true
+	}
+
+	current := v.current
+	v.recompute()
+	if len(v.hunks) > 0 {
+		if current >= len(v.hunks) {
+			current = len(v.hunks) - 1
+		}
+		v.goToHunk(current)
+	} else {
+		v.current = -1
+		v.render()
+	}
+}
+
+// sideText собирает текст стороны из строк
+func (v *DiffView) sideText(isLeft bool) string {
+	lines, trailing, eol := v.rightLines, v.rightTrailing, v.rightEOL
+	if isLeft {
+		lines, trailing, eol = v.leftLines, v.leftTrailing, v.leftEOL
+	}
+	text := strings.Join(lines, eol)
+	if trailing && len(lines) > 0 {
+		text += eol
+	}
+	return text
+}
+
+// diffEOLName возвращает название перевода строки для строки состояния
+func diffEOLName(eol string) string {
+	if eol == "\r\n" {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+// save записывает измененную сторону в ее файл или источник в памяти
+func (v *DiffView) save(isLeft bool) {
+	side := v.right
+	if isLeft {
+		side = v.left
+	}
+
+	switch {
+	case side.Apply != nil:
+		side.Apply(v.sideText(isLeft))
+	case side.Path != "":
+		if err := os.WriteFile(side.Path, []byte(v.sideText(isLeft)), 0644); err != nil {
+			dialog.ShowError(err, v.window)
+			return
+		}
+	default:
+		return
+	}
+
+	if isLeft {
+		v.leftDirty = false
+	} else {
+		v.rightDirty = false
+	}
+	v.render()
+}
+
+// bufferDiffSide возвращает текущий буфер редактора как сторону сравнения
+func (a *App) bufferDiffSide() DiffSide {
+	path := a.editor.filePath
+	name := "Untitled"
+	if path != "" {
+		name = filepath.Base(path)
+	}
+	return DiffSide{
+		Name: name + " (buffer)",
+		Text: a.editor.GetFullText(),
+		Apply: func(text string) {
+			if a.editor.filePath == path {
+				a.editor.SetContent(text)
 			}
-			row++
+		},
+	}
+}
+
+// compareBufferWithDisk сравнивает несохраненный буфер с файлом на диске
+func (a *App) compareBufferWithDisk() {
+	if a.editor == nil || a.editor.filePath == "" {
+		dialog.ShowInformation("Compare", "The current buffer has no file on disk", a.mainWin)
+		return
+	}
+	path := a.editor.filePath
+	data, err := os.ReadFile(path)
+	if err != nil {
+		dialog.ShowError(err, a.mainWin)
+		return
+	}
+	a.showDiffView("Buffer vs Disk",
+		DiffSide{Name: filepath.Base(path) + " (disk)", Path: path, Text: string(data)},
+		a.bufferDiffSide())
+}
+
+// compareBufferWithSaved сравнивает буфер с версией на момент последнего открытия или сохранения
+func (a *App) compareBufferWithSaved() {
+	if a.editor == nil || a.editor.filePath == "" {
+		dialog.ShowInformation("Compare", "The current buffer has not been saved yet", a.mainWin)
+		return
+	}
+	a.showDiffView("Buffer vs Saved",
+		DiffSide{Name: filepath.Base(a.editor.filePath) + " (last saved)", Text: a.editor.savedContent},
+		a.bufferDiffSide())
+}
+
+// compareBufferWithClipboard сравнивает буфер (или выделение) с буфером обмена
+func (a *App) compareBufferWithClipboard() {
+	if a.editor == nil {
+		return
+	}
+	clip := a.mainWin.Clipboard().Content()
+	if clip == "" {
+		dialog.ShowInformation("Compare", "Clipboard is empty", a.mainWin)
+		return
+	}
+
+	side := a.bufferDiffSide()
+	if selected := a.getSelectedText(); selected != "" {
+		side = DiffSide{Name: "Selection", Text: selected}
+	}
+	a.showDiffView("Compare with Clipboard", DiffSide{Name: "Clipboard", Text: clip}, side)
+}
+
+// rememberSelectionForCompare запоминает выделение как первую сторону сравнения
+func (a *App) rememberSelectionForCompare() {
+	if a.editor == nil {
+		return
+	}
+	selected := a.getSelectedText()
+	if selected == "" {
+		dialog.ShowInformation("Compare", "Please select some text first", a.mainWin)
+		return
+	}
+
+	name := "Selection A"
+	if a.editor.filePath != "" {
+		name += " (" + filepath.Base(a.editor.filePath) + ")"
+	}
+	a.compareSelection = &DiffSide{Name: name, Text: selected}
+}
+
+// compareSelectionWithRemembered сравнивает текущее выделение с запомненным
+func (a *App) compareSelectionWithRemembered() {
+	if a.editor == nil {
+		return
+	}
+	if a.compareSelection == nil {
+		dialog.ShowInformation("Compare", "Use \"Remember Selection for Compare\" first", a.mainWin)
+		return
+	}
+	selected := a.getSelectedText()
+	if selected == "" {
+		dialog.ShowInformation("Compare", "Please select some text first", a.mainWin)
+		return
+	}
+
+	name := "Selection B"
+	if a.editor.filePath != "" {
+		name += " (" + filepath.Base(a.editor.filePath) + ")"
+	}
+	a.showDiffView("Compare Selections", *a.compareSelection, DiffSide{Name: name, Text: selected})
+}
+
+// compareBufferWithRecentFile сравнивает буфер с одним из недавно открытых файлов.
+// Вкладок редактора нет (открыт один буфер), поэтому сравнение с другой вкладкой
+// заменено сравнением с недавним файлом; сторона файла читается с диска
+func (a *App) compareBufferWithRecentFile() {
+	if a.editor == nil {
+		return
+	}
+
+	var files []string
+	for _, f := range a.recentFiles {
+		if f != a.editor.filePath {
+			files = append(files, f)
 		}
 	}
+	if len(files) == 0 {
+		dialog.ShowInformation("Compare", "No other recent files", a.mainWin)
+		return
+	}
 
-	win := a.fyneApp.NewWindow("File Diff")
-	win.SetContent(container.NewScroll(grid))
-	win.Resize(fyne.NewSize(800, 600))
-	win.Show()
+	selectEntry := widget.NewSelect(files, nil)
+	selectEntry.SetSelectedIndex(0)
+	dialog.ShowForm("Compare with Recent File", "Compare", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("File", selectEntry)},
+		func(ok bool) {
+			if !ok || selectEntry.Selected == "" {
+				return
+			}
+			path := selectEntry.Selected
+			data, err := os.ReadFile(path)
+			if err != nil {
+				dialog.ShowError(err, a.mainWin)
+				return
+			}
+			a.showDiffView("Compare Files",
+				DiffSide{Name: path, Path: path, Text: string(data)},
+				a.bufferDiffSide())
+		}, a.mainWin)
 }
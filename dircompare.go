@@ -0,0 +1,422 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dirCompareStatus - результат сравнения элемента двух папок
+type dirCompareStatus int
+
+const (
+	dirIdentical dirCompareStatus = iota
+	dirChanged
+	dirOnlyLeft
+	dirOnlyRight
+)
+
+// String возвращает описание статуса
+func (s dirCompareStatus) String() string {
+	switch s {
+	case dirChanged:
+		return "changed"
+	case dirOnlyLeft:
+		return "only left"
+	case dirOnlyRight:
+		return "only right"
+	default:
+		return "identical"
+	}
+}
+
+// dirCompareNode - файл или папка в дереве сравнения
+type dirCompareNode struct {
+	rel      string
+	name     string
+	isDir    bool
+	status   dirCompareStatus
+	children []string
+}
+
+// Служебные папки систем контроля версий не сравниваются
+var dirCompareSkip = map[string]bool{".git": true, ".svn": true, ".hg": true}
+
+// collectDirEntries возвращает относительные пути всех элементов папки
+func collectDirEntries(root string) (map[string]bool, error) {
+	entries := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if d.IsDir() && dirCompareSkip[d.Name()] {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		entries[rel] = d.IsDir()
+		return nil
+	})
+	return entries, err
+}
+
+// sameFileContents сравнивает файлы по размеру и хешу содержимого
+func sameFileContents(left, right string) (bool, error) {
+	li, err := os.Stat(left)
+	if err != nil {
+		return false, err
+	}
+	ri, err := os.Stat(right)
+	if err != nil {
+		return false, err
+	}
+	if li.Size() != ri.Size() {
+		return false, nil
+	}
+
+	lh, err := calculateFileHash(left)
+	if err != nil {
+		return false, err
+	}
+	rh, err := calculateFileHash(right)
+	if err != nil {
+		return false, err
+	}
+	return lh == rh, nil
+}
+
+// compareDirectories строит дерево сравнения двух папок; ключ корня - ""
+func compareDirectories(left, right string) (map[string]*dirCompareNode, error) {
+	leftEntries, err := collectDirEntries(left)
+	if err != nil {
+		return nil, err
+	}
+	rightEntries, err := collectDirEntries(right)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := map[string]*dirCompareNode{"": {isDir: true}}
+	var addNode func(rel string, isDir bool) *dirCompareNode
+	addNode = func(rel string, isDir bool) *dirCompareNode {
+		if n, ok := nodes[rel]; ok {
+			return n
+		}
+		n := &dirCompareNode{rel: rel, name: filepath.Base(rel), isDir: isDir}
+		nodes[rel] = n
+		parent := filepath.Dir(rel)
+		if parent == "." {
+			parent = ""
+		}
+		p := addNode(parent, true)
+		p.children = append(p.children, rel)
+		return n
+	}
+
+	for rel, isDir := range leftEntries {
+		n := addNode(rel, isDir)
+		rightIsDir, inRight := rightEntries[rel]
+		switch {
+		case !inRight:
+			n.status = dirOnlyLeft
+		case isDir != rightIsDir:
+			n.status = dirChanged
+		case !isDir:
+			same, err := sameFileContents(filepath.Join(left, rel), filepath.Join(right, rel))
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				n.status = dirChanged
+			}
+		}
+	}
+	for rel, isDir := range rightEntries {
+		if _, ok := leftEntries[rel]; !ok {
+			addNode(rel, isDir).status = dirOnlyRight
+		}
+	}
+
+	// Папка считается измененной, если изменено что-то внутри
+	var settle func(n *dirCompareNode) dirCompareStatus
+	settle = func(n *dirCompareNode) dirCompareStatus {
+		sort.Slice(n.children, func(i, j int) bool {
+			a, b := nodes[n.children[i]], nodes[n.children[j]]
+			if a.isDir != b.isDir {
+				return a.isDir
+			}
+			return strings.ToLower(a.name) < strings.ToLower(b.name)
+		})
+		for _, c := range n.children {
+			if settle(nodes[c]) != dirIdentical && n.status == dirIdentical {
+				n.status = dirChanged
+			}
+		}
+		return n.status
+	}
+	settle(nodes[""])
+
+	return nodes, nil
+}
+
+// compareDirectoriesDialog выбирает две папки и открывает их сравнение
+func (a *App) compareDirectoriesDialog() {
+	if a.dialogManager == nil {
+		return
+	}
+	a.dialogManager.ShowOpenFolderDialog(func(left string) {
+		a.dialogManager.ShowOpenFolderDialog(func(right string) {
+			a.showDirCompare(left, right)
+		})
+	})
+}
+
+// showDirCompare открывает окно сравнения папок
+func (a *App) showDirCompare(left, right string) {
+	var nodes map[string]*dirCompareNode
+	hideIdentical := false
+
+	win := a.fyneApp.NewWindow("Compare Folders")
+	statusLabel := widget.NewLabel("Comparing...")
+
+	visibleChildren := func(uid string) []string {
+		n, ok := nodes[uid]
+		if !ok {
+			return nil
+		}
+		if !hideIdentical {
+			return n.children
+		}
+		var out []string
+		for _, c := range n.children {
+			if nodes[c].status != dirIdentical {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+
+	tree := widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID { return visibleChildren(uid) },
+		func(uid widget.TreeNodeID) bool {
+			n, ok := nodes[uid]
+			return ok && n.isDir
+		},
+		func(branch bool) fyne.CanvasObject {
+			return container.NewHBox(widget.NewIcon(theme.FileIcon()), widget.NewLabel("name"), widget.NewLabel("status"))
+		},
+		func(uid widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			n, ok := nodes[uid]
+			if !ok {
+				return
+			}
+			row := o.(*fyne.Container)
+			icon := row.Objects[0].(*widget.Icon)
+			name := row.Objects[1].(*widget.Label)
+			status := row.Objects[2].(*widget.Label)
+
+			if n.isDir {
+				icon.SetResource(theme.FolderIcon())
+			} else {
+				icon.SetResource(theme.FileIcon())
+			}
+			name.SetText(n.name)
+			status.SetText("(" + n.status.String() + ")")
+			switch n.status {
+			case dirChanged:
+				name.Importance = widget.WarningImportance
+			case dirOnlyLeft:
+				name.Importance = widget.DangerImportance
+			case dirOnlyRight:
+				name.Importance = widget.SuccessImportance
+			default:
+				name.Importance = widget.LowImportance
+			}
+			name.Refresh()
+		},
+	)
+
+	tree.OnSelected = func(uid widget.TreeNodeID) {
+		tree.Unselect(uid)
+		n, ok := nodes[uid]
+		if !ok || n.isDir {
+			return
+		}
+		a.showDirCompareFile(left, right, n)
+	}
+
+	reload := func() {
+		statusLabel.SetText("Comparing...")
+		go func() {
+			result, err := compareDirectories(left, right)
+			fyne.Do(func() {
+				if err != nil {
+					statusLabel.SetText("")
+					dialog.ShowError(err, win)
+					return
+				}
+				nodes = result
+
+				counts := make(map[dirCompareStatus]int)
+				for _, n := range nodes {
+					if !n.isDir && n.rel != "" {
+						counts[n.status]++
+					}
+				}
+				statusLabel.SetText(fmt.Sprintf("%d identical, %d changed, %d only left, %d only right",
+					counts[dirIdentical], counts[dirChanged], counts[dirOnlyLeft], counts[dirOnlyRight]))
+				tree.Refresh()
+			})
+		}()
+	}
+
+	hideCheck := widget.NewCheck("Hide identical", func(on bool) {
+		hideIdentical = on
+		tree.Refresh()
+	})
+
+	exportBtn := widget.NewButtonWithIcon("Export Patch", theme.DownloadIcon(), func() {
+		if nodes == nil {
+			return
+		}
+		patch, err := dirComparePatch(left, right, nodes)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if patch == "" {
+			dialog.ShowInformation("Export Patch", "There are no differences to export", win)
+			return
+		}
+		a.savePatchFile(patch, win)
+	})
+
+	pathsLabel := widget.NewLabel(fmt.Sprintf("Left: %s\nRight: %s", left, right))
+	pathsLabel.Truncation = fyne.TextTruncateEllipsis
+
+	toolbar := container.NewHBox(
+		widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), reload),
+		hideCheck,
+		exportBtn,
+	)
+
+	win.SetContent(container.NewBorder(
+		container.NewVBox(toolbar, pathsLabel),
+		statusLabel, nil, nil,
+		tree,
+	))
+	win.Resize(fyne.NewSize(800, 650))
+	win.Show()
+
+	reload()
+}
+
+// showDirCompareFile открывает сравнение файла из дерева сравнения папок
+func (a *App) showDirCompareFile(left, right string, n *dirCompareNode) {
+	leftPath := filepath.Join(left, n.rel)
+	rightPath := filepath.Join(right, n.rel)
+
+	switch n.status {
+	case dirOnlyLeft:
+		data, err := os.ReadFile(leftPath)
+		if err != nil {
+			dialog.ShowError(err, a.mainWin)
+			return
+		}
+		a.showDiffView(n.rel,
+			DiffSide{Name: leftPath, Path: leftPath, Text: string(data)},
+			DiffSide{Name: "(missing)"})
+	case dirOnlyRight:
+		data, err := os.ReadFile(rightPath)
+		if err != nil {
+			dialog.ShowError(err, a.mainWin)
+			return
+		}
+		a.showDiffView(n.rel,
+			DiffSide{Name: "(missing)"},
+			DiffSide{Name: rightPath, Path: rightPath, Text: string(data)})
+	default:
+		a.showDiffWindow(leftPath, rightPath)
+	}
+}
+
+// dirComparePatch формирует unified patch всех текстовых различий двух папок
+func dirComparePatch(left, right string, nodes map[string]*dirCompareNode) (string, error) {
+	var rels []string
+	for rel, n := range nodes {
+		// Папки тоже перебираются: с другой стороны на их месте может быть файл
+		if rel != "" && n.status != dirIdentical {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	readText := func(path string) (string, bool, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, err
+		}
+		return string(data), isBinaryContent(data), nil
+	}
+
+	isFile := func(path string) bool {
+		info, err := os.Stat(path)
+		return err == nil && !info.IsDir()
+	}
+
+	var sb strings.Builder
+	for _, rel := range rels {
+		n := nodes[rel]
+		slashRel := filepath.ToSlash(rel)
+		oldName, newName := "a/"+slashRel, "b/"+slashRel
+		var oldText, newText string
+		var binary bool
+
+		// Если с одной стороны папка, файл считается существующим только на другой
+		hasOld := n.status != dirOnlyRight && isFile(filepath.Join(left, rel))
+		hasNew := n.status != dirOnlyLeft && isFile(filepath.Join(right, rel))
+		if !hasOld && !hasNew {
+			continue
+		}
+
+		if hasOld {
+			text, bin, err := readText(filepath.Join(left, rel))
+			if err != nil {
+				return "", err
+			}
+			oldText, binary = text, binary || bin
+		} else {
+			oldName = devNull
+		}
+		if hasNew {
+			text, bin, err := readText(filepath.Join(right, rel))
+			if err != nil {
+				return "", err
+			}
+			newText, binary = text, binary || bin
+		} else {
+			newName = devNull
+		}
+
+		if binary {
+			fmt.Fprintf(&sb, "Binary files %s and %s differ\n", oldName, newName)
+			continue
+		}
+		sb.WriteString(unifiedDiff(oldName, newName, oldText, newText))
+	}
+	return sb.String(), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -8,6 +9,8 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/test"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -32,6 +35,7 @@ import (
 	"sync"
 	"time"
 	"unicode"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -41,8 +45,10 @@ type EditorWidget struct {
 
 	// Основные компоненты
 	content         *widget.Entry    // Изменено с RichText на Entry для редактирования
+	entryWidget     *editorEntry     // Обертка content, размещаемая в дереве виджетов
 	richContent     *widget.RichText // Для отображения с подсветкой
 	lineNumbers     *widget.Label
+	blameGutter     *BlameGutter
 	scrollContainer *container.Scroll
 	mainContainer   *fyne.Container // Изменено с container.Border на *fyne.Container
 
@@ -90,6 +96,7 @@ type EditorWidget struct {
 	autoSaveTimer  *time.Timer
 	lastSavedHash  string
 	lastModified   time.Time
+	savedContent   string // содержимое файла на момент загрузки/сохранения
 	lastRenderHash uint64
 
 	// File watching
@@ -97,15 +104,23 @@ type EditorWidget struct {
 	watcherCancel  context.CancelFunc
 	searchResults  []TextRange
 	highlightTimer *time.Timer
+	// Вхождения из панели поиска и индекс текущего
+	findMatches []TextRange
+	findCurrent int
 
 	// Callbacks
 	onContentChanged func(content string)
 	onCursorChanged  func(row, col int)
 	onFileChanged    func(filepath string)
+	// onExternalConflict вызывается, когда файл изменен и в редакторе, и на диске
+	onExternalConflict func(base, local, disk string)
 
 	// Мультикурсоры
 	cursors         []TextPosition
 	mainCursorIndex int
+	// Блочное выделение (по диапазону на строку) и слой его отрисовки
+	blockSelection  []TextRange
+	cursorContainer *fyne.Container
 
 	// Синхронизация
 	renderMutex sync.Mutex
@@ -127,7 +142,14 @@ type EditorWidget struct {
 	indicatorContainer *fyne.Container
 	fileName           string
 
-	vimMode VimMode
+	vimMode    VimMode
+	vimHandler *VimHandler
+
+	// Обработчик клавиш Emacs режима (нотация C-x, M-y); false - клавишу вводит Entry
+	emacsHandler func(key string) bool
+
+	// Обработчик горячих клавиш приложения; Fyne не передает их холсту, пока фокус у Entry
+	shortcutHandler func(s fyne.Shortcut) bool
 
 	// Bookmarks
 	bookmarks []Bookmark
@@ -209,6 +231,7 @@ func (e *EditorWidget) LoadFile(path string) error {
 	e.filePath = path
 	e.fileName = filepath.Base(path)
 	e.textContent = string(content)
+	e.savedContent = e.textContent
 	e.foldedRanges = make(map[int]FoldRange)
 	e.autoFoldApplied = false
 	e.content.SetText(e.textContent)
@@ -217,6 +240,7 @@ func (e *EditorWidget) LoadFile(path string) error {
 	e.detectLanguage()
 	e.updateDisplay()
 	e.ClearLintErrors()
+	e.ClearBlame()
 	e.startFileWatcher()
 
 	return nil
@@ -235,6 +259,7 @@ func (e *EditorWidget) SaveFile() error {
 	}
 
 	e.isDirty = false
+	e.savedContent = content
 	if info, err := os.Stat(e.filePath); err == nil {
 		e.lastModified = info.ModTime()
 	}
@@ -302,6 +327,15 @@ func (e *EditorWidget) GetBookmarks() []Bookmark {
 	return append([]Bookmark{}, e.bookmarks...)
 }
 
+// SetBookmarks заменяет все закладки, например при восстановлении сессии
+func (e *EditorWidget) SetBookmarks(bookmarks []Bookmark) {
+	e.bookmarks = append([]Bookmark(nil), bookmarks...)
+	e.updateLineNumbers()
+	if e.onBookmarksChanged != nil {
+		e.onBookmarksChanged()
+	}
+}
+
 // GoToBookmark moves cursor to bookmark line
 // GoToBookmark moves cursor to bookmark start line
 func (e *EditorWidget) GoToBookmark(b Bookmark) error {
@@ -399,6 +433,7 @@ func (e *EditorWidget) updateLineNumbers() {
 
 	lines := e.getLineCount()
 	digits := len(fmt.Sprintf("%d", lines))
+	relative := e.config != nil && e.config.Editor.ShowRelativeNumbers
 	var b strings.Builder
 	for i := 1; i <= lines; i++ {
 		if i > 1 {
@@ -408,7 +443,15 @@ func (e *EditorWidget) updateLineNumbers() {
 		if e.IsLineBookmarked(i) {
 			marker = "★ "
 		}
-		b.WriteString(fmt.Sprintf("%s%*d", marker, digits, i))
+		// Относительные номера считаются от строки курсора, сама она нумеруется как обычно
+		number := i
+		if relative && i != e.cursorRow+1 {
+			number = i - (e.cursorRow + 1)
+			if number < 0 {
+				number = -number
+			}
+		}
+		b.WriteString(fmt.Sprintf("%s%*d", marker, digits, number))
 	}
 
 	text := b.String()
@@ -569,9 +612,10 @@ func NewEditor(config *Config) *EditorWidget {
 // setupComponents создает и настраивает UI компоненты
 func (e *EditorWidget) setupComponents() {
 	// Создаем основной текстовый виджет (Entry для редактирования)
-	e.content = widget.NewMultiLineEntry()
+	e.entryWidget = newEditorEntry(e)
+	e.content = &e.entryWidget.Entry
 	e.content.Wrapping = fyne.TextWrapWord
-	configureEntryOverlay(e.content)
+	configureEntryOverlay(e.entryWidget)
 
 	// Настраиваем поведение в зависимости от WordWrap
 	if e.config.Editor.WordWrap { // Исправлено: config.Editor.WordWrap
@@ -590,24 +634,30 @@ func (e *EditorWidget) setupComponents() {
 	e.lineNumbers.TextStyle = fyne.TextStyle{Monospace: true}
 	e.updateLineNumbers()
 
+	// Колонка аннотаций git blame (скрыта по умолчанию)
+	e.blameGutter = NewBlameGutter()
+
 	// Контейнер для направляющих отступа
 	e.indentContainer = container.NewWithoutLayout()
 
 	// Контейнер для индикаторов фолдинга
 	e.indicatorContainer = container.NewWithoutLayout()
 
+	// Слой блочного выделения и дополнительных курсоров
+	e.cursorContainer = container.NewWithoutLayout()
+
 	// Создаем контейнер с прокруткой
 	// Размещаем RichText под Entry, чтобы цветная разметка
 	// не перекрывала курсор и выделение текста.
-	editorLayer := container.NewStack(e.richContent, e.content, e.indentContainer)
+	editorLayer := container.NewStack(e.richContent, e.cursorContainer, e.entryWidget, e.indentContainer)
 	var editorContent fyne.CanvasObject
 	if e.config.Editor.ShowLineNumbers {
-		leftPanel := container.NewBorder(nil, nil, e.indicatorContainer, nil, e.lineNumbers)
+		leftPanel := container.NewBorder(nil, nil, container.NewHBox(e.blameGutter, e.indicatorContainer), nil, e.lineNumbers)
 		editorContent = container.NewBorder(nil, nil, leftPanel, nil, editorLayer)
 	} else if e.config.Editor.CodeFolding {
-		editorContent = container.NewBorder(nil, nil, e.indicatorContainer, nil, editorLayer)
+		editorContent = container.NewBorder(nil, nil, container.NewHBox(e.blameGutter, e.indicatorContainer), nil, editorLayer)
 	} else {
-		editorContent = editorLayer
+		editorContent = container.NewBorder(nil, nil, e.blameGutter, nil, editorLayer)
 	}
 
 	e.scrollContainer = container.NewScroll(editorContent)
@@ -620,7 +670,7 @@ func (e *EditorWidget) setupComponents() {
 
 // configureEntryOverlay скрывает фон и текст стандартного Entry,
 // оставляя только курсор и прямоугольники выделения.
-func configureEntryOverlay(entry *widget.Entry) {
+func configureEntryOverlay(entry *editorEntry) {
 	r := test.WidgetRenderer(entry)
 	rv := reflect.ValueOf(r).Elem()
 
@@ -635,7 +685,7 @@ func configureEntryOverlay(entry *widget.Entry) {
 
 	hideRect("box")
 	hideRect("border")
-	hideEntryText(entry)
+	hideEntryText(&entry.Entry)
 }
 
 // hideEntryText делает текст и плейсхолдер Entry полностью прозрачными.
@@ -662,6 +712,144 @@ func hideEntryText(entry *widget.Entry) {
 	hideField("placeholder")
 }
 
+// editorEntry - Entry редактора; вне режима вставки Vim ввод уходит в VimHandler
+type editorEntry struct {
+	widget.Entry
+	editor *EditorWidget
+
+	shift       bool // нажат Shift: TypedKey не передает модификаторы
+	swallowRune bool // клавиша ушла в горячую клавишу, ее символ вводить не нужно
+}
+
+// newEditorEntry создает многострочный Entry редактора
+func newEditorEntry(editor *EditorWidget) *editorEntry {
+	entry := &editorEntry{editor: editor}
+	entry.MultiLine = true
+	entry.Wrapping = fyne.TextWrapOff
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+// vimCaptures сообщает, перехватывает ли Vim ввод текста
+func (en *editorEntry) vimCaptures() bool {
+	vh := en.editor.vimHandler
+	return vh != nil && vh.mode != VimInsert
+}
+
+// TypedRune передает символ Vim; в режиме вставки Vim только записывает его для повтора
+func (en *editorEntry) TypedRune(r rune) {
+	if en.swallowRune {
+		en.swallowRune = false
+		return
+	}
+	if h := en.editor.emacsHandler; h != nil && h(string(r)) {
+		return
+	}
+	if en.editor.vimHandler != nil {
+		if en.editor.handleVimKey(string(r)) || en.vimCaptures() {
+			return
+		}
+	}
+	en.Entry.TypedRune(r)
+}
+
+// vimInsertKeys - клавиши режима вставки, которые Vim записывает для повтора и макросов
+var vimInsertKeys = map[fyne.KeyName]string{
+	fyne.KeyReturn:    "Return",
+	fyne.KeyEnter:     "Return",
+	fyne.KeyBackspace: "Backspace",
+	fyne.KeyTab:       "Tab",
+	fyne.KeyDelete:    "Delete",
+}
+
+// TypedKey передает специальные клавиши Vim; навигация остается за Entry
+func (en *editorEntry) TypedKey(ev *fyne.KeyEvent) {
+	if h := en.editor.emacsHandler; h != nil && h(emacsKeyName(ev.Name, 0)) {
+		return
+	}
+	if en.editor.vimHandler != nil && ev.Name == fyne.KeyEscape {
+		en.editor.handleVimKey("Escape")
+		return
+	}
+	if en.vimCaptures() {
+		switch ev.Name {
+		case fyne.KeyReturn, fyne.KeyEnter:
+			en.editor.handleVimKey("Return")
+		case fyne.KeyBackspace:
+			en.editor.handleVimKey("Backspace")
+		case fyne.KeyTab:
+			en.editor.handleVimKey("Tab")
+		case fyne.KeyUp, fyne.KeyDown:
+			// В командной строке стрелки листают историю
+			if en.editor.vimHandler.mode == VimCommand {
+				en.editor.handleVimKey(string(ev.Name))
+			} else {
+				en.Entry.TypedKey(ev)
+			}
+		case fyne.KeyLeft, fyne.KeyRight,
+			fyne.KeyHome, fyne.KeyEnd, fyne.KeyPageUp, fyne.KeyPageDown:
+			en.Entry.TypedKey(ev)
+		}
+		return
+	}
+	// Блочная вставка Vim применяет клавишу сама на всех строках
+	if key, ok := vimInsertKeys[ev.Name]; ok && en.editor.vimHandler != nil && en.editor.handleVimKey(key) {
+		return
+	}
+	// Клавиши без Ctrl/Alt (F3, Shift+F3, вторая клавиша аккорда) - горячим клавишам приложения
+	if h := en.editor.shortcutHandler; h != nil {
+		shortcut := &desktop.CustomShortcut{KeyName: ev.Name}
+		if en.shift {
+			shortcut.Modifier = fyne.KeyModifierShift
+		}
+		if h(shortcut) {
+			en.swallowRune = true
+			return
+		}
+	}
+	en.Entry.TypedKey(ev)
+}
+
+// KeyDown отслеживает Shift для горячих клавиш без Ctrl/Alt
+func (en *editorEntry) KeyDown(ev *fyne.KeyEvent) {
+	if ev.Name == desktop.KeyShiftLeft || ev.Name == desktop.KeyShiftRight {
+		en.shift = true
+	}
+	en.Entry.KeyDown(ev)
+}
+
+// KeyUp отслеживает отпускание Shift
+func (en *editorEntry) KeyUp(ev *fyne.KeyEvent) {
+	if ev.Name == desktop.KeyShiftLeft || ev.Name == desktop.KeyShiftRight {
+		en.shift = false
+	}
+	en.Entry.KeyUp(ev)
+}
+
+// TypedShortcut передает Ctrl-команды (Ctrl+r, Ctrl+d ...) Vim в командных режимах
+func (en *editorEntry) TypedShortcut(s fyne.Shortcut) {
+	// В Emacs режиме Ctrl/Alt сочетания - команды Emacs (C-x, C-a, C-y приходят как вырезать, выделить все, повтор)
+	if h := en.editor.emacsHandler; h != nil {
+		if key := emacsShortcutKey(s); key != "" && h(key) {
+			return
+		}
+	}
+	// Ctrl+V приходит как вставка, в Vim это блочное выделение
+	if _, ok := s.(*fyne.ShortcutPaste); ok && en.vimCaptures() {
+		en.editor.handleVimKey("Ctrl+v")
+		return
+	}
+	if cs, ok := s.(*desktop.CustomShortcut); ok && en.vimCaptures() && cs.Modifier == fyne.KeyModifierControl {
+		if en.editor.handleVimKey("Ctrl+" + strings.ToLower(string(cs.KeyName))) {
+			return
+		}
+	}
+	if cs, ok := s.(*desktop.CustomShortcut); ok && en.editor.shortcutHandler != nil && en.editor.shortcutHandler(cs) {
+		return
+	}
+	en.Entry.TypedShortcut(s)
+}
+
 // setupAutoSave настраивает автосохранение
 func (e *EditorWidget) setupAutoSave() {
 	if !e.config.Editor.AutoSave { // Исправлено: config.Editor.AutoSave
@@ -919,6 +1107,48 @@ func (e *EditorWidget) indexToPosition(idx int) TextPosition {
 	return TextPosition{Row: lastRow, Col: len(lines[lastRow])}
 }
 
+// tabSize возвращает ширину табуляции из настроек
+func (e *EditorWidget) tabSize() int {
+	if e.config != nil && e.config.Editor.TabSize > 0 {
+		return e.config.Editor.TabSize
+	}
+	return 4
+}
+
+// displayColumn возвращает экранный столбец байтового смещения в строке:
+// каждый символ занимает один столбец, табуляция - до следующей позиции табуляции
+func displayColumn(line string, offset, tabSize int) int {
+	col := 0
+	for i, r := range line {
+		if i >= offset {
+			break
+		}
+		if r == '\t' {
+			col += tabSize - col%tabSize
+		} else {
+			col++
+		}
+	}
+	return col
+}
+
+// columnOffset возвращает байтовое смещение символа, занимающего экранный
+// столбец col, или len(line), если строка короче
+func columnOffset(line string, col, tabSize int) int {
+	at := 0
+	for i, r := range line {
+		if r == '\t' {
+			at += tabSize - at%tabSize
+		} else {
+			at++
+		}
+		if at > col {
+			return i
+		}
+	}
+	return len(line)
+}
+
 // isIndexInSearchResults проверяет, пересекается ли диапазон с найденными совпадениями
 func (e *EditorWidget) isIndexInSearchResults(start, end int) bool {
 	for _, r := range e.searchResults {
@@ -1020,6 +1250,9 @@ func (e *EditorWidget) updateDisplay() {
 		e.lastRenderHash = contentHash
 	}
 
+	// Курсоры и блочное выделение зависят не только от текста
+	e.updateCursorOverlay()
+
 	// Обновляем номера строк и содержимое в главном потоке UI
 	fyne.Do(func() {
 		if e.lineNumbers != nil {
@@ -1083,6 +1316,29 @@ func (e *EditorWidget) SetFilePath(path string) {
 	e.fileName = filepath.Base(path)
 }
 
+// FollowRename переносит буфер на новый путь после переименования файла на диске
+func (e *EditorWidget) FollowRename(path string) {
+	e.SetFilePath(path)
+	if info, err := os.Stat(path); err == nil {
+		e.lastModified = info.ModTime()
+	}
+	e.detectLanguage()
+	e.updateDisplay()
+	e.startFileWatcher()
+
+	if e.onFileChanged != nil {
+		e.onFileChanged(path)
+	}
+}
+
+// MarkDeleted помечает буфер измененным, когда его файл удален с диска,
+// чтобы содержимое не потерялось при закрытии
+func (e *EditorWidget) MarkDeleted() {
+	e.stopFileWatcher()
+	e.savedContent = ""
+	e.isDirty = true
+}
+
 // GetLanguage возвращает определенный для текущего файла язык
 func (e *EditorWidget) GetLanguage() string {
 	return e.language
@@ -1093,13 +1349,55 @@ func (e *EditorWidget) ExecuteCommand(cmd EditorCommand) error {
 	if err := cmd.Execute(e); err != nil {
 		return err
 	}
+	e.pushUndo(cmd)
+	e.isDirty = true
+	return nil
+}
+
+// ReplaceContent заменяет весь текст, записывая в историю отмены только измененную середину
+func (e *EditorWidget) ReplaceContent(text string, merge bool) {
+	old := e.textContent
+	start := 0
+	for start < len(old) && start < len(text) && old[start] == text[start] {
+		start++
+	}
+	end := 0
+	for end < len(old)-start && end < len(text)-start && old[len(old)-1-end] == text[len(text)-1-end] {
+		end++
+	}
+	if start == len(old) && start == len(text) {
+		return
+	}
+	e.ReplaceRange(start, len(old)-end, text[start:len(text)-end], merge)
+}
+
+// pushUndo добавляет выполненную команду в историю отмены
+func (e *EditorWidget) pushUndo(cmd EditorCommand) {
 	e.undoStack = append(e.undoStack, cmd)
 	e.redoStack = nil
 	if len(e.undoStack) > e.maxUndoLevels {
 		e.undoStack = e.undoStack[1:]
 	}
+}
+
+// ReplaceRange заменяет textContent[start:end] на text с записью в историю отмены.
+// С merge правка сливается с предыдущей, если касается ее текста.
+// Курсор и отображение обновляет вызывающий код.
+func (e *EditorWidget) ReplaceRange(start, end int, text string, merge bool) {
+	cmd := &EditTextCommand{start: start, removed: e.textContent[start:end], text: text}
+	merged := false
+	if merge && len(e.undoStack) > 0 {
+		if last, ok := e.undoStack[len(e.undoStack)-1].(*EditTextCommand); ok {
+			merged = last.absorb(cmd, e.textContent)
+		}
+	}
+	if merged {
+		e.redoStack = nil
+	} else {
+		e.pushUndo(cmd)
+	}
+	e.textContent = e.textContent[:start] + text + e.textContent[end:]
 	e.isDirty = true
-	return nil
 }
 
 // Undo отменяет последнюю команду
@@ -1212,6 +1510,7 @@ func (e *EditorWidget) AddCursorAbove() {
 	if e.cursorRow > 0 {
 		pos := TextPosition{Row: e.cursorRow - 1, Col: e.cursorCol}
 		e.cursors = append(e.cursors, pos)
+		e.updateCursorOverlay()
 	}
 }
 
@@ -1221,6 +1520,7 @@ func (e *EditorWidget) AddCursorBelow() {
 	if e.cursorRow < len(lines)-1 {
 		pos := TextPosition{Row: e.cursorRow + 1, Col: e.cursorCol}
 		e.cursors = append(e.cursors, pos)
+		e.updateCursorOverlay()
 	}
 }
 
@@ -1322,44 +1622,90 @@ func (e *EditorWidget) KillWord() string {
 	return ""
 }
 
-// FindNext ищет следующее вхождение строки
+// FindNext ищет следующее вхождение строки с учетом настроек поиска
 func (e *EditorWidget) FindNext(term string) bool {
-	if term == "" {
+	matches := e.findAll(term)
+	if len(matches) == 0 {
 		return false
 	}
-	content := e.textContent
-	startIdx := e.cursorIndex()
-	idx := strings.Index(content[startIdx:], term)
-	if idx == -1 {
-		idx = strings.Index(content, term)
-		if idx == -1 {
-			return false
+	from := e.cursorIndex()
+	for _, m := range matches {
+		if m[0] >= from {
+			e.selectIndexRange(m[0], m[1])
+			return true
 		}
-	} else {
-		idx += startIdx
 	}
-	e.moveCursorToIndex(idx)
+	if e.config != nil && !e.config.Editor.SearchWrap {
+		return false
+	}
+	e.selectIndexRange(matches[0][0], matches[0][1])
 	return true
 }
 
-// FindPrevious ищет предыдущее вхождение строки
+// FindPrevious ищет предыдущее вхождение строки с учетом настроек поиска
 func (e *EditorWidget) FindPrevious(term string) bool {
-	if term == "" {
+	matches := e.findAll(term)
+	if len(matches) == 0 {
 		return false
 	}
-	content := e.textContent
-	startIdx := e.cursorIndex()
-	if startIdx > 0 {
-		content = content[:startIdx]
+	// Курсор стоит в конце выделенного вхождения - ищем до его начала
+	before := e.cursorIndex()
+	if e.selectionStart != e.selectionEnd {
+		before = min(before, e.positionToIndex(e.selectionStart), e.positionToIndex(e.selectionEnd))
 	}
-	idx := strings.LastIndex(content, term)
-	if idx == -1 {
+	for i := len(matches) - 1; i >= 0; i-- {
+		if matches[i][0] < before {
+			e.selectIndexRange(matches[i][0], matches[i][1])
+			return true
+		}
+	}
+	if e.config != nil && !e.config.Editor.SearchWrap {
 		return false
 	}
-	e.moveCursorToIndex(idx)
+	last := matches[len(matches)-1]
+	e.selectIndexRange(last[0], last[1])
 	return true
 }
 
+// findAll возвращает непустые вхождения строки по настройкам поиска из конфигурации
+func (e *EditorWidget) findAll(term string) [][]int {
+	if term == "" {
+		return nil
+	}
+	opts := SearchOptions{Query: term}
+	if e.config != nil {
+		opts.CaseSensitive = e.config.Editor.SearchCaseSensitive
+		opts.WholeWord = e.config.Editor.SearchWholeWord
+		opts.Regex = e.config.Editor.SearchRegex
+	}
+	re, err := compileSearchPattern(opts)
+	if err != nil {
+		return nil
+	}
+	var matches [][]int
+	for _, m := range re.FindAllStringIndex(e.textContent, -1) {
+		if m[1] > m[0] {
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// selectIndexRange выделяет диапазон текста и ставит курсор в его конец
+func (e *EditorWidget) selectIndexRange(start, end int) {
+	e.selectionStart = e.indexToPosition(start)
+	e.selectionEnd = e.indexToPosition(end)
+	e.moveCursorToIndex(end)
+	e.updateDisplay()
+}
+
+// SetFindMatches задает подсвечиваемые вхождения поиска; current < 0 - без текущего
+func (e *EditorWidget) SetFindMatches(matches []TextRange, current int) {
+	e.findMatches = matches
+	e.findCurrent = current
+	e.updateCursorOverlay()
+}
+
 // cursorIndex возвращает индекс курсора в тексте
 func (e *EditorWidget) cursorIndex() int {
 	lines := strings.Split(e.textContent, "\n")
@@ -1653,6 +1999,38 @@ func (e *EditorWidget) SetVimMode(mode VimMode) {
 	e.vimMode = mode
 }
 
+// SetVimHandler подключает обработчик Vim (nil - Vim отключен)
+func (e *EditorWidget) SetVimHandler(vh *VimHandler) {
+	e.vimHandler = vh
+	if vh != nil {
+		e.SetVimMode(vh.mode)
+	}
+}
+
+// SetEmacsHandler подключает обработку клавиш Emacs режима
+func (e *EditorWidget) SetEmacsHandler(handler func(key string) bool) {
+	e.emacsHandler = handler
+}
+
+// SetShortcutHandler подключает горячие клавиши приложения к редактору
+func (e *EditorWidget) SetShortcutHandler(handler func(s fyne.Shortcut) bool) {
+	e.shortcutHandler = handler
+}
+
+// handleVimKey передает клавишу обработчику Vim
+func (e *EditorWidget) handleVimKey(key string) bool {
+	vh := e.vimHandler
+	if vh == nil {
+		return false
+	}
+	// Необработанные клавиши вставки остаются за Entry, курсор не трогаем
+	if !vh.HandleKey(key) {
+		return false
+	}
+	vh.syncEditor()
+	return true
+}
+
 // applySyntaxHighlighting применяет подсветку синтаксиса
 func (e *EditorWidget) applySyntaxHighlighting() {
 	// Всегда синхронизируем Entry с текущим текстом
@@ -1824,7 +2202,9 @@ func (e *EditorWidget) bracketTypesMatch(open, close rune) bool {
 	return pairs[open] == close
 }
 
-// positionToIndex преобразует позицию в индекс
+// positionToIndex преобразует позицию в индекс. Столбец len(line) - позиция
+// в конце строки перед переводом строки; столбец за концом строки и строка за
+// концом текста прижимаются к концу
 func (e *EditorWidget) positionToIndex(pos TextPosition) int {
 	lines := strings.Split(e.textContent, "\n")
 	index := 0
@@ -1832,11 +2212,11 @@ func (e *EditorWidget) positionToIndex(pos TextPosition) int {
 	for i := 0; i < pos.Row && i < len(lines); i++ {
 		index += len(lines[i]) + 1 // +1 for newline
 	}
-
-	if pos.Row < len(lines) && pos.Col < len(lines[pos.Row]) {
-		index += pos.Col
+	if pos.Row >= len(lines) {
+		return len(e.textContent)
 	}
 
+	index += max(0, min(pos.Col, len(lines[pos.Row])))
 	return index
 }
 
@@ -2104,6 +2484,52 @@ func (e *EditorWidget) updateIndentGuides() {
 	})
 }
 
+// updateCursorOverlay рисует блочное выделение и дополнительные курсоры
+func (e *EditorWidget) updateCursorOverlay() {
+	if e.cursorContainer == nil {
+		return
+	}
+
+	charWidth := MeasureString(" ", theme.TextSize()).Width
+	lineHeight := MeasureString("M", theme.TextSize()).Height
+	innerPad := e.content.Theme().Size(theme.SizeNameInnerPadding)
+	selection := append([]TextRange(nil), e.blockSelection...)
+	cursors := append([]TextPosition(nil), e.cursors...)
+	selColor, cursorColor := e.colors.Selection, e.colors.Cursor
+	if selColor == nil || cursorColor == nil {
+		selColor, cursorColor = theme.Color(theme.ColorNameSelection), theme.Color(theme.ColorNamePrimary)
+	}
+	findRects := e.findMatchRects()
+	matchColor, currentColor := findMatchColors()
+
+	fyne.Do(func() {
+		e.cursorContainer.Objects = nil
+		for _, r := range findRects {
+			c := matchColor
+			if r.current {
+				c = currentColor
+			}
+			rect := canvas.NewRectangle(c)
+			rect.Move(fyne.NewPos(innerPad+float32(r.Start.Col)*charWidth, innerPad+float32(r.Start.Row)*lineHeight))
+			rect.Resize(fyne.NewSize(float32(max(r.End.Col-r.Start.Col, 1))*charWidth, lineHeight))
+			e.cursorContainer.Add(rect)
+		}
+		for _, r := range selection {
+			rect := canvas.NewRectangle(selColor)
+			rect.Move(fyne.NewPos(innerPad+float32(r.Start.Col)*charWidth, innerPad+float32(r.Start.Row)*lineHeight))
+			rect.Resize(fyne.NewSize(float32(max(r.End.Col-r.Start.Col, 1))*charWidth, lineHeight))
+			e.cursorContainer.Add(rect)
+		}
+		for _, c := range cursors {
+			caret := canvas.NewRectangle(cursorColor)
+			caret.Move(fyne.NewPos(innerPad+float32(c.Col)*charWidth, innerPad+float32(c.Row)*lineHeight))
+			caret.Resize(fyne.NewSize(2, lineHeight))
+			e.cursorContainer.Add(caret)
+		}
+		e.cursorContainer.Refresh()
+	})
+}
+
 // parseClickableElements парсит интерактивные элементы в коде
 func (e *EditorWidget) parseClickableElements() {
 	e.clickableRanges = []ClickableRange{}
@@ -2580,6 +3006,13 @@ func (e *EditorWidget) handleExternalFileChange() {
 	// Все UI-операции должны выполняться в главном потоке
 	fyne.Do(func() {
 		win := fyne.CurrentApp().Driver().AllWindows()[0]
+
+		// Изменения и в редакторе, и на диске - предлагаем слияние
+		if e.isDirty && e.onExternalConflict != nil {
+			e.showExternalConflictDialog(win)
+			return
+		}
+
 		message := "File has been modified outside the editor."
 		if e.isDirty {
 			message += "\nReloading will discard your unsaved changes."
@@ -2595,6 +3028,41 @@ func (e *EditorWidget) handleExternalFileChange() {
 	})
 }
 
+// showExternalConflictDialog предлагает перезагрузить, оставить или слить изменения
+func (e *EditorWidget) showExternalConflictDialog(win fyne.Window) {
+	disk, err := os.ReadFile(e.filePath)
+	if err != nil {
+		dialog.ShowError(err, win)
+		return
+	}
+
+	var d *dialog.CustomDialog
+	reloadBtn := widget.NewButton("Reload", func() {
+		d.Hide()
+		if err := e.LoadFile(e.filePath); err != nil {
+			dialog.ShowError(err, win)
+		}
+	})
+	keepBtn := widget.NewButton("Keep Mine", func() {
+		d.Hide()
+		e.savedContent = string(disk)
+	})
+	mergeBtn := widget.NewButton("Merge", func() {
+		d.Hide()
+		base := e.savedContent
+		e.savedContent = string(disk)
+		e.onExternalConflict(base, e.GetFullText(), string(disk))
+	})
+	mergeBtn.Importance = widget.HighImportance
+
+	content := container.NewVBox(
+		widget.NewLabel("File has been modified outside the editor,\nand you have unsaved changes."),
+		container.NewHBox(layout.NewSpacer(), reloadBtn, keepBtn, mergeBtn),
+	)
+	d = dialog.NewCustomWithoutButtons("File Changed", content, win)
+	d.Show()
+}
+
 // CreateObject реализует интерфейс fyne.Widget
 func (e *EditorWidget) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(e.mainContainer)
@@ -2624,7 +3092,7 @@ func (e *EditorWidget) GetFilePath() string { return e.filePath }
 func (e *EditorWidget) GetFileName() string { return e.fileName }
 
 func (e *EditorWidget) showContextMenu(ev *fyne.PointEvent) {
-	c := fyne.CurrentApp().Driver().CanvasForObject(e.content)
+	c := fyne.CurrentApp().Driver().CanvasForObject(e.entryWidget)
 	if c == nil {
 		return
 	}
@@ -2769,6 +3237,30 @@ func isASCII(data []byte) bool {
 	return true
 }
 
+// isBinaryContent определяет двоичные данные по нулевым байтам и невалидному UTF-8
+func isBinaryContent(data []byte) bool {
+	sample := data
+	truncated := len(sample) > 8000
+	if truncated {
+		sample = sample[:8000]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	if isASCII(sample) {
+		return false
+	}
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size == 1 {
+			// Символ мог быть обрезан на границе выборки
+			return !(truncated && !utf8.FullRune(sample))
+		}
+		sample = sample[size:]
+	}
+	return false
+}
+
 func detectLineEnding(data []byte) string {
 	content := string(data)
 	if strings.Contains(content, "\r\n") {
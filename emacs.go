@@ -0,0 +1,1318 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+)
+
+// emacsKillRingMax - размер kill ring (kill-ring-max)
+const emacsKillRingMax = 60
+
+// emacsMarkRingMax - размер mark ring (mark-ring-max)
+const emacsMarkRingMax = 16
+
+// emacsCommands - последовательности клавиш Emacs режима и их действия
+var emacsCommands = map[string]string{
+	"C-f":     "emacs_forward_char",
+	"C-b":     "emacs_backward_char",
+	"C-n":     "emacs_next_line",
+	"C-p":     "emacs_previous_line",
+	"C-a":     "emacs_beginning_of_line",
+	"C-e":     "emacs_end_of_line",
+	"M-f":     "emacs_forward_word",
+	"M-b":     "emacs_backward_word",
+	"M-<":     "emacs_beginning_of_buffer",
+	"M->":     "emacs_end_of_buffer",
+	"C-d":     "emacs_delete_char",
+	"C-k":     "emacs_kill_line",
+	"M-d":     "emacs_kill_word",
+	"M-DEL":   "emacs_backward_kill_word",
+	"C-w":     "emacs_kill_region",
+	"M-w":     "emacs_copy_region",
+	"C-y":     "emacs_yank",
+	"M-y":     "emacs_yank_pop",
+	"C-SPC":   "emacs_set_mark",
+	"C-@":     "emacs_set_mark",
+	"C-x C-x": "emacs_exchange_point_and_mark",
+	"C-g":     "emacs_keyboard_quit",
+	"C-s":     "emacs_search_forward",
+	"C-r":     "emacs_search_backward",
+	"C-u":     "emacs_universal_argument",
+	"C-t":     "emacs_transpose_chars",
+	"M-t":     "emacs_transpose_words",
+	"C-x C-t": "emacs_transpose_lines",
+	"C-x (":   "emacs_start_macro",
+	"C-x )":   "emacs_end_macro",
+	"C-x e":   "emacs_call_macro",
+	"C-x r k": "emacs_kill_rectangle",
+	"C-x r d": "emacs_delete_rectangle",
+	"C-x r y": "emacs_yank_rectangle",
+	"C-x r o": "emacs_open_rectangle",
+	"C-x u":   "undo",
+	"C-/":     "undo",
+	"C-_":     "undo",
+	"C-x C-f": "open_file",
+	"C-x C-s": "save_file",
+}
+
+// emacsPrefixes - начала многоклавишных команд (C-x, C-x r ...)
+var emacsPrefixes = func() map[string]bool {
+	prefixes := make(map[string]bool)
+	for seq := range emacsCommands {
+		keys := strings.Fields(seq)
+		for i := 1; i < len(keys); i++ {
+			prefixes[strings.Join(keys[:i], " ")] = true
+		}
+	}
+	return prefixes
+}()
+
+// emacsKeyNames - названия специальных клавиш в записи Emacs
+var emacsKeyNames = map[fyne.KeyName]string{
+	fyne.KeySpace:     "SPC",
+	fyne.KeyReturn:    "RET",
+	fyne.KeyEnter:     "RET",
+	fyne.KeyBackspace: "DEL",
+	fyne.KeyTab:       "TAB",
+	fyne.KeyEscape:    "ESC",
+	fyne.KeyDelete:    "<deletechar>",
+	fyne.KeyLeft:      "<left>",
+	fyne.KeyRight:     "<right>",
+	fyne.KeyUp:        "<up>",
+	fyne.KeyDown:      "<down>",
+	fyne.KeyHome:      "<home>",
+	fyne.KeyEnd:       "<end>",
+	fyne.KeyPageUp:    "<prior>",
+	fyne.KeyPageDown:  "<next>",
+}
+
+// emacsShifted - символы, которые дает Shift на клавишах с модификатором (M-< и т.п.)
+var emacsShifted = map[string]string{
+	",": "<", ".": ">", "-": "_", "/": "?", "2": "@", "9": "(", "0": ")",
+}
+
+// registerEmacsActions регистрирует действия Emacs режима
+func (hm *HotkeyManager) registerEmacsActions() {
+	hm.actions["emacs_forward_char"] = hm.actionEmacsForwardChar
+	hm.actions["emacs_backward_char"] = hm.actionEmacsBackwardChar
+	hm.actions["emacs_next_line"] = hm.actionEmacsNextLine
+	hm.actions["emacs_previous_line"] = hm.actionEmacsPreviousLine
+	hm.actions["emacs_beginning_of_line"] = hm.actionEmacsBeginningOfLine
+	hm.actions["emacs_end_of_line"] = hm.actionEmacsEndOfLine
+	hm.actions["emacs_forward_word"] = hm.actionEmacsForwardWord
+	hm.actions["emacs_backward_word"] = hm.actionEmacsBackwardWord
+	hm.actions["emacs_beginning_of_buffer"] = hm.actionEmacsBeginningOfBuffer
+	hm.actions["emacs_end_of_buffer"] = hm.actionEmacsEndOfBuffer
+	hm.actions["emacs_delete_char"] = hm.actionEmacsDeleteChar
+	hm.actions["emacs_kill_line"] = hm.actionEmacsKillLine
+	hm.actions["emacs_kill_word"] = hm.actionEmacsKillWord
+	hm.actions["emacs_backward_kill_word"] = hm.actionEmacsBackwardKillWord
+	hm.actions["emacs_kill_region"] = hm.actionEmacsKillRegion
+	hm.actions["emacs_copy_region"] = hm.actionEmacsCopyRegion
+	hm.actions["emacs_yank"] = hm.actionEmacsYank
+	hm.actions["emacs_yank_pop"] = hm.actionEmacsYankPop
+	hm.actions["emacs_set_mark"] = hm.actionEmacsSetMark
+	hm.actions["emacs_exchange_point_and_mark"] = hm.actionEmacsExchangePointAndMark
+	hm.actions["emacs_keyboard_quit"] = hm.actionEmacsKeyboardQuit
+	hm.actions["emacs_search_forward"] = hm.actionEmacsSearchForward
+	hm.actions["emacs_search_backward"] = hm.actionEmacsSearchBackward
+	hm.actions["emacs_universal_argument"] = hm.actionEmacsUniversalArgument
+	hm.actions["emacs_transpose_chars"] = hm.actionEmacsTransposeChars
+	hm.actions["emacs_transpose_words"] = hm.actionEmacsTransposeWords
+	hm.actions["emacs_transpose_lines"] = hm.actionEmacsTransposeLines
+	hm.actions["emacs_start_macro"] = hm.actionEmacsStartMacro
+	hm.actions["emacs_end_macro"] = hm.actionEmacsEndMacro
+	hm.actions["emacs_call_macro"] = hm.actionEmacsCallMacro
+	hm.actions["emacs_kill_rectangle"] = hm.actionEmacsKillRectangle
+	hm.actions["emacs_delete_rectangle"] = hm.actionEmacsDeleteRectangle
+	hm.actions["emacs_yank_rectangle"] = hm.actionEmacsYankRectangle
+	hm.actions["emacs_open_rectangle"] = hm.actionEmacsOpenRectangle
+}
+
+// syncEmacsHandler передает редактору клавиши Emacs, когда включен Emacs режим
+func (hm *HotkeyManager) syncEmacsHandler() {
+	if hm.app == nil || hm.app.editor == nil {
+		return
+	}
+	if hm.currentMode == ModeEmacs {
+		hm.app.editor.SetEmacsHandler(hm.handleEmacsKey)
+	} else {
+		hm.app.editor.SetEmacsHandler(nil)
+	}
+}
+
+// emacsKeyName возвращает запись клавиши в нотации Emacs (C-x, M-y, C-SPC ...)
+func emacsKeyName(name fyne.KeyName, mods fyne.KeyModifier) string {
+	key, special := emacsKeyNames[name]
+	if !special {
+		key = strings.ToLower(string(name))
+		if utf8.RuneCountInString(key) != 1 {
+			key = "<" + key + ">"
+		}
+	}
+	if mods&fyne.KeyModifierShift != 0 && !special {
+		if shifted, ok := emacsShifted[key]; ok {
+			key = shifted
+		} else {
+			key = strings.ToUpper(key)
+		}
+	}
+	if mods&fyne.KeyModifierAlt != 0 {
+		key = "M-" + key
+	}
+	if mods&fyne.KeyModifierControl != 0 {
+		key = "C-" + key
+	}
+	if mods&fyne.KeyModifierSuper != 0 {
+		key = "s-" + key
+	}
+	return key
+}
+
+// emacsShortcutKey переводит сочетание Fyne в клавишу Emacs; стандартные
+// сочетания (вырезать, вставить ...) Fyne присылает отдельными типами
+func emacsShortcutKey(s fyne.Shortcut) string {
+	switch sc := s.(type) {
+	case *desktop.CustomShortcut:
+		return emacsKeyName(sc.KeyName, sc.Modifier)
+	case *fyne.ShortcutCut:
+		return "C-x"
+	case *fyne.ShortcutCopy:
+		return "C-c"
+	case *fyne.ShortcutPaste:
+		return "C-v"
+	case *fyne.ShortcutSelectAll:
+		return "C-a"
+	case *fyne.ShortcutUndo:
+		return "C-z"
+	case *fyne.ShortcutRedo:
+		return "C-y"
+	}
+	return ""
+}
+
+// handleEmacsKeyEvent обрабатывает клавиши Emacs режима, пришедшие мимо редактора
+func (hm *HotkeyManager) handleEmacsKeyEvent(event *fyne.KeyEvent) bool {
+	var mods fyne.KeyModifier
+	state := hm.getModifierState()
+	if state.Ctrl {
+		mods |= fyne.KeyModifierControl
+	}
+	if state.Alt {
+		mods |= fyne.KeyModifierAlt
+	}
+	if state.Shift {
+		mods |= fyne.KeyModifierShift
+	}
+	if mods&(fyne.KeyModifierControl|fyne.KeyModifierAlt) == 0 && len(hm.emacsState.PendingKeys) == 0 {
+		return false
+	}
+	return hm.handleEmacsKey(emacsKeyName(event.Name, mods))
+}
+
+// handleEmacsKey обрабатывает клавишу Emacs режима; false - клавишу обрабатывает Entry
+func (hm *HotkeyManager) handleEmacsKey(key string) bool {
+	state := hm.emacsState
+	if state.RecordingMacro && !state.PlayingMacro {
+		state.MacroKeys = append(state.MacroKeys, key)
+	}
+	state.EchoMessage = ""
+	defer hm.emacsSync()
+
+	// Инкрементальный поиск забирает клавиши, пока не завершится
+	if state.IncrementalSearch && hm.emacsSearchKey(key) {
+		return true
+	}
+
+	// Цифры и минус после C-u (или M-цифры) набирают числовой аргумент
+	if hm.emacsArgumentKey(key) {
+		return true
+	}
+
+	// C-x e можно повторять одной клавишей e
+	if key == "e" && state.LastCommand == "macro" && len(state.PendingKeys) == 0 {
+		return hm.runEmacsCommand("emacs_call_macro", 1)
+	}
+
+	// C-g отменяет и недонабранную последовательность
+	if key == "C-g" {
+		state.PendingKeys = nil
+		return hm.runEmacsCommand("emacs_keyboard_quit", 1)
+	}
+
+	seq := strings.Join(append(state.PendingKeys, key), " ")
+	if action, ok := emacsCommands[seq]; ok {
+		state.PendingKeys = nil
+		return hm.runEmacsCommand(action, len(strings.Fields(seq)))
+	}
+	if emacsPrefixes[seq] {
+		state.PendingKeys = append(state.PendingKeys, key)
+		return true
+	}
+	if len(state.PendingKeys) > 0 {
+		hm.emacsMessage("%s is undefined", seq)
+		state.PendingKeys = nil
+		hm.emacsResetArgument()
+		return true
+	}
+
+	// Самовставка с числовым аргументом: C-u 3 a -> aaa
+	if state.UniversalArg && utf8.RuneCountInString(key) == 1 {
+		if n := hm.emacsCount(); n > 0 {
+			hm.emacsInsert(strings.Repeat(key, n))
+		}
+		hm.emacsResetArgument()
+		state.LastCommand = ""
+		return true
+	}
+
+	// Остальные клавиши (ввод текста, стрелки) обрабатывает Entry
+	hm.emacsResetArgument()
+	state.LastCommand = ""
+	if state.MarkActive && !strings.HasPrefix(key, "C-") && !strings.HasPrefix(key, "M-") {
+		state.MarkActive = false
+	}
+	return false
+}
+
+// runEmacsCommand выполняет действие Emacs; keys - число клавиш его последовательности
+func (hm *HotkeyManager) runEmacsCommand(action string, keys int) bool {
+	state := hm.emacsState
+	state.ThisCommand = ""
+	state.commandKeys = keys
+	hm.executeAction(action)
+	// C-u только набирает аргумент и не прерывает цепочку kill/yank
+	if action == "emacs_universal_argument" {
+		return true
+	}
+	hm.emacsResetArgument()
+	state.LastCommand = state.ThisCommand
+	return true
+}
+
+// emacsArgumentKey набирает числовой аргумент после C-u; M-цифры начинают его сразу
+func (hm *HotkeyManager) emacsArgumentKey(key string) bool {
+	state := hm.emacsState
+	digit := key
+	if strings.HasPrefix(key, "M-") && len(state.PendingKeys) == 0 {
+		digit = key[2:]
+		if len(digit) == 1 && (digit == "-" || digit[0] >= '0' && digit[0] <= '9') && !state.ArgCollecting {
+			state.UniversalArg, state.ArgCollecting = true, true
+			state.PrefixArgument, state.ArgDigits, state.ArgNegative = 1, false, false
+		}
+	}
+	if !state.ArgCollecting || len(digit) != 1 {
+		return false
+	}
+	switch {
+	case digit == "-" && !state.ArgDigits:
+		state.ArgNegative = !state.ArgNegative
+		state.PrefixArgument = 1
+	case digit[0] >= '0' && digit[0] <= '9':
+		d := int(digit[0] - '0')
+		if state.ArgDigits {
+			state.PrefixArgument = state.PrefixArgument*10 + d
+		} else {
+			state.PrefixArgument = d
+		}
+		state.ArgDigits = true
+	default:
+		return false
+	}
+	return true
+}
+
+// emacsCount возвращает числовой аргумент текущей команды (1 без C-u)
+func (hm *HotkeyManager) emacsCount() int {
+	state := hm.emacsState
+	if !state.UniversalArg {
+		return 1
+	}
+	if state.ArgNegative {
+		return -state.PrefixArgument
+	}
+	return state.PrefixArgument
+}
+
+// emacsResetArgument сбрасывает числовой аргумент после выполнения команды
+func (hm *HotkeyManager) emacsResetArgument() {
+	state := hm.emacsState
+	state.UniversalArg, state.ArgCollecting = false, false
+	state.PrefixArgument, state.ArgDigits, state.ArgNegative = 0, false, false
+}
+
+// EmacsStatusText возвращает строку состояния Emacs режима: поиск, префикс, аргумент, запись макроса
+func (hm *HotkeyManager) EmacsStatusText() string {
+	state := hm.emacsState
+	var parts []string
+	if state.RecordingMacro {
+		parts = append(parts, "Def")
+	}
+	switch {
+	case state.IncrementalSearch:
+		prompt := "I-search"
+		if state.SearchFailing {
+			prompt = "Failing I-search"
+		}
+		if state.SearchDirection == SearchBackward {
+			prompt += " backward"
+		}
+		parts = append(parts, prompt+": "+state.SearchTerm)
+	case state.UniversalArg:
+		parts = append(parts, fmt.Sprintf("C-u %d-", hm.emacsCount()))
+	case len(state.PendingKeys) > 0:
+		parts = append(parts, strings.Join(state.PendingKeys, " ")+"-")
+	}
+	if state.EchoMessage != "" {
+		parts = append(parts, state.EchoMessage)
+	}
+	return strings.Join(parts, " | ")
+}
+
+// emacsMessage показывает сообщение в эхо-области (строке состояния) до следующей клавиши
+func (hm *HotkeyManager) emacsMessage(format string, args ...interface{}) {
+	hm.emacsState.EchoMessage = fmt.Sprintf(format, args...)
+}
+
+// emacsEditor возвращает редактор, с которым работают команды Emacs
+func (hm *HotkeyManager) emacsEditor() *EditorWidget {
+	if hm.app == nil {
+		return nil
+	}
+	return hm.app.editor
+}
+
+// emacsSync передает позицию точки Entry и подсвечивает активный регион
+func (hm *HotkeyManager) emacsSync() {
+	e := hm.emacsEditor()
+	if e == nil {
+		return
+	}
+	state := hm.emacsState
+	if !state.IncrementalSearch {
+		e.blockSelection = nil
+		if state.MarkActive && state.Mark != nil {
+			from, to := hm.emacsRegion(e)
+			e.blockSelection = emacsRangeLines(e, from, to)
+		}
+	}
+	e.updateCursorOverlay()
+
+	row, col := e.cursorRow, e.cursorCol
+	fyne.Do(func() {
+		e.content.CursorRow = row
+		e.content.CursorColumn = col
+		e.content.Refresh()
+	})
+	if e.onCursorChanged != nil {
+		e.onCursorChanged(row, col)
+	}
+}
+
+// emacsRangeLines разбивает участок текста на построчные прямоугольники подсветки
+func emacsRangeLines(e *EditorWidget, from, to int) []TextRange {
+	var ranges []TextRange
+	for from < to {
+		end := min(vimLineEnd(e.textContent, from), to)
+		ranges = append(ranges, TextRange{
+			Start: e.indexToPosition(from),
+			End:   e.indexToPosition(end),
+			Text:  e.textContent[from:end],
+		})
+		from = end + 1
+	}
+	return ranges
+}
+
+// emacsSetPoint ставит точку в позицию pos
+func (hm *HotkeyManager) emacsSetPoint(e *EditorWidget, pos int) {
+	e.moveCursorToIndex(max(0, min(pos, len(e.textContent))))
+}
+
+// emacsReplace заменяет text[from:to] на s и ставит точку в point; метки сдвигаются вслед за текстом
+func (hm *HotkeyManager) emacsReplace(e *EditorWidget, from, to int, s string, point int) {
+	state := hm.emacsState
+	shift := func(p TextPosition) int {
+		idx := e.positionToIndex(p)
+		switch {
+		case idx >= to:
+			idx += len(s) - (to - from)
+		case idx > from:
+			idx = from
+		}
+		return idx
+	}
+	mark := -1
+	if state.Mark != nil {
+		mark = shift(*state.Mark)
+	}
+	ring := make([]int, len(state.MarkRing))
+	for i, p := range state.MarkRing {
+		ring[i] = shift(p)
+	}
+
+	// Подряд идущие kill и M-y после yank отменяются одним шагом
+	merge := state.ThisCommand == "kill" && state.LastCommand == "kill" || state.ThisCommand == "yank-pop"
+	e.ReplaceRange(from, to, s, merge)
+	if mark >= 0 {
+		position := e.indexToPosition(mark)
+		state.Mark = &position
+	}
+	for i, idx := range ring {
+		state.MarkRing[i] = e.indexToPosition(idx)
+	}
+	hm.emacsSetPoint(e, point)
+	e.updateDisplay()
+}
+
+// emacsInsert вставляет текст в точке
+func (hm *HotkeyManager) emacsInsert(s string) {
+	if e := hm.emacsEditor(); e != nil {
+		p := e.cursorIndex()
+		hm.emacsReplace(e, p, p, s, p+len(s))
+	}
+}
+
+// emacsIsWordRune - символ слова для M-f, M-b, M-d
+func emacsIsWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// emacsForwardWord переходит к концу следующего слова
+func emacsForwardWord(text string, pos int) int {
+	inWord := false
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if emacsIsWordRune(r) {
+			inWord = true
+		} else if inWord {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+// emacsBackwardWord переходит к началу предыдущего слова
+func emacsBackwardWord(text string, pos int) int {
+	inWord := false
+	for pos > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:pos])
+		if emacsIsWordRune(r) {
+			inWord = true
+		} else if inWord {
+			break
+		}
+		pos -= size
+	}
+	return pos
+}
+
+// Движения
+
+func (hm *HotkeyManager) actionEmacsForwardChar(context HotkeyContext) bool {
+	return hm.emacsMoveChars(hm.emacsCount())
+}
+
+func (hm *HotkeyManager) actionEmacsBackwardChar(context HotkeyContext) bool {
+	return hm.emacsMoveChars(-hm.emacsCount())
+}
+
+// emacsMoveChars сдвигает точку на n символов (назад при n < 0)
+func (hm *HotkeyManager) emacsMoveChars(n int) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	pos := e.cursorIndex()
+	for ; n > 0 && pos < len(e.textContent); n-- {
+		pos = vimNextRune(e.textContent, pos)
+	}
+	for ; n < 0 && pos > 0; n++ {
+		pos = vimPrevRune(e.textContent, pos)
+	}
+	hm.emacsSetPoint(e, pos)
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsNextLine(context HotkeyContext) bool {
+	return hm.emacsMoveLines(hm.emacsCount())
+}
+
+func (hm *HotkeyManager) actionEmacsPreviousLine(context HotkeyContext) bool {
+	return hm.emacsMoveLines(-hm.emacsCount())
+}
+
+// emacsMoveLines сдвигает точку на n строк, сохраняя столбец между подряд идущими C-n/C-p
+func (hm *HotkeyManager) emacsMoveLines(n int) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	state := hm.emacsState
+	if state.LastCommand != "line" {
+		state.GoalColumn = e.cursorCol
+	}
+	state.ThisCommand = "line"
+	row := max(0, min(e.cursorRow+n, e.getLineCount()-1))
+	start := vimRowOffset(e.textContent, row)
+	hm.emacsSetPoint(e, min(start+state.GoalColumn, vimLineEnd(e.textContent, start)))
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsBeginningOfLine(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		hm.emacsSetPoint(e, vimLineStart(e.textContent, e.cursorIndex()))
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsEndOfLine(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		hm.emacsSetPoint(e, vimLineEnd(e.textContent, e.cursorIndex()))
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsForwardWord(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		hm.emacsSetPoint(e, hm.emacsWordTarget(e, hm.emacsCount()))
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsBackwardWord(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		hm.emacsSetPoint(e, hm.emacsWordTarget(e, -hm.emacsCount()))
+	}
+	return true
+}
+
+// emacsWordTarget возвращает позицию через n слов от точки
+func (hm *HotkeyManager) emacsWordTarget(e *EditorWidget, n int) int {
+	pos := e.cursorIndex()
+	for ; n > 0; n-- {
+		pos = emacsForwardWord(e.textContent, pos)
+	}
+	for ; n < 0; n++ {
+		pos = emacsBackwardWord(e.textContent, pos)
+	}
+	return pos
+}
+
+func (hm *HotkeyManager) actionEmacsBeginningOfBuffer(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		hm.emacsPushMark(e, false)
+		hm.emacsSetPoint(e, 0)
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsEndOfBuffer(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		hm.emacsPushMark(e, false)
+		hm.emacsSetPoint(e, len(e.textContent))
+	}
+	return true
+}
+
+// Удаление и kill ring
+
+func (hm *HotkeyManager) actionEmacsDeleteChar(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	pos := e.cursorIndex()
+	end := pos
+	for n := hm.emacsCount(); n > 0 && end < len(e.textContent); n-- {
+		end = vimNextRune(e.textContent, end)
+	}
+	hm.emacsReplace(e, pos, end, "", pos)
+	return true
+}
+
+// emacsKill добавляет текст в kill ring; kill сразу после kill дописывается к последней записи
+func (hm *HotkeyManager) emacsKill(text string, prepend bool) {
+	state := hm.emacsState
+	state.ThisCommand = "kill"
+	if text == "" {
+		return
+	}
+	if state.LastCommand == "kill" && len(state.KillRing) > 0 {
+		last := len(state.KillRing) - 1
+		if prepend {
+			state.KillRing[last] = text + state.KillRing[last]
+		} else {
+			state.KillRing[last] += text
+		}
+	} else {
+		state.KillRing = append(state.KillRing, text)
+		if len(state.KillRing) > emacsKillRingMax {
+			state.KillRing = state.KillRing[1:]
+		}
+	}
+	state.KillRingIndex = len(state.KillRing) - 1
+	if hm.window != nil {
+		hm.window.Clipboard().SetContent(state.KillRing[state.KillRingIndex])
+	}
+}
+
+// emacsKillRange удаляет text[from:to] в kill ring
+func (hm *HotkeyManager) emacsKillRange(e *EditorWidget, from, to int, prepend bool) {
+	if from > to {
+		from, to = to, from
+	}
+	hm.emacsKill(e.textContent[from:to], prepend)
+	hm.emacsReplace(e, from, to, "", from)
+}
+
+func (hm *HotkeyManager) actionEmacsKillLine(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	text := e.textContent
+	pos := e.cursorIndex()
+	end := pos
+	if hm.emacsState.UniversalArg {
+		// С аргументом C-k удаляет n строк целиком вместе с переводами строк
+		for n := hm.emacsCount(); n > 0 && end < len(text); n-- {
+			end = min(vimLineEnd(text, end)+1, len(text))
+		}
+	} else if lineEnd := vimLineEnd(text, pos); strings.TrimSpace(text[pos:lineEnd]) == "" {
+		end = min(lineEnd+1, len(text))
+	} else {
+		end = lineEnd
+	}
+	hm.emacsKillRange(e, pos, end, false)
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsKillWord(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		pos := e.cursorIndex()
+		hm.emacsKillRange(e, pos, hm.emacsWordTarget(e, hm.emacsCount()), false)
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsBackwardKillWord(context HotkeyContext) bool {
+	if e := hm.emacsEditor(); e != nil {
+		pos := e.cursorIndex()
+		hm.emacsKillRange(e, hm.emacsWordTarget(e, -hm.emacsCount()), pos, true)
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsKillRegion(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil || hm.emacsState.Mark == nil {
+		return false
+	}
+	from, to := hm.emacsRegion(e)
+	hm.emacsKillRange(e, from, to, e.cursorIndex() == from && from != to)
+	hm.emacsState.MarkActive = false
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsCopyRegion(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil || hm.emacsState.Mark == nil {
+		return false
+	}
+	from, to := hm.emacsRegion(e)
+	hm.emacsKill(e.textContent[from:to], false)
+	hm.emacsState.MarkActive = false
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsYank(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	state := hm.emacsState
+	if e == nil || len(state.KillRing) == 0 {
+		return false
+	}
+	// C-y n вставляет n-ю с конца запись kill ring
+	if n := hm.emacsCount(); state.UniversalArg && n > 1 {
+		state.KillRingIndex = ((len(state.KillRing)-n)%len(state.KillRing) + len(state.KillRing)) % len(state.KillRing)
+	} else {
+		state.KillRingIndex = len(state.KillRing) - 1
+	}
+	hm.emacsPushMark(e, false)
+	hm.emacsYankAt(e, e.cursorIndex(), e.cursorIndex())
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsYankPop(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	state := hm.emacsState
+	if e == nil || len(state.KillRing) == 0 {
+		return false
+	}
+	if state.LastCommand != "yank" {
+		hm.emacsMessage("Previous command was not a yank")
+		return false
+	}
+	n := len(state.KillRing)
+	state.KillRingIndex = ((state.KillRingIndex-hm.emacsCount())%n + n) % n
+	state.ThisCommand = "yank-pop"
+	hm.emacsYankAt(e, state.YankStart, state.YankEnd)
+	return true
+}
+
+// emacsYankAt заменяет text[from:to] текущей записью kill ring и запоминает ее границы для M-y
+func (hm *HotkeyManager) emacsYankAt(e *EditorWidget, from, to int) {
+	state := hm.emacsState
+	text := state.KillRing[state.KillRingIndex]
+	// Границы прошлой вставки могли устареть, если текст менялся в обход команд Emacs
+	to = max(0, min(to, len(e.textContent)))
+	from = max(0, min(from, to))
+	hm.emacsReplace(e, from, to, text, from+len(text))
+	state.YankStart, state.YankEnd = from, from+len(text)
+	state.ThisCommand = "yank"
+}
+
+// Метка и регион
+
+// emacsMarkIndex возвращает смещение метки; метка в конце строки остается в конце строки
+func (hm *HotkeyManager) emacsMarkIndex(e *EditorWidget) int {
+	return e.positionToIndex(*hm.emacsState.Mark)
+}
+
+// emacsRegion возвращает границы региона между меткой и точкой
+func (hm *HotkeyManager) emacsRegion(e *EditorWidget) (int, int) {
+	mark := hm.emacsMarkIndex(e)
+	point := e.cursorIndex()
+	return min(mark, point), max(mark, point)
+}
+
+// emacsPushMark ставит метку в точке; прежняя метка уходит в mark ring
+func (hm *HotkeyManager) emacsPushMark(e *EditorWidget, activate bool) {
+	state := hm.emacsState
+	if state.Mark != nil {
+		state.MarkRing = append(state.MarkRing, *state.Mark)
+		if len(state.MarkRing) > emacsMarkRingMax {
+			state.MarkRing = state.MarkRing[1:]
+		}
+	}
+	position := e.GetCursorPosition()
+	state.Mark = &position
+	state.MarkActive = activate
+}
+
+func (hm *HotkeyManager) actionEmacsSetMark(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	state := hm.emacsState
+
+	// C-u C-SPC переходит к метке и берет следующую из mark ring
+	if state.UniversalArg {
+		if state.Mark == nil {
+			return false
+		}
+		hm.emacsSetPoint(e, hm.emacsMarkIndex(e))
+		if len(state.MarkRing) > 0 {
+			current := *state.Mark
+			next := state.MarkRing[len(state.MarkRing)-1]
+			state.MarkRing = append([]TextPosition{current}, state.MarkRing[:len(state.MarkRing)-1]...)
+			state.Mark = &next
+		}
+		state.MarkActive = false
+		return true
+	}
+
+	hm.emacsPushMark(e, true)
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsExchangePointAndMark(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	state := hm.emacsState
+	if e == nil || state.Mark == nil {
+		return false
+	}
+	point := e.GetCursorPosition()
+	hm.emacsSetPoint(e, hm.emacsMarkIndex(e))
+	state.Mark = &point
+	state.MarkActive = true
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsKeyboardQuit(context HotkeyContext) bool {
+	state := hm.emacsState
+	state.MarkActive = false
+	state.PendingKeys = nil
+	if state.RecordingMacro {
+		state.RecordingMacro = false
+		state.MacroKeys = nil
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsUniversalArgument(context HotkeyContext) bool {
+	state := hm.emacsState
+	switch {
+	case !state.UniversalArg:
+		state.UniversalArg, state.PrefixArgument = true, 4
+	case !state.ArgDigits:
+		// C-u C-u = 16, C-u C-u C-u = 64
+		state.PrefixArgument *= 4
+	}
+	state.ArgCollecting = true
+	return true
+}
+
+// Инкрементальный поиск
+
+func (hm *HotkeyManager) actionEmacsSearchForward(context HotkeyContext) bool {
+	return hm.emacsStartSearch(SearchForward)
+}
+
+func (hm *HotkeyManager) actionEmacsSearchBackward(context HotkeyContext) bool {
+	return hm.emacsStartSearch(SearchBackward)
+}
+
+// emacsStartSearch начинает инкрементальный поиск от точки
+func (hm *HotkeyManager) emacsStartSearch(direction SearchDirection) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	state := hm.emacsState
+	state.IncrementalSearch = true
+	state.SearchDirection = direction
+	state.SearchStartPos = e.GetCursorPosition()
+	state.SearchTerm = ""
+	state.SearchFailing = false
+	pos := e.cursorIndex()
+	state.SearchMatchStart, state.SearchMatchEnd = pos, pos
+	return true
+}
+
+// emacsSearchKey обрабатывает клавишу во время поиска; false - поиск завершен и
+// клавиша выполняется как обычная команда
+func (hm *HotkeyManager) emacsSearchKey(key string) bool {
+	e := hm.emacsEditor()
+	state := hm.emacsState
+	if e == nil {
+		state.IncrementalSearch = false
+		return false
+	}
+
+	switch {
+	case key == "C-s" || key == "C-r":
+		direction := SearchForward
+		if key == "C-r" {
+			direction = SearchBackward
+		}
+		// Повторный C-s с пустой строкой берет прошлый поиск
+		if state.SearchTerm == "" {
+			state.SearchTerm = state.LastSearchTerm
+			state.SearchDirection = direction
+			hm.emacsSearchUpdate(e, false)
+			return true
+		}
+		state.SearchDirection = direction
+		hm.emacsSearchUpdate(e, true)
+	case key == "DEL":
+		if state.SearchTerm != "" {
+			_, size := utf8.DecodeLastRuneInString(state.SearchTerm)
+			state.SearchTerm = state.SearchTerm[:len(state.SearchTerm)-size]
+			pos := e.positionToIndex(state.SearchStartPos)
+			state.SearchMatchStart, state.SearchMatchEnd = pos, pos
+			hm.emacsSearchUpdate(e, false)
+		}
+	case key == "C-g":
+		hm.emacsEndSearch(e, true)
+	case key == "RET":
+		hm.emacsEndSearch(e, false)
+	case utf8.RuneCountInString(key) == 1:
+		state.SearchTerm += key
+		hm.emacsSearchUpdate(e, false)
+	default:
+		// Любая другая команда завершает поиск и выполняется
+		hm.emacsEndSearch(e, false)
+		return false
+	}
+	return true
+}
+
+// emacsSearchPattern компилирует строку поиска; строка без заглавных ищется без учета регистра
+func emacsSearchPattern(term string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(term)
+	if strings.IndexFunc(term, unicode.IsUpper) < 0 {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// emacsSearchUpdate ищет строку от текущего совпадения (next - следующее совпадение)
+// и подсвечивает все совпадения в буфере
+func (hm *HotkeyManager) emacsSearchUpdate(e *EditorWidget, next bool) {
+	state := hm.emacsState
+	e.searchResults = nil
+	e.blockSelection = nil
+	if state.SearchTerm == "" {
+		state.SearchFailing = false
+		e.applyTokensToRichText()
+		return
+	}
+
+	matches := emacsSearchPattern(state.SearchTerm).FindAllStringIndex(e.textContent, -1)
+	wrap := next && state.SearchFailing
+	found := -1
+	if state.SearchDirection == SearchForward {
+		for i, m := range matches {
+			if m[0] > state.SearchMatchStart || m[0] == state.SearchMatchStart && !next {
+				found = i
+				break
+			}
+		}
+	} else {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if m := matches[i]; m[0] < state.SearchMatchStart || m[0] == state.SearchMatchStart && !next {
+				found = i
+				break
+			}
+		}
+	}
+
+	for _, m := range matches {
+		e.searchResults = append(e.searchResults, TextRange{
+			Start: e.indexToPosition(m[0]),
+			End:   e.indexToPosition(m[1]),
+			Text:  e.textContent[m[0]:m[1]],
+		})
+	}
+	e.applyTokensToRichText()
+
+	// C-s после неудачного поиска начинает с другого конца буфера
+	if found < 0 && wrap && len(matches) > 0 {
+		found = 0
+		if state.SearchDirection == SearchBackward {
+			found = len(matches) - 1
+		}
+	}
+	state.SearchFailing = found < 0
+	if found < 0 {
+		return
+	}
+	m := matches[found]
+	state.SearchMatchStart, state.SearchMatchEnd = m[0], m[1]
+	e.blockSelection = emacsRangeLines(e, m[0], m[1])
+	// Вперед точка встает за совпадением, назад - перед ним
+	if state.SearchDirection == SearchForward {
+		hm.emacsSetPoint(e, m[1])
+	} else {
+		hm.emacsSetPoint(e, m[0])
+	}
+}
+
+// emacsEndSearch завершает поиск; abort возвращает точку в начало поиска,
+// иначе начало поиска запоминается меткой
+func (hm *HotkeyManager) emacsEndSearch(e *EditorWidget, abort bool) {
+	state := hm.emacsState
+	state.IncrementalSearch = false
+	if state.SearchTerm != "" {
+		state.LastSearchTerm = state.SearchTerm
+	}
+	e.searchResults = nil
+	e.blockSelection = nil
+	e.applyTokensToRichText()
+
+	origin := e.positionToIndex(state.SearchStartPos)
+	if abort {
+		hm.emacsSetPoint(e, origin)
+		return
+	}
+	if origin != e.cursorIndex() {
+		point := e.cursorIndex()
+		hm.emacsSetPoint(e, origin)
+		hm.emacsPushMark(e, false)
+		hm.emacsSetPoint(e, point)
+	}
+}
+
+// Перестановки
+
+func (hm *HotkeyManager) actionEmacsTransposeChars(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	text := e.textContent
+	pos := e.cursorIndex()
+	// В конце строки меняются два символа перед точкой
+	if pos == vimLineEnd(text, pos) && pos > vimLineStart(text, pos) {
+		pos = vimPrevRune(text, pos)
+	}
+	for n := hm.emacsCount(); n > 0; n-- {
+		if pos == 0 || pos >= len(text) {
+			break
+		}
+		prev := vimPrevRune(text, pos)
+		next := vimNextRune(text, pos)
+		hm.emacsReplace(e, prev, next, text[pos:next]+text[prev:pos], next)
+		text, pos = e.textContent, next
+	}
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsTransposeWords(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	text := e.textContent
+	start1 := emacsBackwardWord(text, e.cursorIndex())
+	end1 := emacsForwardWord(text, start1)
+	end2 := emacsForwardWord(text, end1)
+	start2 := emacsBackwardWord(text, end2)
+	if start2 < end1 || end1 == end2 {
+		hm.emacsMessage("Don't have two things to transpose")
+		return false
+	}
+	swapped := text[start2:end2] + text[end1:start2] + text[start1:end1]
+	hm.emacsReplace(e, start1, end2, swapped, end2)
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsTransposeLines(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil || e.cursorRow == 0 {
+		return false
+	}
+	lines := strings.Split(e.textContent, "\n")
+	row := e.cursorRow
+	lines[row-1], lines[row] = lines[row], lines[row-1]
+	start := vimRowOffset(e.textContent, row-1)
+	end := vimLineEnd(e.textContent, vimRowOffset(e.textContent, row))
+	swapped := lines[row-1] + "\n" + lines[row]
+	// Точка переходит на начало следующей строки
+	hm.emacsReplace(e, start, end, swapped, min(start+len(swapped)+1, len(e.textContent)))
+	return true
+}
+
+// Клавиатурные макросы
+
+func (hm *HotkeyManager) actionEmacsStartMacro(context HotkeyContext) bool {
+	state := hm.emacsState
+	if state.RecordingMacro || state.PlayingMacro {
+		return false
+	}
+	state.RecordingMacro = true
+	state.MacroKeys = nil
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsEndMacro(context HotkeyContext) bool {
+	state := hm.emacsState
+	if !state.RecordingMacro {
+		return false
+	}
+	state.RecordingMacro = false
+	// Клавиши самой команды C-x ) в макрос не входят
+	keys := state.MacroKeys[:max(0, len(state.MacroKeys)-state.commandKeys)]
+	state.LastMacro = append([]string(nil), keys...)
+	state.MacroKeys = nil
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsCallMacro(context HotkeyContext) bool {
+	state := hm.emacsState
+	if state.RecordingMacro || state.PlayingMacro || len(state.LastMacro) == 0 {
+		return false
+	}
+	count := max(hm.emacsCount(), 1)
+	hm.emacsResetArgument()
+	state.PlayingMacro = true
+	for n := count; n > 0; n-- {
+		for _, key := range state.LastMacro {
+			hm.emacsFeedKey(key)
+		}
+	}
+	state.PlayingMacro = false
+	state.ThisCommand = "macro"
+	return true
+}
+
+// emacsFeedKey повторяет клавишу макроса. Ввод, который при записи обработал Entry,
+// применяется к тексту напрямую: Entry обновляется асинхронно и отстал бы от буфера
+func (hm *HotkeyManager) emacsFeedKey(key string) {
+	if hm.handleEmacsKey(key) {
+		return
+	}
+	e := hm.emacsEditor()
+	if e == nil {
+		return
+	}
+	pos := e.cursorIndex()
+	switch key {
+	case "RET":
+		hm.emacsInsert("\n")
+	case "TAB":
+		hm.emacsInsert("\t")
+	case "DEL":
+		if pos > 0 {
+			hm.emacsReplace(e, vimPrevRune(e.textContent, pos), pos, "", vimPrevRune(e.textContent, pos))
+		}
+	case "<deletechar>":
+		if pos < len(e.textContent) {
+			hm.emacsReplace(e, pos, vimNextRune(e.textContent, pos), "", pos)
+		}
+	case "<left>":
+		hm.emacsMoveChars(-1)
+	case "<right>":
+		hm.emacsMoveChars(1)
+	case "<up>":
+		hm.emacsMoveLines(-1)
+	case "<down>":
+		hm.emacsMoveLines(1)
+	case "<home>":
+		hm.emacsSetPoint(e, vimLineStart(e.textContent, pos))
+	case "<end>":
+		hm.emacsSetPoint(e, vimLineEnd(e.textContent, pos))
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			hm.emacsInsert(key)
+		}
+	}
+	hm.emacsSync()
+}
+
+// Прямоугольники
+
+// emacsRectangle возвращает строки и экранные столбцы прямоугольника между меткой и точкой
+func (hm *HotkeyManager) emacsRectangle(e *EditorWidget) (top, bottom, left, right int, ok bool) {
+	state := hm.emacsState
+	if state.Mark == nil {
+		return 0, 0, 0, 0, false
+	}
+	lines := strings.Split(e.textContent, "\n")
+	column := func(p TextPosition) int {
+		if p.Row >= len(lines) {
+			return 0
+		}
+		return displayColumn(lines[p.Row], p.Col, e.tabSize())
+	}
+	mark, point := *state.Mark, e.GetCursorPosition()
+	markCol, pointCol := column(mark), column(point)
+	return min(mark.Row, point.Row), max(mark.Row, point.Row), min(markCol, pointCol), max(markCol, pointCol), true
+}
+
+// emacsEditRectangle применяет edit к части каждой строки прямоугольника и ставит точку в его левый верхний угол.
+// edit получает байтовые границы столбцов прямоугольника в строке
+func (hm *HotkeyManager) emacsEditRectangle(e *EditorWidget, edit func(line string, from, to int) string) bool {
+	top, bottom, left, right, ok := hm.emacsRectangle(e)
+	if !ok {
+		return false
+	}
+	tab := e.tabSize()
+	lines := strings.Split(e.textContent, "\n")
+	point := columnOffset(lines[top], left, tab)
+	for row := top; row <= bottom && row < len(lines); row++ {
+		line := lines[row]
+		lines[row] = edit(line, columnOffset(line, left, tab), columnOffset(line, right, tab))
+	}
+	start := vimRowOffset(e.textContent, top)
+	end := vimLineEnd(e.textContent, vimRowOffset(e.textContent, bottom))
+	text := strings.Join(lines[top:bottom+1], "\n")
+	hm.emacsReplace(e, start, end, text, start+point)
+	hm.emacsState.MarkActive = false
+	return true
+}
+
+func (hm *HotkeyManager) actionEmacsKillRectangle(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	var killed []string
+	ok := hm.emacsEditRectangle(e, func(line string, from, to int) string {
+		killed = append(killed, line[from:to])
+		return line[:from] + line[to:]
+	})
+	if ok {
+		hm.emacsState.KilledRectangle = killed
+	}
+	return ok
+}
+
+func (hm *HotkeyManager) actionEmacsDeleteRectangle(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	return hm.emacsEditRectangle(e, func(line string, from, to int) string {
+		return line[:from] + line[to:]
+	})
+}
+
+func (hm *HotkeyManager) actionEmacsOpenRectangle(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	if e == nil {
+		return false
+	}
+	_, _, left, right, _ := hm.emacsRectangle(e)
+	return hm.emacsEditRectangle(e, func(line string, from, _ int) string {
+		if displayColumn(line, len(line), e.tabSize()) < left {
+			return line
+		}
+		return line[:from] + strings.Repeat(" ", right-left) + line[from:]
+	})
+}
+
+// actionEmacsYankRectangle вставляет последний удаленный прямоугольник столбцом от точки
+func (hm *HotkeyManager) actionEmacsYankRectangle(context HotkeyContext) bool {
+	e := hm.emacsEditor()
+	rect := hm.emacsState.KilledRectangle
+	if e == nil || len(rect) == 0 {
+		return false
+	}
+	tab := e.tabSize()
+	width := 0
+	for _, r := range rect {
+		width = max(width, displayColumn(r, len(r), tab))
+	}
+
+	// Столбец вставки экранный, на каждой строке он переводится в свое смещение
+	lines := strings.Split(e.textContent, "\n")
+	row := e.cursorRow
+	col := displayColumn(lines[row], e.cursorCol, tab)
+	point := 0
+	for i, piece := range rect {
+		r := row + i
+		if r >= len(lines) {
+			lines = append(lines, "")
+		}
+		line := lines[r]
+		if lineWidth := displayColumn(line, len(line), tab); lineWidth < col {
+			line += strings.Repeat(" ", col-lineWidth)
+		}
+		off := columnOffset(line, col, tab)
+		if off < len(line) {
+			piece += strings.Repeat(" ", width-displayColumn(piece, len(piece), tab))
+		}
+		lines[r] = line[:off] + piece + line[off:]
+		point = off + len(piece)
+	}
+
+	// Точка встает в конец вставки на последней строке
+	start := vimRowOffset(e.textContent, row)
+	for _, line := range lines[row : row+len(rect)-1] {
+		point += len(line) + 1
+	}
+	hm.emacsReplace(e, start, len(e.textContent), strings.Join(lines[row:], "\n"), start+point)
+	// Отдельное имя команды: M-y после вставки прямоугольника не сработает
+	hm.emacsState.ThisCommand = "yank-rectangle"
+	return true
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxFileUndo ограничивает глубину истории файловых операций сайдбара
+const maxFileUndo = 50
+
+// trashEntry описывает файл, перемещенный в корзину
+type trashEntry struct {
+	original string // исходный абсолютный путь
+	trashed  string // путь внутри Trash/files
+	info     string // путь к .trashinfo
+}
+
+// trashDirectory возвращает корзину пользователя по спецификации XDG
+func trashDirectory() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+// moveToTrash перемещает файл или папку в корзину, записывая .trashinfo
+func moveToTrash(path string) (*trashEntry, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return nil, err
+	}
+
+	trash, err := trashDirectory()
+	if err != nil {
+		return nil, err
+	}
+	filesDir := filepath.Join(trash, "files")
+	infoDir := filepath.Join(trash, "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return nil, err
+	}
+
+	// Резервируем имя созданием .trashinfo с O_EXCL - так требует спецификация
+	base := filepath.Base(abs)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	var infoFile *os.File
+	name := base
+	for i := 1; ; i++ {
+		infoFile, err = os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			if _, statErr := os.Lstat(filepath.Join(filesDir, name)); os.IsNotExist(statErr) {
+				break
+			}
+			infoFile.Close()
+			os.Remove(infoFile.Name())
+		} else if !os.IsExist(err) {
+			return nil, err
+		}
+		name = stem + "." + strconv.Itoa(i) + ext
+	}
+
+	entry := &trashEntry{
+		original: abs,
+		trashed:  filepath.Join(filesDir, name),
+		info:     infoFile.Name(),
+	}
+
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: abs}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	_, err = infoFile.WriteString(info)
+	if closeErr := infoFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = movePath(abs, entry.trashed)
+	}
+	if err != nil {
+		os.Remove(entry.info)
+		return nil, err
+	}
+	return entry, nil
+}
+
+// restoreFromTrash возвращает файл из корзины на исходное место
+func restoreFromTrash(entry *trashEntry) error {
+	if _, err := os.Lstat(entry.original); err == nil {
+		return fmt.Errorf("cannot restore %s: file already exists", entry.original)
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.original), 0755); err != nil {
+		return err
+	}
+	if err := movePath(entry.trashed, entry.original); err != nil {
+		return err
+	}
+	os.Remove(entry.info)
+	return nil
+}
+
+// movePath переименовывает путь, копируя его, если источник на другом устройстве
+func movePath(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyPath(src, dst, nil); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// errCopyCancelled возвращается из copyPath, когда копирование отменено
+var errCopyCancelled = errors.New("copy cancelled")
+
+// progressWriter сообщает о каждом записанном блоке; ошибка прерывает копирование
+type progressWriter struct {
+	w        io.Writer
+	progress func(n int64) error
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if err == nil {
+		err = p.progress(int64(n))
+	}
+	return n, err
+}
+
+// pathSize возвращает суммарный размер обычных файлов внутри path
+func pathSize(path string) int64 {
+	var total int64
+	filepath.WalkDir(path, func(_ string, d os.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// copyPath рекурсивно копирует файл, папку или символическую ссылку;
+// progress, если задан, получает число скопированных байт
+func copyPath(src, dst string, progress func(n int64) error) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), progress); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		var w io.Writer = out
+		if progress != nil {
+			w = &progressWriter{w: out, progress: progress}
+		}
+		if _, err := io.Copy(w, in); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+}
+
+// uniqueCopyName подбирает в dir свободное имя вида "name copy.ext"
+func uniqueCopyName(dir, name string) string {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	candidate := filepath.Join(dir, stem+" copy"+ext)
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = filepath.Join(dir, fmt.Sprintf("%s copy %d%s", stem, i, ext))
+	}
+}
+
+// topLevelPaths убирает пути, вложенные в другие пути списка
+func topLevelPaths(paths []string) []string {
+	var result []string
+	for _, path := range paths {
+		nested := false
+		for _, other := range paths {
+			if other != path && isSubPath(other, path) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+// isSubPath сообщает, совпадает ли path с root или лежит внутри него
+func isSubPath(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// rebasePath переносит path из-под oldRoot под newRoot
+func rebasePath(path, oldRoot, newRoot string) string {
+	if path == oldRoot {
+		return newRoot
+	}
+	return newRoot + strings.TrimPrefix(path, oldRoot)
+}
+
+// fileOperation - выполненная файловая операция сайдбара, которую можно отменить
+type fileOperation interface {
+	Undo(s *SidebarWidget) error
+	Description() string
+	Paths() []string // пути, которые затрагивает операция
+}
+
+// createOperation - создание или копирование файла; отмена отправляет его
+// в корзину и возвращает замененный файл
+type createOperation struct {
+	path        string
+	overwritten *trashEntry
+	copied      bool
+}
+
+func (op *createOperation) Undo(s *SidebarWidget) error {
+	if _, err := moveToTrash(op.path); err != nil {
+		return err
+	}
+	s.notifyDeleted(op.path)
+	if op.overwritten != nil {
+		return restoreFromTrash(op.overwritten)
+	}
+	return nil
+}
+
+func (op *createOperation) Description() string {
+	if op.copied {
+		return "Copy '" + filepath.Base(op.path) + "'"
+	}
+	return "Create '" + filepath.Base(op.path) + "'"
+}
+
+func (op *createOperation) Paths() []string {
+	return []string{op.path}
+}
+
+// renameOperation - переименование или перемещение, возможно с заменой файла
+type renameOperation struct {
+	from, to    string
+	overwritten *trashEntry
+}
+
+func (op *renameOperation) Undo(s *SidebarWidget) error {
+	if _, err := os.Lstat(op.from); err == nil {
+		return fmt.Errorf("cannot undo: %s already exists", op.from)
+	}
+	if err := movePath(op.to, op.from); err != nil {
+		return err
+	}
+	s.notifyRenamed(op.to, op.from)
+	if op.overwritten != nil {
+		if err := restoreFromTrash(op.overwritten); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (op *renameOperation) Description() string {
+	if filepath.Dir(op.from) != filepath.Dir(op.to) {
+		return "Move '" + filepath.Base(op.from) + "'"
+	}
+	return "Rename '" + filepath.Base(op.from) + "'"
+}
+
+func (op *renameOperation) Paths() []string {
+	return []string{op.from, op.to}
+}
+
+// deleteOperation - удаление в корзину; отмена восстанавливает файл
+type deleteOperation struct {
+	entry *trashEntry
+}
+
+func (op *deleteOperation) Undo(s *SidebarWidget) error {
+	return restoreFromTrash(op.entry)
+}
+
+func (op *deleteOperation) Description() string {
+	return "Delete '" + filepath.Base(op.entry.original) + "'"
+}
+
+func (op *deleteOperation) Paths() []string {
+	return []string{op.entry.original}
+}
+
+// batchOperation объединяет операции над несколькими файлами в один шаг отмены
+type batchOperation struct {
+	label string
+	ops   []fileOperation
+}
+
+func (op *batchOperation) Undo(s *SidebarWidget) error {
+	for i := len(op.ops) - 1; i >= 0; i-- {
+		if err := op.ops[i].Undo(s); err != nil {
+			// Оставляем в пакете только то, что еще не отменено
+			op.ops = op.ops[:i+1]
+			return err
+		}
+	}
+	return nil
+}
+
+func (op *batchOperation) Description() string {
+	return op.label
+}
+
+func (op *batchOperation) Paths() []string {
+	var paths []string
+	for _, child := range op.ops {
+		paths = append(paths, child.Paths()...)
+	}
+	return paths
+}
@@ -188,6 +188,10 @@ func (fw *FileWatcher) WatchDirectory(path string, recursive bool) error {
 			}
 
 			if info.IsDir() && path != absPath {
+				// Служебные каталоги систем контроля версий не отслеживаем
+				if searchSkipDirs[info.Name()] {
+					return filepath.SkipDir
+				}
 				return fw.addDirectory(path)
 			}
 
@@ -0,0 +1,709 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	findHistorySize   = 20
+	maxFindHighlights = 5000
+)
+
+// FindBar - немодальная панель поиска и замены над редактором
+type FindBar struct {
+	widget.BaseWidget
+
+	// UI компоненты
+	mainContainer  *fyne.Container
+	findEntry      *findBarEntry
+	replaceEntry   *findBarEntry
+	caseCheck      *widget.Check
+	wordCheck      *widget.Check
+	regexCheck     *widget.Check
+	selectionCheck *widget.Check
+	preserveCheck  *widget.Check
+	counterLabel   *widget.Label
+	expandBtn      *widget.Button
+	replaceRow     *fyne.Container
+
+	// Состояние
+	editor       *EditorWidget
+	config       *Config
+	window       fyne.Window
+	pattern      *regexp.Regexp
+	matches      []findBarMatch
+	current      int
+	scopeStart   int // область "в выделении"; scopeEnd < 0 - весь текст
+	scopeEnd     int
+	refreshTimer *time.Timer
+
+	// Callbacks
+	onExecute func(cmd EditorCommand) error
+	onChanged func()
+	onSave    func()
+	onClose   func()
+}
+
+// findBarMatch - вхождение: смещения в тексте и границы групп регулярного выражения
+type findBarMatch struct {
+	start, end int
+	groups     []int
+}
+
+// findBarEntry - поле панели поиска с историей: Enter/Shift+Enter, F3 и Escape
+type findBarEntry struct {
+	widget.SelectEntry
+	shift    bool
+	onEnter  func(shift bool)
+	onEscape func()
+	onToggle func(key fyne.KeyName) bool
+}
+
+func newFindBarEntry(placeHolder string) *findBarEntry {
+	e := &findBarEntry{}
+	e.ExtendBaseWidget(e)
+	e.Wrapping = fyne.TextWrap(fyne.TextTruncateClip)
+	e.SetPlaceHolder(placeHolder)
+	return e
+}
+
+func (e *findBarEntry) KeyDown(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = true
+	}
+	e.SelectEntry.KeyDown(key)
+}
+
+func (e *findBarEntry) KeyUp(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = false
+	}
+	e.SelectEntry.KeyUp(key)
+}
+
+func (e *findBarEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeyF3:
+		if e.onEnter != nil {
+			e.onEnter(e.shift)
+		}
+		return
+	case fyne.KeyEscape:
+		if e.onEscape != nil {
+			e.onEscape()
+		}
+		return
+	}
+	e.SelectEntry.TypedKey(key)
+}
+
+// TypedShortcut переключает опции по Alt+C/W/R/L/P как в VS Code
+func (e *findBarEntry) TypedShortcut(s fyne.Shortcut) {
+	if cs, ok := s.(*desktop.CustomShortcut); ok && cs.Modifier == fyne.KeyModifierAlt && e.onToggle != nil {
+		if e.onToggle(cs.KeyName) {
+			return
+		}
+	}
+	e.SelectEntry.TypedShortcut(s)
+}
+
+// NewFindBar создает скрытую панель поиска для редактора
+func NewFindBar(editor *EditorWidget, config *Config, window fyne.Window) *FindBar {
+	b := &FindBar{
+		editor:   editor,
+		config:   config,
+		window:   window,
+		current:  -1,
+		scopeEnd: -1,
+	}
+	b.ExtendBaseWidget(b)
+	b.setupComponents()
+	b.Hide()
+	return b
+}
+
+// setupComponents создает UI компоненты
+func (b *FindBar) setupComponents() {
+	b.findEntry = newFindBarEntry("Find")
+	b.findEntry.OnChanged = func(string) { b.search(true) }
+	b.findEntry.onEnter = func(shift bool) {
+		b.remember()
+		if shift {
+			b.Previous()
+		} else {
+			b.Next()
+		}
+	}
+	b.findEntry.onEscape = b.Close
+	b.findEntry.onToggle = b.toggleOption
+
+	b.replaceEntry = newFindBarEntry("Replace")
+	b.replaceEntry.onEnter = func(shift bool) {
+		if shift {
+			b.ReplaceAll()
+		} else {
+			b.ReplaceCurrent()
+		}
+	}
+	b.replaceEntry.onEscape = b.Close
+	b.replaceEntry.onToggle = b.toggleOption
+
+	option := func(label string, value *bool) *widget.Check {
+		check := widget.NewCheck(label, func(on bool) {
+			if value != nil {
+				*value = on
+				b.save()
+			}
+			b.search(true)
+		})
+		if value != nil {
+			check.Checked = *value
+		}
+		return check
+	}
+	var editorCfg *EditorConfig
+	if b.config != nil {
+		editorCfg = &b.config.Editor
+		b.findEntry.SetOptions(editorCfg.SearchHistory)
+		b.replaceEntry.SetOptions(editorCfg.ReplaceHistory)
+	} else {
+		editorCfg = &EditorConfig{}
+	}
+	b.caseCheck = option("Aa", &editorCfg.SearchCaseSensitive)
+	b.wordCheck = option("Word", &editorCfg.SearchWholeWord)
+	b.regexCheck = option(".*", &editorCfg.SearchRegex)
+	b.selectionCheck = widget.NewCheck("In selection", b.setInSelection)
+	b.preserveCheck = widget.NewCheck("Preserve case", func(on bool) {
+		editorCfg.SearchPreserveCase = on
+		b.save()
+	})
+	b.preserveCheck.Checked = editorCfg.SearchPreserveCase
+
+	b.counterLabel = widget.NewLabel("No results")
+	b.counterLabel.TextStyle.Italic = true
+
+	b.expandBtn = widget.NewButtonWithIcon("", theme.MenuExpandIcon(), func() {
+		if b.replaceRow.Visible() {
+			b.replaceRow.Hide()
+			b.expandBtn.SetIcon(theme.MenuExpandIcon())
+		} else {
+			b.replaceRow.Show()
+			b.expandBtn.SetIcon(theme.MenuDropDownIcon())
+		}
+	})
+	b.expandBtn.Importance = widget.LowImportance
+
+	findRow := container.NewBorder(nil, nil, b.expandBtn,
+		container.NewHBox(
+			b.caseCheck, b.wordCheck, b.regexCheck, b.selectionCheck,
+			b.counterLabel,
+			widget.NewButtonWithIcon("", theme.MoveUpIcon(), b.Previous),
+			widget.NewButtonWithIcon("", theme.MoveDownIcon(), b.Next),
+			widget.NewButtonWithIcon("", theme.CancelIcon(), b.Close),
+		),
+		b.findEntry,
+	)
+	b.replaceRow = container.NewBorder(nil, nil, widget.NewLabel("    "),
+		container.NewHBox(
+			b.preserveCheck,
+			widget.NewButton("Replace", b.ReplaceCurrent),
+			widget.NewButton("Replace All", b.ReplaceAll),
+		),
+		b.replaceEntry,
+	)
+	b.replaceRow.Hide()
+
+	b.mainContainer = container.NewVBox(findRow, b.replaceRow, widget.NewSeparator())
+}
+
+// SetCallbacks задает выполнение команд, обновление миникарты, сохранение настроек и закрытие
+func (b *FindBar) SetCallbacks(onExecute func(cmd EditorCommand) error, onChanged, onSave, onClose func()) {
+	b.onExecute = onExecute
+	b.onChanged = onChanged
+	b.onSave = onSave
+	b.onClose = onClose
+}
+
+// Open показывает панель; многострочное выделение становится областью поиска, однострочное - запросом
+func (b *FindBar) Open(replace bool) {
+	selected := b.editor.GetSelectedText()
+	if strings.Contains(selected, "\n") {
+		b.selectionCheck.SetChecked(true)
+	} else if selected != "" {
+		b.findEntry.SetText(selected)
+	}
+	if replace {
+		b.replaceRow.Show()
+		b.expandBtn.SetIcon(theme.MenuDropDownIcon())
+	}
+	b.Show()
+	b.search(true)
+	if b.window != nil {
+		b.window.Canvas().Focus(b.findEntry)
+		b.findEntry.TypedShortcut(&fyne.ShortcutSelectAll{})
+	}
+}
+
+// Close скрывает панель и убирает подсветку вхождений
+func (b *FindBar) Close() {
+	if !b.Visible() {
+		return
+	}
+	b.remember()
+	b.Hide()
+	b.selectionCheck.SetChecked(false)
+	b.matches, b.current = nil, -1
+	b.publish()
+	if b.onClose != nil {
+		b.onClose()
+	}
+}
+
+// Query возвращает текущий запрос, если панель открыта
+func (b *FindBar) Query() string {
+	if !b.Visible() {
+		return ""
+	}
+	return b.findEntry.Text
+}
+
+// ContentChanged пересчитывает вхождения после правки текста (с задержкой)
+func (b *FindBar) ContentChanged() {
+	if !b.Visible() || b.findEntry.Text == "" {
+		return
+	}
+	if b.refreshTimer != nil {
+		b.refreshTimer.Stop()
+	}
+	b.refreshTimer = time.AfterFunc(150*time.Millisecond, func() {
+		fyne.Do(func() { b.search(false) })
+	})
+}
+
+// Next выделяет следующее вхождение
+func (b *FindBar) Next() {
+	b.step(1)
+}
+
+// Previous выделяет предыдущее вхождение
+func (b *FindBar) Previous() {
+	b.step(-1)
+}
+
+func (b *FindBar) step(dir int) {
+	if len(b.matches) == 0 {
+		return
+	}
+	i := b.current
+	switch {
+	case i < 0 || !b.isSelected(b.matches[i]):
+		// Текущее вхождение еще не выделено - начинаем с него
+		if i < 0 {
+			i = 0
+		}
+	default:
+		i += dir
+	}
+	wrap := b.config == nil || b.config.Editor.SearchWrap
+	if i >= len(b.matches) {
+		if !wrap {
+			return
+		}
+		i = 0
+	}
+	if i < 0 {
+		if !wrap {
+			return
+		}
+		i = len(b.matches) - 1
+	}
+	b.reveal(i)
+}
+
+// search пересчитывает вхождения; reveal - выделить текущее в редакторе
+func (b *FindBar) search(reveal bool) {
+	b.matches, b.current, b.pattern = nil, -1, nil
+	query := b.findEntry.Text
+	if !b.Visible() || query == "" {
+		b.publish()
+		return
+	}
+	re, err := compileSearchPattern(SearchOptions{
+		Query:         query,
+		Regex:         b.regexCheck.Checked,
+		CaseSensitive: b.caseCheck.Checked,
+		WholeWord:     b.wordCheck.Checked,
+	})
+	if err != nil {
+		b.publish()
+		b.counterLabel.SetText("Invalid pattern")
+		return
+	}
+	b.pattern = re
+
+	text := b.editor.GetContent()
+	from, to := b.scope(len(text))
+	for _, loc := range re.FindAllStringSubmatchIndex(text[from:to], -1) {
+		if loc[1] == loc[0] {
+			continue // пустые совпадения (например, ^) не подсвечиваем
+		}
+		for i := range loc {
+			if loc[i] >= 0 {
+				loc[i] += from
+			}
+		}
+		b.matches = append(b.matches, findBarMatch{start: loc[0], end: loc[1], groups: loc})
+	}
+
+	// Текущее - первое вхождение от начала выделения или курсора
+	if len(b.matches) > 0 {
+		at := b.editor.cursorIndex()
+		if b.editor.selectionStart != b.editor.selectionEnd {
+			at = min(b.editor.positionToIndex(b.editor.selectionStart), b.editor.positionToIndex(b.editor.selectionEnd))
+		}
+		b.current = sort.Search(len(b.matches), func(i int) bool { return b.matches[i].end > at })
+		if b.current == len(b.matches) {
+			b.current = 0
+		}
+	}
+	if reveal && b.current >= 0 {
+		b.reveal(b.current)
+		return
+	}
+	b.publish()
+}
+
+// scope возвращает границы области поиска в тексте длины n
+func (b *FindBar) scope(n int) (int, int) {
+	if b.scopeEnd < 0 {
+		return 0, n
+	}
+	return min(b.scopeStart, n), min(b.scopeEnd, n)
+}
+
+// setInSelection ограничивает поиск текущим выделением
+func (b *FindBar) setInSelection(on bool) {
+	b.scopeStart, b.scopeEnd = 0, -1
+	if on {
+		start := b.editor.positionToIndex(b.editor.selectionStart)
+		end := b.editor.positionToIndex(b.editor.selectionEnd)
+		if start > end {
+			start, end = end, start
+		}
+		if start == end {
+			b.selectionCheck.SetChecked(false)
+			return
+		}
+		b.scopeStart, b.scopeEnd = start, end
+	}
+	b.search(false)
+}
+
+// isSelected проверяет, выделено ли вхождение в редакторе
+func (b *FindBar) isSelected(m findBarMatch) bool {
+	e := b.editor
+	start, end := e.positionToIndex(e.selectionStart), e.positionToIndex(e.selectionEnd)
+	return min(start, end) == m.start && max(start, end) == m.end
+}
+
+// reveal выделяет вхождение в редакторе и делает его текущим
+func (b *FindBar) reveal(i int) {
+	b.current = i
+	b.editor.selectIndexRange(b.matches[i].start, b.matches[i].end)
+	b.publish()
+}
+
+// publish передает вхождения редактору и миникарте и обновляет счетчик
+func (b *FindBar) publish() {
+	var ranges []TextRange
+	if len(b.matches) > 0 {
+		text := b.editor.GetContent()
+		lineStarts := []int{0}
+		for i := 0; i < len(text); i++ {
+			if text[i] == '\n' {
+				lineStarts = append(lineStarts, i+1)
+			}
+		}
+		pos := func(offset int) TextPosition {
+			row := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+			return TextPosition{Row: row, Col: offset - lineStarts[row]}
+		}
+		ranges = make([]TextRange, len(b.matches))
+		for i, m := range b.matches {
+			ranges[i] = TextRange{Start: pos(m.start), End: pos(m.end), Text: text[m.start:m.end]}
+		}
+	}
+	b.editor.SetFindMatches(ranges, b.current)
+
+	switch {
+	case len(b.matches) == 0:
+		b.counterLabel.SetText("No results")
+	case b.current >= 0:
+		b.counterLabel.SetText(fmt.Sprintf("%d of %d", b.current+1, len(b.matches)))
+	default:
+		b.counterLabel.SetText(fmt.Sprintf("%d results", len(b.matches)))
+	}
+	if b.onChanged != nil {
+		b.onChanged()
+	}
+}
+
+// replacement строит текст замены для вхождения
+func (b *FindBar) replacement(text string, m findBarMatch) string {
+	template := b.replaceEntry.Text
+	result := template
+	if b.regexCheck.Checked && b.pattern != nil {
+		result = string(b.pattern.ExpandString(nil, template, text, m.groups))
+	}
+	if b.preserveCheck.Checked {
+		result = preserveCase(text[m.start:m.end], result)
+	}
+	return result
+}
+
+// ReplaceCurrent заменяет текущее вхождение и переходит к следующему
+func (b *FindBar) ReplaceCurrent() {
+	if b.current < 0 || b.onExecute == nil {
+		return
+	}
+	m := b.matches[b.current]
+	if !b.isSelected(m) {
+		// Как в VS Code: первое нажатие только выделяет вхождение
+		b.reveal(b.current)
+		return
+	}
+	text := b.editor.GetContent()
+	replacement := b.replacement(text, m)
+	cmd := &FindReplaceCommand{
+		oldText: text,
+		newText: text[:m.start] + replacement + text[m.end:],
+		count:   1,
+	}
+	b.rememberReplace()
+	if err := b.onExecute(cmd); err != nil {
+		return
+	}
+	if b.scopeEnd >= 0 {
+		b.scopeEnd += len(replacement) - (m.end - m.start)
+	}
+	b.editor.moveCursorToIndex(m.start + len(replacement))
+	b.editor.selectionStart, b.editor.selectionEnd = TextPosition{}, TextPosition{}
+	b.search(true)
+}
+
+// ReplaceAll заменяет все вхождения одной отменяемой командой
+func (b *FindBar) ReplaceAll() {
+	if len(b.matches) == 0 || b.onExecute == nil {
+		return
+	}
+	text := b.editor.GetContent()
+	var out strings.Builder
+	last := 0
+	for _, m := range b.matches {
+		out.WriteString(text[last:m.start])
+		out.WriteString(b.replacement(text, m))
+		last = m.end
+	}
+	out.WriteString(text[last:])
+
+	cmd := &FindReplaceCommand{oldText: text, newText: out.String(), count: len(b.matches)}
+	b.rememberReplace()
+	if err := b.onExecute(cmd); err != nil {
+		return
+	}
+	if b.scopeEnd >= 0 {
+		b.scopeEnd += len(cmd.newText) - len(text)
+	}
+	b.search(false)
+}
+
+// toggleOption переключает опцию по Alt+буква
+func (b *FindBar) toggleOption(key fyne.KeyName) bool {
+	var check *widget.Check
+	switch key {
+	case fyne.KeyC:
+		check = b.caseCheck
+	case fyne.KeyW:
+		check = b.wordCheck
+	case fyne.KeyR:
+		check = b.regexCheck
+	case fyne.KeyL:
+		check = b.selectionCheck
+	case fyne.KeyP:
+		check = b.preserveCheck
+	default:
+		return false
+	}
+	check.SetChecked(!check.Checked)
+	return true
+}
+
+// remember добавляет запрос в историю поиска
+func (b *FindBar) remember() {
+	if b.config == nil {
+		return
+	}
+	b.config.Editor.SearchHistory = pushHistory(b.config.Editor.SearchHistory, b.findEntry.Text)
+	b.findEntry.SetOptions(b.config.Editor.SearchHistory)
+	b.save()
+}
+
+// rememberReplace добавляет строку замены в историю
+func (b *FindBar) rememberReplace() {
+	b.remember()
+	if b.config == nil {
+		return
+	}
+	b.config.Editor.ReplaceHistory = pushHistory(b.config.Editor.ReplaceHistory, b.replaceEntry.Text)
+	b.replaceEntry.SetOptions(b.config.Editor.ReplaceHistory)
+	b.save()
+}
+
+func (b *FindBar) save() {
+	if b.onSave != nil {
+		b.onSave()
+	}
+}
+
+// publishContextKeys публикует состояние панели поиска
+func (b *FindBar) publishContextKeys(ck *ContextKeys) {
+	ck.SetProvider("findWidgetVisible", "Find bar is open", func() interface{} {
+		return b.Visible()
+	})
+	ck.SetProvider("replaceInputVisible", "Replace row of the find bar is open", func() interface{} {
+		return b.Visible() && b.replaceRow.Visible()
+	})
+}
+
+// CreateRenderer реализует интерфейс fyne.Widget
+func (b *FindBar) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(b.mainContainer)
+}
+
+// pushHistory добавляет запись в начало истории без повторов
+func pushHistory(history []string, item string) []string {
+	if item == "" {
+		return history
+	}
+	result := []string{item}
+	for _, h := range history {
+		if h != item && len(result) < findHistorySize {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// preserveCase приводит замену к регистру найденного текста: ВСЕ ЗАГЛАВНЫЕ,
+// все строчные или С заглавной
+func preserveCase(match, replacement string) string {
+	upper, lower := strings.ToUpper(match), strings.ToLower(match)
+	switch {
+	case upper == lower:
+		return replacement
+	case match == upper:
+		return strings.ToUpper(replacement)
+	case match == lower:
+		return strings.ToLower(replacement)
+	}
+	first, _ := utf8.DecodeRuneInString(match)
+	if unicode.IsUpper(first) {
+		r, size := utf8.DecodeRuneInString(replacement)
+		return string(unicode.ToUpper(r)) + replacement[size:]
+	}
+	return replacement
+}
+
+// FindReplaceCommand - замена из панели поиска, отменяемая одним шагом
+type FindReplaceCommand struct {
+	oldText string
+	newText string
+	count   int
+}
+
+// Execute и Undo отказываются менять буфер, отредактированный после замены или ее отмены,
+// чтобы не потерять введенный текст; команда при этом остается в истории
+func (c *FindReplaceCommand) Execute(editor *EditorWidget) error {
+	if editor.textContent != c.oldText {
+		return fmt.Errorf("the buffer has been edited since the replace was undone")
+	}
+	editor.textContent = c.newText
+	editor.isDirty = true
+	editor.updateDisplay()
+	return nil
+}
+
+func (c *FindReplaceCommand) Undo(editor *EditorWidget) error {
+	if editor.textContent != c.newText {
+		return fmt.Errorf("the buffer has been edited since the replace")
+	}
+	editor.textContent = c.oldText
+	editor.isDirty = true
+	editor.updateDisplay()
+	return nil
+}
+
+func (c *FindReplaceCommand) GetDescription() string {
+	return fmt.Sprintf("Replace %d occurrences", c.count)
+}
+
+// findMatchRect - прямоугольник подсветки вхождения в одной строке
+type findMatchRect struct {
+	TextRange
+	current bool
+}
+
+// findMatchRects разбивает вхождения поиска по строкам для отрисовки
+func (e *EditorWidget) findMatchRects() []findMatchRect {
+	matches, current := e.findMatches, e.findCurrent
+	if len(matches) == 0 {
+		return nil
+	}
+	var lines []string
+	var rects []findMatchRect
+	for i, m := range matches {
+		if len(rects) >= maxFindHighlights {
+			break
+		}
+		if m.Start.Row == m.End.Row {
+			rects = append(rects, findMatchRect{TextRange: m, current: i == current})
+			continue
+		}
+		if lines == nil {
+			lines = strings.Split(e.textContent, "\n")
+		}
+		for row := m.Start.Row; row <= m.End.Row && row < len(lines); row++ {
+			r := TextRange{Start: TextPosition{Row: row}, End: TextPosition{Row: row, Col: len(lines[row])}}
+			if row == m.Start.Row {
+				r.Start.Col = m.Start.Col
+			}
+			if row == m.End.Row {
+				r.End.Col = m.End.Col
+			}
+			rects = append(rects, findMatchRect{TextRange: r, current: i == current})
+		}
+	}
+	return rects
+}
+
+// findMatchColors возвращает полупрозрачные цвета вхождений и текущего вхождения
+func findMatchColors() (color.Color, color.Color) {
+	r, g, bl, _ := theme.Color(theme.ColorNameWarning).RGBA()
+	base := color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(bl >> 8)}
+	match, current := base, base
+	match.A, current.A = 0x50, 0xA0
+	return match, current
+}
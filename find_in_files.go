@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// searchMaxFileSize - файлы больше этого размера при поиске пропускаются
+const searchMaxFileSize = 16 * 1024 * 1024
+
+// SearchOptions - параметры поиска в файлах
+type SearchOptions struct {
+	Query         string
+	Root          string
+	Regex         bool
+	CaseSensitive bool
+	WholeWord     bool
+	Include       []string // glob-шаблоны путей, поддерживается **
+	Exclude       []string
+	ContextLines  int
+	UseIgnore     bool              // учитывать .gitignore и .ignore
+	Buffers       map[string]string // несохраненное содержимое открытых файлов
+	Index         *SearchIndex      // триграммный индекс для отбора файлов; nil - обход каталога
+}
+
+// SearchMatch - одно вхождение в файле
+type SearchMatch struct {
+	Line   int // с 1
+	Col    int // байтовое смещение в строке
+	Length int
+	Text   string // строка целиком
+}
+
+// SearchLine - строка результата для показа: совпадение или контекст; Number == 0 - разрыв
+type SearchLine struct {
+	Number  int
+	Text    string
+	Context bool
+}
+
+// SearchFileResult - результаты по одному файлу
+type SearchFileResult struct {
+	Path    string
+	Matches []SearchMatch
+	Lines   []SearchLine
+}
+
+// SearchProgress - счетчики выполняющегося поиска
+type SearchProgress struct {
+	FilesScanned int64
+	FilesMatched int64
+	Matches      int64
+}
+
+// compileSearchPattern строит регулярное выражение по параметрам поиска
+func compileSearchPattern(opts SearchOptions) (*regexp.Regexp, error) {
+	pattern := opts.Query
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// splitGlobList разбирает список шаблонов через запятую
+func splitGlobList(list string) []string {
+	var patterns []string
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			patterns = append(patterns, filepath.ToSlash(part))
+		}
+	}
+	return patterns
+}
+
+// globMatch сопоставляет путь через "/" с шаблоном; ** соответствует любому числу каталогов
+func globMatch(pattern, name string) bool {
+	return globMatchParts(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func globMatchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if globMatchParts(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// pathGlobMatch сопоставляет относительный путь с шаблоном include/exclude:
+// шаблон без "/" проверяется по любому компоненту пути, "dir/" - только по каталогам
+func pathGlobMatch(pattern, rel string, isDir bool) bool {
+	pattern = strings.TrimPrefix(pattern, "./")
+	if strings.HasSuffix(pattern, "/") {
+		if !isDir {
+			return false
+		}
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return globMatch(pattern, rel)
+}
+
+// ignoreRule - правило из .gitignore или .ignore
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// parseIgnoreFile читает правила файла игнорирования; отсутствие файла - не ошибка
+func parseIgnoreFile(file string) []ignoreRule {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var rules []ignoreRule
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasSuffix(line, `\ `) {
+			line = strings.TrimSuffix(line, `\ `) + " "
+		} else {
+			line = strings.TrimRight(line, " \t")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// Шаблон со слешем в начале или середине привязан к каталогу файла игнорирования
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// match проверяет путь относительно каталога файла игнорирования
+func (r ignoreRule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return globMatch(r.pattern, rel)
+	}
+	return globMatch("**/"+r.pattern, rel)
+}
+
+// ignoreMatcher хранит правила игнорирования по каталогам, загружая их при обходе
+type ignoreMatcher struct {
+	root  string
+	mutex sync.Mutex
+	rules map[string][]ignoreRule // каталог относительно корня -> правила
+}
+
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	return &ignoreMatcher{root: root, rules: make(map[string][]ignoreRule)}
+}
+
+// load читает .gitignore и .ignore каталога
+func (m *ignoreMatcher) load(relDir string) {
+	dir := filepath.Join(m.root, filepath.FromSlash(relDir))
+	rules := parseIgnoreFile(filepath.Join(dir, ".gitignore"))
+	rules = append(rules, parseIgnoreFile(filepath.Join(dir, ".ignore"))...)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(rules) > 0 {
+		m.rules[relDir] = rules
+	}
+}
+
+// ignored проверяет путь правилами всех родительских каталогов; побеждает последнее совпавшее
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ignored := false
+	dirs := []string{"."}
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		dirs = append(dirs, strings.Join(parts[:i], "/"))
+	}
+	for _, dir := range dirs {
+		sub := rel
+		if dir != "." {
+			sub = strings.TrimPrefix(rel, dir+"/")
+		}
+		for _, rule := range m.rules[dir] {
+			if rule.match(sub, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// searchSkipDirs - служебные каталоги систем контроля версий
+var searchSkipDirs = map[string]bool{".git": true, ".svn": true, ".hg": true}
+
+// searchFiles ищет по каталогу пулом горутин и отправляет результаты по мере нахождения.
+// Канал results закрывается по завершении или отмене ctx.
+func searchFiles(ctx context.Context, opts SearchOptions, re *regexp.Regexp, results chan<- SearchFileResult, progress *SearchProgress) error {
+	defer close(results)
+
+	root := opts.Root
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+
+	var ignore *ignoreMatcher
+	if opts.UseIgnore {
+		ignore = newIgnoreMatcher(root)
+	}
+
+	paths := make(chan string, 256)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range paths {
+				if ctx.Err() != nil {
+					continue
+				}
+				result, ok := searchFile(p, opts, re)
+				atomic.AddInt64(&progress.FilesScanned, 1)
+				if !ok {
+					continue
+				}
+				atomic.AddInt64(&progress.FilesMatched, 1)
+				atomic.AddInt64(&progress.Matches, int64(len(result.Matches)))
+				select {
+				case results <- result:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+
+	send := func(p string) error {
+		select {
+		case paths <- p:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var walkErr error
+	if candidates, ok := indexCandidates(opts, root, re); ok {
+		// Индекс уже учитывает .gitignore; остается проверить include/exclude
+		for _, p := range candidates {
+			rel, _ := filepath.Rel(root, p)
+			if searchPathExcluded(opts, filepath.ToSlash(rel)) || !searchIncluded(opts, filepath.ToSlash(rel)) {
+				continue
+			}
+			if walkErr = send(p); walkErr != nil {
+				break
+			}
+		}
+	} else {
+		walkErr = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, p)
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				if rel == "." {
+					if ignore != nil {
+						ignore.load(rel)
+					}
+					return nil
+				}
+				if searchSkipDirs[d.Name()] || (ignore != nil && ignore.ignored(rel, true)) || searchExcluded(opts, rel, true) {
+					return filepath.SkipDir
+				}
+				if ignore != nil {
+					ignore.load(rel)
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			if (ignore != nil && ignore.ignored(rel, false)) || searchExcluded(opts, rel, false) || !searchIncluded(opts, rel) {
+				return nil
+			}
+			return send(p)
+		})
+	}
+
+	close(paths)
+	wg.Wait()
+	if walkErr != nil && walkErr != ctx.Err() {
+		return walkErr
+	}
+	return ctx.Err()
+}
+
+// indexCandidates отбирает файлы по индексу, если он построен для каталога поиска.
+// Открытые файлы с несохраненными изменениями добавляются всегда.
+func indexCandidates(opts SearchOptions, root string, re *regexp.Regexp) ([]string, bool) {
+	idx := opts.Index
+	if idx == nil || !opts.UseIgnore || !idx.Ready() {
+		return nil, false
+	}
+	if rel, err := filepath.Rel(idx.Root(), root); err != nil || strings.HasPrefix(filepath.ToSlash(rel), "../") || rel == ".." {
+		return nil, false
+	}
+
+	prefix := root + string(filepath.Separator)
+	seen := make(map[string]bool)
+	var paths []string
+	for _, p := range idx.Candidates(re) {
+		if p == root || strings.HasPrefix(p, prefix) {
+			paths = append(paths, p)
+			seen[p] = true
+		}
+	}
+	for p := range opts.Buffers {
+		if !seen[p] && strings.HasPrefix(p, prefix) {
+			paths = append(paths, p)
+		}
+	}
+	return paths, true
+}
+
+// searchPathExcluded проверяет шаблоны исключения для файла и всех его каталогов
+func searchPathExcluded(opts SearchOptions, rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if searchExcluded(opts, strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return searchExcluded(opts, rel, false)
+}
+
+// searchExcluded проверяет шаблоны исключения
+func searchExcluded(opts SearchOptions, rel string, isDir bool) bool {
+	for _, pattern := range opts.Exclude {
+		if pathGlobMatch(pattern, rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchIncluded проверяет шаблоны включения; пустой список включает все файлы
+func searchIncluded(opts SearchOptions, rel string) bool {
+	if len(opts.Include) == 0 {
+		return true
+	}
+	for _, pattern := range opts.Include {
+		if pathGlobMatch(pattern, rel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchFile ищет вхождения в одном файле; ok == false - совпадений нет или файл пропущен
+func searchFile(p string, opts SearchOptions, re *regexp.Regexp) (SearchFileResult, bool) {
+	var data []byte
+	if content, open := opts.Buffers[p]; open {
+		data = []byte(content)
+	} else {
+		info, err := os.Stat(p)
+		if err != nil || info.Size() > searchMaxFileSize {
+			return SearchFileResult{}, false
+		}
+		data, err = os.ReadFile(p)
+		if err != nil || isBinaryContent(data) {
+			return SearchFileResult{}, false
+		}
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), searchMaxFileSize)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+
+	result := SearchFileResult{Path: p}
+	matched := make(map[int]bool)
+	for i, line := range lines {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			result.Matches = append(result.Matches, SearchMatch{Line: i + 1, Col: loc[0], Length: loc[1] - loc[0], Text: line})
+			matched[i] = true
+		}
+	}
+	if len(result.Matches) == 0 {
+		return result, false
+	}
+	result.Lines = searchContextLines(lines, matched, opts.ContextLines)
+	return result, true
+}
+
+// searchContextLines собирает строки с совпадениями и контекстом, объединяя пересекающиеся блоки
+func searchContextLines(lines []string, matched map[int]bool, contextLines int) []SearchLine {
+	show := make(map[int]bool)
+	for i := range matched {
+		for j := i - contextLines; j <= i+contextLines; j++ {
+			if j >= 0 && j < len(lines) {
+				show[j] = true
+			}
+		}
+	}
+	numbers := make([]int, 0, len(show))
+	for i := range show {
+		numbers = append(numbers, i)
+	}
+	sort.Ints(numbers)
+
+	var result []SearchLine
+	for k, i := range numbers {
+		if k > 0 && i != numbers[k-1]+1 {
+			result = append(result, SearchLine{})
+		}
+		result = append(result, SearchLine{Number: i + 1, Text: lines[i], Context: !matched[i]})
+	}
+	return result
+}
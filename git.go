@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitClient выполняет команды git через CLI
+type GitClient struct {
+	gitPath string
+	workDir string
+}
+
+// BlameLine описывает авторство одной строки файла
+type BlameLine struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Summary string
+	Line    int
+	Text    string
+}
+
+// GitCommit описывает коммит из истории файла
+type GitCommit struct {
+	Hash    string
+	Parents []string
+	Author  string
+	Date    time.Time
+	Summary string
+	Path    string // Путь к файлу в этом коммите (относительно корня репозитория)
+}
+
+// uncommittedHash - хеш, которым git blame помечает незакоммиченные строки
+const uncommittedHash = "0000000000000000000000000000000000000000"
+
+// NewGitClient создает клиент git для указанной рабочей директории
+func NewGitClient(gitPath, workDir string) *GitClient {
+	if gitPath == "" {
+		gitPath = "git"
+	}
+	return &GitClient{gitPath: gitPath, workDir: workDir}
+}
+
+// run выполняет команду git и возвращает stdout
+func (g *GitClient) run(args ...string) (string, error) {
+	return g.runWithInput("", args...)
+}
+
+// runWithInput выполняет команду git, передавая input в stdin
+func (g *GitClient) runWithInput(input string, args ...string) (string, error) {
+	cmd := exec.Command(g.gitPath, args...)
+	cmd.Dir = g.workDir
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return stdout.String(), fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// RepoRoot возвращает корень репозитория
+func (g *GitClient) RepoRoot() (string, error) {
+	out, err := g.run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(out)), nil
+}
+
+// relativePath возвращает путь файла относительно корня репозитория в формате git
+func (g *GitClient) relativePath(path string) (string, error) {
+	root, err := g.RepoRoot()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// Разрешаем симлинки, чтобы путь совпадал с тем, что вернул git
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Blame возвращает авторство строк файла. Если contents не пустой,
+// аннотируется переданный текст буфера вместо версии на диске.
+func (g *GitClient) Blame(path, contents string) ([]BlameLine, error) {
+	args := []string{"blame", "--porcelain"}
+	if contents != "" {
+		args = append(args, "--contents", "-")
+	}
+	args = append(args, "--", filepath.Base(path))
+
+	out, err := g.runWithInput(contents, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlamePorcelain(out), nil
+}
+
+// parseBlamePorcelain разбирает вывод git blame --porcelain
+func parseBlamePorcelain(out string) []BlameLine {
+	type commitInfo struct {
+		author  string
+		date    time.Time
+		summary string
+	}
+
+	commits := make(map[string]*commitInfo)
+	var result []BlameLine
+	var current *BlameLine
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "\t") {
+			// Строка с содержимым завершает запись
+			if current != nil {
+				current.Text = line[1:]
+				if info := commits[current.Hash]; info != nil {
+					current.Author = info.author
+					current.Date = info.date
+					current.Summary = info.summary
+				}
+				result = append(result, *current)
+				current = nil
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && len(fields[0]) == 40 && current == nil {
+			lineNum, _ := strconv.Atoi(fields[2])
+			current = &BlameLine{Hash: fields[0], Line: lineNum}
+			if commits[current.Hash] == nil {
+				commits[current.Hash] = &commitInfo{}
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		info := commits[current.Hash]
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			info.author = value
+		case "author-time":
+			if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+				info.date = time.Unix(ts, 0)
+			}
+		case "summary":
+			info.summary = value
+		}
+	}
+
+	return result
+}
+
+// FileHistory возвращает список коммитов, затрагивающих файл (с учетом переименований)
+func (g *GitClient) FileHistory(path string) ([]GitCommit, error) {
+	rel, err := g.relativePath(path)
+	if err != nil {
+		return nil, err
+	}
+	root, err := g.RepoRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	historyClient := NewGitClient(g.gitPath, root)
+	out, err := historyClient.run("log", "--follow", "--name-only",
+		"--format=%x1e%H%x1f%P%x1f%an%x1f%at%x1f%s", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitLog(out, rel), nil
+}
+
+// parseGitLog разбирает вывод git log с форматом, используемым в FileHistory
+func parseGitLog(out, defaultPath string) []GitCommit {
+	var commits []GitCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		lines := strings.Split(record, "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) < 5 {
+			continue
+		}
+
+		commit := GitCommit{
+			Hash:    fields[0],
+			Parents: strings.Fields(fields[1]),
+			Author:  fields[2],
+			Summary: fields[4],
+			Path:    defaultPath,
+		}
+		if ts, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			commit.Date = time.Unix(ts, 0)
+		}
+		for _, l := range lines[1:] {
+			if l = strings.TrimSpace(l); l != "" {
+				commit.Path = l
+				break
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// ShowCommit возвращает полное описание коммита с патчем
+func (g *GitClient) ShowCommit(hash string) (string, error) {
+	return g.run("show", "--stat", "--patch", "--format=fuller", hash)
+}
+
+// FileAtRevision возвращает содержимое файла в указанной ревизии.
+// relPath задается относительно корня репозитория.
+func (g *GitClient) FileAtRevision(rev, relPath string) (string, error) {
+	root, err := g.RepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return NewGitClient(g.gitPath, root).run("show", rev+":"+relPath)
+}
+
+// ShortHash возвращает сокращенный хеш коммита
+func ShortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+// GitFileStatus описывает состояние файла в рабочей копии
+type GitFileStatus struct {
+	Path     string // Относительно корня репозитория
+	OrigPath string // Исходный путь для переименований
+	Index    byte   // Статус в индексе (X из git status)
+	WorkTree byte   // Статус в рабочей копии (Y из git status)
+}
+
+// IsStaged возвращает true, если у файла есть проиндексированные изменения
+func (s GitFileStatus) IsStaged() bool {
+	return s.Index != ' ' && s.Index != '?' && s.Index != '!'
+}
+
+// IsUnstaged возвращает true, если у файла есть непроиндексированные изменения
+func (s GitFileStatus) IsUnstaged() bool {
+	return s.WorkTree != ' ' || s.IsUntracked()
+}
+
+// IsUntracked возвращает true для неотслеживаемых файлов
+func (s GitFileStatus) IsUntracked() bool {
+	return s.Index == '?' && s.WorkTree == '?'
+}
+
+// Status возвращает список измененных файлов репозитория
+func (g *GitClient) Status() ([]GitFileStatus, error) {
+	out, err := g.run("status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	return parseGitStatus(out), nil
+}
+
+// parseGitStatus разбирает вывод git status --porcelain=v1 -z
+func parseGitStatus(out string) []GitFileStatus {
+	var result []GitFileStatus
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		status := GitFileStatus{
+			Index:    entry[0],
+			WorkTree: entry[1],
+			Path:     entry[3:],
+		}
+		// Для переименований и копий следующим элементом идет исходный путь
+		if (status.Index == 'R' || status.Index == 'C') && i+1 < len(entries) {
+			status.OrigPath = entries[i+1]
+			i++
+		}
+		result = append(result, status)
+	}
+	return result
+}
+
+// hasHead возвращает true, если в репозитории есть хотя бы один коммит
+func (g *GitClient) hasHead() bool {
+	_, err := g.run("rev-parse", "--verify", "-q", "HEAD")
+	return err == nil
+}
+
+// Stage добавляет файлы в индекс
+func (g *GitClient) Stage(paths ...string) error {
+	_, err := g.run(append([]string{"add", "-A", "--"}, paths...)...)
+	return err
+}
+
+// Unstage убирает файлы из индекса, сохраняя изменения в рабочей копии
+func (g *GitClient) Unstage(paths ...string) error {
+	if !g.hasHead() {
+		_, err := g.run(append([]string{"rm", "--cached", "-r", "-q", "--"}, paths...)...)
+		return err
+	}
+	_, err := g.run(append([]string{"reset", "-q", "HEAD", "--"}, paths...)...)
+	return err
+}
+
+// Discard отменяет непроиндексированные изменения файла.
+// Неотслеживаемые файлы удаляются.
+func (g *GitClient) Discard(status GitFileStatus) error {
+	if status.IsUntracked() {
+		_, err := g.run("clean", "-f", "-q", "--", status.Path)
+		return err
+	}
+	_, err := g.run("checkout", "-q", "--", status.Path)
+	return err
+}
+
+// Commit создает коммит из проиндексированных изменений
+func (g *GitClient) Commit(message string, amend bool) error {
+	args := []string{"commit", "-q", "-F", "-"}
+	if amend {
+		args = append(args, "--amend")
+	}
+	_, err := g.runWithInput(message, args...)
+	return err
+}
+
+// LastCommitMessage возвращает сообщение последнего коммита
+func (g *GitClient) LastCommitMessage() (string, error) {
+	out, err := g.run("log", "-1", "--format=%B")
+	return strings.TrimSpace(out), err
+}
+
+// IndexContent возвращает содержимое файла в индексе
+func (g *GitClient) IndexContent(relPath string) (string, error) {
+	return g.FileAtRevision("", relPath)
+}
+
+// StashPush сохраняет изменения рабочей копии в stash
+func (g *GitClient) StashPush(message string, includeUntracked bool) error {
+	args := []string{"stash", "push", "-q"}
+	if includeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if message != "" {
+		args = append(args, "-m", message)
+	}
+	_, err := g.run(args...)
+	return err
+}
+
+// StashPop применяет и удаляет последний stash
+func (g *GitClient) StashPop() error {
+	_, err := g.run("stash", "pop", "-q")
+	return err
+}
+
+// StashList возвращает описания сохраненных stash
+func (g *GitClient) StashList() ([]string, error) {
+	out, err := g.run("stash", "list")
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmptyLines(out), nil
+}
+
+// CurrentBranch возвращает имя текущей ветки
+func (g *GitClient) CurrentBranch() (string, error) {
+	out, err := g.run("symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		// Detached HEAD
+		out, err = g.run("rev-parse", "--short", "HEAD")
+	}
+	return strings.TrimSpace(out), err
+}
+
+// Branches возвращает список локальных веток
+func (g *GitClient) Branches() ([]string, error) {
+	out, err := g.run("branch", "--format=%(refname:short)")
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmptyLines(out), nil
+}
+
+// CreateBranch создает новую ветку и, при необходимости, переключается на нее
+func (g *GitClient) CreateBranch(name string, checkout bool) error {
+	var err error
+	if checkout {
+		_, err = g.run("checkout", "-q", "-b", name)
+	} else {
+		_, err = g.run("branch", name)
+	}
+	return err
+}
+
+// Checkout переключает рабочую копию на указанную ветку
+func (g *GitClient) Checkout(branch string) error {
+	_, err := g.run("checkout", "-q", branch)
+	return err
+}
+
+// splitNonEmptyLines разбивает вывод команды на непустые строки
+func splitNonEmptyLines(out string) []string {
+	var lines []string
+	for _, l := range strings.Split(out, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
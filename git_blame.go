@@ -0,0 +1,376 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// BlameGutter - колонка с аннотациями git blame слева от номеров строк
+type BlameGutter struct {
+	widget.BaseWidget
+
+	label   *widget.Label
+	lines   []BlameLine
+	popup   *widget.PopUp
+	hovered int
+
+	onOpenCommit func(hash string)
+}
+
+// NewBlameGutter создает скрытую колонку blame
+func NewBlameGutter() *BlameGutter {
+	b := &BlameGutter{hovered: -1}
+	b.label = widget.NewLabel("")
+	b.label.Wrapping = fyne.TextWrapOff
+	b.label.TextStyle = fyne.TextStyle{Monospace: true}
+	b.ExtendBaseWidget(b)
+	b.Hide()
+	return b
+}
+
+// SetLines задает аннотации и показывает колонку
+func (b *BlameGutter) SetLines(lines []BlameLine) {
+	b.lines = lines
+
+	authorWidth := 0
+	for _, l := range lines {
+		if n := len([]rune(l.Author)); n > authorWidth {
+			authorWidth = n
+		}
+	}
+	if authorWidth > 16 {
+		authorWidth = 16
+	}
+
+	var sb strings.Builder
+	prevHash := ""
+	for i, l := range lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		// Повторяющиеся подряд коммиты показываем только один раз
+		if l.Hash == prevHash {
+			continue
+		}
+		prevHash = l.Hash
+		if l.Hash == uncommittedHash {
+			sb.WriteString("Not committed")
+			continue
+		}
+		author := []rune(l.Author)
+		if len(author) > authorWidth {
+			author = author[:authorWidth]
+		}
+		sb.WriteString(fmt.Sprintf("%s %-*s %s", ShortHash(l.Hash), authorWidth, string(author), l.Date.Format("2006-01-02")))
+	}
+
+	b.label.SetText(sb.String())
+	b.Show()
+	b.Refresh()
+}
+
+// Clear скрывает колонку и удаляет аннотации
+func (b *BlameGutter) Clear() {
+	b.lines = nil
+	b.hidePopup()
+	b.label.SetText("")
+	b.Hide()
+}
+
+// IsActive возвращает true, если аннотации отображаются
+func (b *BlameGutter) IsActive() bool {
+	return len(b.lines) > 0
+}
+
+// lineAt возвращает индекс строки blame для координаты Y
+func (b *BlameGutter) lineAt(y float32) int {
+	lineHeight := MeasureString("M", theme.TextSize()).Height
+	if lineHeight <= 0 {
+		return -1
+	}
+	row := int((y - theme.InnerPadding()) / lineHeight)
+	if row < 0 || row >= len(b.lines) {
+		return -1
+	}
+	return row
+}
+
+// MouseIn реализует desktop.Hoverable
+func (b *BlameGutter) MouseIn(ev *desktop.MouseEvent) {
+	b.MouseMoved(ev)
+}
+
+// MouseMoved показывает подсказку с информацией о коммите строки
+func (b *BlameGutter) MouseMoved(ev *desktop.MouseEvent) {
+	row := b.lineAt(ev.Position.Y)
+	if row == b.hovered {
+		return
+	}
+	b.hovered = row
+	b.hidePopup()
+	if row < 0 {
+		return
+	}
+
+	c := fyne.CurrentApp().Driver().CanvasForObject(b)
+	if c == nil {
+		return
+	}
+
+	l := b.lines[row]
+	var text string
+	if l.Hash == uncommittedHash {
+		text = "Not committed yet"
+	} else {
+		text = fmt.Sprintf("%s\n%s, %s\n\n%s\n\nClick to show the full commit",
+			l.Hash, l.Author, l.Date.Format("2006-01-02 15:04"), l.Summary)
+	}
+	b.popup = widget.NewPopUp(widget.NewLabel(text), c)
+	b.popup.ShowAtPosition(ev.AbsolutePosition.Add(fyne.NewPos(16, 16)))
+}
+
+// MouseOut скрывает подсказку
+func (b *BlameGutter) MouseOut() {
+	b.hovered = -1
+	b.hidePopup()
+}
+
+// Tapped открывает коммит, к которому относится строка
+func (b *BlameGutter) Tapped(ev *fyne.PointEvent) {
+	row := b.lineAt(ev.Position.Y)
+	if row < 0 || b.lines[row].Hash == uncommittedHash {
+		return
+	}
+	b.hidePopup()
+	if b.onOpenCommit != nil {
+		b.onOpenCommit(b.lines[row].Hash)
+	}
+}
+
+func (b *BlameGutter) hidePopup() {
+	if b.popup != nil {
+		b.popup.Hide()
+		b.popup = nil
+	}
+}
+
+// CreateRenderer реализует интерфейс fyne.Widget
+func (b *BlameGutter) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(b.label)
+}
+
+// SetBlame показывает аннотации git blame в редакторе
+func (e *EditorWidget) SetBlame(lines []BlameLine) {
+	if e.blameGutter != nil {
+		e.blameGutter.SetLines(lines)
+	}
+}
+
+// ClearBlame скрывает аннотации git blame
+func (e *EditorWidget) ClearBlame() {
+	if e.blameGutter != nil {
+		e.blameGutter.Clear()
+	}
+}
+
+// IsBlameVisible возвращает true, если аннотации git blame отображаются
+func (e *EditorWidget) IsBlameVisible() bool {
+	return e.blameGutter != nil && e.blameGutter.IsActive()
+}
+
+// gitClientFor создает git клиент для директории указанного файла
+func (a *App) gitClientFor(path string) *GitClient {
+	gitPath := ""
+	if a.config != nil {
+		gitPath = a.config.ExternalTools.GitPath
+	}
+	return NewGitClient(gitPath, filepath.Dir(path))
+}
+
+// toggleBlame включает или выключает аннотации git blame для текущего файла
+func (a *App) toggleBlame() {
+	if a.editor == nil {
+		return
+	}
+	if a.editor.IsBlameVisible() {
+		a.editor.ClearBlame()
+		return
+	}
+	if a.currentFile == "" {
+		dialog.ShowInformation("Git Blame", "Please open a file first", a.mainWin)
+		return
+	}
+
+	path := a.currentFile
+	contents := ""
+	if a.editor.IsDirty() {
+		contents = a.editor.GetFullText()
+	}
+
+	go func() {
+		lines, err := a.gitClientFor(path).Blame(path, contents)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, a.mainWin)
+				return
+			}
+			if a.currentFile != path {
+				return
+			}
+			a.editor.SetBlame(lines)
+		})
+	}()
+}
+
+// showCommitDetails открывает окно с полным описанием коммита
+func (a *App) showCommitDetails(path, hash string) {
+	go func() {
+		out, err := a.gitClientFor(path).ShowCommit(hash)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, a.mainWin)
+				return
+			}
+
+			grid := widget.NewTextGridFromString(out)
+			win := a.fyneApp.NewWindow("Commit " + ShortHash(hash))
+			win.SetContent(container.NewScroll(grid))
+			win.Resize(fyne.NewSize(800, 600))
+			win.Show()
+		})
+	}()
+}
+
+// showFileHistory показывает список коммитов, затрагивающих текущий файл
+func (a *App) showFileHistory() {
+	if a.currentFile == "" {
+		dialog.ShowInformation("File History", "Please open a file first", a.mainWin)
+		return
+	}
+
+	path := a.currentFile
+	go func() {
+		commits, err := a.gitClientFor(path).FileHistory(path)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, a.mainWin)
+				return
+			}
+			if len(commits) == 0 {
+				dialog.ShowInformation("File History", "No commits found for this file", a.mainWin)
+				return
+			}
+			a.showFileHistoryList(path, commits)
+		})
+	}()
+}
+
+// showFileHistoryList отображает панель истории файла
+func (a *App) showFileHistoryList(path string, commits []GitCommit) {
+	historyList := widget.NewList(
+		func() int { return len(commits) },
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil,
+				container.NewHBox(
+					widget.NewButton("Parent", nil),
+					widget.NewButton("Working Copy", nil),
+					widget.NewButton("Commit", nil),
+				),
+				widget.NewLabel("Commit"),
+			)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*fyne.Container)
+			label := row.Objects[0].(*widget.Label)
+			buttons := row.Objects[1].(*fyne.Container)
+
+			c := commits[i]
+			label.SetText(fmt.Sprintf("%s  %s  %s  %s",
+				ShortHash(c.Hash), c.Date.Format("2006-01-02"), c.Author, c.Summary))
+
+			buttons.Objects[0].(*widget.Button).OnTapped = func() {
+				a.showRevisionDiff(path, commits, i, false)
+			}
+			buttons.Objects[1].(*widget.Button).OnTapped = func() {
+				a.showRevisionDiff(path, commits, i, true)
+			}
+			buttons.Objects[2].(*widget.Button).OnTapped = func() {
+				a.showCommitDetails(path, c.Hash)
+			}
+		},
+	)
+
+	historyList.OnSelected = func(id widget.ListItemID) {
+		if id >= 0 && id < len(commits) {
+			a.showRevisionDiff(path, commits, id, false)
+		}
+		historyList.UnselectAll()
+	}
+
+	historyDialog := dialog.NewCustom("History: "+filepath.Base(path), "Close", historyList, a.mainWin)
+	historyDialog.Resize(fyne.NewSize(900, 500))
+	historyDialog.Show()
+}
+
+// showRevisionDiff открывает сравнение ревизии с родителем или рабочей копией
+func (a *App) showRevisionDiff(path string, commits []GitCommit, index int, againstWorkingCopy bool) {
+	commit := commits[index]
+	git := a.gitClientFor(path)
+
+	// Текст редактора читаем в UI-потоке, до запуска горутины
+	workingText, inEditor := "", false
+	if againstWorkingCopy && a.editor != nil && a.editor.filePath == path {
+		workingText, inEditor = a.editor.GetFullText(), true
+	}
+
+	go func() {
+		revText, err := git.FileAtRevision(commit.Hash, commit.Path)
+		if err != nil {
+			fyne.Do(func() { dialog.ShowError(err, a.mainWin) })
+			return
+		}
+
+		var baseName, baseText string
+		if againstWorkingCopy {
+			baseName = "Working Copy"
+			if inEditor {
+				baseText = workingText
+			} else {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					fyne.Do(func() { dialog.ShowError(err, a.mainWin) })
+					return
+				}
+				baseText = string(data)
+			}
+		} else if len(commit.Parents) > 0 {
+			parentPath := commit.Path
+			if index+1 < len(commits) {
+				parentPath = commits[index+1].Path
+			}
+			baseName = ShortHash(commit.Parents[0])
+			// Файл мог отсутствовать в родительском коммите
+			baseText, _ = git.FileAtRevision(commit.Parents[0], parentPath)
+		} else {
+			baseName = "(empty)"
+		}
+
+		revName := ShortHash(commit.Hash)
+		fyne.Do(func() {
+			if againstWorkingCopy {
+				a.showDiffContents(filepath.Base(path), revName, revText, baseName, baseText)
+			} else {
+				a.showDiffContents(filepath.Base(path), baseName, baseText, revName, revText)
+			}
+		})
+	}()
+}
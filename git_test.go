@@ -0,0 +1,321 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestRepo создает пустой репозиторий во временном каталоге;
+// тест пропускается, если git не установлен
+func newTestRepo(t *testing.T) (*GitClient, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// Изолируем тест от пользовательской и системной конфигурации git
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	g := NewGitClient("", dir)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"symbolic-ref", "HEAD", "refs/heads/main"},
+	} {
+		if _, err := g.run(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g, dir
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// commitTestFile записывает файл и коммитит его
+func commitTestFile(t *testing.T, g *GitClient, dir, name, content, message string) {
+	t.Helper()
+	writeTestFile(t, dir, name, content)
+	if err := g.Stage(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Commit(message, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// statusOf возвращает статус файла или nil, если файл не изменен
+func statusOf(t *testing.T, g *GitClient, path string) *GitFileStatus {
+	t.Helper()
+	statuses, err := g.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range statuses {
+		if statuses[i].Path == path {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+func TestParseGitStatus(t *testing.T) {
+	out := " M main.go\x00A  new.go\x00R  renamed.go\x00old.go\x00?? notes.txt\x00MM both.go\x00"
+	want := []GitFileStatus{
+		{Path: "main.go", Index: ' ', WorkTree: 'M'},
+		{Path: "new.go", Index: 'A', WorkTree: ' '},
+		{Path: "renamed.go", OrigPath: "old.go", Index: 'R', WorkTree: ' '},
+		{Path: "notes.txt", Index: '?', WorkTree: '?'},
+		{Path: "both.go", Index: 'M', WorkTree: 'M'},
+	}
+	got := parseGitStatus(out)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseGitStatus() = %+v, want %+v", got, want)
+	}
+
+	if !got[0].IsUnstaged() || got[0].IsStaged() {
+		t.Errorf("%s: want unstaged only", got[0].Path)
+	}
+	if !got[1].IsStaged() || got[1].IsUnstaged() {
+		t.Errorf("%s: want staged only", got[1].Path)
+	}
+	if !got[3].IsUntracked() || got[3].IsStaged() || !got[3].IsUnstaged() {
+		t.Errorf("%s: want untracked", got[3].Path)
+	}
+	if !got[4].IsStaged() || !got[4].IsUnstaged() {
+		t.Errorf("%s: want staged and unstaged", got[4].Path)
+	}
+}
+
+func TestGitClientStatus(t *testing.T) {
+	g, dir := newTestRepo(t)
+	commitTestFile(t, g, dir, "a.txt", "one\n", "initial")
+
+	writeTestFile(t, dir, "a.txt", "two\n")
+	writeTestFile(t, dir, "sub/b.txt", "new\n")
+
+	if s := statusOf(t, g, "a.txt"); s == nil || s.Index != ' ' || s.WorkTree != 'M' {
+		t.Errorf("a.txt status = %+v, want ' M'", s)
+	}
+	if s := statusOf(t, g, "sub/b.txt"); s == nil || !s.IsUntracked() {
+		t.Errorf("sub/b.txt status = %+v, want untracked", s)
+	}
+}
+
+func TestGitClientStageUnstage(t *testing.T) {
+	g, dir := newTestRepo(t)
+
+	// До первого коммита Unstage работает через git rm --cached
+	writeTestFile(t, dir, "a.txt", "one\n")
+	if err := g.Stage("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if s := statusOf(t, g, "a.txt"); s == nil || s.Index != 'A' {
+		t.Fatalf("after Stage: status = %+v, want 'A'", s)
+	}
+	if err := g.Unstage("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if s := statusOf(t, g, "a.txt"); s == nil || !s.IsUntracked() {
+		t.Fatalf("after Unstage without HEAD: status = %+v, want untracked", s)
+	}
+
+	if err := g.Stage("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Commit("initial", false); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, dir, "a.txt", "two\n")
+	if err := g.Stage("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if s := statusOf(t, g, "a.txt"); s == nil || s.Index != 'M' || s.WorkTree != ' ' {
+		t.Fatalf("after Stage: status = %+v, want 'M '", s)
+	}
+	if err := g.Unstage("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if s := statusOf(t, g, "a.txt"); s == nil || s.Index != ' ' || s.WorkTree != 'M' {
+		t.Fatalf("after Unstage: status = %+v, want ' M'", s)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "two\n" {
+		t.Errorf("Unstage changed the working copy: %q", got)
+	}
+}
+
+func TestGitClientDiscard(t *testing.T) {
+	g, dir := newTestRepo(t)
+	commitTestFile(t, g, dir, "a.txt", "one\n", "initial")
+
+	writeTestFile(t, dir, "a.txt", "two\n")
+	s := statusOf(t, g, "a.txt")
+	if s == nil {
+		t.Fatal("a.txt is not modified")
+	}
+	if err := g.Discard(*s); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "one\n" {
+		t.Errorf("after Discard: a.txt = %q, want %q", got, "one\n")
+	}
+
+	writeTestFile(t, dir, "untracked.txt", "tmp\n")
+	s = statusOf(t, g, "untracked.txt")
+	if s == nil {
+		t.Fatal("untracked.txt is not listed")
+	}
+	if err := g.Discard(*s); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "untracked.txt")); !os.IsNotExist(err) {
+		t.Errorf("Discard kept the untracked file: %v", err)
+	}
+}
+
+func TestGitClientCommitAmend(t *testing.T) {
+	g, dir := newTestRepo(t)
+	commitTestFile(t, g, dir, "a.txt", "one\n", "initial")
+
+	if msg, err := g.LastCommitMessage(); err != nil || msg != "initial" {
+		t.Fatalf("LastCommitMessage() = %q, %v", msg, err)
+	}
+
+	writeTestFile(t, dir, "b.txt", "two\n")
+	if err := g.Stage("b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Commit("initial, with b", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg, err := g.LastCommitMessage(); err != nil || msg != "initial, with b" {
+		t.Errorf("after amend: LastCommitMessage() = %q, %v", msg, err)
+	}
+	out, err := g.run("rev-list", "--count", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "1\n" {
+		t.Errorf("amend created a new commit: rev-list count = %q", out)
+	}
+	if content, err := g.FileAtRevision("HEAD", "b.txt"); err != nil || content != "two\n" {
+		t.Errorf("amended commit: b.txt = %q, %v", content, err)
+	}
+
+	if err := g.Commit("nothing staged", false); err == nil {
+		t.Error("Commit with nothing staged succeeded")
+	}
+}
+
+func TestGitClientStash(t *testing.T) {
+	g, dir := newTestRepo(t)
+	commitTestFile(t, g, dir, "a.txt", "one\n", "initial")
+
+	writeTestFile(t, dir, "a.txt", "two\n")
+	writeTestFile(t, dir, "new.txt", "new\n")
+	if err := g.StashPush("work in progress", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readTestFile(t, dir, "a.txt"); got != "one\n" {
+		t.Errorf("after StashPush: a.txt = %q, want %q", got, "one\n")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("StashPush with untracked kept new.txt: %v", err)
+	}
+	stashes, err := g.StashList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stashes) != 1 {
+		t.Fatalf("StashList() = %q, want one entry", stashes)
+	}
+
+	if err := g.StashPop(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "two\n" {
+		t.Errorf("after StashPop: a.txt = %q, want %q", got, "two\n")
+	}
+	if got := readTestFile(t, dir, "new.txt"); got != "new\n" {
+		t.Errorf("after StashPop: new.txt = %q, want %q", got, "new\n")
+	}
+	if stashes, err := g.StashList(); err != nil || len(stashes) != 0 {
+		t.Errorf("after StashPop: StashList() = %q, %v", stashes, err)
+	}
+}
+
+func TestGitClientBranches(t *testing.T) {
+	g, dir := newTestRepo(t)
+	commitTestFile(t, g, dir, "a.txt", "main\n", "initial")
+
+	if branch, err := g.CurrentBranch(); err != nil || branch != "main" {
+		t.Fatalf("CurrentBranch() = %q, %v", branch, err)
+	}
+
+	if err := g.CreateBranch("feature", true); err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := g.CurrentBranch(); branch != "feature" {
+		t.Errorf("after CreateBranch with checkout: CurrentBranch() = %q", branch)
+	}
+	commitTestFile(t, g, dir, "a.txt", "feature\n", "feature change")
+
+	if err := g.CreateBranch("other", false); err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := g.CurrentBranch(); branch != "feature" {
+		t.Errorf("CreateBranch without checkout switched to %q", branch)
+	}
+
+	branches, err := g.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"feature", "main", "other"}; !reflect.DeepEqual(branches, want) {
+		t.Errorf("Branches() = %q, want %q", branches, want)
+	}
+
+	if err := g.Checkout("main"); err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := g.CurrentBranch(); branch != "main" {
+		t.Errorf("after Checkout: CurrentBranch() = %q", branch)
+	}
+	if got := readTestFile(t, dir, "a.txt"); got != "main\n" {
+		t.Errorf("after Checkout: a.txt = %q, want %q", got, "main\n")
+	}
+
+	if err := g.Checkout("missing"); err == nil {
+		t.Error("Checkout of a missing branch succeeded")
+	}
+}
@@ -6,7 +6,6 @@ require (
 	fyne.io/fyne/v2 v2.6.3
 	github.com/alecthomas/chroma/v2 v2.20.0
 	github.com/fsnotify/fsnotify v1.9.0
-	github.com/lithammer/fuzzysearch v1.1.8
 	github.com/sergi/go-diff v1.0.0
 	github.com/sourcegraph/go-lsp v0.0.0-20240223163137-f80c5dd31dfd
 	github.com/sourcegraph/jsonrpc2 v0.2.1
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"log"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -33,10 +34,14 @@ type HotkeyManager struct {
 	// Контекст
 	currentContext HotkeyContext
 	focusedWidget  fyne.Focusable
+	contextKeys    *ContextKeys // ключи для условий when
 
 	// Callbacks для действий
 	actions map[string]HotkeyAction
 
+	// Привязки раскладки до пользовательских изменений из CustomBindings: id -> комбинация
+	defaultBindings map[string]string
+
 	// Мультиклавишные комбинации
 	pendingKeys     []string
 	pendingTimeout  *time.Timer
@@ -59,6 +64,7 @@ type HotkeyManager struct {
 // RegisteredShortcut - зарегистрированная горячая клавиша
 type RegisteredShortcut struct {
 	ID            string
+	ActionName    string
 	KeyBinding    string
 	Shortcut      *desktop.CustomShortcut
 	Action        HotkeyAction
@@ -73,6 +79,8 @@ type RegisteredShortcut struct {
 	RequiredContext HotkeyContext
 	RequiredMode    InputMode
 	RequiredFocus   string
+	When            string   // условие над ключами контекста, например "editorHasSelection && language == go"
+	condition       whenExpr // разобранное When; nil - без условия
 
 	// Метаданные
 	Category string
@@ -149,20 +157,50 @@ type VimState struct {
 
 // EmacsState - состояние Emacs режима
 type EmacsState struct {
-	PendingKeys    []fyne.KeyName
+	PendingKeys    []string
 	KillRing       []string
 	KillRingIndex  int
 	Mark           *TextPosition
 	MarkActive     bool
+	MarkRing       []TextPosition
 	PrefixArgument int
 	UniversalArg   bool
 	LastCommand    string
+	ThisCommand    string
+
+	// Набор числового аргумента после C-u
+	ArgCollecting bool
+	ArgDigits     bool
+	ArgNegative   bool
+
+	// Границы последней вставки для M-y и столбец для C-n/C-p
+	YankStart  int
+	YankEnd    int
+	GoalColumn int
 
 	// Режим поиска
 	IncrementalSearch bool
 	SearchTerm        string
 	SearchDirection   SearchDirection
 	SearchStartPos    TextPosition
+	SearchMatchStart  int
+	SearchMatchEnd    int
+	SearchFailing     bool
+	LastSearchTerm    string
+
+	// Клавиатурные макросы
+	RecordingMacro bool
+	PlayingMacro   bool
+	MacroKeys      []string
+	LastMacro      []string
+
+	// Последний удаленный прямоугольник (C-x r k)
+	KilledRectangle []string
+
+	// Сообщение эхо-области; стирается следующей клавишей
+	EchoMessage string
+
+	commandKeys int // число клавиш выполняемой команды
 }
 
 // VimMode - режимы Vim
@@ -217,6 +255,7 @@ const (
 	ResolutionModeSpecific
 	ResolutionUserChoice
 	ResolutionDisable
+	ResolutionConditionSpecific
 )
 
 // KeyLogger - логгер нажатий клавиш для отладки
@@ -240,6 +279,30 @@ type KeyLogEntry struct {
 
 func (hm *HotkeyManager) SetApp(app *App) {
 	hm.app = app
+	hm.syncVimHandler()
+	hm.syncEmacsHandler()
+	hm.registerContextKeys()
+	if app != nil && app.editor != nil {
+		app.editor.SetShortcutHandler(hm.dispatchShortcut)
+	}
+}
+
+// syncVimHandler подключает обработчик Vim к редактору, когда включен Vim режим
+func (hm *HotkeyManager) syncVimHandler() {
+	if hm.app == nil || hm.app.editor == nil {
+		return
+	}
+	if hm.currentMode != ModeVim {
+		hm.app.editor.SetVimHandler(nil)
+		return
+	}
+	if hm.app.editor.vimHandler == nil {
+		vh := NewVimHandler(hm.app.editor)
+		vh.onModeChanged = func(mode VimMode) {
+			hm.vimState.Mode = mode
+		}
+		hm.app.editor.SetVimHandler(vh)
+	}
 }
 
 // NewHotkeyManager создает новый менеджер горячих клавиш
@@ -251,8 +314,10 @@ func NewHotkeyManager(config *Config, window fyne.Window) *HotkeyManager {
 		globalShortcuts:  make(map[string]*GlobalShortcut),
 		contextShortcuts: make(map[HotkeyContext]map[string]*RegisteredShortcut),
 		actions:          make(map[string]HotkeyAction),
+		defaultBindings:  make(map[string]string),
 		currentMode:      ModeNormal,
 		currentContext:   ContextGlobal,
+		contextKeys:      NewContextKeys(),
 		pendingKeys:      []string{},
 		debugMode:        false,
 
@@ -264,7 +329,6 @@ func NewHotkeyManager(config *Config, window fyne.Window) *HotkeyManager {
 		},
 
 		emacsState: &EmacsState{
-			PendingKeys:   []fyne.KeyName{},
 			KillRing:      []string{},
 			KillRingIndex: 0,
 		},
@@ -286,6 +350,7 @@ func NewHotkeyManager(config *Config, window fyne.Window) *HotkeyManager {
 
 	// Определяем режим ввода из конфигурации
 	hm.determineInputMode()
+	hm.registerContextKeys()
 
 	// Регистрируем стандартные действия
 	hm.registerStandardActions()
@@ -383,10 +448,7 @@ func (hm *HotkeyManager) registerStandardActions() {
 	hm.actions["vim_paste"] = hm.actionVimPaste
 
 	// Emacs специальные действия
-	hm.actions["emacs_kill_line"] = hm.actionEmacsKillLine
-	hm.actions["emacs_kill_word"] = hm.actionEmacsKillWord
-	hm.actions["emacs_yank"] = hm.actionEmacsYank
-	hm.actions["emacs_set_mark"] = hm.actionEmacsSetMark
+	hm.registerEmacsActions()
 }
 
 // loadFromConfig загружает горячие клавиши из конфигурации
@@ -455,13 +517,6 @@ func (hm *HotkeyManager) loadFromConfig() {
 	// Сравнение файлов
 	hm.registerShortcut("compare_files", kb.CompareFiles, "compare_files", ContextGlobal, "Tools")
 
-	// Кастомные привязки
-	for action, keyBinding := range kb.CustomBindings {
-		if actionFunc, exists := hm.actions[action]; exists {
-			hm.registerShortcutWithAction(action, keyBinding, actionFunc, ContextGlobal, "Custom")
-		}
-	}
-
 	// Загружаем специальные режимы
 	if kb.EnableVimBindings {
 		hm.loadVimBindings()
@@ -470,10 +525,27 @@ func (hm *HotkeyManager) loadFromConfig() {
 	if kb.EnableEmacsBindings {
 		hm.loadEmacsBindings()
 	}
+
+	// Кастомные привязки действий, у которых нет привязки в раскладке;
+	// остальные записи CustomBindings переопределяют раскладку в registerShortcut
+	for action, keyBinding := range kb.CustomBindings {
+		if _, builtin := hm.defaultBindings[action]; builtin || keyBinding == "" {
+			continue
+		}
+		if actionFunc, exists := hm.actions[action]; exists {
+			hm.registerShortcutWithAction(action, keyBinding, actionFunc, ContextGlobal, "Custom")
+			hm.setActionName(action, action)
+		}
+	}
 }
 
 // registerShortcut регистрирует горячую клавишу
+// Пользовательская привязка из CustomBindings заменяет привязку раскладки ("" - удалена).
 func (hm *HotkeyManager) registerShortcut(id, keyBinding, actionName string, context HotkeyContext, category string) {
+	hm.defaultBindings[id] = keyBinding
+	if override, ok := hm.config.KeyBindings.CustomBindings[id]; ok {
+		keyBinding = override
+	}
 	if keyBinding == "" {
 		return
 	}
@@ -485,6 +557,16 @@ func (hm *HotkeyManager) registerShortcut(id, keyBinding, actionName string, con
 	}
 
 	hm.registerShortcutWithAction(id, keyBinding, action, context, category)
+	hm.setActionName(id, actionName)
+}
+
+// setActionName запоминает имя действия зарегистрированной привязки
+func (hm *HotkeyManager) setActionName(id, actionName string) {
+	hm.mutex.Lock()
+	defer hm.mutex.Unlock()
+	if shortcut, ok := hm.shortcuts[id]; ok {
+		shortcut.ActionName = actionName
+	}
 }
 
 // registerShortcutWithAction регистрирует горячую клавишу с функцией
@@ -496,6 +578,14 @@ func (hm *HotkeyManager) registerShortcutWithAction(id, keyBinding string, actio
 		return
 	}
 
+	// Условие привязки; с ошибкой в условии клавиша не регистрируется, чтобы не сработать где не нужно
+	when := hm.config.KeyBindings.When[id]
+	condition, err := parseWhen(when)
+	if err != nil {
+		log.Printf("Error parsing condition for shortcut '%s': %v", id, err)
+		return
+	}
+
 	registered := &RegisteredShortcut{
 		ID:              id,
 		KeyBinding:      keyBinding,
@@ -509,24 +599,26 @@ func (hm *HotkeyManager) registerShortcutWithAction(id, keyBinding string, actio
 		Category:        category,
 		RequiredContext: context,
 		RequiredMode:    ModeNormal, // По умолчанию
+		When:            when,
+		condition:       condition,
 	}
 
 	hm.mutex.Lock()
 	defer hm.mutex.Unlock()
 
 	// Проверяем конфликты
-	if conflicting := hm.findConflictingShortcuts(keyBinding, context); len(conflicting) > 0 {
+	if conflicting := hm.findConflictingShortcuts(keyBinding, context, condition); len(conflicting) > 0 {
 		hm.resolveConflicts(registered, conflicting)
 	}
 
 	// Регистрируем глобально
 	hm.shortcuts[id] = registered
 
-	// Регистрируем по контексту
-	hm.contextShortcuts[context][keyBinding] = registered
+	// Регистрируем по контексту; одна комбинация с разными условиями хранится под ключом с условием
+	hm.contextShortcuts[context][contextShortcutKey(keyBinding, when)] = registered
 
-	// Регистрируем в Fyne если это одношаговая комбинация
-	if len(parts) == 1 && (context == ContextGlobal || context == ContextEditor) {
+	// Регистрируем в Fyne первую комбинацию; аккорды и условия разбирает dispatchShortcut
+	if context == ContextGlobal || context == ContextEditor {
 		hm.window.Canvas().AddShortcut(shortcut, hm.createShortcutHandler(registered))
 	}
 }
@@ -933,112 +1025,74 @@ func (hm *HotkeyManager) handleVimCommandMode(event *fyne.KeyEvent) bool {
 	return false
 }
 
-// handleEmacsKeyEvent обрабатывает клавиши в Emacs режиме
-func (hm *HotkeyManager) handleEmacsKeyEvent(event *fyne.KeyEvent) bool {
-	state := hm.emacsState
-
-	// Обрабатываем последовательности клавиш
-	state.PendingKeys = append(state.PendingKeys, event.Name)
-	keySequence := hm.keySequenceToString(state.PendingKeys)
-
-	// Основные команды Emacs
-	switch keySequence {
-	case "C-f":
-		hm.executeAction("emacs_forward_char")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-b":
-		hm.executeAction("emacs_backward_char")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-n":
-		hm.executeAction("emacs_next_line")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-p":
-		hm.executeAction("emacs_previous_line")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-a":
-		hm.executeAction("emacs_beginning_of_line")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-e":
-		hm.executeAction("emacs_end_of_line")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-k":
-		hm.executeAction("emacs_kill_line")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-y":
-		hm.executeAction("emacs_yank")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-w":
-		hm.executeAction("emacs_kill_region")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-SPC":
-		hm.executeAction("emacs_set_mark")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-s":
-		hm.executeAction("emacs_search_forward")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-r":
-		hm.executeAction("emacs_search_backward")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-x C-f":
-		hm.executeAction("open_file")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
-	case "C-x C-s":
-		hm.executeAction("save_file")
-		state.PendingKeys = []fyne.KeyName{}
-		return true
+// handleMultiKeySequence обрабатывает мультиклавишные комбинации
+func (hm *HotkeyManager) handleMultiKeySequence(event *fyne.KeyEvent) bool {
+	// Нажатие самого модификатора не прерывает аккорд
+	if isModifierKey(event.Name) {
+		return len(hm.pendingKeys) > 0
 	}
-
-	// Сбрасываем если последовательность стала слишком длинной
-	if len(state.PendingKeys) > 3 {
-		state.PendingKeys = []fyne.KeyName{}
+	// Сочетания с Ctrl, Alt и Super придут в dispatchShortcut
+	if state := hm.getModifierState(); state.Ctrl || state.Alt || state.Super {
+		return false
 	}
 
-	return false
+	handled, shortcut := hm.handleChordKey(hm.formatKeyEvent(event))
+	if shortcut != nil {
+		hm.executeShortcut(shortcut)
+	}
+	return handled
 }
 
-// handleMultiKeySequence обрабатывает мультиклавишные комбинации
-func (hm *HotkeyManager) handleMultiKeySequence(event *fyne.KeyEvent) bool {
-	// Добавляем клавишу к ожидающим с учетом модификаторов
-	combo := hm.formatKeyEvent(event)
+// handleChordKey добавляет комбинацию к ожидающим клавишам аккорда.
+// handled - клавиша вошла в аккорд; shortcut - аккорд набран полностью и его нужно выполнить.
+func (hm *HotkeyManager) handleChordKey(combo string) (handled bool, shortcut *RegisteredShortcut) {
 	hm.pendingKeys = append(hm.pendingKeys, combo)
-
 	keySequence := strings.Join(hm.pendingKeys, " ")
 
 	// Проверяем зарегистрированные последовательности
-	for _, shortcut := range hm.shortcuts {
-		if shortcut.Enabled && hm.isContextActive(shortcut.Context) && strings.Contains(shortcut.KeyBinding, " ") {
-			shortcutSequence := shortcut.KeyBinding
-
-			if keySequence == shortcutSequence {
-				// Найдена полная последовательность
-				hm.executeShortcut(shortcut)
-				hm.clearPendingKeys()
-				return true
-			}
+	var complete []*RegisteredShortcut
+	partial := false
+	for _, sc := range hm.shortcuts {
+		if !sc.Enabled || !hm.isContextActive(sc.Context) || !strings.Contains(sc.KeyBinding, " ") {
+			continue
+		}
+		sequence := canonicalBinding(sc.KeyBinding)
+		if sequence == keySequence {
+			complete = append(complete, sc)
+		} else if strings.HasPrefix(sequence, keySequence+" ") {
+			partial = true
+		}
+	}
 
-			if strings.HasPrefix(shortcutSequence, keySequence) {
-				// Частичное совпадение - ждем еще клавиш
-				hm.resetPendingTimeout()
-				return true
+	if len(complete) > 0 {
+		// Найдена полная последовательность; привязки с условием проверяются первыми
+		hm.clearPendingKeys()
+		sortShortcutCandidates(complete)
+		for _, sc := range complete {
+			if hm.canExecuteShortcut(sc) {
+				return true, sc
 			}
 		}
+		return true, nil
+	}
+	if partial {
+		// Частичное совпадение - ждем еще клавиш
+		hm.resetPendingTimeout()
+		return true, nil
 	}
 
 	// Сбрасываем если нет совпадений
 	hm.clearPendingKeys()
+	return false, nil
+}
+
+// isModifierKey сообщает, что клавиша - сам модификатор
+func isModifierKey(name fyne.KeyName) bool {
+	switch name {
+	case desktop.KeyControlLeft, desktop.KeyControlRight, desktop.KeyShiftLeft, desktop.KeyShiftRight,
+		desktop.KeyAltLeft, desktop.KeyAltRight, desktop.KeySuperLeft, desktop.KeySuperRight:
+		return true
+	}
 	return false
 }
 
@@ -1200,14 +1254,65 @@ func (hm *HotkeyManager) formatKeyEvent(event *fyne.KeyEvent) string {
 		parts = append(parts, "Super")
 	}
 
-	parts = append(parts, string(event.Name))
+	parts = append(parts, keyNameString(event.Name))
 
 	return strings.Join(parts, "+")
 }
 
+// keyNameString записывает fyne.KeyName так, как ее понимает parseKeyName
+func keyNameString(name fyne.KeyName) string {
+	switch name {
+	case fyne.KeyReturn, fyne.KeyEnter:
+		return "Enter"
+	case fyne.KeyBackspace:
+		return "Backspace"
+	case fyne.KeyPageUp:
+		return "PageUp"
+	case fyne.KeyPageDown:
+		return "PageDown"
+	}
+	return string(name)
+}
+
+// formatShortcut записывает комбинацию в каноническом виде: Ctrl+Alt+Shift+Super+Клавиша
+func formatShortcut(shortcut *desktop.CustomShortcut) string {
+	var parts []string
+	for _, m := range []struct {
+		mod  fyne.KeyModifier
+		name string
+	}{
+		{fyne.KeyModifierControl, "Ctrl"},
+		{fyne.KeyModifierAlt, "Alt"},
+		{fyne.KeyModifierShift, "Shift"},
+		{fyne.KeyModifierSuper, "Super"},
+	} {
+		if shortcut.Modifier&m.mod != 0 {
+			parts = append(parts, m.name)
+		}
+	}
+	return strings.Join(append(parts, keyNameString(shortcut.KeyName)), "+")
+}
+
+// canonicalBinding приводит привязку к каноническому виду, чтобы "Shift+Alt+F" совпадала с "Alt+Shift+F"
+func canonicalBinding(keyBinding string) string {
+	var hm HotkeyManager
+	parts := strings.Fields(keyBinding)
+	for i, part := range parts {
+		if shortcut, err := hm.parseKeyBinding(part); err == nil {
+			parts[i] = formatShortcut(shortcut)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // matchesKeyEvent проверяет соответствие shortcut событию клавиши
+// Сочетания с Ctrl, Alt и Super Fyne передает как shortcut (dispatchShortcut), здесь - клавиши без них.
 func (hm *HotkeyManager) matchesKeyEvent(shortcut *desktop.CustomShortcut, event *fyne.KeyEvent) bool {
-	return shortcut.KeyName == event.Name // Fyne автоматически проверяет модификаторы
+	if shortcut.Modifier&^fyne.KeyModifierShift != 0 {
+		return false
+	}
+	shift := shortcut.Modifier&fyne.KeyModifierShift != 0
+	return shortcut.KeyName == event.Name && shift == hm.modifierState.Shift
 }
 
 // isContextActive проверяет активность контекста
@@ -1229,14 +1334,80 @@ func (hm *HotkeyManager) canExecuteShortcut(shortcut *RegisteredShortcut) bool {
 		return false
 	}
 
+	if shortcut.condition != nil && !shortcut.condition.eval(hm.contextKeys) {
+		return false
+	}
+
 	return true
 }
 
 // createShortcutHandler создает обработчик для Fyne shortcut
 func (hm *HotkeyManager) createShortcutHandler(shortcut *RegisteredShortcut) func(fyne.Shortcut) {
-	return func(fyne.Shortcut) {
-		hm.executeShortcut(shortcut)
+	return func(s fyne.Shortcut) {
+		hm.dispatchShortcut(s)
+	}
+}
+
+// dispatchShortcut выполняет привязку для нажатой комбинации с учетом условий when.
+// Одной комбинации в Fyne соответствует один обработчик, поэтому выбор среди привязок
+// с разными условиями делается здесь: сначала с условием, затем по приоритету контекста.
+func (hm *HotkeyManager) dispatchShortcut(s fyne.Shortcut) bool {
+	hm.mutex.Lock()
+	hm.handleFocusChanged(hm.window.Canvas().Focused())
+
+	// Сначала аккорды вида "Ctrl+K Ctrl+C"
+	if cs, ok := s.(*desktop.CustomShortcut); ok {
+		if handled, shortcut := hm.handleChordKey(formatShortcut(cs)); handled {
+			hm.mutex.Unlock()
+			if shortcut != nil {
+				hm.executeShortcut(shortcut)
+			}
+			return true
+		}
 	}
+
+	var candidates []*RegisteredShortcut
+	for _, shortcut := range hm.shortcuts {
+		if shortcut.Shortcut == nil || strings.Contains(shortcut.KeyBinding, " ") ||
+			shortcut.Shortcut.ShortcutName() != s.ShortcutName() {
+			continue
+		}
+		if shortcut.Context == ContextGlobal || shortcut.Context == ContextEditor {
+			candidates = append(candidates, shortcut)
+		}
+	}
+	hm.mutex.Unlock()
+
+	sortShortcutCandidates(candidates)
+	for _, shortcut := range candidates {
+		if hm.canExecuteShortcut(shortcut) {
+			hm.executeShortcut(shortcut)
+			return true
+		}
+	}
+	return false
+}
+
+// sortShortcutCandidates упорядочивает привязки одной комбинации: с условием, затем по приоритету
+func sortShortcutCandidates(candidates []*RegisteredShortcut) {
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if (a.condition != nil) != (b.condition != nil) {
+			return a.condition != nil
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.ID < b.ID
+	})
+}
+
+// contextShortcutKey - ключ привязки в contextShortcuts: комбинация и условие
+func contextShortcutKey(keyBinding, when string) string {
+	if when == "" {
+		return keyBinding
+	}
+	return keyBinding + " when " + when
 }
 
 // updateModifierState обновляет состояние модификаторов
@@ -1269,7 +1440,7 @@ func (hm *HotkeyManager) handleFocusChanged(obj fyne.Focusable) {
 	oldContext := hm.currentContext
 
 	switch obj.(type) {
-	case *EditorWidget:
+	case *EditorWidget, *editorEntry:
 		hm.currentContext = ContextEditor
 	case *SidebarWidget:
 		hm.currentContext = ContextSidebar
@@ -1279,6 +1450,8 @@ func (hm *HotkeyManager) handleFocusChanged(obj fyne.Focusable) {
 		hm.currentContext = ContextGlobal
 	}
 
+	hm.updateFocusKeys(obj)
+
 	// Уведомляем о смене контекста
 	if oldContext != hm.currentContext {
 		hm.onContextChanged(oldContext, hm.currentContext)
@@ -1319,11 +1492,13 @@ func (hm *HotkeyManager) resetPendingTimeout() {
 // Методы разрешения конфликтов
 
 // findConflictingShortcuts находит конфликтующие горячие клавиши
-func (hm *HotkeyManager) findConflictingShortcuts(keyBinding string, context HotkeyContext) []*RegisteredShortcut {
+// Привязки с несовместимыми условиями (language == go и language == rust) не конфликтуют.
+func (hm *HotkeyManager) findConflictingShortcuts(keyBinding string, context HotkeyContext, condition whenExpr) []*RegisteredShortcut {
 	var conflicts []*RegisteredShortcut
 
 	for _, shortcut := range hm.shortcuts {
-		if shortcut.Context == context && shortcut.KeyBinding == keyBinding {
+		if shortcut.Context == context && canonicalBinding(shortcut.KeyBinding) == canonicalBinding(keyBinding) &&
+			whenOverlaps(shortcut.condition, condition) {
 			conflicts = append(conflicts, shortcut)
 		}
 	}
@@ -1335,6 +1510,14 @@ func (hm *HotkeyManager) findConflictingShortcuts(keyBinding string, context Hot
 func (hm *HotkeyManager) resolveConflicts(newShortcut *RegisteredShortcut, conflicts []*RegisteredShortcut) {
 	resolution := hm.conflictResolver.resolve(newShortcut, conflicts)
 
+	for _, conflict := range conflicts {
+		conflict.ConflictsWith = append(conflict.ConflictsWith, newShortcut.ID)
+		newShortcut.ConflictsWith = append(newShortcut.ConflictsWith, conflict.ID)
+	}
+	if hm.debugMode {
+		log.Printf("Shortcut '%s' (%s) conflicts with %v", newShortcut.ID, newShortcut.KeyBinding, newShortcut.ConflictsWith)
+	}
+
 	switch resolution {
 	case ResolutionHighestPriority:
 		// Оставляем с наивысшим приоритетом
@@ -1381,11 +1564,13 @@ func (hm *HotkeyManager) SetMode(mode InputMode) {
 
 	if mode != ModeEmacs {
 		hm.emacsState = &EmacsState{
-			PendingKeys:   []fyne.KeyName{},
 			KillRing:      []string{},
 			KillRingIndex: 0,
 		}
 	}
+
+	hm.syncVimHandler()
+	hm.syncEmacsHandler()
 }
 
 // GetMode возвращает текущий режим ввода
@@ -1431,21 +1616,16 @@ func (hm *HotkeyManager) UpdateKeyBinding(id, newKeyBinding string) error {
 
 	// Удаляем старую привязку
 	oldKeyBinding := shortcut.KeyBinding
-	delete(hm.contextShortcuts[shortcut.Context], oldKeyBinding)
-
-	partsOld := strings.Split(oldKeyBinding, " ")
-	if len(partsOld) == 1 && (shortcut.Context == ContextGlobal || shortcut.Context == ContextEditor) {
-		hm.window.Canvas().RemoveShortcut(shortcut.Shortcut)
-	}
+	delete(hm.contextShortcuts[shortcut.Context], contextShortcutKey(oldKeyBinding, shortcut.When))
 
 	// Добавляем новую
 	shortcut.Shortcut = newShortcut
 	shortcut.KeyBinding = newKeyBinding
-	hm.contextShortcuts[shortcut.Context][newKeyBinding] = shortcut
+	hm.contextShortcuts[shortcut.Context][contextShortcutKey(newKeyBinding, shortcut.When)] = shortcut
 
-	// Обновляем в Fyne
-	if len(partsNew) == 1 && (shortcut.Context == ContextGlobal || shortcut.Context == ContextEditor) {
-		hm.window.Canvas().AddShortcut(newShortcut, hm.createShortcutHandler(shortcut))
+	// Обновляем в Fyne; старую комбинацию не снимаем - ее могут использовать другие привязки,
+	// а обработчик без подходящих привязок ничего не делает
+	if shortcut.Context == ContextGlobal || shortcut.Context == ContextEditor {
 		hm.window.Canvas().AddShortcut(newShortcut, hm.createShortcutHandler(shortcut))
 	}
 
@@ -1708,7 +1888,7 @@ func (hm *HotkeyManager) actionFind(context HotkeyContext) bool {
 		return false
 	}
 
-	// Показываем диалог поиска
+	// Показываем панель поиска
 	hm.app.showFind()
 	return true
 }
@@ -1718,14 +1898,18 @@ func (hm *HotkeyManager) actionFindNext(context HotkeyContext) bool {
 		return false
 	}
 
-	// Ищем следующее вхождение
-	if hm.app.lastSearchText != "" {
-		found := hm.app.editor.FindNext(hm.app.lastSearchText)
-		if !found {
+	// Ищем следующее вхождение запроса панели поиска, иначе - выделенного текста
+	if hm.app.findBar != nil && hm.app.findBar.Query() != "" {
+		hm.app.findBar.Next()
+		return true
+	}
+	if term := hm.app.editor.GetSelectedText(); term != "" {
+		if !hm.app.editor.FindNext(term) {
 			dialog.ShowInformation("Find", "No more occurrences found", hm.window)
 		}
+		return true
 	}
-
+	hm.app.showFind()
 	return true
 }
 
@@ -1734,14 +1918,18 @@ func (hm *HotkeyManager) actionFindPrevious(context HotkeyContext) bool {
 		return false
 	}
 
-	// Ищем предыдущее вхождение
-	if hm.app.lastSearchText != "" {
-		found := hm.app.editor.FindPrevious(hm.app.lastSearchText)
-		if !found {
+	// Ищем предыдущее вхождение запроса панели поиска, иначе - выделенного текста
+	if hm.app.findBar != nil && hm.app.findBar.Query() != "" {
+		hm.app.findBar.Previous()
+		return true
+	}
+	if term := hm.app.editor.GetSelectedText(); term != "" {
+		if !hm.app.editor.FindPrevious(term) {
 			dialog.ShowInformation("Find", "No more occurrences found", hm.window)
 		}
+		return true
 	}
-
+	hm.app.showFind()
 	return true
 }
 
@@ -1750,7 +1938,7 @@ func (hm *HotkeyManager) actionReplace(context HotkeyContext) bool {
 		return false
 	}
 
-	// Показываем диалог замены
+	// Показываем панель поиска с заменой
 	hm.app.showReplace()
 	return true
 }
@@ -1857,7 +2045,7 @@ func (hm *HotkeyManager) actionFileSwitcher(context HotkeyContext) bool {
 		return false
 	}
 
-	hm.app.showFileSwitcher()
+	hm.app.showQuickOpen()
 	return true
 }
 
@@ -2202,70 +2390,6 @@ func (hm *HotkeyManager) actionVimPaste(context HotkeyContext) bool {
 	return true
 }
 
-// Emacs специальные действия
-func (hm *HotkeyManager) actionEmacsKillLine(context HotkeyContext) bool {
-	if hm.app == nil || hm.app.editor == nil {
-		return false
-	}
-
-	// Удаляем от курсора до конца строки
-	killedText := hm.app.editor.KillToEndOfLine()
-
-	// Добавляем в kill ring
-	hm.emacsState.KillRing = append(hm.emacsState.KillRing, killedText)
-	if len(hm.emacsState.KillRing) > 10 {
-		hm.emacsState.KillRing = hm.emacsState.KillRing[1:]
-	}
-	hm.emacsState.KillRingIndex = len(hm.emacsState.KillRing) - 1
-
-	return true
-}
-
-func (hm *HotkeyManager) actionEmacsKillWord(context HotkeyContext) bool {
-	if hm.app == nil || hm.app.editor == nil {
-		return false
-	}
-
-	// Удаляем слово
-	killedWord := hm.app.editor.KillWord()
-
-	// Добавляем в kill ring
-	hm.emacsState.KillRing = append(hm.emacsState.KillRing, killedWord)
-	if len(hm.emacsState.KillRing) > 10 {
-		hm.emacsState.KillRing = hm.emacsState.KillRing[1:]
-	}
-	hm.emacsState.KillRingIndex = len(hm.emacsState.KillRing) - 1
-
-	return true
-}
-
-func (hm *HotkeyManager) actionEmacsYank(context HotkeyContext) bool {
-	if hm.app == nil || hm.app.editor == nil {
-		return false
-	}
-
-	// Вставляем из kill ring
-	if len(hm.emacsState.KillRing) > 0 && hm.emacsState.KillRingIndex >= 0 {
-		text := hm.emacsState.KillRing[hm.emacsState.KillRingIndex]
-		hm.app.editor.InsertText(text)
-	}
-
-	return true
-}
-
-func (hm *HotkeyManager) actionEmacsSetMark(context HotkeyContext) bool {
-	if hm.app == nil || hm.app.editor == nil {
-		return false
-	}
-
-	// Устанавливаем метку в текущей позиции
-	position := hm.app.editor.GetCursorPosition()
-	hm.emacsState.Mark = &position
-	hm.emacsState.MarkActive = true
-
-	return true
-}
-
 // getActionDescription возвращает описание действия
 func (hm *HotkeyManager) getActionDescription(actionID string) string {
 	descriptions := map[string]string{
@@ -2463,7 +2587,13 @@ func NewConflictResolver() *ConflictResolver {
 
 // resolve разрешает конфликт горячих клавиш
 func (cr *ConflictResolver) resolve(newShortcut *RegisteredShortcut, conflicts []*RegisteredShortcut) ConflictResolution {
-	// Простая реализация - приоритет по контексту
+	// Пересекающиеся, но разные условия: при нажатии выбирается привязка с условием
+	for _, conflict := range conflicts {
+		if conflict.When != newShortcut.When {
+			return ResolutionConditionSpecific
+		}
+	}
+	// Иначе приоритет по контексту
 	return ResolutionHighestPriority
 }
 
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// shortcutRecorderMaxParts - сколько комбинаций записывается в аккорд ("Ctrl+K Ctrl+C")
+const shortcutRecorderMaxParts = 2
+
+// shortcutRecorder - поле, записывающее нажатую комбинацию клавиш вместо ввода текста
+type shortcutRecorder struct {
+	widget.BaseWidget
+
+	parts     []string
+	modifiers fyne.KeyModifier
+	focused   bool
+
+	background *canvas.Rectangle
+	label      *widget.Label
+
+	OnChanged func(binding string)
+}
+
+// newShortcutRecorder создает поле записи комбинации
+func newShortcutRecorder() *shortcutRecorder {
+	r := &shortcutRecorder{
+		background: canvas.NewRectangle(theme.Color(theme.ColorNameInputBackground)),
+		label:      widget.NewLabel(""),
+	}
+	r.background.CornerRadius = theme.InputRadiusSize()
+	r.background.StrokeWidth = theme.InputBorderSize()
+	r.ExtendBaseWidget(r)
+	r.update()
+	return r
+}
+
+// CreateRenderer реализует fyne.Widget
+func (r *shortcutRecorder) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(r.background, r.label))
+}
+
+// Binding возвращает записанную комбинацию
+func (r *shortcutRecorder) Binding() string {
+	return strings.Join(r.parts, " ")
+}
+
+// Clear сбрасывает запись
+func (r *shortcutRecorder) Clear() {
+	r.parts = nil
+	r.update()
+	r.changed()
+}
+
+// Tapped передает полю фокус
+func (r *shortcutRecorder) Tapped(*fyne.PointEvent) {
+	if c := fyne.CurrentApp().Driver().CanvasForObject(r); c != nil {
+		c.Focus(r)
+	}
+}
+
+// FocusGained реализует fyne.Focusable
+func (r *shortcutRecorder) FocusGained() {
+	r.focused = true
+	r.update()
+}
+
+// FocusLost реализует fyne.Focusable
+func (r *shortcutRecorder) FocusLost() {
+	r.focused = false
+	r.modifiers = 0
+	r.update()
+}
+
+// TypedRune реализует fyne.Focusable; символы записываются через TypedKey
+func (r *shortcutRecorder) TypedRune(rune) {}
+
+// KeyDown отслеживает модификаторы
+func (r *shortcutRecorder) KeyDown(ev *fyne.KeyEvent) {
+	r.modifiers |= recorderModifier(ev.Name)
+}
+
+// KeyUp отслеживает отпускание модификаторов
+func (r *shortcutRecorder) KeyUp(ev *fyne.KeyEvent) {
+	r.modifiers &^= recorderModifier(ev.Name)
+}
+
+// TypedKey записывает клавишу без Ctrl/Alt: F-клавиши, стрелки или вторую часть аккорда
+func (r *shortcutRecorder) TypedKey(ev *fyne.KeyEvent) {
+	if isModifierKey(ev.Name) {
+		return
+	}
+	shortcut := &desktop.CustomShortcut{KeyName: ev.Name, Modifier: r.modifiers & fyne.KeyModifierShift}
+	// Обычная буква без модификаторов не может начинать привязку - она нужна для ввода текста
+	if len(r.parts) == 0 && shortcut.Modifier == 0 && isPrintableKey(ev.Name) {
+		return
+	}
+	r.record(shortcut)
+}
+
+// TypedShortcut записывает комбинацию с Ctrl/Alt/Super
+func (r *shortcutRecorder) TypedShortcut(s fyne.Shortcut) {
+	ctrl := fyne.KeyModifierShortcutDefault
+	switch sc := s.(type) {
+	case *desktop.CustomShortcut:
+		r.record(sc)
+	case *fyne.ShortcutCut:
+		r.record(&desktop.CustomShortcut{KeyName: fyne.KeyX, Modifier: ctrl})
+	case *fyne.ShortcutCopy:
+		r.record(&desktop.CustomShortcut{KeyName: fyne.KeyC, Modifier: ctrl})
+	case *fyne.ShortcutPaste:
+		r.record(&desktop.CustomShortcut{KeyName: fyne.KeyV, Modifier: ctrl})
+	case *fyne.ShortcutSelectAll:
+		r.record(&desktop.CustomShortcut{KeyName: fyne.KeyA, Modifier: ctrl})
+	case *fyne.ShortcutUndo:
+		r.record(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: ctrl})
+	case *fyne.ShortcutRedo:
+		r.record(&desktop.CustomShortcut{KeyName: fyne.KeyY, Modifier: ctrl})
+	}
+}
+
+// record добавляет комбинацию; после полного аккорда запись начинается заново
+func (r *shortcutRecorder) record(shortcut *desktop.CustomShortcut) {
+	if len(r.parts) >= shortcutRecorderMaxParts {
+		r.parts = nil
+	}
+	r.parts = append(r.parts, formatShortcut(shortcut))
+	r.update()
+	r.changed()
+}
+
+func (r *shortcutRecorder) changed() {
+	if r.OnChanged != nil {
+		r.OnChanged(r.Binding())
+	}
+}
+
+// update перерисовывает поле
+func (r *shortcutRecorder) update() {
+	switch {
+	case len(r.parts) > 0:
+		r.label.SetText(r.Binding())
+	case r.focused:
+		r.label.SetText("Press the key combination...")
+	default:
+		r.label.SetText("Click here and press the key combination")
+	}
+	r.background.StrokeColor = color.Transparent
+	if r.focused {
+		r.background.StrokeColor = theme.Color(theme.ColorNamePrimary)
+	}
+	r.background.Refresh()
+}
+
+// recorderModifier возвращает модификатор, соответствующий клавише
+func recorderModifier(name fyne.KeyName) fyne.KeyModifier {
+	switch name {
+	case desktop.KeyShiftLeft, desktop.KeyShiftRight:
+		return fyne.KeyModifierShift
+	case desktop.KeyControlLeft, desktop.KeyControlRight:
+		return fyne.KeyModifierControl
+	case desktop.KeyAltLeft, desktop.KeyAltRight:
+		return fyne.KeyModifierAlt
+	case desktop.KeySuperLeft, desktop.KeySuperRight:
+		return fyne.KeyModifierSuper
+	}
+	return 0
+}
+
+// isPrintableKey сообщает, вводит ли клавиша символ
+func isPrintableKey(name fyne.KeyName) bool {
+	return len(name) == 1 || name == fyne.KeySpace
+}
+
+// shortcutRow - строка таблицы горячих клавиш
+type shortcutRow struct {
+	ID          string
+	Description string
+	Binding     string
+	When        string
+	Context     HotkeyContext
+	Source      string // default, user или keymap
+	Conflicts   []string
+
+	condition whenExpr
+}
+
+// shortcutRows собирает все действия: привязанные, снятые пользователем и без привязки
+func (hm *HotkeyManager) shortcutRows() []shortcutRow {
+	hm.mutex.RLock()
+	defer hm.mutex.RUnlock()
+
+	kb := &hm.config.KeyBindings
+	defaults := keyBindingFields(&DefaultConfig().KeyBindings)
+	source := func(id, binding, category string) string {
+		if _, ok := kb.CustomBindings[id]; ok {
+			return "user"
+		}
+		if category == "Vim" || category == "Emacs" {
+			return "default"
+		}
+		if def, ok := defaults[id]; ok && *def == binding && (kb.KeymapPreset == "" || kb.KeymapPreset == "vscode") {
+			return "default"
+		}
+		return "keymap"
+	}
+
+	var rows []shortcutRow
+	seen := make(map[string]bool)
+	for id, sc := range hm.shortcuts {
+		rows = append(rows, shortcutRow{
+			ID:          id,
+			Description: sc.Description,
+			Binding:     sc.KeyBinding,
+			When:        sc.When,
+			Context:     sc.Context,
+			Source:      source(id, hm.defaultBindings[id], sc.Category),
+			Conflicts:   append([]string(nil), sc.ConflictsWith...),
+			condition:   sc.condition,
+		})
+		seen[id] = true
+		seen[sc.ActionName] = true
+	}
+	// Снятые привязки и действия без клавиш тоже можно назначить
+	addUnbound := func(id string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		condition, _ := parseWhen(kb.When[id])
+		rows = append(rows, shortcutRow{
+			ID:          id,
+			Description: hm.getActionDescription(id),
+			When:        kb.When[id],
+			Context:     ContextGlobal,
+			Source:      source(id, "", ""),
+			condition:   condition,
+		})
+	}
+	for id := range hm.defaultBindings {
+		addUnbound(id)
+	}
+	for name := range hm.actions {
+		addUnbound(name)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Description < rows[j].Description
+	})
+	return rows
+}
+
+// shortcutEditor - таблица горячих клавиш с поиском, записью и сбросом
+type shortcutEditor struct {
+	hm        *HotkeyManager
+	window    fyne.Window
+	onChanged func() // сохранение конфигурации после изменения
+
+	rows     []shortcutRow
+	filtered []shortcutRow
+	selected int
+
+	search    *widget.Entry
+	table     *widget.Table
+	conflicts *widget.Label
+}
+
+var shortcutEditorColumns = []struct {
+	title string
+	width float32
+}{
+	{"Command", 280},
+	{"Keybinding", 170},
+	{"When", 170},
+	{"Context", 80},
+	{"Source", 70},
+}
+
+// newShortcutEditor создает редактор горячих клавиш
+func newShortcutEditor(hm *HotkeyManager, window fyne.Window, onChanged func()) *shortcutEditor {
+	return &shortcutEditor{hm: hm, window: window, onChanged: onChanged, selected: -1}
+}
+
+// newShortcutEditor создает редактор горячих клавиш приложения
+func (a *App) newShortcutEditor() *shortcutEditor {
+	return newShortcutEditor(a.hotkeyManager, a.mainWin, a.saveKeyBindings)
+}
+
+// Widget строит содержимое редактора
+func (se *shortcutEditor) Widget() fyne.CanvasObject {
+	se.search = widget.NewEntry()
+	se.search.SetPlaceHolder("Search by command, id, key or source...")
+	se.search.OnChanged = func(string) { se.applyFilter() }
+
+	se.table = widget.NewTableWithHeaders(
+		func() (int, int) { return len(se.filtered), len(shortcutEditorColumns) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(se.cellText(id))
+		},
+	)
+	se.table.ShowHeaderColumn = false
+	se.table.UpdateHeader = func(id widget.TableCellID, obj fyne.CanvasObject) {
+		label := obj.(*widget.Label)
+		label.TextStyle = fyne.TextStyle{Bold: true}
+		if id.Col >= 0 {
+			label.SetText(shortcutEditorColumns[id.Col].title)
+		}
+	}
+	for i, col := range shortcutEditorColumns {
+		se.table.SetColumnWidth(i, col.width)
+	}
+	se.table.OnSelected = func(id widget.TableCellID) {
+		se.selected = id.Row
+		se.showConflicts()
+	}
+
+	se.conflicts = widget.NewLabel("")
+	se.conflicts.Wrapping = fyne.TextWrapWord
+
+	buttons := container.NewHBox(
+		widget.NewButton("Change...", func() { se.withSelected(se.recordBinding) }),
+		widget.NewButton("Change When...", func() { se.withSelected(se.editWhen) }),
+		widget.NewButton("Remove", func() { se.withSelected(se.removeBinding) }),
+		widget.NewButton("Reset", func() { se.withSelected(se.resetBinding) }),
+		widget.NewButton("Reset All", se.resetAll),
+	)
+
+	se.Refresh()
+	return container.NewBorder(se.search, container.NewVBox(se.conflicts, buttons), nil, nil, se.table)
+}
+
+// Refresh перечитывает привязки из менеджера клавиш
+func (se *shortcutEditor) Refresh() {
+	se.rows = se.hm.shortcutRows()
+	se.applyFilter()
+}
+
+// Reload перерегистрирует клавиши по конфигурации и обновляет таблицу
+func (se *shortcutEditor) Reload() {
+	se.hm.ReloadShortcuts()
+	se.Refresh()
+}
+
+// applyFilter оставляет строки, подходящие под строку поиска
+func (se *shortcutEditor) applyFilter() {
+	query := strings.ToLower(strings.TrimSpace(se.search.Text))
+	se.filtered = se.filtered[:0]
+	for _, row := range se.rows {
+		text := strings.ToLower(strings.Join([]string{row.Description, row.ID, row.Binding, row.When, row.Source}, " "))
+		if query == "" || strings.Contains(text, query) {
+			se.filtered = append(se.filtered, row)
+		}
+	}
+	se.selected = -1
+	se.table.UnselectAll()
+	se.table.Refresh()
+	se.showConflicts()
+}
+
+// cellText возвращает текст ячейки таблицы
+func (se *shortcutEditor) cellText(id widget.TableCellID) string {
+	if id.Row < 0 || id.Row >= len(se.filtered) {
+		return ""
+	}
+	row := se.filtered[id.Row]
+	switch id.Col {
+	case 0:
+		if row.Description == "" || row.Description == row.ID {
+			return row.ID
+		}
+		return fmt.Sprintf("%s (%s)", row.Description, row.ID)
+	case 1:
+		if len(row.Conflicts) > 0 {
+			return "⚠ " + row.Binding
+		}
+		if row.Binding == "" {
+			return "-"
+		}
+		return row.Binding
+	case 2:
+		return row.When
+	case 3:
+		return se.hm.contextToString(row.Context)
+	case 4:
+		return row.Source
+	}
+	return ""
+}
+
+// showConflicts показывает конфликты выбранной привязки
+func (se *shortcutEditor) showConflicts() {
+	if se.selected < 0 || se.selected >= len(se.filtered) {
+		se.conflicts.SetText("")
+		return
+	}
+	row := se.filtered[se.selected]
+	if len(row.Conflicts) == 0 {
+		se.conflicts.SetText("No conflicts")
+		return
+	}
+	se.conflicts.SetText(fmt.Sprintf("⚠ %s conflicts with: %s", row.Binding, strings.Join(row.Conflicts, ", ")))
+}
+
+// withSelected вызывает действие для выбранной строки
+func (se *shortcutEditor) withSelected(action func(row shortcutRow)) {
+	if se.selected < 0 || se.selected >= len(se.filtered) {
+		dialog.ShowInformation("Key Bindings", "Select a command first", se.window)
+		return
+	}
+	action(se.filtered[se.selected])
+}
+
+// conflictsFor ищет привязки, которые перекроет комбинация для строки
+func (se *shortcutEditor) conflictsFor(row shortcutRow, binding string) []string {
+	se.hm.mutex.RLock()
+	defer se.hm.mutex.RUnlock()
+
+	var ids []string
+	for _, sc := range se.hm.findConflictingShortcuts(binding, row.Context, row.condition) {
+		if sc.ID != row.ID {
+			ids = append(ids, sc.ID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// recordBinding назначает действию комбинацию, нажатую пользователем
+func (se *shortcutEditor) recordBinding(row shortcutRow) {
+	recorder := newShortcutRecorder()
+	conflictLabel := widget.NewLabel("")
+	conflictLabel.Wrapping = fyne.TextWrapWord
+	recorder.OnChanged = func(binding string) {
+		if binding == "" {
+			conflictLabel.SetText("")
+			return
+		}
+		if ids := se.conflictsFor(row, binding); len(ids) > 0 {
+			conflictLabel.SetText("⚠ Already used by: " + strings.Join(ids, ", "))
+		} else {
+			conflictLabel.SetText("No conflicts")
+		}
+	}
+
+	current := row.Binding
+	if current == "" {
+		current = "none"
+	}
+	content := container.NewVBox(
+		widget.NewLabel(row.Description),
+		widget.NewLabel("Current: "+current),
+		recorder,
+		container.NewHBox(widget.NewButton("Clear", recorder.Clear),
+			widget.NewLabel("Press a second combination to make a chord")),
+		conflictLabel,
+	)
+
+	d := dialog.NewCustomConfirm("Change Key Binding", "Save", "Cancel", content, func(save bool) {
+		if !save || recorder.Binding() == "" {
+			return
+		}
+		for _, part := range recorder.parts {
+			if _, err := se.hm.parseKeyBinding(part); err != nil {
+				dialog.ShowError(err, se.window)
+				return
+			}
+		}
+		se.setCustomBinding(row.ID, recorder.Binding())
+	}, se.window)
+	d.Resize(fyne.NewSize(420, 260))
+	d.Show()
+	se.window.Canvas().Focus(recorder)
+}
+
+// editWhen изменяет условие привязки
+func (se *shortcutEditor) editWhen(row shortcutRow) {
+	entry := widget.NewEntry()
+	entry.SetText(row.When)
+	entry.SetPlaceHolder("e.g. editorHasSelection && language == go")
+	entry.Validator = func(s string) error {
+		_, err := parseWhen(s)
+		return err
+	}
+
+	var hints []string
+	for _, key := range se.hm.contextKeys.Keys() {
+		hints = append(hints, key+" - "+se.hm.contextKeys.Description(key))
+	}
+	hintLabel := widget.NewLabel(strings.Join(hints, "\n"))
+
+	content := container.NewBorder(entry, nil, nil, nil, container.NewVScroll(hintLabel))
+	d := dialog.NewCustomConfirm("When: "+row.ID, "Save", "Cancel", content, func(save bool) {
+		if !save || entry.Validate() != nil {
+			return
+		}
+		kb := &se.hm.config.KeyBindings
+		if when := strings.TrimSpace(entry.Text); when != "" {
+			if kb.When == nil {
+				kb.When = make(map[string]string)
+			}
+			kb.When[row.ID] = when
+		} else {
+			delete(kb.When, row.ID)
+		}
+		se.changed()
+	}, se.window)
+	d.Resize(fyne.NewSize(520, 400))
+	d.Show()
+}
+
+// removeBinding снимает привязку; пустое значение в CustomBindings скрывает и привязку раскладки
+func (se *shortcutEditor) removeBinding(row shortcutRow) {
+	if row.Binding == "" {
+		return
+	}
+	se.setCustomBinding(row.ID, "")
+}
+
+// resetBinding возвращает привязку раскладки
+func (se *shortcutEditor) resetBinding(row shortcutRow) {
+	kb := &se.hm.config.KeyBindings
+	if _, ok := kb.CustomBindings[row.ID]; !ok {
+		return
+	}
+	delete(kb.CustomBindings, row.ID)
+	se.changed()
+}
+
+// resetAll удаляет все пользовательские привязки
+func (se *shortcutEditor) resetAll() {
+	kb := &se.hm.config.KeyBindings
+	if len(kb.CustomBindings) == 0 {
+		return
+	}
+	dialog.ShowConfirm("Reset Key Bindings",
+		fmt.Sprintf("Reset %d user key bindings to the keymap defaults?", len(kb.CustomBindings)),
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			kb.CustomBindings = make(map[string]string)
+			se.changed()
+		}, se.window)
+}
+
+// setCustomBinding записывает пользовательскую привязку
+func (se *shortcutEditor) setCustomBinding(id, binding string) {
+	kb := &se.hm.config.KeyBindings
+	if kb.CustomBindings == nil {
+		kb.CustomBindings = make(map[string]string)
+	}
+	kb.CustomBindings[id] = binding
+	se.changed()
+}
+
+// changed применяет изменения конфигурации и сохраняет ее
+func (se *shortcutEditor) changed() {
+	se.Reload()
+	if se.onChanged != nil {
+		se.onChanged()
+	}
+}
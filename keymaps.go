@@ -0,0 +1,656 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// keymapPreset - набор привязок, знакомый по другому редактору
+type keymapPreset struct {
+	ID        string
+	Title     string
+	Overrides map[string]string // действие -> комбинация поверх привязок по умолчанию ("" - без привязки)
+}
+
+// keymapPresets - доступные пресеты; привязки по умолчанию совпадают с VS Code
+var keymapPresets = []keymapPreset{
+	{ID: "vscode", Title: "VS Code"},
+	{ID: "sublime", Title: "Sublime Text", Overrides: map[string]string{
+		"go_to_symbol":     "Ctrl+R",
+		"toggle_sidebar":   "Ctrl+K Ctrl+B",
+		"toggle_terminal":  "",
+		"format_document":  "",
+		"format_selection": "",
+		"comment_block":    "Ctrl+Shift+/",
+		"expand_selection": "Ctrl+Shift+Space",
+		"shrink_selection": "",
+		"fold_all":         "Ctrl+K Ctrl+1",
+		"unfold_all":       "Ctrl+K Ctrl+J",
+		"file_explorer":    "",
+		"compare_files":    "",
+	}},
+	{ID: "jetbrains", Title: "JetBrains", Overrides: map[string]string{
+		"new_file":         "Alt+Insert",
+		"close_file":       "Ctrl+F4",
+		"close_all":        "",
+		"redo":             "Ctrl+Shift+Z",
+		"replace":          "Ctrl+R",
+		"go_to_symbol":     "Ctrl+F12",
+		"go_to_definition": "Ctrl+B",
+		"file_switcher":    "Ctrl+Shift+N",
+		"toggle_sidebar":   "Alt+1",
+		"toggle_terminal":  "Alt+F12",
+		"command_palette":  "Ctrl+Shift+A",
+		"file_explorer":    "",
+		"format_document":  "Ctrl+Alt+L",
+		"format_selection": "Ctrl+Alt+Shift+L",
+		"comment_block":    "Ctrl+Shift+/",
+		"select_line":      "",
+		"select_word":      "Alt+J",
+		"expand_selection": "Ctrl+W",
+		"shrink_selection": "Ctrl+Shift+W",
+		"fold_block":       "Ctrl+-",
+		"unfold_block":     "Ctrl+=",
+		"fold_all":         "Ctrl+Shift+-",
+		"unfold_all":       "Ctrl+Shift+=",
+		"open_powershell":  "",
+		"compare_files":    "Ctrl+D",
+	}},
+}
+
+// findKeymapPreset возвращает пресет по идентификатору
+func findKeymapPreset(id string) *keymapPreset {
+	for i := range keymapPresets {
+		if keymapPresets[i].ID == id {
+			return &keymapPresets[i]
+		}
+	}
+	return nil
+}
+
+// keyBindingFields связывает имена действий с полями KeyBindingsConfig
+func keyBindingFields(kb *KeyBindingsConfig) map[string]*string {
+	return map[string]*string{
+		"new_file":         &kb.NewFile,
+		"open_file":        &kb.OpenFile,
+		"save_file":        &kb.SaveFile,
+		"save_as_file":     &kb.SaveAsFile,
+		"close_file":       &kb.CloseFile,
+		"close_all":        &kb.CloseAll,
+		"cut":              &kb.Cut,
+		"copy":             &kb.Copy,
+		"paste":            &kb.Paste,
+		"select_all":       &kb.SelectAll,
+		"undo":             &kb.Undo,
+		"redo":             &kb.Redo,
+		"find":             &kb.Find,
+		"find_next":        &kb.FindNext,
+		"find_previous":    &kb.FindPrevious,
+		"replace":          &kb.Replace,
+		"find_in_files":    &kb.FindInFiles,
+		"go_to_line":       &kb.GoToLine,
+		"go_to_symbol":     &kb.GoToSymbol,
+		"go_to_definition": &kb.GoToDefinition,
+		"file_switcher":    &kb.FileSwitcher,
+		"toggle_sidebar":   &kb.ToggleSidebar,
+		"toggle_minimap":   &kb.ToggleMinimap,
+		"toggle_terminal":  &kb.ToggleTerminal,
+		"command_palette":  &kb.CommandPalette,
+		"file_explorer":    &kb.FileExplorer,
+		"format_document":  &kb.FormatDocument,
+		"format_selection": &kb.FormatSelection,
+		"comment_line":     &kb.CommentLine,
+		"comment_block":    &kb.CommentBlock,
+		"select_line":      &kb.SelectLine,
+		"select_word":      &kb.SelectWord,
+		"expand_selection": &kb.ExpandSelection,
+		"shrink_selection": &kb.ShrinkSelection,
+		"add_cursor_above": &kb.AddCursorAbove,
+		"add_cursor_below": &kb.AddCursorBelow,
+		"fold_block":       &kb.FoldBlock,
+		"unfold_block":     &kb.UnfoldBlock,
+		"fold_all":         &kb.FoldAll,
+		"unfold_all":       &kb.UnfoldAll,
+		"open_terminal":    &kb.OpenTerminal,
+		"open_powershell":  &kb.OpenPowerShell,
+		"open_cmd":         &kb.OpenCMD,
+		"compare_files":    &kb.CompareFiles,
+	}
+}
+
+// applyKeymapPreset переписывает привязки действий пресетом.
+// CustomBindings и режимы Vim/Emacs остаются как есть.
+func applyKeymapPreset(kb *KeyBindingsConfig, id string) error {
+	preset := findKeymapPreset(id)
+	if preset == nil {
+		return fmt.Errorf("unknown keymap preset: %s", id)
+	}
+
+	defaults := DefaultConfig().KeyBindings
+	fields := keyBindingFields(kb)
+	for action, value := range keyBindingFields(&defaults) {
+		*fields[action] = *value
+	}
+	for action, binding := range preset.Overrides {
+		*fields[action] = binding
+	}
+	for action := range fields {
+		delete(kb.When, action)
+	}
+
+	kb.KeymapPreset = id
+	kb.EnableVSCodeBindings = id == "vscode"
+	return nil
+}
+
+// vscodeCommands - команды VS Code и соответствующие им действия
+var vscodeCommands = map[string]string{
+	"workbench.action.files.newUntitledFile":    "new_file",
+	"workbench.action.files.openFile":           "open_file",
+	"workbench.action.files.openFileFolder":     "open_file",
+	"workbench.action.files.save":               "save_file",
+	"workbench.action.files.saveAs":             "save_as_file",
+	"workbench.action.closeActiveEditor":        "close_file",
+	"workbench.action.closeAllEditors":          "close_all",
+	"editor.action.clipboardCutAction":          "cut",
+	"editor.action.clipboardCopyAction":         "copy",
+	"editor.action.clipboardPasteAction":        "paste",
+	"editor.action.selectAll":                   "select_all",
+	"undo":                                      "undo",
+	"redo":                                      "redo",
+	"actions.find":                              "find",
+	"editor.action.nextMatchFindAction":         "find_next",
+	"editor.action.previousMatchFindAction":     "find_previous",
+	"editor.action.startFindReplaceAction":      "replace",
+	"workbench.action.findInFiles":              "find_in_files",
+	"workbench.view.search":                     "find_in_files",
+	"workbench.action.gotoLine":                 "go_to_line",
+	"workbench.action.gotoSymbol":               "go_to_symbol",
+	"editor.action.revealDefinition":            "go_to_definition",
+	"editor.action.goToDeclaration":             "go_to_definition",
+	"workbench.action.quickOpen":                "file_switcher",
+	"workbench.action.toggleSidebarVisibility":  "toggle_sidebar",
+	"editor.action.toggleMinimap":               "toggle_minimap",
+	"workbench.action.terminal.toggleTerminal":  "toggle_terminal",
+	"workbench.action.showCommands":             "command_palette",
+	"workbench.view.explorer":                   "file_explorer",
+	"editor.action.formatDocument":              "format_document",
+	"editor.action.formatSelection":             "format_selection",
+	"editor.action.commentLine":                 "comment_line",
+	"editor.action.blockComment":                "comment_block",
+	"expandLineSelection":                       "select_line",
+	"editor.action.addSelectionToNextFindMatch": "select_word",
+	"editor.action.smartSelect.expand":          "expand_selection",
+	"editor.action.smartSelect.shrink":          "shrink_selection",
+	"editor.action.insertCursorAbove":           "add_cursor_above",
+	"editor.action.insertCursorBelow":           "add_cursor_below",
+	"editor.fold":                               "fold_block",
+	"editor.unfold":                             "unfold_block",
+	"editor.foldAll":                            "fold_all",
+	"editor.unfoldAll":                          "unfold_all",
+	"workbench.action.terminal.new":             "open_terminal",
+	"workbench.files.action.compareFileWith":    "compare_files",
+}
+
+// sublimeCommands - команды Sublime Text без аргументов и соответствующие им действия
+var sublimeCommands = map[string]string{
+	"new_file":               "new_file",
+	"prompt_open_file":       "open_file",
+	"save":                   "save_file",
+	"prompt_save_as":         "save_as_file",
+	"close":                  "close_file",
+	"close_file":             "close_file",
+	"close_all":              "close_all",
+	"cut":                    "cut",
+	"copy":                   "copy",
+	"paste":                  "paste",
+	"select_all":             "select_all",
+	"undo":                   "undo",
+	"redo":                   "redo",
+	"redo_or_repeat":         "redo",
+	"find_next":              "find_next",
+	"find_prev":              "find_previous",
+	"goto_definition":        "go_to_definition",
+	"toggle_side_bar":        "toggle_sidebar",
+	"toggle_minimap":         "toggle_minimap",
+	"reindent":               "format_document",
+	"find_under_expand":      "select_word",
+	"expand_selection_scope": "expand_selection",
+	"fold":                   "fold_block",
+	"unfold":                 "unfold_block",
+	"unfold_all":             "unfold_all",
+}
+
+// sublimeAction определяет действие для команды Sublime Text с учетом аргументов
+func sublimeAction(command string, args map[string]interface{}) string {
+	str := func(key string) string {
+		s, _ := args[key].(string)
+		return s
+	}
+	flag := func(key string) bool {
+		b, _ := args[key].(bool)
+		return b
+	}
+
+	switch command {
+	case "show_panel":
+		switch str("panel") {
+		case "find", "incremental_find":
+			return "find"
+		case "replace":
+			return "replace"
+		case "find_in_files":
+			return "find_in_files"
+		}
+		return ""
+	case "show_overlay":
+		switch str("overlay") {
+		case "command_palette":
+			return "command_palette"
+		case "goto":
+			switch {
+			case str("text") == ":":
+				return "go_to_line"
+			case str("text") == "@":
+				return "go_to_symbol"
+			case flag("show_files"):
+				return "file_switcher"
+			}
+		}
+		return ""
+	case "toggle_comment":
+		if flag("block") {
+			return "comment_block"
+		}
+		return "comment_line"
+	case "expand_selection":
+		if str("to") == "line" {
+			return "select_line"
+		}
+		return "expand_selection"
+	case "select_lines":
+		if flag("forward") {
+			return "add_cursor_below"
+		}
+		return "add_cursor_above"
+	case "fold_by_level":
+		return "fold_all"
+	}
+	return sublimeCommands[command]
+}
+
+// keymapImport - результат импорта раскладки из другого редактора
+type keymapImport struct {
+	Format   string
+	Bindings map[string]string // действие -> комбинация
+	When     map[string]string // действие -> условие when из VS Code
+	Removed  map[string]string // привязки, снятые записями "-command" VS Code
+	Unmapped []string          // команды, которым нет соответствия среди действий
+	Invalid  []string          // комбинации, которые не удалось разобрать
+}
+
+// importKeymap читает keybindings.json VS Code или .sublime-keymap
+func (hm *HotkeyManager) importKeymap(path string) (*keymapImport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = cleanJSONC(data)
+
+	result := &keymapImport{
+		Bindings: make(map[string]string),
+		When:     make(map[string]string),
+		Removed:  make(map[string]string),
+	}
+	unmapped := make(map[string]bool)
+	invalid := make(map[string]bool)
+
+	// add учитывает одну запись; более поздние записи переопределяют ранние, как в обоих редакторах
+	add := func(command, action string, keys []string, when string) {
+		remove := strings.HasPrefix(command, "-")
+		if _, ok := hm.actions[action]; !ok {
+			unmapped[strings.TrimPrefix(command, "-")] = true
+			return
+		}
+		binding, err := hm.convertImportedKeys(keys)
+		if err != nil {
+			invalid[fmt.Sprintf("%s (%s): %v", strings.Join(keys, " "), command, err)] = true
+			return
+		}
+		if remove {
+			result.Removed[action] = binding
+			return
+		}
+		if _, err := parseWhen(when); err != nil {
+			invalid[fmt.Sprintf("%s (%s): %v", strings.Join(keys, " "), command, err)] = true
+			return
+		}
+		result.Bindings[action] = binding
+		result.When[action] = when
+		delete(result.Removed, action)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".sublime-keymap") {
+		result.Format = "Sublime Text"
+		var entries []struct {
+			Keys    []string               `json:"keys"`
+			Command string                 `json:"command"`
+			Args    map[string]interface{} `json:"args"`
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("invalid Sublime Text keymap: %v", err)
+		}
+		for _, e := range entries {
+			if e.Command == "" || len(e.Keys) == 0 {
+				continue
+			}
+			add(e.Command, sublimeAction(e.Command, e.Args), e.Keys, "")
+		}
+	} else {
+		result.Format = "VS Code"
+		var entries []struct {
+			Key     string `json:"key"`
+			Command string `json:"command"`
+			When    string `json:"when"`
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("invalid VS Code keybindings: %v", err)
+		}
+		for _, e := range entries {
+			if e.Command == "" || e.Key == "" {
+				continue
+			}
+			add(e.Command, vscodeCommands[strings.TrimPrefix(e.Command, "-")], strings.Fields(e.Key), e.When)
+		}
+	}
+
+	for command := range unmapped {
+		result.Unmapped = append(result.Unmapped, command)
+	}
+	for entry := range invalid {
+		result.Invalid = append(result.Invalid, entry)
+	}
+	sort.Strings(result.Unmapped)
+	sort.Strings(result.Invalid)
+	return result, nil
+}
+
+// importedModifiers - модификаторы VS Code и Sublime Text в нашей записи
+var importedModifiers = map[string]string{
+	"ctrl":    "Ctrl",
+	"control": "Ctrl",
+	"primary": "Ctrl",
+	"shift":   "Shift",
+	"alt":     "Alt",
+	"option":  "Alt",
+	"cmd":     "Super",
+	"meta":    "Super",
+	"win":     "Super",
+	"super":   "Super",
+}
+
+// importedKeyNames - имена клавиш, которые пишутся иначе, чем в нашей записи
+var importedKeyNames = map[string]string{
+	"enter":         "Enter",
+	"escape":        "Escape",
+	"tab":           "Tab",
+	"space":         "Space",
+	"backspace":     "Backspace",
+	"delete":        "Delete",
+	"insert":        "Insert",
+	"home":          "Home",
+	"end":           "End",
+	"pageup":        "PageUp",
+	"pagedown":      "PageDown",
+	"page_up":       "PageUp",
+	"page_down":     "PageDown",
+	"up":            "Up",
+	"down":          "Down",
+	"left":          "Left",
+	"right":         "Right",
+	"forward_slash": "/",
+	"backquote":     "`",
+	"minus":         "-",
+	"equals":        "=",
+}
+
+// convertImportedKeys переводит аккорд вида ["ctrl+k", "ctrl+c"] в запись "Ctrl+K Ctrl+C"
+func (hm *HotkeyManager) convertImportedKeys(keys []string) (string, error) {
+	var chord []string
+	for _, key := range keys {
+		parts := strings.Split(strings.ToLower(strings.TrimSpace(key)), "+")
+		name := parts[len(parts)-1]
+		if name == "" {
+			return "", fmt.Errorf("unsupported key: %s", key)
+		}
+
+		var out []string
+		for _, mod := range parts[:len(parts)-1] {
+			m, ok := importedModifiers[mod]
+			if !ok {
+				return "", fmt.Errorf("unknown modifier: %s", mod)
+			}
+			out = append(out, m)
+		}
+		if n, ok := importedKeyNames[name]; ok {
+			name = n
+		} else {
+			name = strings.ToUpper(name)
+		}
+		out = append(out, name)
+
+		binding := strings.Join(out, "+")
+		if _, err := hm.parseKeyBinding(binding); err != nil {
+			return "", err
+		}
+		chord = append(chord, binding)
+	}
+	return strings.Join(chord, " "), nil
+}
+
+// cleanJSONC убирает комментарии и висячие запятые, которые допускают оба редактора
+func cleanJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Висячая запятая перед закрывающей скобкой
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// applyImportedKeymap записывает импортированные привязки в конфигурацию.
+// Действия без поля в KeyBindingsConfig попадают в CustomBindings.
+func applyImportedKeymap(kb *KeyBindingsConfig, imp *keymapImport) {
+	fields := keyBindingFields(kb)
+	if kb.CustomBindings == nil {
+		kb.CustomBindings = make(map[string]string)
+	}
+	if kb.When == nil {
+		kb.When = make(map[string]string)
+	}
+
+	for action, binding := range imp.Removed {
+		if field, ok := fields[action]; ok && *field == binding {
+			*field = ""
+		} else if kb.CustomBindings[action] == binding {
+			delete(kb.CustomBindings, action)
+		}
+	}
+	for action, binding := range imp.Bindings {
+		if field, ok := fields[action]; ok {
+			*field = binding
+		} else {
+			kb.CustomBindings[action] = binding
+		}
+		if when := imp.When[action]; when != "" {
+			kb.When[action] = when
+		} else {
+			delete(kb.When, action)
+		}
+	}
+	kb.KeymapPreset = "custom"
+}
+
+// ReloadShortcuts перерегистрирует все горячие клавиши из текущей конфигурации без перезапуска
+func (hm *HotkeyManager) ReloadShortcuts() {
+	hm.clearPendingKeys()
+
+	hm.mutex.Lock()
+	for _, shortcut := range hm.shortcuts {
+		if shortcut.Context == ContextGlobal || shortcut.Context == ContextEditor {
+			hm.window.Canvas().RemoveShortcut(shortcut.Shortcut)
+		}
+	}
+	hm.shortcuts = make(map[string]*RegisteredShortcut)
+	hm.defaultBindings = make(map[string]string)
+	for ctx := range hm.contextShortcuts {
+		hm.contextShortcuts[ctx] = make(map[string]*RegisteredShortcut)
+	}
+	hm.mutex.Unlock()
+
+	hm.loadFromConfig()
+}
+
+// ApplyKeymapPreset переключает пресет и сразу перерегистрирует клавиши
+func (hm *HotkeyManager) ApplyKeymapPreset(id string) error {
+	if err := applyKeymapPreset(&hm.config.KeyBindings, id); err != nil {
+		return err
+	}
+	hm.ReloadShortcuts()
+	return nil
+}
+
+// showKeymapPresets предлагает выбрать пресет раскладки
+func (a *App) showKeymapPresets() {
+	var titles []string
+	for _, p := range keymapPresets {
+		titles = append(titles, p.Title)
+	}
+
+	list := widget.NewList(
+		func() int { return len(keymapPresets) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			title := titles[i]
+			if keymapPresets[i].ID == a.config.KeyBindings.KeymapPreset {
+				title += "  ✓"
+			}
+			o.(*widget.Label).SetText(title)
+		},
+	)
+
+	d := dialog.NewCustom("Keymap", "Close", list, a.mainWin)
+	list.OnSelected = func(i widget.ListItemID) {
+		d.Hide()
+		if err := a.hotkeyManager.ApplyKeymapPreset(keymapPresets[i].ID); err != nil {
+			dialog.ShowError(err, a.mainWin)
+			return
+		}
+		a.saveKeyBindings()
+	}
+	d.Resize(fyne.NewSize(350, 250))
+	d.Show()
+}
+
+// importKeymapFile импортирует keybindings.json VS Code или .sublime-keymap
+func (a *App) importKeymapFile() {
+	a.dialogManager.ShowOpenFileDialogWithFilter([]string{".json", ".sublime-keymap"}, func(path string) {
+		imp, err := a.hotkeyManager.importKeymap(path)
+		if err != nil {
+			dialog.ShowError(err, a.mainWin)
+			return
+		}
+
+		applyImportedKeymap(&a.config.KeyBindings, imp)
+		a.hotkeyManager.ReloadShortcuts()
+		a.saveKeyBindings()
+		a.showKeymapImportReport(imp)
+	})
+}
+
+// showKeymapImportReport показывает итог импорта и команды без соответствия
+func (a *App) showKeymapImportReport(imp *keymapImport) {
+	var report strings.Builder
+	fmt.Fprintf(&report, "Imported %d bindings from %s", len(imp.Bindings), imp.Format)
+	if len(imp.Removed) > 0 {
+		fmt.Fprintf(&report, ", removed %d", len(imp.Removed))
+	}
+	report.WriteString(".\n")
+
+	if len(imp.Unmapped) > 0 {
+		fmt.Fprintf(&report, "\nUnmapped commands (%d):\n", len(imp.Unmapped))
+		for _, command := range imp.Unmapped {
+			report.WriteString("  " + command + "\n")
+		}
+	}
+	if len(imp.Invalid) > 0 {
+		fmt.Fprintf(&report, "\nUnsupported keys (%d):\n", len(imp.Invalid))
+		for _, entry := range imp.Invalid {
+			report.WriteString("  " + entry + "\n")
+		}
+	}
+
+	text := widget.NewLabel(report.String())
+	text.Wrapping = fyne.TextWrapWord
+	d := dialog.NewCustom("Keymap Import", "Close", container.NewVScroll(text), a.mainWin)
+	d.Resize(fyne.NewSize(500, 400))
+	d.Show()
+}
+
+// saveKeyBindings сохраняет привязки клавиш в файл настроек
+func (a *App) saveKeyBindings() {
+	kb := a.config.KeyBindings
+	if err := a.configManager.UpdateConfig(func(c *Config) {
+		c.KeyBindings = kb
+	}); err != nil {
+		log.Printf("Failed to save key bindings: %v", err)
+	}
+}
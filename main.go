@@ -10,13 +10,10 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"github.com/lithammer/fuzzysearch/fuzzy"
 	lsp "github.com/sourcegraph/go-lsp"
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
-	"sort"
 	"strings"
 )
 
@@ -30,28 +27,32 @@ type Command struct {
 
 // App представляет основное приложение
 type App struct {
-	fyneApp            fyne.App
-	mainWin            fyne.Window
-	editor             *EditorWidget
-	sidebar            *SidebarWidget
-	minimap            *MinimapWidget
-	config             *Config
-	configManager      *ConfigManager
-	hotkeyManager      *HotkeyManager
-	dialogManager      *DialogManager
-	terminalMgr        *TerminalManager
-	lspManager         *LSPManager
-	mainContent        fyne.CanvasObject
-	currentFile        string
-	recentFiles        []string
-	commandHistory     *CommandHistory
-	findDialog         dialog.Dialog
-	searchResults      []TextRange
-	currentSearchIndex int
-	lastSearchText     string
-	statusBar          *widget.Label
-	breadcrumb         *fyne.Container
-	appTheme           *AppTheme
+	fyneApp          fyne.App
+	mainWin          fyne.Window
+	editor           *EditorWidget
+	sidebar          *SidebarWidget
+	sourceControl    *SourceControlPanel
+	searchPanel      *SearchPanel
+	searchIndex      *SearchIndex
+	fileIndex        *FileIndex
+	rootIndexes      map[string]*FileIndex
+	workspace        *Workspace
+	minimap          *MinimapWidget
+	findBar          *FindBar
+	config           *Config
+	configManager    *ConfigManager
+	hotkeyManager    *HotkeyManager
+	dialogManager    *DialogManager
+	terminalMgr      *TerminalManager
+	lspManager       *LSPManager
+	mainContent      fyne.CanvasObject
+	currentFile      string
+	recentFiles      []string
+	compareSelection *DiffSide // запомненное выделение для сравнения
+	commandHistory   *CommandHistory
+	statusBar        *widget.Label
+	breadcrumb       *fyne.Container
+	appTheme         *AppTheme
 }
 
 // NewApp создает новое приложение
@@ -106,7 +107,15 @@ func (a *App) setupUI() {
 	// Создаем основные компоненты
 	a.editor = NewEditor(a.config)
 	a.sidebar = NewSidebar(a.config, a.mainWin)
+	a.sourceControl = NewSourceControlPanel(a.config, a.mainWin)
+	a.sidebar.AddView("Source Control", theme.StorageIcon(), a.sourceControl)
+	a.searchPanel = NewSearchPanel(a.config, a.mainWin)
+	a.searchPanel.SetRoot(a.sidebar.GetCurrentPath())
+	a.openSearchIndex(a.sidebar.GetCurrentPath())
+	a.openFileIndex(a.sidebar.GetCurrentPath())
+	a.sidebar.AddView("Search", theme.SearchIcon(), a.searchPanel)
 	a.minimap = NewMinimap(a.editor)
+	a.findBar = NewFindBar(a.editor, a.config, a.mainWin)
 
 	// Создаем менеджеры
 	a.dialogManager = NewDialogManager(a.mainWin, a.editor, a.config)
@@ -149,6 +158,7 @@ func (a *App) setupUI() {
 
 	// Передаем ссылку на App в HotkeyManager для доступа к методам
 	a.hotkeyManager.SetApp(a)
+	a.dialogManager.SetShortcutEditor(a.newShortcutEditor)
 
 	// Настраиваем callbacks
 	a.setupCallbacks()
@@ -162,14 +172,14 @@ func (a *App) setupUI() {
 	// Устанавливаем горячие клавиши
 	a.setupHotkeys()
 
-	// Загружаем последнюю сессию если настроено
-	if a.config.App.StartupBehavior == "last_session" && len(a.recentFiles) > 0 {
-		a.loadFile(a.recentFiles[0])
-	}
-
 	// Устанавливаем начальную директорию для сайдбара
 	homeDir, _ := os.UserHomeDir()
 	a.sidebar.SetRootPath(homeDir)
+
+	// Загружаем последнюю сессию если настроено
+	if a.hotExitEnabled() && !a.restoreSession() && len(a.recentFiles) > 0 {
+		a.loadFile(a.recentFiles[0])
+	}
 }
 
 // createMainMenu создает главное меню
@@ -180,9 +190,15 @@ func (a *App) createMainMenu() {
 		fyne.NewMenuItem("Save", a.saveFile),
 		fyne.NewMenuItem("Save As...", a.saveAsFile),
 		fyne.NewMenuItemSeparator(),
-		fyne.NewMenuItem("File Switcher...", a.showFileSwitcher),
+		fyne.NewMenuItem("Quick Open...", a.showQuickOpen),
 		fyne.NewMenuItem("Recent Files", a.showRecentFiles),
 		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Open Workspace...", a.showOpenWorkspace),
+		fyne.NewMenuItem("Recent Workspaces...", a.showRecentWorkspaces),
+		fyne.NewMenuItem("Add Folder to Workspace...", a.addFolderToWorkspace),
+		fyne.NewMenuItem("Save Workspace As...", a.saveWorkspaceAs),
+		fyne.NewMenuItem("Close Workspace", a.closeWorkspace),
+		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Exit", func() {
 			a.checkAndExit()
 		}),
@@ -200,6 +216,7 @@ func (a *App) createMainMenu() {
 		fyne.NewMenuItem("Find...", a.showFind),
 		fyne.NewMenuItem("Replace...", a.showReplace),
 		fyne.NewMenuItem("Find in Files...", a.showFindInFiles),
+		fyne.NewMenuItem("Structural Replace...", a.showStructuralReplace),
 		fyne.NewMenuItem("Go to Line...", a.showGoToLine),
 		fyne.NewMenuItem("Go to Symbol...", a.showGoToSymbol),
 	)
@@ -216,11 +233,25 @@ func (a *App) createMainMenu() {
 		fyne.NewMenuItem("Reset Zoom", a.resetZoom),
 	)
 
+	compareItem := fyne.NewMenuItem("Compare Buffer", nil)
+	compareItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("With File on Disk", a.compareBufferWithDisk),
+		fyne.NewMenuItem("With Saved Version", a.compareBufferWithSaved),
+		fyne.NewMenuItem("With Clipboard", a.compareBufferWithClipboard),
+		fyne.NewMenuItem("With Recent File...", a.compareBufferWithRecentFile),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Remember Selection for Compare", a.rememberSelectionForCompare),
+		fyne.NewMenuItem("Compare Selection with Remembered", a.compareSelectionWithRemembered),
+	)
+
 	toolsMenu := fyne.NewMenu("Tools",
 		fyne.NewMenuItem("Terminal (CMD)", a.openCMD),
 		fyne.NewMenuItem("Terminal (PowerShell)", a.openPowerShell),
 		fyne.NewMenuItem("Custom Tools", a.showCustomTools),
 		fyne.NewMenuItem("Compare Files...", a.compareFiles),
+		fyne.NewMenuItem("Compare Folders...", a.compareDirectoriesDialog),
+		compareItem,
+		fyne.NewMenuItem("Apply Patch...", a.applyPatchFile),
 		fyne.NewMenuItem("Get File Hash", a.showFileHash),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("Format Code", a.formatCode),
@@ -230,6 +261,14 @@ func (a *App) createMainMenu() {
 		fyne.NewMenuItem("Build Project", a.buildProject),
 	)
 
+	gitMenu := fyne.NewMenu("Git",
+		fyne.NewMenuItem("Source Control", a.showSourceControl),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Toggle Blame", a.toggleBlame),
+		fyne.NewMenuItem("File History", a.showFileHistory),
+		fyne.NewMenuItem("Resolve Conflicts", a.resolveConflicts),
+	)
+
 	bookmarkMenu := fyne.NewMenu("Bookmarks",
 		fyne.NewMenuItem("Add Bookmark", a.addBookmark),
 		fyne.NewMenuItem("Go to Bookmark", a.goToBookmark),
@@ -244,11 +283,13 @@ func (a *App) createMainMenu() {
 	settingsMenu := fyne.NewMenu("Settings",
 		fyne.NewMenuItem("Preferences", a.showPreferences),
 		fyne.NewMenuItem("Key Bindings", a.showKeyBindings),
+		fyne.NewMenuItem("Keymap...", a.showKeymapPresets),
+		fyne.NewMenuItem("Import Keymap...", a.importKeymapFile),
 		fyne.NewMenuItemSeparator(),
 		fyne.NewMenuItem("About", a.showAbout),
 	)
 
-	mainMenu := fyne.NewMainMenu(fileMenu, editMenu, viewMenu, toolsMenu, bookmarkMenu, foldMenu, settingsMenu)
+	mainMenu := fyne.NewMainMenu(fileMenu, editMenu, viewMenu, toolsMenu, gitMenu, bookmarkMenu, foldMenu, settingsMenu)
 	a.mainWin.SetMainMenu(mainMenu)
 }
 
@@ -266,9 +307,9 @@ func (a *App) createMainLayout() {
 	// Основной контент с редактором и миниатюрой
 	var editorContent fyne.CanvasObject
 	if a.minimap != nil && a.minimap.IsVisible() {
-		editorContent = container.NewBorder(nil, nil, nil, a.minimap, a.editor)
+		editorContent = container.NewBorder(a.findBar, nil, nil, a.minimap, a.editor)
 	} else {
-		editorContent = a.editor
+		editorContent = container.NewBorder(a.findBar, nil, nil, nil, a.editor)
 	}
 
 	// Добавляем боковую панель если видима
@@ -327,6 +368,9 @@ func (a *App) setupCallbacks() {
 			if a.minimap != nil {
 				a.minimap.SetContent(content)
 			}
+			if a.findBar != nil {
+				a.findBar.ContentChanged()
+			}
 			if a.lspManager != nil && a.editor.filePath != "" {
 				if err := a.lspManager.DidChange(a.editor.language, a.editor.filePath, content); err != nil {
 					log.Printf("LSP change error: %v", err)
@@ -350,6 +394,14 @@ func (a *App) setupCallbacks() {
 			}
 		}
 
+		a.editor.onExternalConflict = func(base, local, disk string) {
+			a.showExternalMerge(a.editor.filePath, base, local, disk)
+		}
+
+		a.editor.blameGutter.onOpenCommit = func(hash string) {
+			a.showCommitDetails(a.currentFile, hash)
+		}
+
 		a.editor.onBookmarksChanged = func() {
 			if a.minimap != nil {
 				a.minimap.Refresh()
@@ -357,6 +409,28 @@ func (a *App) setupCallbacks() {
 		}
 	}
 
+	// Callbacks для панели поиска
+	if a.findBar != nil {
+		a.findBar.SetCallbacks(
+			func(cmd EditorCommand) error { // onExecute
+				if err := a.commandHistory.Execute(cmd, a.editor); err != nil {
+					dialog.ShowError(err, a.mainWin)
+					return err
+				}
+				return nil
+			},
+			func() { // onChanged
+				if a.minimap != nil {
+					a.minimap.Refresh()
+				}
+			},
+			a.configManager.SaveConfigAsync,
+			func() { // onClose
+				a.mainWin.Canvas().Focus(a.editor.entryWidget)
+			},
+		)
+	}
+
 	// Callbacks для сайдбара
 	if a.sidebar != nil {
 		a.sidebar.SetCallbacks(
@@ -403,8 +477,39 @@ func (a *App) setupCallbacks() {
 				if a.terminalMgr != nil {
 					a.terminalMgr.SetWorkingDirectory(path)
 				}
+				if a.sourceControl != nil {
+					a.sourceControl.SetWorkDir(path)
+				}
+				if a.searchPanel != nil {
+					a.searchPanel.SetRoot(path)
+				}
+				a.openSearchIndex(path)
+				a.openFileIndex(path)
 			},
 		)
+		a.sidebar.SetFileOpCallbacks(a.followRenamedPath, a.handleDeletedPath, a.showDiffWindow)
+		a.sidebar.SetRootsCallback(a.syncRootIndexes)
+	}
+
+	// Callbacks для панели управления версиями
+	if a.sourceControl != nil {
+		a.sourceControl.SetCallbacks(
+			a.showDiffContents,
+			func(path string) { // onOpenFile
+				a.loadFile(path)
+			},
+			func() { // onChanged
+				if a.sidebar != nil {
+					a.sidebar.RefreshPath("")
+				}
+			},
+		)
+	}
+
+	// Callbacks для панели поиска в файлах
+	if a.searchPanel != nil {
+		a.searchPanel.SetCallbacks(a.openBuffers, a.openSearchMatch, a.showReplacePreview)
+		a.searchPanel.SetIndex(func() *SearchIndex { return a.searchIndex })
 	}
 
 	// Callbacks для миниатюры
@@ -529,6 +634,7 @@ func (a *App) loadFile(path string) {
 					log.Printf("LSP open error: %v", err)
 				}
 			}
+			a.offerConflictResolution(path)
 		}
 	}
 }
@@ -548,6 +654,9 @@ func (a *App) saveFile() {
 		dialog.ShowError(err, a.mainWin)
 	} else {
 		a.updateTitle()
+		if a.sourceControl != nil {
+			a.sourceControl.Reload()
+		}
 		if a.lspManager != nil {
 			if err := a.lspManager.DidSave(a.editor.language, a.editor.filePath, a.editor.textContent); err != nil {
 				log.Printf("LSP save error: %v", err)
@@ -588,11 +697,14 @@ func (a *App) undo() {
 		return
 	}
 
-	err := a.commandHistory.Undo(a.editor)
-	if err != nil {
+	if !a.commandHistory.CanUndo() {
 		// Нет действий для отмены
 		return
 	}
+	if err := a.commandHistory.Undo(a.editor); err != nil {
+		dialog.ShowError(err, a.mainWin)
+		return
+	}
 
 	a.editor.updateDisplay()
 }
@@ -602,11 +714,14 @@ func (a *App) redo() {
 		return
 	}
 
-	err := a.commandHistory.Redo(a.editor)
-	if err != nil {
+	if !a.commandHistory.CanRedo() {
 		// Нет действий для повтора
 		return
 	}
+	if err := a.commandHistory.Redo(a.editor); err != nil {
+		dialog.ShowError(err, a.mainWin)
+		return
+	}
 
 	a.editor.updateDisplay()
 }
@@ -682,93 +797,89 @@ func (a *App) selectAll() {
 
 // Search operations - Полная реализация
 
+// showFind открывает панель поиска над редактором
 func (a *App) showFind() {
-	if a.editor == nil {
-		return
+	if a.findBar != nil {
+		a.findBar.Open(false)
 	}
-
-	a.dialogManager.ShowFindDialog(func(text string, caseSensitive, wholeWord, regex bool) {
-		a.lastSearchText = text
-		// Выполняем поиск
-		cmd := &FindCommand{
-			searchTerm:    text,
-			caseSensitive: caseSensitive,
-			wholeWord:     wholeWord,
-			useRegex:      regex,
-		}
-
-		err := cmd.Execute(a.editor)
-		if err != nil {
-			dialog.ShowError(err, a.mainWin)
-			return
-		}
-
-		// Сохраняем результаты поиска
-		a.searchResults = a.editor.searchResults
-		a.currentSearchIndex = 0
-
-		// Переходим к первому результату
-		if len(a.searchResults) > 0 {
-			a.goToSearchResult(0)
-		} else {
-			dialog.ShowInformation("Find", "No matches found", a.mainWin)
-		}
-	})
 }
 
+// showReplace открывает панель поиска со строкой замены
 func (a *App) showReplace() {
-	if a.editor == nil {
+	if a.findBar != nil {
+		a.findBar.Open(true)
+	}
+}
+
+// showFindInFiles открывает панель поиска в файлах с выделенным текстом
+func (a *App) showFindInFiles() {
+	if a.sidebar == nil || a.searchPanel == nil {
 		return
 	}
+	if !a.sidebar.IsVisible() {
+		a.toggleSidebar()
+	}
+	a.sidebar.ShowView("Search")
+	selected := ""
+	if a.editor != nil {
+		selected = a.editor.GetSelectedText()
+	}
+	a.searchPanel.FocusQuery(selected)
+}
 
-	a.dialogManager.ShowReplaceDialog(func(find, replace string, caseSensitive, wholeWord, regex, replaceAll bool) {
-		if replaceAll {
-			// Заменяем все вхождения
-			cmd := &ReplaceTextCommand{
-				findText:    find,
-				replaceText: replace,
-			}
-			a.commandHistory.Execute(cmd, a.editor)
-		} else {
-			// Заменяем текущее вхождение
-			a.replaceCurrentMatch(find, replace)
+// openBuffers возвращает несохраненное содержимое открытых файлов для поиска
+func (a *App) openBuffers() map[string]string {
+	buffers := make(map[string]string)
+	if a.editor != nil && a.editor.filePath != "" && a.editor.IsDirty() {
+		if abs, err := filepath.Abs(a.editor.filePath); err == nil {
+			buffers[abs] = a.editor.GetContent()
 		}
-	})
+	}
+	return buffers
 }
 
-func (a *App) showFindInFiles() {
-	// Создаем диалог поиска в файлах
-	searchEntry := widget.NewEntry()
-	searchEntry.SetPlaceHolder("Search text...")
-
-	pathEntry := widget.NewEntry()
-	if a.sidebar != nil {
-		pathEntry.SetText(a.sidebar.GetCurrentPath())
+// followRenamedPath переносит открытый буфер и недавние файлы на новый путь
+func (a *App) followRenamedPath(oldPath, newPath string) {
+	for i, file := range a.recentFiles {
+		if isSubPath(oldPath, file) {
+			a.recentFiles[i] = rebasePath(file, oldPath, newPath)
+		}
 	}
 
-	includeEntry := widget.NewEntry()
-	includeEntry.SetPlaceHolder("*.go,*.rs,*.py")
+	if a.editor == nil || a.editor.filePath == "" || !isSubPath(oldPath, a.editor.filePath) {
+		a.config.App.LastOpenedFiles = a.recentFiles
+		a.configManager.SaveConfigAsync()
+		return
+	}
+	// onFileChanged обновит заголовок, хлебные крошки и сохранит недавние файлы
+	a.editor.FollowRename(rebasePath(a.editor.filePath, oldPath, newPath))
+}
 
-	excludeEntry := widget.NewEntry()
-	excludeEntry.SetPlaceHolder("*.log,*.tmp")
+// handleDeletedPath сообщает об удалении файла, открытого в редакторе
+func (a *App) handleDeletedPath(path string) {
+	if a.editor == nil || a.editor.filePath == "" || !isSubPath(path, a.editor.filePath) {
+		return
+	}
 
-	content := container.NewVBox(
-		widget.NewLabel("Search for:"),
-		searchEntry,
-		widget.NewLabel("In folder:"),
-		pathEntry,
-		widget.NewLabel("Include files:"),
-		includeEntry,
-		widget.NewLabel("Exclude files:"),
-		excludeEntry,
-	)
+	a.editor.MarkDeleted()
+	a.updateTitle()
+	dialog.ShowInformation("File Deleted",
+		fmt.Sprintf("'%s' was deleted from disk.\nIts contents are kept in the editor; save to recreate the file.", filepath.Base(a.editor.filePath)),
+		a.mainWin)
+}
 
-	dialog.ShowCustomConfirm("Find in Files", "Search", "Cancel", content, func(confirmed bool) {
-		if confirmed && searchEntry.Text != "" {
-			// Выполняем поиск в файлах
-			a.performFindInFiles(searchEntry.Text, pathEntry.Text, includeEntry.Text, excludeEntry.Text)
-		}
-	}, a.mainWin)
+// openSearchMatch открывает файл и выделяет найденное вхождение
+func (a *App) openSearchMatch(path string, line, col, length int) {
+	if abs, err := filepath.Abs(a.currentFile); err != nil || abs != path {
+		a.loadFile(path)
+	}
+	a.goToLine(line)
+	if a.editor != nil {
+		a.editor.cursorCol = col
+		a.editor.selectionStart = TextPosition{Row: line - 1, Col: col}
+		a.editor.selectionEnd = TextPosition{Row: line - 1, Col: col + length}
+		a.editor.updateDisplay()
+	}
 }
 
 func (a *App) showGoToLine() {
@@ -985,19 +1096,38 @@ func (a *App) getAvailableCommands() []Command {
 	return []Command{
 		{Name: "New File", Shortcut: "Ctrl+N", Icon: theme.DocumentCreateIcon(), Action: a.newFile},
 		{Name: "Open File", Shortcut: "Ctrl+O", Icon: theme.FolderOpenIcon(), Action: a.openFile},
-		{Name: "File Switcher", Shortcut: "Ctrl+P", Icon: theme.DocumentIcon(), Action: a.showFileSwitcher},
+		{Name: "Quick Open", Shortcut: "Ctrl+P", Icon: theme.DocumentIcon(), Action: a.showQuickOpen},
+		{Name: "Open Workspace", Shortcut: "", Icon: theme.StorageIcon(), Action: a.showOpenWorkspace},
+		{Name: "Recent Workspaces", Shortcut: "", Icon: theme.HistoryIcon(), Action: a.showRecentWorkspaces},
+		{Name: "Add Folder to Workspace", Shortcut: "", Icon: theme.FolderNewIcon(), Action: a.addFolderToWorkspace},
+		{Name: "Save Workspace As", Shortcut: "", Icon: theme.DocumentSaveIcon(), Action: a.saveWorkspaceAs},
 		{Name: "Save File", Shortcut: "Ctrl+S", Icon: theme.DocumentSaveIcon(), Action: a.saveFile},
 		{Name: "Find", Shortcut: "Ctrl+F", Icon: theme.SearchIcon(), Action: a.showFind},
 		{Name: "Replace", Shortcut: "Ctrl+H", Icon: theme.SearchReplaceIcon(), Action: a.showReplace},
+		{Name: "Structural Search and Replace (Go)", Shortcut: "", Icon: theme.SearchReplaceIcon(), Action: a.showStructuralReplace},
 		{Name: "Toggle Sidebar", Shortcut: "Ctrl+B", Icon: theme.MenuIcon(), Action: a.toggleSidebar}, // Исправлено: заменено ViewListIcon на MenuIcon
 		{Name: "Toggle Minimap", Shortcut: "Ctrl+M", Icon: theme.ViewFullScreenIcon(), Action: a.toggleMinimap},
 		{Name: "Compare Files", Shortcut: "Ctrl+Shift+D", Icon: theme.ViewRefreshIcon(), Action: a.compareFiles},
+		{Name: "Compare Folders", Shortcut: "", Icon: theme.FolderOpenIcon(), Action: a.compareDirectoriesDialog},
+		{Name: "Apply Patch", Shortcut: "", Icon: theme.DocumentIcon(), Action: a.applyPatchFile},
+		{Name: "Compare Buffer with Disk", Shortcut: "", Icon: theme.ViewRefreshIcon(), Action: a.compareBufferWithDisk},
+		{Name: "Compare Buffer with Saved Version", Shortcut: "", Icon: theme.ViewRefreshIcon(), Action: a.compareBufferWithSaved},
+		{Name: "Compare Buffer with Clipboard", Shortcut: "", Icon: theme.ViewRefreshIcon(), Action: a.compareBufferWithClipboard},
+		{Name: "Compare Buffer with Recent File", Shortcut: "", Icon: theme.ViewRefreshIcon(), Action: a.compareBufferWithRecentFile},
+		{Name: "Remember Selection for Compare", Shortcut: "", Icon: theme.ContentCopyIcon(), Action: a.rememberSelectionForCompare},
+		{Name: "Compare Selection with Remembered", Shortcut: "", Icon: theme.ViewRefreshIcon(), Action: a.compareSelectionWithRemembered},
 		{Name: "Get File Hash", Shortcut: "", Icon: theme.InfoIcon(), Action: a.showFileHash},
+		{Name: "Source Control", Shortcut: "Ctrl+Shift+G", Icon: theme.StorageIcon(), Action: a.showSourceControl},
+		{Name: "Toggle Git Blame", Shortcut: "", Icon: theme.AccountIcon(), Action: a.toggleBlame},
+		{Name: "File History", Shortcut: "", Icon: theme.HistoryIcon(), Action: a.showFileHistory},
+		{Name: "Resolve Merge Conflicts", Shortcut: "", Icon: theme.ContentPasteIcon(), Action: a.resolveConflicts},
 		{Name: "Format Code", Shortcut: "Shift+Alt+F", Icon: theme.DocumentIcon(), Action: a.formatCode},
 		{Name: "Add Bookmark", Shortcut: "Ctrl+F2", Icon: theme.ContentAddIcon(), Action: a.addBookmark},
 		{Name: "Go to Bookmark", Shortcut: "F2", Icon: theme.NavigateNextIcon(), Action: a.goToBookmark},
 		{Name: "Remove Bookmark", Shortcut: "Shift+F2", Icon: theme.ContentRemoveIcon(), Action: a.removeBookmark},
 		{Name: "Preferences", Shortcut: "", Icon: theme.SettingsIcon(), Action: a.showPreferences},
+		{Name: "Select Keymap", Shortcut: "", Icon: theme.SettingsIcon(), Action: a.showKeymapPresets},
+		{Name: "Import Keymap from VS Code / Sublime Text", Shortcut: "", Icon: theme.UploadIcon(), Action: a.importKeymapFile},
 		{Name: "About", Shortcut: "", Icon: theme.InfoIcon(), Action: a.showAbout},
 	}
 }
@@ -1301,51 +1431,11 @@ func (a *App) showPreferences() {
 	})
 }
 
+// showKeyBindings открывает редактор горячих клавиш
 func (a *App) showKeyBindings() {
-	// Создаем диалог управления горячими клавишами
-	shortcuts := a.hotkeyManager.GetShortcuts()
-
-	// Группируем по категориям
-	categories := make(map[string][]*RegisteredShortcut)
-	for _, shortcut := range shortcuts {
-		categories[shortcut.Category] = append(categories[shortcut.Category], shortcut)
-	}
-
-	// Создаем вкладки для каждой категории
-	tabs := container.NewAppTabs()
-
-	for category, categoryShortcuts := range categories {
-		// Создаем список для категории
-		list := widget.NewList(
-			func() int { return len(categoryShortcuts) },
-			func() fyne.CanvasObject {
-				return container.NewHBox(
-					widget.NewLabel("Action"),
-					widget.NewLabel("Shortcut"),
-					widget.NewButton("Change", nil),
-				)
-			},
-			func(i widget.ListItemID, o fyne.CanvasObject) {
-				box := o.(*fyne.Container)
-				actionLabel := box.Objects[0].(*widget.Label)
-				shortcutLabel := box.Objects[1].(*widget.Label)
-				button := box.Objects[2].(*widget.Button)
-
-				shortcut := categoryShortcuts[i]
-				actionLabel.SetText(shortcut.Description)
-				shortcutLabel.SetText(shortcut.KeyBinding)
-
-				button.OnTapped = func() {
-					a.changeKeyBinding(shortcut)
-				}
-			},
-		)
-
-		tabs.Append(container.NewTabItem(category, list))
-	}
-
-	keyDialog := dialog.NewCustom("Key Bindings", "Close", tabs, a.mainWin)
-	keyDialog.Resize(fyne.NewSize(700, 500))
+	editor := a.newShortcutEditor()
+	keyDialog := dialog.NewCustom("Key Bindings", "Close", editor.Widget(), a.mainWin)
+	keyDialog.Resize(fyne.NewSize(820, 560))
 	keyDialog.Show()
 }
 
@@ -1353,102 +1443,6 @@ func (a *App) showAbout() {
 	a.dialogManager.ShowAboutDialog()
 }
 
-func (a *App) showFileSwitcher() {
-	if len(a.recentFiles) == 0 {
-		dialog.ShowInformation("File Switcher", "No recent files", a.mainWin)
-		return
-	}
-
-	searchEntry := widget.NewEntry()
-	searchEntry.SetPlaceHolder("Type to search...")
-
-	filteredFiles := append([]string{}, a.recentFiles...)
-	fileList := widget.NewList(
-		func() int { return len(filteredFiles) },
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewIcon(theme.DocumentIcon()),
-				widget.NewLabel("File"),
-			)
-		},
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			box := o.(*fyne.Container)
-			label := box.Objects[1].(*widget.Label)
-			label.SetText(filepath.Base(filteredFiles[i]))
-		},
-	)
-
-	searchEntry.OnChanged = func(text string) {
-		if text == "" {
-			filteredFiles = append([]string{}, a.recentFiles...)
-		} else {
-			matches := fuzzy.RankFindNormalizedFold(text, a.recentFiles)
-			sort.Sort(matches)
-			filteredFiles = filteredFiles[:0]
-			for _, m := range matches {
-				filteredFiles = append(filteredFiles, a.recentFiles[m.OriginalIndex])
-			}
-		}
-		fileList.Refresh()
-	}
-
-	var switcherDialog dialog.Dialog
-	fileList.OnSelected = func(id widget.ListItemID) {
-		if id >= 0 && id < len(filteredFiles) {
-			a.loadFile(filteredFiles[id])
-			if switcherDialog != nil {
-				switcherDialog.Hide()
-			}
-		}
-	}
-
-	content := container.NewBorder(searchEntry, nil, nil, nil, fileList)
-	switcherDialog = dialog.NewCustom("File Switcher", "Close", content, a.mainWin)
-	switcherDialog.Resize(fyne.NewSize(600, 400))
-	switcherDialog.Show()
-	AnimateShow(content)
-	a.mainWin.Canvas().Focus(searchEntry)
-}
-
-func (a *App) showRecentFiles() {
-	if len(a.recentFiles) == 0 {
-		dialog.ShowInformation("Recent Files", "No recent files", a.mainWin)
-		return
-	}
-
-	// Создаем список недавних файлов
-	fileList := widget.NewList(
-		func() int { return len(a.recentFiles) },
-		func() fyne.CanvasObject {
-			return container.NewHBox(
-				widget.NewIcon(theme.DocumentIcon()),
-				widget.NewLabel("File"),
-				widget.NewButton("Open", nil),
-			)
-		},
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			box := o.(*fyne.Container)
-			if len(box.Objects) >= 3 {
-				label, ok1 := box.Objects[1].(*widget.Label)
-				button, ok2 := box.Objects[2].(*widget.Button)
-
-				if ok1 && ok2 {
-					label.SetText(filepath.Base(a.recentFiles[i]))
-					button.OnTapped = func() {
-						recentFile := a.recentFiles[i]
-						a.loadFile(recentFile)
-					}
-				}
-			}
-		},
-	)
-
-	recentDialog := dialog.NewCustom("Recent Files", "Close",
-		container.NewScroll(fileList), a.mainWin)
-	recentDialog.Resize(fyne.NewSize(600, 400))
-	recentDialog.Show()
-}
-
 // Utility methods - Полная реализация
 
 func (a *App) getCurrentWorkingDir() string {
@@ -1466,6 +1460,9 @@ func (a *App) getCurrentWorkingDir() string {
 
 func (a *App) updateTitle() {
 	title := "Programmer's Notepad"
+	if a.workspace != nil {
+		title = fmt.Sprintf("%s - %s", a.workspace.Name(), title)
+	}
 	if a.currentFile != "" {
 		title = fmt.Sprintf("%s - %s", filepath.Base(a.currentFile), title)
 		if a.editor != nil && a.editor.IsDirty() {
@@ -1530,9 +1527,28 @@ func (a *App) applyConfigChanges() {
 		a.minimap.SetWidth(a.config.Minimap.Width)
 		a.minimap.Refresh()
 	}
+
+	// Перерегистрируем горячие клавиши: пресет или привязки могли измениться
+	if a.hotkeyManager != nil {
+		a.hotkeyManager.config = a.config
+		a.hotkeyManager.ReloadShortcuts()
+	}
 }
 
 func (a *App) checkAndExit() {
+	// При горячем выходе несохраненные изменения остаются в сессии
+	if a.hotExitEnabled() {
+		err := a.saveSession()
+		if err == nil {
+			a.cleanup()
+			a.fyneApp.Quit()
+			return
+		}
+		log.Printf("Failed to save session: %v", err)
+	} else {
+		discardSession()
+	}
+
 	if a.editor != nil && a.editor.IsDirty() {
 		dialog.ShowConfirm("Unsaved Changes",
 			"Do you want to save before exiting?",
@@ -1550,6 +1566,8 @@ func (a *App) checkAndExit() {
 }
 
 func (a *App) cleanup() {
+	a.saveWorkspaceState()
+
 	// Сохраняем позицию и размер окна
 	size := a.mainWin.Canvas().Size()
 	a.config.App.WindowWidth = int(size.Width)
@@ -1570,6 +1588,17 @@ func (a *App) cleanup() {
 		a.minimap.Cleanup()
 	}
 
+	if a.searchIndex != nil {
+		a.searchIndex.Close()
+	}
+
+	if a.fileIndex != nil {
+		a.fileIndex.Close()
+	}
+	for _, idx := range a.rootIndexes {
+		idx.Close()
+	}
+
 	if a.hotkeyManager != nil {
 		a.hotkeyManager.Cleanup()
 	}
@@ -1641,183 +1670,6 @@ func (a *App) deleteSelectedText() {
 	a.commandHistory.Execute(cmd, a.editor)
 }
 
-func (a *App) goToSearchResult(index int) {
-	if index < 0 || index >= len(a.searchResults) {
-		return
-	}
-
-	result := a.searchResults[index]
-	a.editor.cursorRow = result.Start.Row
-	a.editor.cursorCol = result.Start.Col
-
-	// Выделяем найденный текст
-	a.editor.selectionStart = result.Start
-	a.editor.selectionEnd = result.End
-
-	a.editor.updateDisplay()
-}
-
-func (a *App) replaceCurrentMatch(find, replace string) {
-	if a.editor == nil || len(a.searchResults) == 0 {
-		return
-	}
-
-	// Заменяем текущее совпадение
-	result := a.searchResults[a.currentSearchIndex]
-
-	// Создаем команду замены для конкретного места
-	lines := strings.Split(a.editor.textContent, "\n")
-	if result.Start.Row < len(lines) {
-		line := lines[result.Start.Row]
-		newLine := line[:result.Start.Col] + replace + line[result.End.Col:]
-		lines[result.Start.Row] = newLine
-
-		oldContent := a.editor.textContent
-		a.editor.textContent = strings.Join(lines, "\n")
-
-		// Добавляем в историю
-		cmd := &ReplaceTextCommand{
-			findText:    find,
-			replaceText: replace,
-			oldText:     oldContent,
-		}
-		cmd.oldText = oldContent
-		a.commandHistory.commands = append(a.commandHistory.commands, cmd)
-		a.commandHistory.currentIndex++
-
-		a.editor.updateDisplay()
-	}
-}
-
-func (a *App) performFindInFiles(searchText, path, include, exclude string) {
-	if path == "" {
-		path = "."
-	}
-
-	// Компилируем регулярное выражение
-	re, err := regexp.Compile(searchText)
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("invalid regex: %w", err), a.mainWin)
-		return
-	}
-
-	// Парсим паттерны включения и исключения
-	parsePatterns := func(p string) []string {
-		var patterns []string
-		for _, part := range strings.Split(p, ",") {
-			part = strings.TrimSpace(part)
-			if part != "" {
-				patterns = append(patterns, part)
-			}
-		}
-		return patterns
-	}
-
-	includePatterns := parsePatterns(include)
-	excludePatterns := parsePatterns(exclude)
-
-	matchesPath := func(p string) bool {
-		name := filepath.Base(p)
-		for _, pattern := range excludePatterns {
-			if ok, _ := filepath.Match(pattern, name); ok {
-				return false
-			}
-		}
-		if len(includePatterns) == 0 {
-			return true
-		}
-		for _, pattern := range includePatterns {
-			if ok, _ := filepath.Match(pattern, name); ok {
-				return true
-			}
-		}
-		return false
-	}
-
-	type fileResult struct {
-		File  string
-		Line  int
-		Col   int
-		Match string
-	}
-
-	var results []fileResult
-
-	// Обходим файлы в директории
-	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
-		if !matchesPath(p) {
-			return nil
-		}
-
-		file, err := os.Open(p)
-		if err != nil {
-			return nil
-		}
-		defer file.Close()
-
-		scanner := bufio.NewScanner(file)
-		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
-		lineNum := 1
-		for scanner.Scan() {
-			line := scanner.Text()
-			locs := re.FindAllStringIndex(line, -1)
-			for _, loc := range locs {
-				results = append(results, fileResult{
-					File:  p,
-					Line:  lineNum,
-					Col:   loc[0],
-					Match: line[loc[0]:loc[1]],
-				})
-			}
-			lineNum++
-		}
-		return nil
-	})
-
-	if len(results) == 0 {
-		dialog.ShowInformation("Find in Files", "No matches found", a.mainWin)
-		return
-	}
-
-	// Создаем список результатов
-	resultList := widget.NewList(
-		func() int { return len(results) },
-		func() fyne.CanvasObject {
-			return widget.NewLabel("")
-		},
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
-			r := results[i]
-			label.SetText(fmt.Sprintf("%s:%d:%d - %s", r.File, r.Line, r.Col+1, strings.TrimSpace(r.Match)))
-		},
-	)
-
-	resultList.OnSelected = func(id widget.ListItemID) {
-		if id < 0 || id >= len(results) {
-			return
-		}
-		r := results[id]
-		a.loadFile(r.File)
-		a.goToLine(r.Line)
-		if a.editor != nil {
-			a.editor.cursorCol = r.Col
-			a.editor.selectionStart = TextPosition{Row: r.Line - 1, Col: r.Col}
-			a.editor.selectionEnd = TextPosition{Row: r.Line - 1, Col: r.Col + len(r.Match)}
-			a.editor.updateDisplay()
-		}
-	}
-
-	dlg := dialog.NewCustom("Find in Files", "Close", container.NewScroll(resultList), a.mainWin)
-	dlg.Resize(fyne.NewSize(600, 400))
-	dlg.Show()
-}
-
 func (a *App) showLintResults(errors []CompilerError) {
 	// Создаем список ошибок
 	errorList := widget.NewList(
@@ -1913,6 +1765,13 @@ func (a *App) updateStatusBar(row, col int) {
 	if a.statusBar != nil && a.editor != nil {
 		totalLines := a.editor.getLineCount()
 		status := fmt.Sprintf("Line %d, Column %d | Total Lines: %d", row+1, col+1, totalLines)
+		if vh := a.editor.vimHandler; vh != nil {
+			status += " | " + vh.StatusText()
+		} else if a.hotkeyManager != nil && a.hotkeyManager.GetMode() == ModeEmacs {
+			if text := a.hotkeyManager.EmacsStatusText(); text != "" {
+				status += " | " + text
+			}
+		}
 		fyne.Do(func() {
 			a.statusBar.SetText(status)
 		})
@@ -1957,26 +1816,6 @@ func (a *App) scrollEditorTo(position float32) {
 	a.editor.scrollContainer.Refresh()
 }
 
-func (a *App) changeKeyBinding(shortcut *RegisteredShortcut) {
-	// Диалог изменения горячей клавиши
-	entry := widget.NewEntry()
-	entry.SetPlaceHolder("Press new key combination...")
-
-	dialog.ShowCustomConfirm("Change Key Binding", "Save", "Cancel",
-		container.NewVBox(
-			widget.NewLabel(shortcut.Description),
-			widget.NewLabel("Current: "+shortcut.KeyBinding),
-			entry,
-		), func(save bool) {
-			if save && entry.Text != "" {
-				err := a.hotkeyManager.UpdateKeyBinding(shortcut.ID, entry.Text)
-				if err != nil {
-					dialog.ShowError(err, a.mainWin)
-				}
-			}
-		}, a.mainWin)
-}
-
 // Helper functions
 
 func getLanguageByExtension(ext string) string {
@@ -0,0 +1,530 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Маркеры конфликтов в формате git (diff3)
+const (
+	conflictOursMarker   = "<<<<<<<"
+	conflictBaseMarker   = "|||||||"
+	conflictSepMarker    = "======="
+	conflictTheirsMarker = ">>>>>>>"
+)
+
+// mergeConflict - конфликт в тексте, строки [start, end) включают маркеры
+type mergeConflict struct {
+	start, end  int
+	ours        []string
+	base        []string
+	theirs      []string
+	hasBase     bool
+	oursLabel   string
+	baseLabel   string
+	theirsLabel string
+}
+
+// mergeChunk - замена строк базы [baseStart, baseEnd) на lines
+type mergeChunk struct {
+	baseStart int
+	baseEnd   int
+	lines     []string
+}
+
+// isConflictMarker проверяет, начинается ли строка с маркера конфликта
+func isConflictMarker(line, marker string) bool {
+	if !strings.HasPrefix(line, marker) {
+		return false
+	}
+	rest := line[len(marker):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r'
+}
+
+// markerLabel возвращает подпись после маркера
+func markerLabel(line, marker string) string {
+	return strings.TrimSpace(line[len(marker):])
+}
+
+// parseConflicts находит конфликты с маркерами в строках текста
+func parseConflicts(lines []string) []mergeConflict {
+	var conflicts []mergeConflict
+
+	for i := 0; i < len(lines); i++ {
+		if !isConflictMarker(lines[i], conflictOursMarker) {
+			continue
+		}
+
+		c := mergeConflict{start: i, oursLabel: markerLabel(lines[i], conflictOursMarker)}
+		section := &c.ours
+		closed := false
+
+		j := i + 1
+		for ; j < len(lines); j++ {
+			line := lines[j]
+			switch {
+			case isConflictMarker(line, conflictOursMarker):
+				// Вложенный маркер - считаем конфликт незакрытым
+				j = len(lines)
+			case isConflictMarker(line, conflictBaseMarker) && section == &c.ours:
+				c.hasBase = true
+				c.baseLabel = markerLabel(line, conflictBaseMarker)
+				section = &c.base
+			case isConflictMarker(line, conflictSepMarker) && section != &c.theirs:
+				section = &c.theirs
+			case isConflictMarker(line, conflictTheirsMarker) && section == &c.theirs:
+				c.theirsLabel = markerLabel(line, conflictTheirsMarker)
+				c.end = j + 1
+				closed = true
+			default:
+				*section = append(*section, line)
+			}
+			if closed || j >= len(lines) {
+				break
+			}
+		}
+
+		if !closed {
+			continue
+		}
+		conflicts = append(conflicts, c)
+		i = c.end - 1
+	}
+
+	return conflicts
+}
+
+// hasConflictMarkers возвращает true, если текст содержит конфликты слияния
+func hasConflictMarkers(text string) bool {
+	if !strings.Contains(text, conflictOursMarker) {
+		return false
+	}
+	lines, _ := splitDiffLines(text)
+	return len(parseConflicts(lines)) > 0
+}
+
+// diffChunks возвращает изменения other относительно base
+func diffChunks(base, other []string) []mergeChunk {
+	rows := computeDiffRows(base, other, false)
+
+	var chunks []mergeChunk
+	for _, h := range buildDiffHunks(rows) {
+		c := mergeChunk{baseStart: -1}
+		for i := h.start; i < h.end; i++ {
+			if rows[i].left >= 0 {
+				if c.baseStart < 0 {
+					c.baseStart = rows[i].left
+				}
+				c.baseEnd = rows[i].left + 1
+			}
+			if rows[i].right >= 0 {
+				c.lines = append(c.lines, other[rows[i].right])
+			}
+		}
+		if c.baseStart < 0 {
+			// Чистая вставка - позиция после предыдущей общей строки
+			c.baseStart = 0
+			if h.start > 0 {
+				c.baseStart = rows[h.start-1].left + 1
+			}
+			c.baseEnd = c.baseStart
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks
+}
+
+// applyChunks применяет изменения к участку базы [start, end)
+func applyChunks(base []string, start, end int, chunks []mergeChunk) []string {
+	var out []string
+	pos := start
+	for _, c := range chunks {
+		out = append(out, base[pos:c.baseStart]...)
+		out = append(out, c.lines...)
+		pos = c.baseEnd
+	}
+	return append(out, base[pos:end]...)
+}
+
+// equalLines сравнивает два набора строк
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// merge3 выполняет трехстороннее слияние, оставляя маркеры в местах конфликтов
+func merge3(base, ours, theirs []string, oursLabel, baseLabel, theirsLabel string) ([]string, int) {
+	oc := diffChunks(base, ours)
+	tc := diffChunks(base, theirs)
+
+	var result []string
+	conflicts := 0
+	pos, a, b := 0, 0, 0
+
+	for a < len(oc) || b < len(tc) {
+		// Начинаем группу с самого раннего изменения
+		start := len(base)
+		if a < len(oc) {
+			start = oc[a].baseStart
+		}
+		if b < len(tc) && tc[b].baseStart < start {
+			start = tc[b].baseStart
+		}
+		end := start
+
+		var ourGroup, theirGroup []mergeChunk
+		for changed := true; changed; {
+			changed = false
+			for a < len(oc) && (oc[a].baseStart < end || oc[a].baseStart == start) {
+				ourGroup = append(ourGroup, oc[a])
+				if oc[a].baseEnd > end {
+					end = oc[a].baseEnd
+				}
+				a++
+				changed = true
+			}
+			for b < len(tc) && (tc[b].baseStart < end || tc[b].baseStart == start) {
+				theirGroup = append(theirGroup, tc[b])
+				if tc[b].baseEnd > end {
+					end = tc[b].baseEnd
+				}
+				b++
+				changed = true
+			}
+		}
+
+		result = append(result, base[pos:start]...)
+		pos = end
+
+		oursRegion := applyChunks(base, start, end, ourGroup)
+		theirsRegion := applyChunks(base, start, end, theirGroup)
+
+		switch {
+		case len(theirGroup) == 0:
+			result = append(result, oursRegion...)
+		case len(ourGroup) == 0:
+			result = append(result, theirsRegion...)
+		case equalLines(oursRegion, theirsRegion):
+			result = append(result, oursRegion...)
+		default:
+			conflicts++
+			result = append(result, conflictOursMarker+" "+oursLabel)
+			result = append(result, oursRegion...)
+			result = append(result, conflictBaseMarker+" "+baseLabel)
+			result = append(result, base[start:end]...)
+			result = append(result, conflictSepMarker)
+			result = append(result, theirsRegion...)
+			result = append(result, conflictTheirsMarker+" "+theirsLabel)
+		}
+	}
+
+	result = append(result, base[pos:]...)
+	return result, conflicts
+}
+
+// MergeView - окно разрешения конфликтов слияния
+type MergeView struct {
+	app    *App
+	window fyne.Window
+	path   string
+
+	trailingNewline bool
+	conflicts       []mergeConflict
+	current         int
+
+	oursGrid    *widget.TextGrid
+	baseGrid    *widget.TextGrid
+	theirsGrid  *widget.TextGrid
+	oursLabel   *widget.Label
+	baseLabel   *widget.Label
+	theirsLabel *widget.Label
+	result      *widget.Entry
+	statusLabel *widget.Label
+	stageCheck  *widget.Check
+}
+
+// showMergeEditor открывает редактор слияния для текста с маркерами конфликтов
+func (a *App) showMergeEditor(path, text string, stage bool) {
+	v := &MergeView{app: a, path: path}
+	_, v.trailingNewline = splitDiffLines(text)
+
+	v.window = a.fyneApp.NewWindow("Merge: " + path)
+	v.window.SetContent(v.build(stage))
+	v.window.Resize(fyne.NewSize(1200, 800))
+
+	v.result.SetText(text)
+	v.reparse()
+	if len(v.conflicts) > 0 {
+		v.goToConflict(0)
+	}
+	v.window.Show()
+}
+
+// showExternalMerge сливает изменения буфера с изменениями файла на диске
+func (a *App) showExternalMerge(path, base, local, disk string) {
+	baseLines, _ := splitDiffLines(base)
+	localLines, _ := splitDiffLines(local)
+	diskLines, trailing := splitDiffLines(disk)
+
+	merged, conflicts := merge3(baseLines, localLines, diskLines, "Editor", "Last saved", "Disk")
+	text := strings.Join(merged, "\n")
+	if trailing && len(merged) > 0 {
+		text += "\n"
+	}
+
+	if conflicts == 0 {
+		dialog.ShowConfirm("Merge",
+			"Changes were merged without conflicts.\nReview the result before saving?",
+			func(review bool) {
+				if review {
+					a.showMergeEditor(path, text, false)
+					return
+				}
+				a.editor.SetContent(text)
+			}, a.mainWin)
+		return
+	}
+	a.showMergeEditor(path, text, false)
+}
+
+// resolveConflicts открывает редактор слияния для текущего файла
+func (a *App) resolveConflicts() {
+	if a.editor == nil || a.currentFile == "" {
+		dialog.ShowInformation("Resolve Conflicts", "Please open a file first", a.mainWin)
+		return
+	}
+	text := a.editor.GetFullText()
+	if !hasConflictMarkers(text) {
+		dialog.ShowInformation("Resolve Conflicts", "No merge conflicts found in this file", a.mainWin)
+		return
+	}
+	a.showMergeEditor(a.currentFile, text, true)
+}
+
+// offerConflictResolution предлагает открыть редактор слияния при наличии маркеров
+func (a *App) offerConflictResolution(path string) {
+	if a.editor == nil || !hasConflictMarkers(a.editor.GetFullText()) {
+		return
+	}
+	dialog.ShowConfirm("Merge Conflicts",
+		"This file contains merge conflict markers.\nOpen the merge editor?",
+		func(open bool) {
+			if open && a.currentFile == path {
+				a.showMergeEditor(path, a.editor.GetFullText(), true)
+			}
+		}, a.mainWin)
+}
+
+// build создает интерфейс редактора слияния
+func (v *MergeView) build(stage bool) fyne.CanvasObject {
+	newPane := func(title string) (*widget.Label, *widget.TextGrid, fyne.CanvasObject) {
+		label := widget.NewLabel(title)
+		label.TextStyle = fyne.TextStyle{Bold: true}
+		label.Truncation = fyne.TextTruncateEllipsis
+		grid := widget.NewTextGrid()
+		return label, grid, container.NewBorder(label, nil, nil, nil, container.NewScroll(grid))
+	}
+
+	var oursPane, basePane, theirsPane fyne.CanvasObject
+	v.oursLabel, v.oursGrid, oursPane = newPane("Ours")
+	v.baseLabel, v.baseGrid, basePane = newPane("Base")
+	v.theirsLabel, v.theirsGrid, theirsPane = newPane("Theirs")
+
+	v.result = widget.NewMultiLineEntry()
+	v.result.TextStyle = fyne.TextStyle{Monospace: true}
+	v.result.Wrapping = fyne.TextWrapOff
+	v.result.OnChanged = func(string) { v.reparse() }
+
+	resultLabel := widget.NewLabel("Result")
+	resultLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	v.statusLabel = widget.NewLabel("")
+	v.stageCheck = widget.NewCheck("Stage file (git add)", nil)
+	v.stageCheck.SetChecked(stage)
+
+	toolbar := container.NewHBox(
+		widget.NewButtonWithIcon("Previous", theme.MoveUpIcon(), func() { v.navigate(-1) }),
+		widget.NewButtonWithIcon("Next", theme.MoveDownIcon(), func() { v.navigate(1) }),
+		widget.NewSeparator(),
+		widget.NewButton("Accept Ours", func() { v.accept(func(c mergeConflict) []string { return c.ours }) }),
+		widget.NewButton("Accept Theirs", func() { v.accept(func(c mergeConflict) []string { return c.theirs }) }),
+		widget.NewButton("Accept Both", func() {
+			v.accept(func(c mergeConflict) []string { return append(append([]string{}, c.ours...), c.theirs...) })
+		}),
+		widget.NewSeparator(),
+		v.statusLabel,
+	)
+
+	bottom := container.NewHBox(
+		v.stageCheck,
+		widget.NewButton("Cancel", func() { v.window.Close() }),
+		widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), v.save),
+	)
+
+	sides := container.NewGridWithColumns(3, oursPane, basePane, theirsPane)
+	resultPane := container.NewBorder(resultLabel, nil, nil, nil, v.result)
+	split := container.NewVSplit(sides, resultPane)
+	split.SetOffset(0.4)
+
+	return container.NewBorder(toolbar, container.NewBorder(nil, nil, nil, bottom), nil, nil, split)
+}
+
+// reparse заново находит конфликты в результате
+func (v *MergeView) reparse() {
+	lines, _ := splitDiffLines(v.result.Text)
+	v.conflicts = parseConflicts(lines)
+
+	// Текущий конфликт - тот, в котором или после которого стоит курсор
+	v.current = -1
+	for i, c := range v.conflicts {
+		if v.result.CursorRow < c.end {
+			v.current = i
+			break
+		}
+	}
+	if v.current < 0 && len(v.conflicts) > 0 {
+		v.current = len(v.conflicts) - 1
+	}
+	v.showCurrent()
+}
+
+// showCurrent отображает стороны текущего конфликта
+func (v *MergeView) showCurrent() {
+	if v.current < 0 {
+		v.oursGrid.SetText("")
+		v.baseGrid.SetText("")
+		v.theirsGrid.SetText("")
+		v.oursLabel.SetText("Ours")
+		v.baseLabel.SetText("Base")
+		v.theirsLabel.SetText("Theirs")
+		v.statusLabel.SetText("All conflicts resolved")
+		return
+	}
+
+	c := v.conflicts[v.current]
+	v.oursGrid.SetText(strings.Join(c.ours, "\n"))
+	v.theirsGrid.SetText(strings.Join(c.theirs, "\n"))
+	if c.hasBase {
+		v.baseGrid.SetText(strings.Join(c.base, "\n"))
+	} else {
+		v.baseGrid.SetText("(base not available)")
+	}
+
+	withLabel := func(title, label string) string {
+		if label == "" {
+			return title
+		}
+		return title + ": " + label
+	}
+	v.oursLabel.SetText(withLabel("Ours", c.oursLabel))
+	v.baseLabel.SetText(withLabel("Base", c.baseLabel))
+	v.theirsLabel.SetText(withLabel("Theirs", c.theirsLabel))
+	v.statusLabel.SetText(fmt.Sprintf("Conflict %d of %d", v.current+1, len(v.conflicts)))
+}
+
+// navigate переходит к следующему или предыдущему конфликту
+func (v *MergeView) navigate(delta int) {
+	if len(v.conflicts) == 0 {
+		return
+	}
+	next := v.current + delta
+	if next < 0 {
+		next = len(v.conflicts) - 1
+	} else if next >= len(v.conflicts) {
+		next = 0
+	}
+	v.goToConflict(next)
+}
+
+// goToConflict перемещает курсор результата к конфликту
+func (v *MergeView) goToConflict(index int) {
+	v.current = index
+	v.result.CursorRow = v.conflicts[index].start
+	v.result.CursorColumn = 0
+	v.result.Refresh()
+	v.showCurrent()
+}
+
+// accept заменяет текущий конфликт выбранными строками
+func (v *MergeView) accept(choose func(mergeConflict) []string) {
+	if v.current < 0 || v.current >= len(v.conflicts) {
+		return
+	}
+	c := v.conflicts[v.current]
+	index := v.current
+
+	lines, trailing := splitDiffLines(v.result.Text)
+	resolved := make([]string, 0, len(lines))
+	resolved = append(resolved, lines[:c.start]...)
+	resolved = append(resolved, choose(c)...)
+	resolved = append(resolved, lines[c.end:]...)
+
+	text := strings.Join(resolved, "\n")
+	if trailing && len(resolved) > 0 {
+		text += "\n"
+	}
+	v.result.CursorRow = c.start
+	v.result.SetText(text)
+	v.reparse()
+
+	if len(v.conflicts) > 0 {
+		if index >= len(v.conflicts) {
+			index = 0
+		}
+		v.goToConflict(index)
+	}
+}
+
+// save записывает результат слияния
+func (v *MergeView) save() {
+	write := func() {
+		if err := os.WriteFile(v.path, []byte(v.result.Text), 0644); err != nil {
+			dialog.ShowError(err, v.window)
+			return
+		}
+
+		a := v.app
+		if v.stageCheck.Checked {
+			if err := a.gitClientFor(v.path).Stage(v.path); err != nil {
+				dialog.ShowError(err, v.window)
+				return
+			}
+		}
+
+		if a.editor != nil && a.editor.filePath == v.path {
+			if err := a.editor.LoadFile(v.path); err != nil {
+				dialog.ShowError(err, a.mainWin)
+			}
+		}
+		if a.sourceControl != nil {
+			a.sourceControl.Reload()
+		}
+		v.window.Close()
+	}
+
+	if len(v.conflicts) > 0 {
+		dialog.ShowConfirm("Unresolved Conflicts",
+			fmt.Sprintf("%d conflicts are still unresolved. Save anyway?", len(v.conflicts)),
+			func(ok bool) {
+				if ok {
+					write()
+				}
+			}, v.window)
+		return
+	}
+	write()
+}
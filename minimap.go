@@ -777,6 +777,7 @@ func (m *MinimapWidget) drawContent() {
 		return
 	}
 
+	marks := m.findMarks()
 	for i, line := range m.coloredLines {
 		y := float32(i) * m.lineHeight
 
@@ -786,9 +787,49 @@ func (m *MinimapWidget) drawContent() {
 		}
 
 		m.drawLine(line, 0, y)
+		m.drawFindMarks(marks[i], y)
 	}
 }
 
+// findMarks группирует вхождения панели поиска по строкам
+func (m *MinimapWidget) findMarks() map[int][]findMatchRect {
+	if m.editor == nil {
+		return nil
+	}
+	rects := m.editor.findMatchRects()
+	if len(rects) == 0 {
+		return nil
+	}
+	marks := make(map[int][]findMatchRect)
+	for _, r := range rects {
+		marks[r.Start.Row] = append(marks[r.Start.Row], r)
+	}
+	return marks
+}
+
+// drawFindMarks отмечает вхождения поиска в строке и на правом краю миниатюры
+func (m *MinimapWidget) drawFindMarks(rects []findMatchRect, y float32) {
+	if len(rects) == 0 {
+		return
+	}
+	matchColor, currentColor := findMatchColors()
+	edgeColor := matchColor
+	for _, r := range rects {
+		c := matchColor
+		if r.current {
+			c, edgeColor = currentColor, currentColor
+		}
+		rect := canvas.NewRectangle(c)
+		rect.Move(fyne.NewPos(float32(r.Start.Col)*m.charWidth, y))
+		rect.Resize(fyne.NewSize(float32(max(r.End.Col-r.Start.Col, 1))*m.charWidth, m.lineHeight))
+		m.canvas.Add(rect)
+	}
+	edge := canvas.NewRectangle(edgeColor)
+	edge.Move(fyne.NewPos(m.width-3, y))
+	edge.Resize(fyne.NewSize(3, max(m.lineHeight, 2)))
+	m.canvas.Add(edge)
+}
+
 // drawLine рисует отдельную строку
 func (m *MinimapWidget) drawLine(line *MinimapLine, x, y float32) {
 	if line == nil || len(line.Segments) == 0 {
@@ -0,0 +1,704 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// patchContextLines - количество строк контекста в unified diff
+const patchContextLines = 3
+
+// devNull - имя отсутствующего файла в unified diff
+const devNull = "/dev/null"
+
+const noNewlineMarker = `\ No newline at end of file`
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// PatchHunk - блок изменений unified diff
+type PatchHunk struct {
+	OldStart, OldCount int
+	NewStart, NewCount int
+	Header             string
+	Lines              []string // строки с префиксами ' ', '-', '+'
+	oldNoNewline       bool
+	newNoNewline       bool
+}
+
+// FilePatch - изменения одного файла
+type FilePatch struct {
+	OldPath string
+	NewPath string
+	Hunks   []PatchHunk
+}
+
+// TargetPath возвращает относительный путь изменяемого файла
+func (fp FilePatch) TargetPath() string {
+	if fp.NewPath != devNull {
+		return fp.NewPath
+	}
+	return fp.OldPath
+}
+
+// unifiedDiff формирует unified diff двух текстов
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	oldLines, oldTrailing := splitDiffLines(oldText)
+	newLines, newTrailing := splitDiffLines(newText)
+
+	rows := computeDiffRows(oldLines, newLines, false)
+	hunks := buildDiffHunks(rows)
+	if len(hunks) == 0 && oldTrailing == newTrailing {
+		return ""
+	}
+	if oldTrailing != newTrailing && (len(hunks) == 0 || hunks[len(hunks)-1].end < len(rows)) {
+		// Перевод строки в конце файла изменился, а последняя строка вне блоков
+		hunks = append(hunks, diffHunk{start: len(rows) - 1, end: len(rows)})
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	for i := 0; i < len(hunks); {
+		start := hunks[i].start - patchContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := hunks[i].end + patchContextLines
+		// Объединяем блоки с пересекающимся контекстом
+		for i++; i < len(hunks) && hunks[i].start-patchContextLines <= end; i++ {
+			end = hunks[i].end + patchContextLines
+		}
+		if end > len(rows) {
+			end = len(rows)
+		}
+		writeUnifiedHunk(&sb, rows[start:end], rows[start:], oldLines, newLines, oldTrailing, newTrailing)
+	}
+
+	return sb.String()
+}
+
+// writeUnifiedHunk записывает один блок unified diff
+func writeUnifiedHunk(sb *strings.Builder, rows, rest []diffRow, oldLines, newLines []string, oldTrailing, newTrailing bool) {
+	// Позиция блока - номер первой строки стороны (или предыдущей, если строк нет)
+	oldPos, newPos := len(oldLines), len(newLines)
+	for _, r := range rest {
+		if r.left >= 0 {
+			oldPos = r.left
+			break
+		}
+	}
+	for _, r := range rest {
+		if r.right >= 0 {
+			newPos = r.right
+			break
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, r := range rows {
+		if r.left >= 0 {
+			oldCount++
+		}
+		if r.right >= 0 {
+			newCount++
+		}
+	}
+	if oldCount > 0 {
+		oldPos++
+	}
+	if newCount > 0 {
+		newPos++
+	}
+	fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldPos, oldCount, newPos, newCount)
+
+	lastOld := func(idx int) bool { return !oldTrailing && idx == len(oldLines)-1 }
+	lastNew := func(idx int) bool { return !newTrailing && idx == len(newLines)-1 }
+	writeLine := func(prefix byte, line string, noNewline bool) {
+		sb.WriteByte(prefix)
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+		if noNewline {
+			sb.WriteString(noNewlineMarker + "\n")
+		}
+	}
+
+	for i := 0; i < len(rows); i++ {
+		r := rows[i]
+		// Последняя общая строка с разным переводом строки выводится как замена
+		if r.kind == diffRowEqual && lastOld(r.left) == lastNew(r.right) {
+			writeLine(' ', oldLines[r.left], lastOld(r.left))
+			continue
+		}
+
+		j := i
+		for j < len(rows) && (j == i || rows[j].kind != diffRowEqual) {
+			j++
+		}
+		for _, c := range rows[i:j] {
+			if c.left >= 0 {
+				writeLine('-', oldLines[c.left], lastOld(c.left))
+			}
+		}
+		for _, c := range rows[i:j] {
+			if c.right >= 0 {
+				writeLine('+', newLines[c.right], lastNew(c.right))
+			}
+		}
+		i = j - 1
+	}
+}
+
+// stripPatchPath убирает префиксы a/ и b/ и метку времени из пути в заголовке
+func stripPatchPath(path string) string {
+	if i := strings.IndexByte(path, '\t'); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimSpace(path)
+	if path == devNull {
+		return path
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// parsePatch разбирает unified diff, содержащий изменения одного или нескольких файлов
+func parsePatch(text string) ([]FilePatch, error) {
+	lines, _ := splitDiffLines(text)
+
+	var patches []FilePatch
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") || i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			continue
+		}
+
+		fp := FilePatch{
+			OldPath: stripPatchPath(lines[i][4:]),
+			NewPath: stripPatchPath(lines[i+1][4:]),
+		}
+		i += 2
+
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@") {
+			m := hunkHeaderRe.FindStringSubmatch(lines[i])
+			if m == nil {
+				return nil, fmt.Errorf("malformed hunk header at line %d", i+1)
+			}
+			h := PatchHunk{Header: lines[i], OldCount: 1, NewCount: 1}
+			h.OldStart, _ = strconv.Atoi(m[1])
+			h.NewStart, _ = strconv.Atoi(m[3])
+			if m[2] != "" {
+				h.OldCount, _ = strconv.Atoi(m[2])
+			}
+			if m[4] != "" {
+				h.NewCount, _ = strconv.Atoi(m[4])
+			}
+			i++
+
+			oldSeen, newSeen := 0, 0
+			for i < len(lines) && (oldSeen < h.OldCount || newSeen < h.NewCount || strings.HasPrefix(lines[i], `\`)) {
+				line := lines[i]
+				if line == "" {
+					line = " "
+				}
+				switch line[0] {
+				case ' ':
+					oldSeen++
+					newSeen++
+				case '-':
+					oldSeen++
+				case '+':
+					newSeen++
+				case '\\':
+					// Маркер относится к предыдущей строке
+					if n := len(h.Lines); n > 0 {
+						switch h.Lines[n-1][0] {
+						case '-':
+							h.oldNoNewline = true
+						case '+':
+							h.newNoNewline = true
+						default:
+							h.oldNoNewline, h.newNoNewline = true, true
+						}
+					}
+					i++
+					continue
+				default:
+					return nil, fmt.Errorf("unexpected line %d in hunk: %q", i+1, lines[i])
+				}
+				h.Lines = append(h.Lines, line)
+				i++
+			}
+			if oldSeen != h.OldCount || newSeen != h.NewCount {
+				return nil, fmt.Errorf("hunk %q in %s is truncated", h.Header, fp.TargetPath())
+			}
+			fp.Hunks = append(fp.Hunks, h)
+		}
+		i--
+
+		patches = append(patches, fp)
+	}
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file changes found in patch")
+	}
+	return patches, nil
+}
+
+// matchLinesAt проверяет совпадение строк начиная с позиции
+func matchLinesAt(lines, want []string, pos int) bool {
+	if pos < 0 || pos+len(want) > len(lines) {
+		return false
+	}
+	for i, w := range want {
+		if lines[pos+i] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPatchHunks применяет выбранные блоки к тексту
+func applyPatchHunks(text string, hunks []PatchHunk, accepted []bool) (string, error) {
+	lines, trailing := splitDiffLines(text)
+	if text == "" {
+		trailing = true
+	}
+
+	delta := 0
+	for n, h := range hunks {
+		if !accepted[n] {
+			continue
+		}
+
+		var oldPart, newPart []string
+		for _, l := range h.Lines {
+			switch l[0] {
+			case ' ':
+				oldPart = append(oldPart, l[1:])
+				newPart = append(newPart, l[1:])
+			case '-':
+				oldPart = append(oldPart, l[1:])
+			case '+':
+				newPart = append(newPart, l[1:])
+			}
+		}
+
+		expected := h.OldStart - 1 + delta
+		if h.OldCount == 0 {
+			expected = h.OldStart + delta
+		}
+
+		// Ищем совпадение контекста рядом с ожидаемой позицией
+		pos := -1
+		for offset := 0; offset <= len(lines); offset++ {
+			if matchLinesAt(lines, oldPart, expected-offset) {
+				pos = expected - offset
+				break
+			}
+			if offset > 0 && matchLinesAt(lines, oldPart, expected+offset) {
+				pos = expected + offset
+				break
+			}
+		}
+		if pos < 0 {
+			return "", fmt.Errorf("hunk %s does not apply", h.Header)
+		}
+
+		result := make([]string, 0, len(lines)-len(oldPart)+len(newPart))
+		result = append(result, lines[:pos]...)
+		result = append(result, newPart...)
+		result = append(result, lines[pos+len(oldPart):]...)
+
+		// Блок, затрагивающий конец файла, определяет перевод строки в конце
+		if pos+len(oldPart) == len(lines) {
+			trailing = !h.newNoNewline
+		}
+
+		lines = result
+		delta += len(newPart) - len(oldPart)
+	}
+
+	out := strings.Join(lines, "\n")
+	if trailing && len(lines) > 0 {
+		out += "\n"
+	}
+	return out, nil
+}
+
+// patchPathName возвращает имя стороны для заголовка unified diff
+func patchPathName(prefix string, side DiffSide) string {
+	name := side.Name
+	if side.Path != "" {
+		name = filepath.Base(side.Path)
+	}
+	return prefix + filepath.ToSlash(name)
+}
+
+// exportPatch сохраняет текущее сравнение как unified patch
+func (v *DiffView) exportPatch() {
+	patch := unifiedDiff(patchPathName("a/", v.left), patchPathName("b/", v.right), v.sideText(true), v.sideText(false))
+	if patch == "" {
+		dialog.ShowInformation("Export Patch", "There are no differences to export", v.window)
+		return
+	}
+	v.app.savePatchFile(patch, v.window)
+}
+
+// savePatchFile запрашивает путь и записывает patch
+func (a *App) savePatchFile(patch string, parent fyne.Window) {
+	a.dialogManager.ShowSaveFileDialogWithName("changes.patch", func(path string) {
+		if err := os.WriteFile(path, []byte(patch), 0644); err != nil {
+			dialog.ShowError(err, parent)
+		}
+	})
+}
+
+// applyPatchFile выбирает patch файл и открывает предпросмотр его применения
+func (a *App) applyPatchFile() {
+	a.dialogManager.ShowOpenFileDialogWithFilter([]string{".patch", ".diff"}, func(path string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			dialog.ShowError(err, a.mainWin)
+			return
+		}
+		patches, err := parsePatch(string(data))
+		if err != nil {
+			dialog.ShowError(err, a.mainWin)
+			return
+		}
+
+		root := filepath.Dir(path)
+		if a.sidebar != nil && a.sidebar.rootPath != "" {
+			root = a.sidebar.rootPath
+		}
+		a.showPatchPreview(filepath.Base(path), root, patches)
+	})
+}
+
+// patchHunkRef ссылается на блок в списке изменений
+type patchHunkRef struct {
+	file int
+	hunk int
+}
+
+// showPatchPreview показывает блоки patch с возможностью выбрать применяемые
+func (a *App) showPatchPreview(title, root string, patches []FilePatch) {
+	accepted := make([][]bool, len(patches))
+	var refs []patchHunkRef
+	for i, fp := range patches {
+		accepted[i] = make([]bool, len(fp.Hunks))
+		for j := range fp.Hunks {
+			accepted[i][j] = true
+			refs = append(refs, patchHunkRef{file: i, hunk: j})
+		}
+	}
+
+	win := a.fyneApp.NewWindow("Apply Patch: " + title)
+
+	preview := widget.NewTextGrid()
+	showHunk := func(ref patchHunkRef) {
+		h := patches[ref.file].Hunks[ref.hunk]
+		rows := make([]widget.TextGridRow, 0, len(h.Lines)+1)
+		rows = append(rows, widget.TextGridRow{
+			Cells: textGridCells(h.Header),
+			Style: &widget.CustomTextGridStyle{FGColor: diffGutterColor},
+		})
+		for _, l := range h.Lines {
+			row := widget.TextGridRow{Cells: textGridCells(l)}
+			switch l[0] {
+			case '-':
+				row.Style = &widget.CustomTextGridStyle{BGColor: diffDeleteLineColor}
+			case '+':
+				row.Style = &widget.CustomTextGridStyle{BGColor: diffInsertLineColor}
+			}
+			rows = append(rows, row)
+		}
+		preview.Rows = rows
+		preview.Refresh()
+	}
+
+	selected := -1
+	hunkList := widget.NewList(
+		func() int { return len(refs) },
+		func() fyne.CanvasObject { return widget.NewCheck("", nil) },
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			ref := refs[id]
+			fp := patches[ref.file]
+			check := o.(*widget.Check)
+			check.OnChanged = nil
+			check.Text = fmt.Sprintf("%s  %s", fp.TargetPath(), fp.Hunks[ref.hunk].Header)
+			check.SetChecked(accepted[ref.file][ref.hunk])
+			check.OnChanged = func(on bool) { accepted[ref.file][ref.hunk] = on }
+		},
+	)
+	hunkList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		showHunk(refs[id])
+	}
+
+	setAll := func(on bool) {
+		for i := range accepted {
+			for j := range accepted[i] {
+				accepted[i][j] = on
+			}
+		}
+		hunkList.Refresh()
+	}
+
+	resultDiff := func() {
+		if selected < 0 {
+			return
+		}
+		fp := patches[refs[selected].file]
+		original, patched, err := patchedFileContents(root, fp, accepted[refs[selected].file])
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		a.showDiffContents("Patch Result", fp.TargetPath()+" (current)", original, fp.TargetPath()+" (patched)", patched)
+	}
+
+	apply := func() {
+		if err := a.applyPatches(root, patches, accepted); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		win.Close()
+	}
+
+	rootLabel := widget.NewLabel("Target: " + root)
+	rootLabel.Truncation = fyne.TextTruncateEllipsis
+
+	toolbar := container.NewHBox(
+		widget.NewButton("Accept All", func() { setAll(true) }),
+		widget.NewButton("Reject All", func() { setAll(false) }),
+		widget.NewButtonWithIcon("Preview Result", theme.VisibilityIcon(), resultDiff),
+	)
+	bottom := container.NewBorder(nil, nil, nil,
+		container.NewHBox(
+			widget.NewButton("Cancel", func() { win.Close() }),
+			widget.NewButtonWithIcon("Apply", theme.ConfirmIcon(), apply),
+		),
+		rootLabel,
+	)
+
+	split := container.NewHSplit(hunkList, container.NewScroll(preview))
+	split.SetOffset(0.4)
+
+	win.SetContent(container.NewBorder(toolbar, bottom, nil, nil, split))
+	win.Resize(fyne.NewSize(1000, 650))
+	win.Show()
+
+	if len(refs) > 0 {
+		hunkList.Select(0)
+	}
+}
+
+// textGridCells преобразует строку в ячейки TextGrid
+func textGridCells(text string) []widget.TextGridCell {
+	runes := []rune(text)
+	cells := make([]widget.TextGridCell, len(runes))
+	for i, r := range runes {
+		cells[i] = widget.TextGridCell{Rune: r}
+	}
+	return cells
+}
+
+// patchedFileContents возвращает текущее и измененное содержимое файла
+func patchedFileContents(root string, fp FilePatch, accepted []bool) (string, string, error) {
+	var original string
+	if fp.OldPath != devNull {
+		path, err := patchFilePath(root, fp.OldPath)
+		if err != nil {
+			return "", "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", err
+		}
+		original = string(data)
+	}
+
+	patched, err := applyPatchHunks(original, fp.Hunks, accepted)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", fp.TargetPath(), err)
+	}
+	return original, patched, nil
+}
+
+// patchFilePath приводит путь из патча к абсолютному, отклоняя пути вне root
+func patchFilePath(root, rel string) (string, error) {
+	if filepath.IsAbs(rel) {
+		return "", fmt.Errorf("patch path %s is absolute", rel)
+	}
+	path := filepath.Join(root, rel)
+	r, err := filepath.Rel(root, path)
+	if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("patch path %s is outside %s", rel, root)
+	}
+	return path, nil
+}
+
+// patchWrite - запись одного файла патча: новое содержимое, удаление или переименование
+type patchWrite struct {
+	path    string
+	oldPath string
+	content string
+	remove  bool
+}
+
+// patchBackup - прежнее состояние файла для отката неудачного применения патча
+type patchBackup struct {
+	path    string
+	data    []byte
+	mode    os.FileMode
+	existed bool
+}
+
+// writePatchResults записывает файлы патча; если запись обрывается на середине,
+// уже затронутые файлы восстанавливаются в обратном порядке
+func writePatchResults(results []patchWrite) (err error) {
+	var backups []patchBackup
+	backup := func(path string) error {
+		info, statErr := os.Lstat(path)
+		if os.IsNotExist(statErr) {
+			backups = append(backups, patchBackup{path: path})
+			return nil
+		}
+		if statErr != nil {
+			return statErr
+		}
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		backups = append(backups, patchBackup{path: path, data: data, mode: info.Mode().Perm(), existed: true})
+		return nil
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := len(backups) - 1; i >= 0; i-- {
+			b := backups[i]
+			var restoreErr error
+			if b.existed {
+				restoreErr = os.WriteFile(b.path, b.data, b.mode)
+			} else if restoreErr = os.Remove(b.path); os.IsNotExist(restoreErr) {
+				restoreErr = nil
+			}
+			if restoreErr != nil {
+				log.Printf("Failed to restore %s: %v", b.path, restoreErr)
+			}
+		}
+	}()
+
+	for _, r := range results {
+		if err := backup(r.path); err != nil {
+			return err
+		}
+		if r.remove {
+			if err := os.Remove(r.path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(r.path, []byte(r.content), 0644); err != nil {
+			return err
+		}
+		if r.oldPath != "" {
+			if err := backup(r.oldPath); err != nil {
+				return err
+			}
+			if err := os.Remove(r.oldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyPatches применяет выбранные блоки; файлы записываются, только если все блоки применимы,
+// а при ошибке записи уже измененные файлы возвращаются к прежнему содержимому
+func (a *App) applyPatches(root string, patches []FilePatch, accepted [][]bool) error {
+	var results []patchWrite
+	for i, fp := range patches {
+		// Пути берутся из файла патча, поэтому не даем ему выйти за пределы корня
+		target, err := patchFilePath(root, fp.TargetPath())
+		if err != nil {
+			return err
+		}
+		oldPath := ""
+		if fp.OldPath != devNull {
+			if oldPath, err = patchFilePath(root, fp.OldPath); err != nil {
+				return err
+			}
+		}
+
+		all, some := true, false
+		for _, on := range accepted[i] {
+			all = all && on
+			some = some || on
+		}
+		if !some {
+			continue
+		}
+
+		_, patched, err := patchedFileContents(root, fp, accepted[i])
+		if err != nil {
+			return err
+		}
+
+		r := patchWrite{path: target, content: patched}
+		if fp.NewPath == devNull && all {
+			r.remove = true
+		}
+		if fp.OldPath != devNull && fp.NewPath != devNull && fp.OldPath != fp.NewPath {
+			r.oldPath = oldPath
+		}
+		// Новый или переименованный файл не должен затирать существующий
+		if fp.OldPath == devNull || r.oldPath != "" {
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("cannot create %s: file already exists", fp.TargetPath())
+			}
+		}
+		results = append(results, r)
+	}
+
+	if err := writePatchResults(results); err != nil {
+		return err
+	}
+
+	// Обновляем открытый файл, если он не содержит несохраненных изменений
+	for _, r := range results {
+		if a.editor != nil && a.editor.filePath == r.path && !r.remove && !a.editor.IsDirty() {
+			if err := a.editor.LoadFile(r.path); err != nil {
+				dialog.ShowError(err, a.mainWin)
+			}
+		}
+	}
+	if a.sidebar != nil {
+		a.sidebar.RefreshPath("")
+	}
+	if a.sourceControl != nil {
+		a.sourceControl.Reload()
+	}
+
+	dialog.ShowInformation("Apply Patch", fmt.Sprintf("Patched %d files", len(results)), a.mainWin)
+	return nil
+}
@@ -0,0 +1,799 @@
+package main
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	maxFileIndexSize   = 200000 // больше файлов не индексируем (например, корень - домашний каталог)
+	maxQuickOpenShown  = 200
+	quickOpenRecentMax = 20
+)
+
+// FileIndex - список файлов каталога для быстрого открытия, обновляемый в фоне
+type FileIndex struct {
+	root    string
+	mutex   sync.RWMutex
+	files   map[string]struct{} // пути относительно корня через "/"
+	ignore  *ignoreMatcher
+	ready   bool
+	monitor *DirectoryMonitor
+	closed  bool
+}
+
+// NewFileIndex создает пустой индекс каталога
+func NewFileIndex(root string) *FileIndex {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	return &FileIndex{root: abs, files: make(map[string]struct{})}
+}
+
+// Root возвращает корень индекса
+func (fi *FileIndex) Root() string {
+	return fi.root
+}
+
+// Ready сообщает, завершено ли построение индекса
+func (fi *FileIndex) Ready() bool {
+	fi.mutex.RLock()
+	defer fi.mutex.RUnlock()
+	return fi.ready
+}
+
+// Build обходит каталог с учетом .gitignore и .ignore
+func (fi *FileIndex) Build() {
+	ignore := newIgnoreMatcher(fi.root)
+	files := make(map[string]struct{})
+	filepath.WalkDir(fi.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if len(files) >= maxFileIndexSize {
+			return filepath.SkipAll
+		}
+		rel := fi.rel(p)
+		if d.IsDir() {
+			if rel != "." && (searchSkipDirs[d.Name()] || ignore.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			ignore.load(rel)
+			return nil
+		}
+		if d.Type().IsRegular() && !ignore.ignored(rel, false) {
+			files[rel] = struct{}{}
+		}
+		return nil
+	})
+
+	fi.mutex.Lock()
+	fi.files, fi.ignore, fi.ready = files, ignore, true
+	fi.mutex.Unlock()
+}
+
+// Watch подключает DirectoryMonitor для обновления списка файлов
+func (fi *FileIndex) Watch() error {
+	monitor, err := NewDirectoryMonitor(fi.root, true)
+	if err != nil {
+		return err
+	}
+	monitor.OnChange(func(path string, changeType FileEventType) {
+		fi.Update(path)
+	})
+	fi.mutex.Lock()
+	defer fi.mutex.Unlock()
+	if fi.closed {
+		monitor.Stop()
+		return nil
+	}
+	fi.monitor = monitor
+	return nil
+}
+
+// Close останавливает наблюдение за каталогом
+func (fi *FileIndex) Close() {
+	fi.mutex.Lock()
+	monitor := fi.monitor
+	fi.monitor, fi.closed = nil, true
+	fi.mutex.Unlock()
+	if monitor != nil {
+		monitor.Stop()
+	}
+}
+
+// Update обновляет список после события файловой системы
+func (fi *FileIndex) Update(p string) {
+	rel := fi.rel(p)
+	if rel == "." || strings.HasPrefix(rel, "../") {
+		return
+	}
+	if name := filepath.Base(p); name == ".gitignore" || name == ".ignore" {
+		go fi.Build()
+		return
+	}
+
+	fi.mutex.RLock()
+	ignore := fi.ignore
+	fi.mutex.RUnlock()
+	if ignore == nil || fi.excluded(ignore, rel) {
+		return
+	}
+
+	info, err := os.Stat(p)
+	switch {
+	case err != nil:
+		// Удален файл или каталог целиком
+		fi.mutex.Lock()
+		delete(fi.files, rel)
+		prefix := rel + "/"
+		for f := range fi.files {
+			if strings.HasPrefix(f, prefix) {
+				delete(fi.files, f)
+			}
+		}
+		fi.mutex.Unlock()
+	case info.IsDir():
+		added := make(map[string]struct{})
+		filepath.WalkDir(p, func(sub string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			subRel := fi.rel(sub)
+			if d.IsDir() {
+				if searchSkipDirs[d.Name()] || ignore.ignored(subRel, true) {
+					return filepath.SkipDir
+				}
+				ignore.load(subRel)
+				return nil
+			}
+			if d.Type().IsRegular() && !ignore.ignored(subRel, false) {
+				added[subRel] = struct{}{}
+			}
+			return nil
+		})
+		fi.mutex.Lock()
+		for f := range added {
+			fi.files[f] = struct{}{}
+		}
+		fi.mutex.Unlock()
+	case info.Mode().IsRegular() && !ignore.ignored(rel, false):
+		fi.mutex.Lock()
+		if len(fi.files) < maxFileIndexSize {
+			fi.files[rel] = struct{}{}
+		}
+		fi.mutex.Unlock()
+	}
+}
+
+// excluded проверяет служебные и игнорируемые каталоги на пути к файлу
+func (fi *FileIndex) excluded(ignore *ignoreMatcher, rel string) bool {
+	parts := strings.Split(rel, "/")
+	for i := range parts[:len(parts)-1] {
+		if searchSkipDirs[parts[i]] || ignore.ignored(strings.Join(parts[:i+1], "/"), true) {
+			return true
+		}
+	}
+	return searchSkipDirs[parts[len(parts)-1]]
+}
+
+// Paths возвращает абсолютные пути всех файлов индекса
+func (fi *FileIndex) Paths() []string {
+	fi.mutex.RLock()
+	defer fi.mutex.RUnlock()
+	paths := make([]string, 0, len(fi.files))
+	for f := range fi.files {
+		paths = append(paths, filepath.Join(fi.root, filepath.FromSlash(f)))
+	}
+	return paths
+}
+
+func (fi *FileIndex) rel(p string) string {
+	rel, err := filepath.Rel(fi.root, p)
+	if err != nil {
+		return "../"
+	}
+	return filepath.ToSlash(rel)
+}
+
+// openFileIndex строит в фоне индекс файлов для быстрого открытия. Корень
+// репозитория уже обходит и отслеживает индекс поиска, поэтому FileIndex
+// строится только для остальных каталогов; вызывается после openSearchIndex
+func (a *App) openFileIndex(root string) {
+	if root == "" {
+		return
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		abs = root
+	}
+	if a.fileIndex != nil {
+		if abs == a.fileIndex.Root() {
+			return
+		}
+		a.fileIndex.Close()
+		a.fileIndex = nil
+	}
+	if a.searchIndex != nil && a.searchIndex.Root() == abs {
+		return
+	}
+	idx := NewFileIndex(root)
+	a.fileIndex = idx
+	go func() {
+		idx.Build()
+		if err := idx.Watch(); err != nil {
+			log.Printf("Failed to watch %s for quick open: %v", root, err)
+		}
+	}()
+}
+
+// Оценка нечеткого совпадения в стиле fzf: очки за совпавшие символы,
+// бонусы за начало слова, camelCase и подряд идущие символы, штрафы за пропуски.
+const (
+	fuzzyScoreMatch        = 16
+	fuzzyScoreGapStart     = -3
+	fuzzyScoreGapExtension = -1
+	fuzzyBonusBoundary     = fuzzyScoreMatch / 2
+	fuzzyBonusDelimiter    = fuzzyBonusBoundary + 1
+	fuzzyBonusNonWord      = fuzzyScoreMatch / 2
+	fuzzyBonusCamel        = fuzzyBonusBoundary + fuzzyScoreGapExtension
+	fuzzyBonusConsecutive  = -(fuzzyScoreGapStart + fuzzyScoreGapExtension)
+	fuzzyBonusFirstChar    = 2
+	fuzzyBonusBaseName     = fuzzyScoreMatch * 2
+)
+
+type fuzzyCharClass int
+
+const (
+	fuzzyCharWhite fuzzyCharClass = iota
+	fuzzyCharDelimiter
+	fuzzyCharNonWord
+	fuzzyCharLower
+	fuzzyCharUpper
+	fuzzyCharNumber
+)
+
+func fuzzyClassOf(r rune) fuzzyCharClass {
+	switch {
+	case unicode.IsLower(r):
+		return fuzzyCharLower
+	case unicode.IsUpper(r):
+		return fuzzyCharUpper
+	case unicode.IsDigit(r):
+		return fuzzyCharNumber
+	case unicode.IsLetter(r):
+		return fuzzyCharLower
+	case unicode.IsSpace(r):
+		return fuzzyCharWhite
+	case r == '/' || r == '\\' || r == '_' || r == '-' || r == '.' || r == ':':
+		return fuzzyCharDelimiter
+	}
+	return fuzzyCharNonWord
+}
+
+func fuzzyBonus(prev, class fuzzyCharClass) int {
+	if class > fuzzyCharNonWord {
+		switch prev {
+		case fuzzyCharWhite:
+			return fuzzyBonusBoundary + 2
+		case fuzzyCharDelimiter:
+			return fuzzyBonusDelimiter
+		case fuzzyCharNonWord:
+			return fuzzyBonusBoundary
+		}
+	}
+	if prev == fuzzyCharLower && class == fuzzyCharUpper || prev != fuzzyCharNumber && class == fuzzyCharNumber {
+		return fuzzyBonusCamel
+	}
+	if class <= fuzzyCharNonWord {
+		return fuzzyBonusNonWord
+	}
+	return 0
+}
+
+// fuzzyScore сопоставляет шаблон с текстом как fzf (v1): находит самое позднее
+// начало совпадения, которое еще укладывается в кратчайшее окно, и считает очки.
+// Регистр учитывается, только если в шаблоне есть заглавные буквы.
+func fuzzyScore(pattern, text string) (score int, positions []int, ok bool) {
+	pat := []rune(pattern)
+	if len(pat) == 0 {
+		return 0, nil, true
+	}
+	caseSensitive := strings.ToLower(pattern) != pattern
+	runes := []rune(text)
+	fold := func(r rune) rune {
+		if caseSensitive {
+			return r
+		}
+		return unicode.ToLower(r)
+	}
+
+	// Вперед: конец первого полного совпадения
+	pi, end := 0, -1
+	for i, r := range runes {
+		if fold(r) == pat[pi] {
+			pi++
+			if pi == len(pat) {
+				end = i + 1
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	// Назад: самое позднее начало внутри найденного окна
+	start := 0
+	pi = len(pat) - 1
+	for i := end - 1; i >= 0; i-- {
+		if fold(runes[i]) == pat[pi] {
+			pi--
+			if pi < 0 {
+				start = i
+				break
+			}
+		}
+	}
+
+	prevClass := fuzzyCharDelimiter
+	if start > 0 {
+		prevClass = fuzzyClassOf(runes[start-1])
+	}
+	inGap, consecutive, firstBonus := false, 0, 0
+	pi = 0
+	for i := start; i < end; i++ {
+		r := runes[i]
+		class := fuzzyClassOf(r)
+		if pi < len(pat) && fold(r) == pat[pi] {
+			score += fuzzyScoreMatch
+			bonus := fuzzyBonus(prevClass, class)
+			if consecutive == 0 {
+				firstBonus = bonus
+			} else {
+				if bonus >= fuzzyBonusBoundary && bonus > firstBonus {
+					firstBonus = bonus
+				}
+				bonus = max(bonus, firstBonus, fuzzyBonusConsecutive)
+			}
+			if pi == 0 {
+				score += bonus * fuzzyBonusFirstChar
+			} else {
+				score += bonus
+			}
+			positions = append(positions, i)
+			inGap = false
+			consecutive++
+			pi++
+		} else {
+			if inGap {
+				score += fuzzyScoreGapExtension
+			} else {
+				score += fuzzyScoreGapStart
+			}
+			inGap, consecutive, firstBonus = true, 0, 0
+		}
+		prevClass = class
+	}
+	return score, positions, true
+}
+
+// fuzzyScorePath оценивает путь по словам запроса через пробел; все слова должны совпасть.
+// Совпадение целиком в имени файла получает бонус.
+func fuzzyScorePath(query []string, path string) (int, []int, bool) {
+	slash := strings.LastIndex(path, "/")
+	baseStart := len([]rune(path[:slash+1]))
+	total := 0
+	var positions []int
+	for _, term := range query {
+		score, pos, ok := fuzzyScore(term, path)
+		if !ok {
+			return 0, nil, false
+		}
+		// Сначала пробуем найти слово в имени файла
+		if baseScore, basePos, baseOK := fuzzyScore(term, path[slash+1:]); baseOK {
+			for i := range basePos {
+				basePos[i] += baseStart
+			}
+			baseScore += fuzzyBonusBaseName
+			if len(basePos) > 0 && basePos[0] == baseStart {
+				baseScore += fuzzyBonusBoundary // имя файла начинается с запроса
+			}
+			if baseScore >= score {
+				score, pos = baseScore, basePos
+			}
+		}
+		total += score
+		positions = append(positions, pos...)
+	}
+	sort.Ints(positions)
+	return total, positions, true
+}
+
+var quickOpenLocation = regexp.MustCompile(`^(.*?)(?::(\d+))?(?::(\d+))?:?$`)
+
+// parseQuickOpenQuery отделяет суффикс :строка:колонка от запроса
+func parseQuickOpenQuery(query string) (pattern string, line, col int) {
+	m := quickOpenLocation.FindStringSubmatch(strings.TrimSpace(query))
+	if m == nil {
+		return query, 0, 0
+	}
+	line, _ = strconv.Atoi(m[2])
+	col, _ = strconv.Atoi(m[3])
+	return m[1], line, col
+}
+
+// quickOpenItem - кандидат быстрого открытия
+type quickOpenItem struct {
+	path      string // абсолютный путь
+	name      string // путь для поиска и отображения
+	recent    int    // позиция в недавних файлах, -1 - не открывался
+	score     int
+	positions []int
+}
+
+// quickOpenEntry - поле ввода, передающее стрелки и Enter списку
+type quickOpenEntry struct {
+	widget.Entry
+	onKey func(key fyne.KeyName) bool
+}
+
+func newQuickOpenEntry() *quickOpenEntry {
+	e := &quickOpenEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *quickOpenEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.onKey != nil && e.onKey(key.Name) {
+		return
+	}
+	e.Entry.TypedKey(key)
+}
+
+// syncRootIndexes открывает индексы для дополнительных корней рабочей области
+// и закрывает индексы убранных; первый корень индексирует openFileIndex
+func (a *App) syncRootIndexes(roots []string) {
+	keep := make(map[string]bool)
+	if len(roots) > 1 {
+		for _, root := range roots[1:] {
+			keep[root] = true
+		}
+	}
+	for root, idx := range a.rootIndexes {
+		if !keep[root] {
+			idx.Close()
+			delete(a.rootIndexes, root)
+		}
+	}
+	for root := range keep {
+		if _, ok := a.rootIndexes[root]; ok {
+			continue
+		}
+		if a.rootIndexes == nil {
+			a.rootIndexes = make(map[string]*FileIndex)
+		}
+		idx := NewFileIndex(root)
+		a.rootIndexes[root] = idx
+		go func() {
+			idx.Build()
+			if err := idx.Watch(); err != nil {
+				log.Printf("Failed to watch %s for quick open: %v", root, err)
+			}
+		}()
+	}
+}
+
+// quickOpenIndex - источник файлов быстрого открытия: индекс поиска или FileIndex
+type quickOpenIndex interface {
+	Root() string
+	Ready() bool
+	Paths() []string
+}
+
+// fileIndexes возвращает индексы всех корней рабочей области; для корня
+// репозитория используется индекс поиска
+func (a *App) fileIndexes() []quickOpenIndex {
+	var indexes []quickOpenIndex
+	if a.searchIndex != nil {
+		indexes = append(indexes, a.searchIndex)
+	} else if a.fileIndex != nil {
+		indexes = append(indexes, a.fileIndex)
+	}
+	if a.sidebar != nil {
+		for _, root := range a.sidebar.Roots() {
+			if idx, ok := a.rootIndexes[root]; ok {
+				indexes = append(indexes, idx)
+			}
+		}
+	}
+	return indexes
+}
+
+// fileIndexesReady сообщает, достроены ли все индексы
+func (a *App) fileIndexesReady() bool {
+	for _, idx := range a.fileIndexes() {
+		if !idx.Ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// quickOpenCandidates собирает недавние файлы и файлы индекса без повторов
+func (a *App) quickOpenCandidates(recentOnly bool) []quickOpenItem {
+	indexes := a.fileIndexes()
+	// Файлы дополнительных корней показываются с именем корня впереди
+	prefix := func(i int, idx quickOpenIndex) string {
+		if i == 0 {
+			return ""
+		}
+		return filepath.Base(idx.Root()) + "/"
+	}
+	display := func(path string) string {
+		for i, idx := range indexes {
+			if rel, err := filepath.Rel(idx.Root(), path); err == nil && !strings.HasPrefix(rel, "..") {
+				return prefix(i, idx) + filepath.ToSlash(rel)
+			}
+		}
+		return filepath.ToSlash(path)
+	}
+
+	seen := make(map[string]bool)
+	var items []quickOpenItem
+	for i, f := range a.recentFiles {
+		abs, err := filepath.Abs(f)
+		if err != nil || seen[abs] {
+			continue
+		}
+		seen[abs] = true
+		items = append(items, quickOpenItem{path: abs, name: display(abs), recent: i})
+	}
+	if recentOnly {
+		return items
+	}
+	for i, idx := range indexes {
+		root := idx.Root()
+		for _, abs := range idx.Paths() {
+			if seen[abs] {
+				continue
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				continue
+			}
+			items = append(items, quickOpenItem{path: abs, name: prefix(i, idx) + filepath.ToSlash(rel), recent: -1})
+		}
+	}
+	return items
+}
+
+// rankQuickOpen отбирает и сортирует кандидатов по запросу; недавние файлы поднимаются выше.
+// Без запроса показываются недавние файлы, затем остальные по алфавиту.
+func rankQuickOpen(items []quickOpenItem, pattern string) []quickOpenItem {
+	terms := strings.Fields(pattern)
+	var ranked []quickOpenItem
+	for _, item := range items {
+		if len(terms) == 0 {
+			item.score, item.positions = 0, nil
+			if item.recent >= 0 {
+				item.score = len(items) - item.recent
+			}
+			ranked = append(ranked, item)
+			continue
+		}
+		score, positions, ok := fuzzyScorePath(terms, item.name)
+		if !ok {
+			continue
+		}
+		if item.recent >= 0 && item.recent < quickOpenRecentMax {
+			score += (quickOpenRecentMax - item.recent) * 2
+		}
+		item.score, item.positions = score, positions
+		ranked = append(ranked, item)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		if len(terms) > 0 && len(ranked[i].name) != len(ranked[j].name) {
+			return len(ranked[i].name) < len(ranked[j].name)
+		}
+		return ranked[i].name < ranked[j].name
+	})
+	if len(ranked) > maxQuickOpenShown {
+		ranked = ranked[:maxQuickOpenShown]
+	}
+	return ranked
+}
+
+// quickOpenSegments строит текст с выделенными совпавшими символами
+func quickOpenSegments(text string, positions []int, offset int, colorName fyne.ThemeColorName) []widget.RichTextSegment {
+	matched := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		matched[p-offset] = true
+	}
+	var segments []widget.RichTextSegment
+	var run []rune
+	runMatched := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		style := widget.RichTextStyle{Inline: true, ColorName: colorName}
+		if runMatched {
+			style.ColorName = theme.ColorNamePrimary
+			style.TextStyle = fyne.TextStyle{Bold: true}
+		}
+		segments = append(segments, &widget.TextSegment{Text: string(run), Style: style})
+		run = nil
+	}
+	for i, r := range []rune(text) {
+		if matched[i] != runMatched {
+			flush()
+			runMatched = matched[i]
+		}
+		run = append(run, r)
+	}
+	flush()
+	return segments
+}
+
+// showQuickOpen открывает быстрый переход к файлу рабочего каталога
+func (a *App) showQuickOpen() {
+	a.openQuickOpen("Quick Open", false)
+}
+
+// showRecentFiles открывает быстрый переход по недавним файлам
+func (a *App) showRecentFiles() {
+	a.openQuickOpen("Recent Files", true)
+}
+
+func (a *App) openQuickOpen(title string, recentOnly bool) {
+	items := a.quickOpenCandidates(recentOnly)
+	if len(items) == 0 && (recentOnly || len(a.fileIndexes()) == 0) {
+		dialog.ShowInformation(title, "No recent files", a.mainWin)
+		return
+	}
+
+	var shown []quickOpenItem
+	selected := 0
+	line, col := 0, 0
+	status := widget.NewLabel("")
+	status.TextStyle.Italic = true
+
+	list := widget.NewList(
+		func() int { return len(shown) },
+		func() fyne.CanvasObject {
+			name := widget.NewRichText()
+			dir := widget.NewRichText()
+			dir.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, container.NewHBox(widget.NewIcon(theme.DocumentIcon()), name), nil, dir)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			box := o.(*fyne.Container)
+			dir := box.Objects[0].(*widget.RichText)
+			name := box.Objects[1].(*fyne.Container).Objects[1].(*widget.RichText)
+			item := shown[i]
+			slash := strings.LastIndex(item.name, "/")
+			base, parent := item.name[slash+1:], item.name[:slash+1]
+			name.Segments = quickOpenSegments(base, item.positions, len([]rune(parent)), theme.ColorNameForeground)
+			name.Refresh()
+			dir.Segments = quickOpenSegments(strings.TrimSuffix(parent, "/"), item.positions, 0, theme.ColorNamePlaceHolder)
+			dir.Refresh()
+		},
+	)
+
+	var quickDialog dialog.Dialog
+	open := func(i int) {
+		if i < 0 || i >= len(shown) {
+			return
+		}
+		quickDialog.Hide()
+		if line > 0 {
+			a.openSearchMatch(shown[i].path, line, max(col-1, 0), 0)
+		} else if abs, err := filepath.Abs(a.currentFile); err != nil || abs != shown[i].path {
+			a.loadFile(shown[i].path)
+		}
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		selected = id
+		open(id)
+	}
+
+	entry := newQuickOpenEntry()
+	entry.SetPlaceHolder("Search files by name (append :line:column to go to a location)")
+	generation := 0
+	update := func(text string) {
+		var pattern string
+		pattern, line, col = parseQuickOpenQuery(text)
+		generation++
+		current := generation
+		candidates := items
+		go func() {
+			ranked := rankQuickOpen(candidates, pattern)
+			fyne.Do(func() {
+				if current != generation {
+					return
+				}
+				shown, selected = ranked, 0
+				list.UnselectAll()
+				list.Refresh()
+				list.ScrollToTop()
+				switch {
+				case !recentOnly && !a.fileIndexesReady():
+					status.SetText("Indexing files...")
+				case len(shown) == 0:
+					status.SetText("No matching files")
+				default:
+					status.SetText("")
+				}
+			})
+		}()
+	}
+	entry.OnChanged = update
+	entry.onKey = func(key fyne.KeyName) bool {
+		switch key {
+		case fyne.KeyDown, fyne.KeyUp:
+			if len(shown) == 0 {
+				return true
+			}
+			if key == fyne.KeyDown {
+				selected = min(selected+1, len(shown)-1)
+			} else {
+				selected = max(selected-1, 0)
+			}
+			// Выделяем без открытия файла
+			onSelected := list.OnSelected
+			list.OnSelected = nil
+			list.Select(selected)
+			list.OnSelected = onSelected
+			return true
+		case fyne.KeyReturn, fyne.KeyEnter:
+			open(selected)
+			return true
+		case fyne.KeyEscape:
+			quickDialog.Hide()
+			return true
+		}
+		return false
+	}
+
+	content := container.NewBorder(entry, status, nil, nil, list)
+	quickDialog = dialog.NewCustom(title, "Close", content, a.mainWin)
+	quickDialog.Resize(fyne.NewSize(700, 450))
+	quickDialog.Show()
+	AnimateShow(content)
+	a.mainWin.Canvas().Focus(entry)
+	update("")
+
+	// Индекс еще строится - обновляем список, когда он будет готов
+	if indexes := a.fileIndexes(); !recentOnly && !a.fileIndexesReady() {
+		go func() {
+			for _, idx := range indexes {
+				for !idx.Ready() {
+					time.Sleep(200 * time.Millisecond)
+				}
+			}
+			fyne.Do(func() {
+				items = a.quickOpenCandidates(false)
+				update(entry.Text)
+			})
+		}()
+	}
+}
@@ -0,0 +1,483 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// replaceEdit - одна замена в файле
+type replaceEdit struct {
+	match       SearchMatch
+	replacement string
+	enabled     bool
+}
+
+// replaceFile - замены в одном файле
+type replaceFile struct {
+	path  string
+	edits []*replaceEdit
+}
+
+// enabledEdits возвращает выбранные замены
+func (f *replaceFile) enabledEdits() []*replaceEdit {
+	var edits []*replaceEdit
+	for _, e := range f.edits {
+		if e.enabled {
+			edits = append(edits, e)
+		}
+	}
+	return edits
+}
+
+// searchReplacement вычисляет замену вхождения; в режиме regex раскрываются $1, ${name}
+func searchReplacement(re *regexp.Regexp, m SearchMatch, template string, regexMode bool) string {
+	if !regexMode {
+		return template
+	}
+	for _, loc := range re.FindAllStringSubmatchIndex(m.Text, -1) {
+		if loc[0] == m.Col {
+			return string(re.ExpandString(nil, template, m.Text, loc))
+		}
+	}
+	return template
+}
+
+// buildReplacePlan строит список замен по результатам поиска
+func buildReplacePlan(results []SearchFileResult, re *regexp.Regexp, template string, regexMode bool) []*replaceFile {
+	var plan []*replaceFile
+	for _, r := range results {
+		f := &replaceFile{path: r.Path}
+		for _, m := range r.Matches {
+			f.edits = append(f.edits, &replaceEdit{
+				match:       m,
+				replacement: searchReplacement(re, m, template, regexMode),
+				enabled:     true,
+			})
+		}
+		plan = append(plan, f)
+	}
+	return plan
+}
+
+// applyReplaceEdits применяет замены к тексту; если текст изменился после поиска - ошибка
+func applyReplaceEdits(content string, edits []*replaceEdit) (string, error) {
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	sorted := append([]*replaceEdit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i].match, sorted[j].match
+		return a.Line > b.Line || (a.Line == b.Line && a.Col > b.Col)
+	})
+
+	result := content
+	for _, e := range sorted {
+		m := e.match
+		if m.Line-1 >= len(lineStarts) {
+			return "", fmt.Errorf("line %d no longer exists", m.Line)
+		}
+		start := lineStarts[m.Line-1] + m.Col
+		end := start + m.Length
+		if end > len(result) || result[start:end] != m.Text[m.Col:m.Col+m.Length] {
+			return "", fmt.Errorf("line %d has changed since the search", m.Line)
+		}
+		result = result[:start] + e.replacement + result[end:]
+	}
+	return result, nil
+}
+
+// fileChange - новое содержимое файла на диске
+type fileChange struct {
+	path       string
+	oldContent string
+	newContent string
+}
+
+// writeFilesAtomically записывает файлы: сначала во временные рядом с исходными, затем
+// переименовывает; при ошибке уже записанные файлы возвращаются к прежнему содержимому
+func writeFilesAtomically(changes []fileChange, undo bool) error {
+	type pending struct {
+		change fileChange
+		temp   string
+	}
+	var temps []pending
+	cleanup := func() {
+		for _, p := range temps {
+			os.Remove(p.temp)
+		}
+	}
+
+	for _, c := range changes {
+		expected, content := c.oldContent, c.newContent
+		if undo {
+			expected, content = c.newContent, c.oldContent
+		}
+		data, err := os.ReadFile(c.path)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		if string(data) != expected {
+			cleanup()
+			return fmt.Errorf("%s was modified outside the editor", c.path)
+		}
+		info, err := os.Stat(c.path)
+		if err != nil {
+			cleanup()
+			return err
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(c.path), "."+filepath.Base(c.path)+".*.tmp")
+		if err != nil {
+			cleanup()
+			return err
+		}
+		temps = append(temps, pending{change: c, temp: tmp.Name()})
+		_, err = tmp.WriteString(content)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), info.Mode().Perm())
+		}
+		if err != nil {
+			cleanup()
+			return err
+		}
+	}
+
+	for i, p := range temps {
+		if err := os.Rename(p.temp, p.change.path); err != nil {
+			// Откатываем переименованные файлы
+			for _, done := range temps[:i] {
+				previous := done.change.oldContent
+				if undo {
+					previous = done.change.newContent
+				}
+				if rbErr := os.WriteFile(done.change.path, []byte(previous), 0644); rbErr != nil {
+					err = fmt.Errorf("%v; rollback of %s failed: %v", err, done.change.path, rbErr)
+				}
+			}
+			for _, rest := range temps[i:] {
+				os.Remove(rest.temp)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceInFilesCommand - замена в файлах проекта, отменяемая одним шагом.
+// Файлы на диске переписываются атомарно, открытый файл меняется в редакторе.
+type ReplaceInFilesCommand struct {
+	disk       []fileChange
+	bufferPath string
+	bufferOld  string
+	bufferNew  string
+	count      int
+}
+
+func (c *ReplaceInFilesCommand) Execute(editor *EditorWidget) error {
+	return c.apply(editor, false)
+}
+
+func (c *ReplaceInFilesCommand) Undo(editor *EditorWidget) error {
+	return c.apply(editor, true)
+}
+
+// apply переписывает файлы и открытый буфер; если буфер изменился после
+// замены, ничего не меняется и возвращается ошибка, чтобы команда осталась в истории
+func (c *ReplaceInFilesCommand) apply(editor *EditorWidget, undo bool) error {
+	from, to := c.bufferOld, c.bufferNew
+	if undo {
+		from, to = to, from
+	}
+
+	changes := c.disk
+	open := false
+	if c.bufferPath != "" {
+		if editor != nil {
+			if abs, err := filepath.Abs(editor.filePath); err == nil && abs == c.bufferPath {
+				open = true
+			}
+		}
+		if open && editor.textContent != from {
+			return fmt.Errorf("%s has been edited since the replace", filepath.Base(c.bufferPath))
+		}
+		// Если в редакторе уже другой файл, он меняется на диске вместе с остальными
+		if !open {
+			changes = append(append([]fileChange(nil), c.disk...),
+				fileChange{path: c.bufferPath, oldContent: c.bufferOld, newContent: c.bufferNew})
+		}
+	}
+
+	if err := writeFilesAtomically(changes, undo); err != nil {
+		return err
+	}
+	if open {
+		editor.textContent = to
+		editor.isDirty = true
+		editor.updateDisplay()
+	}
+	return nil
+}
+
+func (c *ReplaceInFilesCommand) GetDescription() string {
+	files := len(c.disk)
+	if c.bufferPath != "" {
+		files++
+	}
+	return fmt.Sprintf("Replace %d occurrences in %d files", c.count, files)
+}
+
+// replaceSource возвращает текущее содержимое файла: текст редактора, если файл открыт
+func (a *App) replaceSource(path string) (content string, open bool, err error) {
+	if a.editor != nil && a.editor.filePath != "" {
+		if abs, absErr := filepath.Abs(a.editor.filePath); absErr == nil && abs == path {
+			return a.editor.GetContent(), true, nil
+		}
+	}
+	data, err := os.ReadFile(path)
+	return string(data), false, err
+}
+
+// showReplacePreview показывает замены по файлам с выбором и diff перед применением
+func (a *App) showReplacePreview(root string, results []SearchFileResult, re *regexp.Regexp, template string, regexMode bool) {
+	plan := buildReplacePlan(results, re, template, regexMode)
+	if len(plan) == 0 {
+		dialog.ShowInformation("Replace in Files", "Nothing to replace", a.mainWin)
+		return
+	}
+	byPath := make(map[string]*replaceFile)
+	for _, f := range plan {
+		byPath[f.path] = f
+	}
+	rel := func(path string) string {
+		if r, err := filepath.Rel(root, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return path
+	}
+
+	win := a.fyneApp.NewWindow("Replace in Files")
+	preview := widget.NewTextGrid()
+	summary := widget.NewLabel("")
+
+	showFile := func(f *replaceFile) {
+		original, _, err := a.replaceSource(f.path)
+		var diff string
+		if err == nil {
+			var replaced string
+			replaced, err = applyReplaceEdits(original, f.enabledEdits())
+			diff = unifiedDiff(rel(f.path), rel(f.path), original, replaced)
+		}
+		if err != nil {
+			diff = err.Error()
+		}
+		var rows []widget.TextGridRow
+		for _, l := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+			row := widget.TextGridRow{Cells: textGridCells(l)}
+			switch {
+			case strings.HasPrefix(l, "@@"):
+				row.Style = &widget.CustomTextGridStyle{FGColor: diffGutterColor}
+			case strings.HasPrefix(l, "-") && !strings.HasPrefix(l, "---"):
+				row.Style = &widget.CustomTextGridStyle{BGColor: diffDeleteLineColor}
+			case strings.HasPrefix(l, "+") && !strings.HasPrefix(l, "+++"):
+				row.Style = &widget.CustomTextGridStyle{BGColor: diffInsertLineColor}
+			}
+			rows = append(rows, row)
+		}
+		preview.Rows = rows
+		preview.Refresh()
+	}
+	updateSummary := func() {
+		files, count := 0, 0
+		for _, f := range plan {
+			if n := len(f.enabledEdits()); n > 0 {
+				files++
+				count += n
+			}
+		}
+		summary.SetText(fmt.Sprintf("%d replacements in %d files", count, files))
+	}
+
+	// Узлы дерева: файл - путь, замена - путь + "\n" + индекс
+	var tree *widget.Tree
+	tree = widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			if uid == "" {
+				ids := make([]widget.TreeNodeID, len(plan))
+				for i, f := range plan {
+					ids[i] = f.path
+				}
+				return ids
+			}
+			f := byPath[uid]
+			if f == nil {
+				return nil
+			}
+			ids := make([]widget.TreeNodeID, len(f.edits))
+			for i := range f.edits {
+				ids[i] = uid + "\n" + strconv.Itoa(i)
+			}
+			return ids
+		},
+		func(uid widget.TreeNodeID) bool {
+			return uid == "" || !strings.Contains(uid, "\n")
+		},
+		func(bool) fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), nil, label)
+		},
+		func(uid widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			box := o.(*fyne.Container)
+			label := box.Objects[0].(*widget.Label)
+			check := box.Objects[1].(*widget.Check)
+			check.OnChanged = nil
+
+			if branch {
+				f := byPath[uid]
+				label.TextStyle.Bold = true
+				label.SetText(fmt.Sprintf("%s (%d)", rel(f.path), len(f.edits)))
+				check.SetChecked(len(f.enabledEdits()) > 0)
+				check.OnChanged = func(on bool) {
+					for _, e := range f.edits {
+						e.enabled = on
+					}
+					tree.Refresh()
+					showFile(f)
+					updateSummary()
+				}
+				return
+			}
+
+			sep := strings.LastIndex(uid, "\n")
+			f := byPath[uid[:sep]]
+			i, _ := strconv.Atoi(uid[sep+1:])
+			e := f.edits[i]
+			m := e.match
+			label.TextStyle.Bold = false
+			label.SetText(fmt.Sprintf("%d: %s[%s → %s]%s", m.Line, strings.TrimLeft(m.Text[:m.Col], " \t"),
+				m.Text[m.Col:m.Col+m.Length], e.replacement, m.Text[m.Col+m.Length:]))
+			check.SetChecked(e.enabled)
+			check.OnChanged = func(on bool) {
+				e.enabled = on
+				tree.RefreshItem(f.path)
+				showFile(f)
+				updateSummary()
+			}
+		},
+	)
+	tree.OnSelected = func(uid widget.TreeNodeID) {
+		if sep := strings.LastIndex(uid, "\n"); sep >= 0 {
+			uid = uid[:sep]
+		}
+		if f := byPath[uid]; f != nil {
+			showFile(f)
+		}
+	}
+
+	setAll := func(on bool) {
+		for _, f := range plan {
+			for _, e := range f.edits {
+				e.enabled = on
+			}
+		}
+		tree.Refresh()
+		updateSummary()
+	}
+
+	apply := func() {
+		cmd, err := a.buildReplaceCommand(plan)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if cmd.count == 0 {
+			win.Close()
+			return
+		}
+		if err := a.commandHistory.Execute(cmd, a.editor); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		win.Close()
+		if a.sidebar != nil {
+			a.sidebar.RefreshPath("")
+		}
+		if a.sourceControl != nil {
+			a.sourceControl.Reload()
+		}
+		if a.searchPanel != nil {
+			a.searchPanel.Search()
+		}
+		dialog.ShowInformation("Replace in Files", cmd.GetDescription()+". Use Undo to revert.", a.mainWin)
+	}
+
+	toolbar := container.NewHBox(
+		widget.NewButton("Accept All", func() { setAll(true) }),
+		widget.NewButton("Reject All", func() { setAll(false) }),
+	)
+	bottom := container.NewBorder(nil, nil, nil,
+		container.NewHBox(
+			widget.NewButton("Cancel", func() { win.Close() }),
+			widget.NewButtonWithIcon("Replace", theme.ConfirmIcon(), apply),
+		),
+		summary,
+	)
+
+	split := container.NewHSplit(tree, container.NewScroll(preview))
+	split.SetOffset(0.45)
+
+	win.SetContent(container.NewBorder(toolbar, bottom, nil, nil, split))
+	win.Resize(fyne.NewSize(1100, 650))
+	win.Show()
+
+	for _, f := range plan {
+		tree.OpenBranch(f.path)
+	}
+	updateSummary()
+	showFile(plan[0])
+}
+
+// buildReplaceCommand проверяет выбранные замены по текущему содержимому файлов
+func (a *App) buildReplaceCommand(plan []*replaceFile) (*ReplaceInFilesCommand, error) {
+	cmd := &ReplaceInFilesCommand{}
+	for _, f := range plan {
+		edits := f.enabledEdits()
+		if len(edits) == 0 {
+			continue
+		}
+		content, open, err := a.replaceSource(f.path)
+		if err != nil {
+			return nil, err
+		}
+		replaced, err := applyReplaceEdits(content, edits)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.path, err)
+		}
+		if open {
+			cmd.bufferPath, cmd.bufferOld, cmd.bufferNew = f.path, content, replaced
+		} else {
+			cmd.disk = append(cmd.disk, fileChange{path: f.path, oldContent: content, newContent: replaced})
+		}
+		cmd.count += len(edits)
+	}
+	return cmd, nil
+}
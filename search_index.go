@@ -0,0 +1,728 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// searchIndexVersion меняется при изменении формата файла индекса
+const searchIndexVersion = 1
+
+// searchIndexSaveDelay - задержка записи индекса после изменений файлов
+const searchIndexSaveDelay = 5 * time.Second
+
+// indexedFile - файл в индексе: признаки изменения и множество триграмм
+type indexedFile struct {
+	Path     string // относительно корня, через "/"
+	Size     int64
+	ModTime  int64
+	Trigrams []uint32 // отсортированы
+}
+
+// searchIndexData - сохраняемое на диск содержимое индекса
+type searchIndexData struct {
+	Version int
+	Root    string
+	Built   time.Time
+	Files   []indexedFile
+}
+
+// SearchIndex - триграммный индекс файлов каталога для сужения поиска
+type SearchIndex struct {
+	root      string
+	cacheFile string // "" - индекс не сохраняется
+	maxSize   int64
+	ttl       time.Duration
+
+	mutex    sync.RWMutex
+	files    map[string]*indexedFile
+	postings map[uint32]map[string]struct{}
+	ignore   *ignoreMatcher
+	built    time.Time
+	ready    bool
+
+	monitor   *DirectoryMonitor
+	saveTimer *time.Timer
+	closed    bool
+}
+
+// NewSearchIndex создает индекс каталога; настройки кэша берутся из AdvancedConfig
+func NewSearchIndex(root string, cfg AdvancedConfig) (*SearchIndex, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	idx := &SearchIndex{
+		root:     root,
+		maxSize:  cfg.CacheMaxSize,
+		ttl:      time.Duration(cfg.CacheTTL) * time.Second,
+		files:    make(map[string]*indexedFile),
+		postings: make(map[uint32]map[string]struct{}),
+	}
+	if cfg.EnableCaching {
+		dir := cfg.CacheDirectory
+		if dir == "" {
+			dir = filepath.Join(getConfigDirectory(), "cache")
+		}
+		h := fnv.New64a()
+		h.Write([]byte(root))
+		idx.cacheFile = filepath.Join(dir, "search-index", fmt.Sprintf("%x.gob", h.Sum64()))
+	}
+	return idx, nil
+}
+
+// Root возвращает индексируемый каталог
+func (idx *SearchIndex) Root() string {
+	return idx.root
+}
+
+// Ready сообщает, построен ли индекс
+func (idx *SearchIndex) Ready() bool {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return idx.ready
+}
+
+// Build загружает индекс с диска и обновляет изменившиеся файлы; без кэша или
+// с устаревшим кэшем индекс строится заново
+func (idx *SearchIndex) Build() error {
+	cached := idx.load()
+
+	ignore := newIgnoreMatcher(idx.root)
+	seen := make(map[string]bool)
+	changed := false
+	err := filepath.WalkDir(idx.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel := idx.rel(p)
+		if d.IsDir() {
+			if rel != "." && (searchSkipDirs[d.Name()] || ignore.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			ignore.load(rel)
+			return nil
+		}
+		if !d.Type().IsRegular() || ignore.ignored(rel, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		seen[rel] = true
+		if f, ok := cached[rel]; ok && f.Size == info.Size() && f.ModTime == info.ModTime().UnixNano() {
+			idx.put(f)
+			return nil
+		}
+		if f := indexFile(p, rel, info); f != nil {
+			idx.put(f)
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	idx.mutex.Lock()
+	for rel := range idx.files {
+		if !seen[rel] {
+			idx.removeLocked(rel)
+			changed = true
+		}
+	}
+	for rel := range cached {
+		if !seen[rel] {
+			changed = true
+		}
+	}
+	idx.ignore = ignore
+	idx.ready = true
+	if changed || idx.built.IsZero() {
+		idx.built = time.Now()
+	}
+	idx.mutex.Unlock()
+
+	if changed {
+		return idx.Save()
+	}
+	return nil
+}
+
+// load читает сохраненный индекс; устаревший по CacheTTL или чужой индекс игнорируется
+func (idx *SearchIndex) load() map[string]*indexedFile {
+	if idx.cacheFile == "" {
+		return nil
+	}
+	file, err := os.Open(idx.cacheFile)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var data searchIndexData
+	if err := gob.NewDecoder(file).Decode(&data); err != nil {
+		log.Printf("Search index %s is damaged: %v", idx.cacheFile, err)
+		return nil
+	}
+	if data.Version != searchIndexVersion || data.Root != idx.root {
+		return nil
+	}
+	if idx.ttl > 0 && time.Since(data.Built) > idx.ttl {
+		return nil
+	}
+
+	cached := make(map[string]*indexedFile, len(data.Files))
+	for i := range data.Files {
+		cached[data.Files[i].Path] = &data.Files[i]
+	}
+	idx.mutex.Lock()
+	idx.built = data.Built
+	idx.mutex.Unlock()
+	return cached
+}
+
+// Save записывает индекс в каталог кэша с учетом CacheMaxSize
+func (idx *SearchIndex) Save() error {
+	if idx.cacheFile == "" {
+		return nil
+	}
+
+	idx.mutex.RLock()
+	data := searchIndexData{Version: searchIndexVersion, Root: idx.root, Built: idx.built}
+	for _, f := range idx.files {
+		data.Files = append(data.Files, *f)
+	}
+	idx.mutex.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&data); err != nil {
+		return err
+	}
+	if idx.maxSize > 0 && int64(buf.Len()) > idx.maxSize {
+		return fmt.Errorf("search index for %s (%d bytes) exceeds the cache size limit", idx.root, buf.Len())
+	}
+
+	dir := filepath.Dir(idx.cacheFile)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp := idx.cacheFile + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, idx.cacheFile); err != nil {
+		return err
+	}
+	pruneSearchIndexCache(dir, idx.maxSize, idx.ttl, idx.cacheFile)
+	return nil
+}
+
+// pruneSearchIndexCache удаляет устаревшие индексы и самые старые, пока кэш больше лимита
+func pruneSearchIndexCache(dir string, maxSize int64, ttl time.Duration, keep string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type cacheEntry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cacheEntry
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() || filepath.Ext(e.Name()) != ".gob" {
+			continue
+		}
+		p := filepath.Join(dir, e.Name())
+		if p != keep && ttl > 0 && time.Since(info.ModTime()) > ttl {
+			os.Remove(p)
+			continue
+		}
+		files = append(files, cacheEntry{path: p, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+	if maxSize <= 0 {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= maxSize {
+			break
+		}
+		if f.path == keep {
+			continue
+		}
+		os.Remove(f.path)
+		total -= f.size
+	}
+}
+
+// scheduleSave откладывает запись индекса, чтобы объединить серию изменений
+func (idx *SearchIndex) scheduleSave() {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if idx.saveTimer != nil {
+		idx.saveTimer.Stop()
+	}
+	idx.saveTimer = time.AfterFunc(searchIndexSaveDelay, func() {
+		if err := idx.Save(); err != nil {
+			log.Printf("Failed to save search index: %v", err)
+		}
+	})
+}
+
+// Watch подключает DirectoryMonitor для инкрементального обновления индекса
+func (idx *SearchIndex) Watch() error {
+	monitor, err := NewDirectoryMonitor(idx.root, true)
+	if err != nil {
+		return err
+	}
+	monitor.OnChange(func(path string, changeType FileEventType) {
+		idx.Update(path)
+	})
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	if idx.closed {
+		monitor.Stop()
+		return nil
+	}
+	idx.monitor = monitor
+	return nil
+}
+
+// Close останавливает наблюдение и сохраняет индекс
+func (idx *SearchIndex) Close() {
+	idx.mutex.Lock()
+	monitor := idx.monitor
+	idx.monitor = nil
+	idx.closed = true
+	pending := idx.saveTimer != nil && idx.saveTimer.Stop()
+	idx.mutex.Unlock()
+
+	if monitor != nil {
+		monitor.Stop()
+	}
+	if pending {
+		if err := idx.Save(); err != nil {
+			log.Printf("Failed to save search index: %v", err)
+		}
+	}
+}
+
+// Update переиндексирует путь после события файловой системы
+func (idx *SearchIndex) Update(p string) {
+	rel := idx.rel(p)
+	if rel == "." || strings.HasPrefix(rel, "../") {
+		return
+	}
+	for _, part := range strings.Split(rel, "/") {
+		if searchSkipDirs[part] {
+			return
+		}
+	}
+	// Изменились правила игнорирования - проще перестроить индекс
+	if name := filepath.Base(p); name == ".gitignore" || name == ".ignore" {
+		go func() {
+			if err := idx.Build(); err != nil {
+				log.Printf("Failed to rebuild search index: %v", err)
+			}
+		}()
+		return
+	}
+
+	info, err := os.Stat(p)
+	idx.mutex.Lock()
+	ignore := idx.ignore
+	switch {
+	case err != nil:
+		idx.removeLocked(rel)
+	case info.IsDir():
+		idx.mutex.Unlock()
+		idx.indexDir(p)
+		idx.scheduleSave()
+		return
+	case ignore != nil && ignore.ignored(rel, false):
+		idx.removeLocked(rel)
+	default:
+		idx.mutex.Unlock()
+		if f := indexFile(p, rel, info); f != nil {
+			idx.put(f)
+		} else {
+			idx.mutex.Lock()
+			idx.removeLocked(rel)
+			idx.mutex.Unlock()
+		}
+		idx.scheduleSave()
+		return
+	}
+	idx.mutex.Unlock()
+	idx.scheduleSave()
+}
+
+// indexDir индексирует новый каталог целиком
+func (idx *SearchIndex) indexDir(dir string) {
+	idx.mutex.RLock()
+	ignore := idx.ignore
+	idx.mutex.RUnlock()
+	if ignore == nil {
+		return
+	}
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel := idx.rel(p)
+		if d.IsDir() {
+			if searchSkipDirs[d.Name()] || ignore.ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			ignore.load(rel)
+			return nil
+		}
+		if !d.Type().IsRegular() || ignore.ignored(rel, false) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			if f := indexFile(p, rel, info); f != nil {
+				idx.put(f)
+			}
+		}
+		return nil
+	})
+}
+
+// rel возвращает путь относительно корня через "/"
+func (idx *SearchIndex) rel(p string) string {
+	rel, err := filepath.Rel(idx.root, p)
+	if err != nil {
+		return "../"
+	}
+	return filepath.ToSlash(rel)
+}
+
+// put добавляет или заменяет файл в индексе
+func (idx *SearchIndex) put(f *indexedFile) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	idx.removeLocked(f.Path)
+	idx.files[f.Path] = f
+	for _, t := range f.Trigrams {
+		set := idx.postings[t]
+		if set == nil {
+			set = make(map[string]struct{})
+			idx.postings[t] = set
+		}
+		set[f.Path] = struct{}{}
+	}
+}
+
+// removeLocked удаляет файл или все файлы каталога; вызывается под mutex
+func (idx *SearchIndex) removeLocked(rel string) {
+	remove := func(f *indexedFile) {
+		for _, t := range f.Trigrams {
+			if set := idx.postings[t]; set != nil {
+				delete(set, f.Path)
+				if len(set) == 0 {
+					delete(idx.postings, t)
+				}
+			}
+		}
+		delete(idx.files, f.Path)
+	}
+	if f, ok := idx.files[rel]; ok {
+		remove(f)
+		return
+	}
+	prefix := rel + "/"
+	for path, f := range idx.files {
+		if strings.HasPrefix(path, prefix) {
+			remove(f)
+		}
+	}
+}
+
+// indexFile читает файл и собирает его триграммы; двоичные и большие файлы не индексируются
+func indexFile(p, rel string, info fs.FileInfo) *indexedFile {
+	if info.Size() > searchMaxFileSize {
+		return nil
+	}
+	data, err := os.ReadFile(p)
+	if err != nil || isBinaryContent(data) {
+		return nil
+	}
+	return &indexedFile{
+		Path:     rel,
+		Size:     info.Size(),
+		ModTime:  info.ModTime().UnixNano(),
+		Trigrams: textTrigrams(data),
+	}
+}
+
+// textTrigrams возвращает отсортированное множество триграмм текста без учета регистра ASCII
+func textTrigrams(data []byte) []uint32 {
+	set := make(map[uint32]struct{})
+	for i := 0; i+3 <= len(data); i++ {
+		set[makeTrigram(data[i], data[i+1], data[i+2])] = struct{}{}
+	}
+	trigrams := make([]uint32, 0, len(set))
+	for t := range set {
+		trigrams = append(trigrams, t)
+	}
+	sort.Slice(trigrams, func(i, j int) bool { return trigrams[i] < trigrams[j] })
+	return trigrams
+}
+
+func makeTrigram(a, b, c byte) uint32 {
+	return uint32(asciiLower(a))<<16 | uint32(asciiLower(b))<<8 | uint32(asciiLower(c))
+}
+
+func asciiLower(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// Paths возвращает все проиндексированные файлы (абсолютные пути)
+func (idx *SearchIndex) Paths() []string {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	paths := make([]string, 0, len(idx.files))
+	for rel := range idx.files {
+		paths = append(paths, filepath.Join(idx.root, filepath.FromSlash(rel)))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Candidates возвращает файлы, которые могут содержать совпадение с выражением (абсолютные пути)
+func (idx *SearchIndex) Candidates(re *regexp.Regexp) []string {
+	query := trigramQueryAll
+	if parsed, err := syntax.Parse(re.String(), syntax.Perl); err == nil {
+		query = regexTrigramQuery(parsed.Simplify())
+	}
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+
+	set := idx.evaluate(query)
+	paths := make([]string, 0, len(idx.files))
+	for rel := range idx.files {
+		if set == nil {
+			paths = append(paths, filepath.Join(idx.root, filepath.FromSlash(rel)))
+		} else if _, ok := set[rel]; ok {
+			paths = append(paths, filepath.Join(idx.root, filepath.FromSlash(rel)))
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// trigramQuery - условие на триграммы: все из trigrams и все sub (and) или одно из sub (or)
+type trigramQuery struct {
+	or       bool
+	trigrams []uint32
+	sub      []*trigramQuery
+}
+
+// trigramQueryAll - условие без ограничений
+var trigramQueryAll = &trigramQuery{}
+
+func (q *trigramQuery) all() bool {
+	return !q.or && len(q.trigrams) == 0 && len(q.sub) == 0
+}
+
+// regexTrigramQuery выводит из регулярного выражения триграммы, обязательные для совпадения
+func regexTrigramQuery(re *syntax.Regexp) *trigramQuery {
+	switch re.Op {
+	case syntax.OpLiteral:
+		q := &trigramQuery{}
+		for _, s := range literalRuns(re) {
+			if sub := literalTrigramQuery(s); !sub.all() {
+				q.sub = append(q.sub, sub)
+			}
+		}
+		return q
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexTrigramQuery(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return regexTrigramQuery(re.Sub[0])
+		}
+		return trigramQueryAll
+	case syntax.OpConcat:
+		q := &trigramQuery{}
+		var literal strings.Builder
+		flush := func() {
+			if sub := literalTrigramQuery(literal.String()); !sub.all() {
+				q.sub = append(q.sub, sub)
+			}
+			literal.Reset()
+		}
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				// Соседние литералы склеиваются, чтобы получить триграммы на их стыке
+				runs := literalRuns(sub)
+				for i, run := range runs {
+					if i > 0 {
+						flush()
+					}
+					literal.WriteString(run)
+				}
+				continue
+			}
+			flush()
+			if sq := regexTrigramQuery(sub); !sq.all() {
+				q.sub = append(q.sub, sq)
+			}
+		}
+		flush()
+		return q
+	case syntax.OpAlternate:
+		q := &trigramQuery{or: true}
+		for _, sub := range re.Sub {
+			sq := regexTrigramQuery(sub)
+			if sq.all() {
+				return trigramQueryAll
+			}
+			q.sub = append(q.sub, sq)
+		}
+		return q
+	}
+	return trigramQueryAll
+}
+
+// literalRuns разбивает литерал на части, пригодные для триграмм: индекс не учитывает
+// регистр только для ASCII, поэтому при (?i) символы вне ASCII разрывают литерал
+func literalRuns(re *syntax.Regexp) []string {
+	if re.Flags&syntax.FoldCase == 0 {
+		return []string{string(re.Rune)}
+	}
+	runs := []string{""}
+	for _, r := range re.Rune {
+		if r > 127 {
+			runs = append(runs, "")
+			continue
+		}
+		runs[len(runs)-1] += string(r)
+	}
+	return runs
+}
+
+// literalTrigramQuery требует все триграммы строки
+func literalTrigramQuery(s string) *trigramQuery {
+	if len(s) < 3 {
+		return trigramQueryAll
+	}
+	q := &trigramQuery{}
+	for i := 0; i+3 <= len(s); i++ {
+		q.trigrams = append(q.trigrams, makeTrigram(s[i], s[i+1], s[i+2]))
+	}
+	return q
+}
+
+// evaluate возвращает файлы, удовлетворяющие условию; nil - все файлы. Вызывается под mutex
+func (idx *SearchIndex) evaluate(q *trigramQuery) map[string]struct{} {
+	if q.all() {
+		return nil
+	}
+	if q.or {
+		result := make(map[string]struct{})
+		for _, sub := range q.sub {
+			set := idx.evaluate(sub)
+			if set == nil {
+				return nil
+			}
+			for p := range set {
+				result[p] = struct{}{}
+			}
+		}
+		return result
+	}
+
+	var result map[string]struct{}
+	intersect := func(set map[string]struct{}) {
+		if result == nil {
+			result = make(map[string]struct{}, len(set))
+			for p := range set {
+				result[p] = struct{}{}
+			}
+			return
+		}
+		for p := range result {
+			if _, ok := set[p]; !ok {
+				delete(result, p)
+			}
+		}
+	}
+	for _, t := range q.trigrams {
+		intersect(idx.postings[t])
+		if len(result) == 0 {
+			return result
+		}
+	}
+	for _, sub := range q.sub {
+		if set := idx.evaluate(sub); set != nil {
+			intersect(set)
+		}
+	}
+	return result
+}
+
+// isRepositoryRoot сообщает, лежит ли в каталоге репозиторий системы контроля версий
+func isRepositoryRoot(dir string) bool {
+	for name := range searchSkipDirs {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// openSearchIndex строит индекс для корня репозитория в фоне и подключает наблюдение за ним.
+// Произвольные каталоги (например, домашний) не индексируются.
+func (a *App) openSearchIndex(root string) {
+	if root == "" {
+		return
+	}
+	if a.searchIndex != nil {
+		if abs, err := filepath.Abs(root); err == nil && abs == a.searchIndex.Root() {
+			return
+		}
+		a.searchIndex.Close()
+		a.searchIndex = nil
+	}
+	if !isRepositoryRoot(root) {
+		return
+	}
+
+	idx, err := NewSearchIndex(root, a.config.Advanced)
+	if err != nil {
+		log.Printf("Search index: %v", err)
+		return
+	}
+	a.searchIndex = idx
+	go func() {
+		if err := idx.Build(); err != nil {
+			log.Printf("Failed to build search index for %s: %v", root, err)
+		}
+		if err := idx.Watch(); err != nil {
+			log.Printf("Failed to watch %s for index updates: %v", root, err)
+		}
+	}()
+}
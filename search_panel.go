@@ -0,0 +1,480 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// searchRefreshInterval - как часто панель показывает новые результаты во время поиска
+const searchRefreshInterval = 150 * time.Millisecond
+
+// SearchPanel - панель поиска в файлах в боковой панели
+type SearchPanel struct {
+	widget.BaseWidget
+
+	// UI компоненты
+	mainContainer *fyne.Container
+	queryEntry    *widget.Entry
+	replaceEntry  *widget.Entry
+	folderEntry   *widget.Entry
+	includeEntry  *widget.Entry
+	excludeEntry  *widget.Entry
+	regexCheck    *widget.Check
+	caseCheck     *widget.Check
+	wordCheck     *widget.Check
+	ignoreCheck   *widget.Check
+	contextSelect *widget.Select
+	searchBtn     *widget.Button
+	replaceBtn    *widget.Button
+	cancelBtn     *widget.Button
+	statusLabel   *widget.Label
+	resultTree    *widget.Tree
+
+	// Состояние
+	config   *Config
+	window   fyne.Window
+	mutex    sync.Mutex
+	results  []SearchFileResult
+	byPath   map[string]int
+	root     string
+	cancel   context.CancelFunc
+	progress *SearchProgress
+	running  bool
+	pattern  *regexp.Regexp // выражение последнего поиска
+	regex    bool
+
+	// Callbacks
+	buffers   func() map[string]string
+	index     func() *SearchIndex
+	onOpen    func(path string, line, col, length int)
+	onReplace func(root string, results []SearchFileResult, re *regexp.Regexp, template string, regexMode bool)
+}
+
+// NewSearchPanel создает панель поиска в файлах
+func NewSearchPanel(config *Config, window fyne.Window) *SearchPanel {
+	p := &SearchPanel{
+		config: config,
+		window: window,
+		byPath: make(map[string]int),
+	}
+	p.ExtendBaseWidget(p)
+	p.setupComponents()
+	return p
+}
+
+// setupComponents создает UI компоненты
+func (p *SearchPanel) setupComponents() {
+	p.queryEntry = widget.NewEntry()
+	p.queryEntry.SetPlaceHolder("Search")
+	p.queryEntry.OnSubmitted = func(string) { p.Search() }
+
+	p.replaceEntry = widget.NewEntry()
+	p.replaceEntry.SetPlaceHolder("Replace ($1, ${name} in regex mode)")
+	p.replaceEntry.OnSubmitted = func(string) { p.Replace() }
+
+	p.folderEntry = widget.NewEntry()
+	p.folderEntry.SetPlaceHolder("Folder")
+
+	p.includeEntry = widget.NewEntry()
+	p.includeEntry.SetPlaceHolder("Files to include: *.go, src/**/*.ts")
+	p.includeEntry.OnSubmitted = func(string) { p.Search() }
+
+	p.excludeEntry = widget.NewEntry()
+	p.excludeEntry.SetPlaceHolder("Files to exclude: node_modules, **/*.min.js")
+	p.excludeEntry.OnSubmitted = func(string) { p.Search() }
+
+	p.regexCheck = widget.NewCheck(".*", nil)
+	p.caseCheck = widget.NewCheck("Aa", nil)
+	p.wordCheck = widget.NewCheck("Word", nil)
+	p.ignoreCheck = widget.NewCheck(".gitignore", nil)
+	p.ignoreCheck.SetChecked(true)
+
+	p.contextSelect = widget.NewSelect([]string{"0", "1", "2", "3", "5"}, nil)
+	p.contextSelect.SetSelected("0")
+
+	p.searchBtn = widget.NewButtonWithIcon("", theme.SearchIcon(), p.Search)
+	p.cancelBtn = widget.NewButtonWithIcon("", theme.MediaStopIcon(), p.Cancel)
+	p.cancelBtn.Disable()
+	p.replaceBtn = widget.NewButtonWithIcon("", theme.ContentPasteIcon(), p.Replace)
+	collapseBtn := widget.NewButtonWithIcon("", theme.ZoomOutIcon(), func() { p.resultTree.CloseAllBranches() })
+
+	p.statusLabel = widget.NewLabel("")
+	p.statusLabel.TextStyle.Italic = true
+	p.statusLabel.Truncation = fyne.TextTruncateEllipsis
+
+	p.resultTree = widget.NewTree(p.childUIDs, p.isBranch, p.createNode, p.updateNode)
+	p.resultTree.OnSelected = func(uid widget.TreeNodeID) {
+		p.resultTree.Unselect(uid)
+		p.openNode(uid)
+	}
+
+	queryRow := container.NewBorder(nil, nil, nil, container.NewHBox(p.searchBtn, p.cancelBtn, collapseBtn), p.queryEntry)
+	replaceRow := container.NewBorder(nil, nil, nil, p.replaceBtn, p.replaceEntry)
+	toggles := container.NewHBox(p.regexCheck, p.caseCheck, p.wordCheck, p.ignoreCheck,
+		widget.NewLabel("Context:"), p.contextSelect)
+
+	p.mainContainer = container.NewBorder(
+		container.NewVBox(queryRow, replaceRow, toggles, p.folderEntry, p.includeEntry, p.excludeEntry, p.statusLabel),
+		nil,
+		nil,
+		nil,
+		p.resultTree,
+	)
+}
+
+// SetRoot задает каталог поиска по умолчанию
+func (p *SearchPanel) SetRoot(dir string) {
+	p.folderEntry.SetText(dir)
+}
+
+// SetIndex задает источник триграммного индекса для отбора файлов
+func (p *SearchPanel) SetIndex(index func() *SearchIndex) {
+	p.index = index
+}
+
+// SetCallbacks задает обработчики панели
+func (p *SearchPanel) SetCallbacks(buffers func() map[string]string, onOpen func(path string, line, col, length int),
+	onReplace func(root string, results []SearchFileResult, re *regexp.Regexp, template string, regexMode bool)) {
+	p.buffers = buffers
+	p.onOpen = onOpen
+	p.onReplace = onReplace
+}
+
+// FocusQuery переводит фокус в строку поиска, подставляя текст
+func (p *SearchPanel) FocusQuery(text string) {
+	if text != "" && !strings.Contains(text, "\n") {
+		p.queryEntry.SetText(text)
+	}
+	if c := fyne.CurrentApp().Driver().CanvasForObject(p.queryEntry); c != nil {
+		c.Focus(p.queryEntry)
+	}
+}
+
+// options собирает параметры поиска из полей панели
+func (p *SearchPanel) options() SearchOptions {
+	contextLines, _ := strconv.Atoi(p.contextSelect.Selected)
+	opts := SearchOptions{
+		Query:         p.queryEntry.Text,
+		Root:          p.folderEntry.Text,
+		Regex:         p.regexCheck.Checked,
+		CaseSensitive: p.caseCheck.Checked,
+		WholeWord:     p.wordCheck.Checked,
+		Include:       splitGlobList(p.includeEntry.Text),
+		Exclude:       splitGlobList(p.excludeEntry.Text),
+		ContextLines:  contextLines,
+		UseIgnore:     p.ignoreCheck.Checked,
+	}
+	if p.buffers != nil {
+		opts.Buffers = p.buffers()
+	}
+	if p.index != nil {
+		opts.Index = p.index()
+	}
+	return opts
+}
+
+// Search запускает поиск; предыдущий поиск отменяется
+func (p *SearchPanel) Search() {
+	opts := p.options()
+	if opts.Query == "" {
+		return
+	}
+	re, err := compileSearchPattern(opts)
+	if err != nil {
+		p.statusLabel.SetText("Invalid regex: " + err.Error())
+		return
+	}
+	if opts.Root == "" {
+		opts.Root = "."
+	}
+
+	p.Cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := &SearchProgress{}
+
+	p.mutex.Lock()
+	p.results = nil
+	p.byPath = make(map[string]int)
+	p.root, _ = filepath.Abs(opts.Root)
+	p.cancel = cancel
+	p.progress = progress
+	p.running = true
+	p.pattern = re
+	p.regex = opts.Regex
+	p.mutex.Unlock()
+
+	p.resultTree.Refresh()
+	p.cancelBtn.Enable()
+	p.statusLabel.SetText("Searching...")
+
+	results := make(chan SearchFileResult, 64)
+	done := make(chan error, 1)
+	go func() {
+		done <- searchFiles(ctx, opts, re, results, progress)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(searchRefreshInterval)
+		defer ticker.Stop()
+		var added []string
+		flush := func() {
+			p.mutex.Lock()
+			current := p.progress == progress
+			p.mutex.Unlock()
+			if !current {
+				return
+			}
+			opened := added
+			added = nil
+			fyne.Do(func() {
+				p.resultTree.Refresh()
+				for _, uid := range opened {
+					p.resultTree.OpenBranch(uid)
+				}
+				p.updateStatus(progress, false, nil)
+			})
+		}
+		for {
+			select {
+			case result, ok := <-results:
+				if !ok {
+					err := <-done
+					p.finish(progress, err)
+					return
+				}
+				p.mutex.Lock()
+				if p.progress == progress {
+					p.byPath[result.Path] = len(p.results)
+					p.results = append(p.results, result)
+					added = append(added, result.Path)
+				}
+				p.mutex.Unlock()
+			case <-ticker.C:
+				flush()
+			}
+		}
+	}()
+}
+
+// finish показывает итог поиска и упорядочивает файлы
+func (p *SearchPanel) finish(progress *SearchProgress, err error) {
+	p.mutex.Lock()
+	current := p.progress == progress
+	if current {
+		sort.SliceStable(p.results, func(i, j int) bool { return p.results[i].Path < p.results[j].Path })
+		for i, r := range p.results {
+			p.byPath[r.Path] = i
+		}
+		p.running = false
+	}
+	paths := make([]string, 0, len(p.results))
+	for _, r := range p.results {
+		paths = append(paths, r.Path)
+	}
+	p.mutex.Unlock()
+	if !current {
+		return
+	}
+
+	fyne.Do(func() {
+		p.cancelBtn.Disable()
+		p.resultTree.Refresh()
+		for _, uid := range paths {
+			p.resultTree.OpenBranch(uid)
+		}
+		p.updateStatus(progress, true, err)
+	})
+}
+
+// updateStatus показывает прогресс и счетчики
+func (p *SearchPanel) updateStatus(progress *SearchProgress, finished bool, err error) {
+	matches := atomic.LoadInt64(&progress.Matches)
+	files := atomic.LoadInt64(&progress.FilesMatched)
+	scanned := atomic.LoadInt64(&progress.FilesScanned)
+	text := fmt.Sprintf("%d results in %d files (%d scanned)", matches, files, scanned)
+	switch {
+	case !finished:
+		text = "Searching... " + text
+	case err == context.Canceled:
+		text = "Cancelled: " + text
+	case err != nil:
+		text = "Error: " + err.Error()
+	case matches == 0:
+		text = fmt.Sprintf("No results (%d files scanned)", scanned)
+	}
+	p.statusLabel.SetText(text)
+}
+
+// Replace показывает замену найденного; поиск выполняется заново, если условия изменились
+func (p *SearchPanel) Replace() {
+	if p.onReplace == nil || p.queryEntry.Text == "" {
+		return
+	}
+	p.mutex.Lock()
+	running := p.running
+	stale := p.pattern == nil || p.pattern.String() != p.patternString()
+	results := append([]SearchFileResult(nil), p.results...)
+	root, re, regexMode := p.root, p.pattern, p.regex
+	p.mutex.Unlock()
+
+	if running {
+		p.statusLabel.SetText("Wait for the search to finish before replacing")
+		return
+	}
+	if stale {
+		// Показываем, что именно будет заменено; замену нужно запустить повторно
+		p.Search()
+		return
+	}
+	p.onReplace(root, results, re, p.replaceEntry.Text, regexMode)
+}
+
+// patternString возвращает выражение для текущих параметров поиска
+func (p *SearchPanel) patternString() string {
+	re, err := compileSearchPattern(p.options())
+	if err != nil {
+		return ""
+	}
+	return re.String()
+}
+
+// Cancel останавливает выполняющийся поиск
+func (p *SearchPanel) Cancel() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.cancel != nil && p.running {
+		p.cancel()
+	}
+}
+
+// Узлы дерева: файл - путь, строка - путь + "\n" + индекс в SearchFileResult.Lines
+
+func (p *SearchPanel) childUIDs(uid widget.TreeNodeID) []widget.TreeNodeID {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if uid == "" {
+		ids := make([]widget.TreeNodeID, len(p.results))
+		for i, r := range p.results {
+			ids[i] = r.Path
+		}
+		return ids
+	}
+	i, ok := p.byPath[uid]
+	if !ok {
+		return nil
+	}
+	ids := make([]widget.TreeNodeID, len(p.results[i].Lines))
+	for k := range p.results[i].Lines {
+		ids[k] = uid + "\n" + strconv.Itoa(k)
+	}
+	return ids
+}
+
+func (p *SearchPanel) isBranch(uid widget.TreeNodeID) bool {
+	return uid == "" || !strings.Contains(uid, "\n")
+}
+
+func (p *SearchPanel) createNode(branch bool) fyne.CanvasObject {
+	label := widget.NewLabel("")
+	label.Truncation = fyne.TextTruncateEllipsis
+	if branch {
+		return container.NewBorder(nil, nil, widget.NewIcon(theme.FileIcon()), nil, label)
+	}
+	return label
+}
+
+func (p *SearchPanel) updateNode(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if branch {
+		i, ok := p.byPath[uid]
+		if !ok {
+			return
+		}
+		label := obj.(*fyne.Container).Objects[0].(*widget.Label)
+		label.TextStyle.Bold = true
+		label.SetText(fmt.Sprintf("%s (%d)", p.relPath(uid), len(p.results[i].Matches)))
+		return
+	}
+
+	label := obj.(*widget.Label)
+	line, ok := p.nodeLine(uid)
+	if !ok {
+		return
+	}
+	switch {
+	case line.Number == 0:
+		label.Importance = widget.LowImportance
+		label.SetText("   ⋯")
+	case line.Context:
+		label.Importance = widget.LowImportance
+		label.SetText(fmt.Sprintf("%5d  %s", line.Number, strings.TrimRight(line.Text, " \t")))
+	default:
+		label.Importance = widget.MediumImportance
+		label.SetText(fmt.Sprintf("%5d: %s", line.Number, strings.TrimRight(line.Text, " \t")))
+	}
+}
+
+// nodeLine возвращает строку результата по идентификатору узла; вызывается под mutex
+func (p *SearchPanel) nodeLine(uid widget.TreeNodeID) (SearchLine, bool) {
+	sep := strings.LastIndex(uid, "\n")
+	i, ok := p.byPath[uid[:sep]]
+	k, err := strconv.Atoi(uid[sep+1:])
+	if !ok || err != nil || k >= len(p.results[i].Lines) {
+		return SearchLine{}, false
+	}
+	return p.results[i].Lines[k], true
+}
+
+// relPath возвращает путь относительно каталога поиска
+func (p *SearchPanel) relPath(path string) string {
+	if rel, err := filepath.Rel(p.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// openNode открывает файл на выбранной строке
+func (p *SearchPanel) openNode(uid widget.TreeNodeID) {
+	if p.isBranch(uid) || p.onOpen == nil {
+		return
+	}
+	path := uid[:strings.LastIndex(uid, "\n")]
+
+	p.mutex.Lock()
+	line, ok := p.nodeLine(uid)
+	col, length := 0, 0
+	if ok && !line.Context {
+		for _, m := range p.results[p.byPath[path]].Matches {
+			if m.Line == line.Number {
+				col, length = m.Col, m.Length
+				break
+			}
+		}
+	}
+	p.mutex.Unlock()
+
+	if ok && line.Number > 0 {
+		p.onOpen(path, line.Number, col, length)
+	}
+}
+
+// CreateRenderer реализует интерфейс fyne.Widget
+func (p *SearchPanel) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(p.mainContainer)
+}
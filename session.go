@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// sessionVersion меняется при несовместимом изменении формата сессии
+const sessionVersion = 1
+
+// sessionRestoreDelay - задержка восстановления прокрутки, пока окно не разложено
+const sessionRestoreDelay = 300 * time.Millisecond
+
+// Session - состояние, сохраняемое при горячем выходе и восстанавливаемое при запуске
+type Session struct {
+	Version   int             `json:"version"`
+	Saved     time.Time       `json:"saved"`
+	Buffers   []SessionBuffer `json:"buffers"`
+	Workspace string          `json:"workspace,omitempty"` // файл рабочей области
+	Roots     []string        `json:"roots,omitempty"`
+	Layout    WorkspaceLayout `json:"layout"`
+}
+
+// SessionBuffer - открытый буфер; содержимое хранится только для измененных
+// и безымянных буферов, остальные перечитываются с диска
+type SessionBuffer struct {
+	Path           string       `json:"path,omitempty"`
+	Content        *string      `json:"content,omitempty"`
+	CursorRow      int          `json:"cursor_row"`
+	CursorCol      int          `json:"cursor_col"`
+	SelectionStart TextPosition `json:"selection_start"`
+	SelectionEnd   TextPosition `json:"selection_end"`
+	ScrollX        float32      `json:"scroll_x"`
+	ScrollY        float32      `json:"scroll_y"`
+	Folds          []int        `json:"folds,omitempty"`
+	Bookmarks      []Bookmark   `json:"bookmarks,omitempty"`
+	Active         bool         `json:"active,omitempty"`
+}
+
+// sessionPath возвращает путь к файлу сессии
+func sessionPath() string {
+	return filepath.Join(getConfigDirectory(), "session.json")
+}
+
+// loadSession читает сохраненную сессию; отсутствие файла не ошибка
+func loadSession() (*Session, error) {
+	data, err := os.ReadFile(sessionPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("cannot parse session: %v", err)
+	}
+	if session.Version != sessionVersion {
+		return nil, nil
+	}
+	return session, nil
+}
+
+// save записывает сессию через временный файл
+func (s *Session) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := sessionPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// discardSession удаляет сохраненную сессию, чтобы она не восстановилась устаревшей
+func discardSession() {
+	if err := os.Remove(sessionPath()); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove session: %v", err)
+	}
+}
+
+// hotExitEnabled сообщает, сохраняется ли сессия вместо вопроса о сохранении
+func (a *App) hotExitEnabled() bool {
+	return a.config.App.StartupBehavior == "last_session"
+}
+
+// captureSession собирает буферы, корни и раскладку окна
+func (a *App) captureSession() *Session {
+	session := &Session{
+		Version: sessionVersion,
+		Saved:   time.Now(),
+		Roots:   a.sidebar.Roots(),
+	}
+	if a.workspace != nil {
+		session.Workspace = a.workspace.path
+	}
+
+	size := a.mainWin.Canvas().Size()
+	session.Layout = WorkspaceLayout{
+		SidebarVisible: a.sidebar.IsVisible(),
+		MinimapVisible: a.minimap != nil && a.minimap.IsVisible(),
+		SidebarView:    a.sidebar.ActiveView(),
+		WindowWidth:    int(size.Width),
+		WindowHeight:   int(size.Height),
+	}
+
+	if a.editor != nil && (a.editor.filePath != "" || a.editor.IsDirty()) {
+		session.Buffers = append(session.Buffers, a.captureBuffer())
+	}
+	return session
+}
+
+// captureBuffer сохраняет состояние редактора
+func (a *App) captureBuffer() SessionBuffer {
+	e := a.editor
+	buffer := SessionBuffer{
+		Path:           e.filePath,
+		CursorRow:      e.cursorRow,
+		CursorCol:      e.cursorCol,
+		SelectionStart: e.selectionStart,
+		SelectionEnd:   e.selectionEnd,
+		Folds:          e.foldStarts(),
+		Bookmarks:      e.GetBookmarks(),
+		Active:         true,
+	}
+	if buffer.Path != "" {
+		buffer.Path, _ = filepath.Abs(buffer.Path)
+	}
+	if e.scrollContainer != nil {
+		buffer.ScrollX = e.scrollContainer.Offset.X
+		buffer.ScrollY = e.scrollContainer.Offset.Y
+	}
+	if e.IsDirty() || e.filePath == "" {
+		content := e.GetFullText()
+		buffer.Content = &content
+	}
+	return buffer
+}
+
+// saveSession сохраняет сессию для горячего выхода
+func (a *App) saveSession() error {
+	return a.captureSession().save()
+}
+
+// restoreSession восстанавливает прошлую сессию; false, если восстанавливать нечего
+func (a *App) restoreSession() bool {
+	session, err := loadSession()
+	if err != nil {
+		log.Printf("Failed to load session: %v", err)
+		return false
+	}
+	if session == nil {
+		return false
+	}
+
+	// Рабочая область задает корни и настройки; ее редакторы заменяет сессия
+	restored := false
+	if session.Workspace != "" {
+		if ws, err := loadWorkspace(session.Workspace); err == nil {
+			ws.Editors = nil
+			a.applyWorkspace(ws)
+			restored = true
+		} else {
+			log.Printf("Failed to restore workspace: %v", err)
+		}
+	}
+	if !restored && len(session.Roots) > 0 {
+		if err := a.sidebar.SetRoots(session.Roots); err != nil {
+			log.Printf("Failed to restore workspace folders: %v", err)
+		}
+	}
+
+	layout := session.Layout
+	a.sidebar.SetVisible(layout.SidebarVisible)
+	if a.minimap != nil {
+		a.minimap.SetVisible(layout.MinimapVisible)
+	}
+	a.createMainLayout()
+	if layout.SidebarView != "" {
+		a.sidebar.ShowView(layout.SidebarView)
+	}
+	if layout.WindowWidth > 0 && layout.WindowHeight > 0 {
+		a.mainWin.Resize(fyne.NewSize(float32(layout.WindowWidth), float32(layout.WindowHeight)))
+	}
+
+	for _, buffer := range session.Buffers {
+		if buffer.Active {
+			a.restoreBuffer(buffer)
+			break
+		}
+	}
+	return true
+}
+
+// restoreBuffer открывает буфер сессии с несохраненным содержимым и позицией
+func (a *App) restoreBuffer(buffer SessionBuffer) {
+	e := a.editor
+	_, statErr := os.Stat(buffer.Path)
+	switch {
+	case buffer.Path != "" && statErr == nil:
+		a.loadFile(buffer.Path)
+		if e.filePath != buffer.Path {
+			return
+		}
+		if buffer.Content != nil && *buffer.Content != e.textContent {
+			e.SetContent(*buffer.Content)
+		}
+
+	case buffer.Content != nil:
+		// Безымянный буфер или файл, удаленный после выхода
+		e.Clear()
+		e.SetContent(*buffer.Content)
+		if buffer.Path != "" {
+			e.SetFilePath(buffer.Path)
+			a.currentFile = buffer.Path
+			a.updateBreadcrumb(buffer.Path)
+		}
+
+	default:
+		return
+	}
+
+	e.restoreFolds(buffer.Folds)
+	e.SetBookmarks(buffer.Bookmarks)
+	// Позиции проходят через смещение: столбец конца строки сохраняется,
+	// а позиции за концом изменившегося на диске файла прижимаются к нему
+	e.moveCursorToIndex(e.positionToIndex(TextPosition{Row: buffer.CursorRow, Col: buffer.CursorCol}))
+	e.selectionStart = e.indexToPosition(e.positionToIndex(buffer.SelectionStart))
+	e.selectionEnd = e.indexToPosition(e.positionToIndex(buffer.SelectionEnd))
+	e.updateDisplay()
+	a.updateTitle()
+
+	// Прокрутку можно восстановить только после раскладки окна
+	scroll := fyne.NewPos(buffer.ScrollX, buffer.ScrollY)
+	time.AfterFunc(sessionRestoreDelay, func() {
+		fyne.Do(func() {
+			if e.scrollContainer != nil {
+				e.scrollContainer.ScrollToOffset(scroll)
+			}
+		})
+	})
+}
+
+// foldStarts возвращает первые строки свернутых блоков по возрастанию
+func (e *EditorWidget) foldStarts() []int {
+	rows := make([]int, 0, len(e.foldedRanges))
+	for row, fold := range e.foldedRanges {
+		if fold.IsFolded {
+			rows = append(rows, row)
+		}
+	}
+	sort.Ints(rows)
+	return rows
+}
+
+// restoreFolds сворачивает блоки по возрастанию строк, как они были записаны в foldedRanges
+func (e *EditorWidget) restoreFolds(rows []int) {
+	for _, row := range rows {
+		if _, folded := e.foldedRanges[row]; !folded {
+			e.toggleFold(row)
+		}
+	}
+}
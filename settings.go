@@ -126,6 +126,10 @@ type EditorConfig struct {
 	SearchWholeWord     bool `json:"search_whole_word"`
 	SearchRegex         bool `json:"search_regex"`
 	SearchWrap          bool `json:"search_wrap"`
+	SearchPreserveCase  bool `json:"search_preserve_case"`
+	// История панели поиска, последние записи первыми
+	SearchHistory  []string `json:"search_history,omitempty"`
+	ReplaceHistory []string `json:"replace_history,omitempty"`
 
 	// Vim режим
 	VimMode     bool `json:"vim_mode"`
@@ -279,6 +283,12 @@ type KeyBindingsConfig struct {
 	// Кастомные клавиши
 	CustomBindings map[string]string `json:"custom_bindings"`
 
+	// Условия привязок (when): действие -> выражение над ключами контекста
+	When map[string]string `json:"when,omitempty"`
+
+	// Пресет раскладки: vscode, sublime, jetbrains или custom после импорта
+	KeymapPreset string `json:"keymap_preset"`
+
 	// Настройки системы клавиш
 	EnableVimBindings    bool `json:"enable_vim_bindings"`
 	EnableEmacsBindings  bool `json:"enable_emacs_bindings"`
@@ -432,6 +442,10 @@ type ConfigManager struct {
 
 	saveCh chan saveRequest
 	saveWg sync.WaitGroup
+
+	// overrideBase - пользовательские значения настроек, переопределенных
+	// рабочей областью; в файл пишутся они, а не значения рабочей области
+	overrideBase json.RawMessage
 }
 
 type saveRequest struct {
@@ -660,6 +674,7 @@ func DefaultConfig() *Config {
 			CompareFiles: "Ctrl+Shift+D",
 
 			CustomBindings: map[string]string{},
+			KeymapPreset:   "vscode",
 
 			EnableVimBindings:    false,
 			EnableEmacsBindings:  false,
@@ -958,8 +973,17 @@ func (cm *ConfigManager) saveConfigUnsafe() error {
 		return fmt.Errorf("invalid config: %v", err)
 	}
 
+	// Настройки рабочей области не попадают в пользовательский файл
+	toSave := cm.config
+	if cm.overrideBase != nil {
+		toSave = cm.copyConfig(cm.config)
+		if err := json.Unmarshal(cm.overrideBase, toSave); err != nil {
+			return fmt.Errorf("cannot restore overridden settings: %v", err)
+		}
+	}
+
 	// Сериализуем в JSON с красивым форматированием
-	data, err := json.MarshalIndent(cm.config, "", "  ")
+	data, err := json.MarshalIndent(toSave, "", "  ")
 	if err != nil {
 		return fmt.Errorf("cannot serialize config: %v", err)
 	}
@@ -1014,6 +1038,81 @@ func (cm *ConfigManager) UpdateConfig(updater func(*Config)) error {
 	return nil
 }
 
+// SetOverrides накладывает поверх текущих настроек JSON-объект той же
+// структуры, что и файл конфигурации (например {"editor": {"tab_size": 2}}).
+// Пустое значение снимает ранее наложенные переопределения.
+func (cm *ConfigManager) SetOverrides(overrides json.RawMessage) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.config == nil {
+		return fmt.Errorf("config is not loaded")
+	}
+
+	// Возвращаем пользовательские значения прошлой рабочей области
+	if cm.overrideBase != nil {
+		if err := json.Unmarshal(cm.overrideBase, cm.config); err != nil {
+			return err
+		}
+		cm.overrideBase = nil
+	}
+	if len(overrides) == 0 || string(overrides) == "null" {
+		cm.notifyCallbacks()
+		return nil
+	}
+
+	var overlay map[string]interface{}
+	if err := json.Unmarshal(overrides, &overlay); err != nil {
+		return fmt.Errorf("invalid settings overrides: %v", err)
+	}
+	current, err := json.Marshal(cm.config)
+	if err != nil {
+		return err
+	}
+	var full map[string]interface{}
+	if err := json.Unmarshal(current, &full); err != nil {
+		return err
+	}
+	base, err := json.Marshal(pickJSONKeys(full, overlay))
+	if err != nil {
+		return err
+	}
+
+	backup := cm.copyConfig(cm.config)
+	backup.ConfigPath = cm.config.ConfigPath
+	if err := json.Unmarshal(overrides, cm.config); err != nil {
+		*cm.config = *backup
+		return fmt.Errorf("invalid settings overrides: %v", err)
+	}
+	if err := cm.validateConfig(cm.config); err != nil {
+		*cm.config = *backup
+		return fmt.Errorf("invalid settings overrides: %v", err)
+	}
+
+	cm.overrideBase = base
+	cm.notifyCallbacks()
+	return nil
+}
+
+// pickJSONKeys оставляет в full только ключи, присутствующие в shape
+func pickJSONKeys(full, shape map[string]interface{}) map[string]interface{} {
+	picked := make(map[string]interface{})
+	for key, value := range shape {
+		current, ok := full[key]
+		if !ok {
+			continue
+		}
+		nestedShape, shapeIsObject := value.(map[string]interface{})
+		nestedFull, fullIsObject := current.(map[string]interface{})
+		if shapeIsObject && fullIsObject {
+			picked[key] = pickJSONKeys(nestedFull, nestedShape)
+		} else {
+			picked[key] = current
+		}
+	}
+	return picked
+}
+
 // GetString возвращает строковое значение настройки
 func (cm *ConfigManager) GetString(path string) string {
 	value := cm.getValue(path)
@@ -1731,6 +1830,13 @@ func (cm *ConfigManager) validateKeyBindingsConfig(kb *KeyBindingsConfig) error
 		used[binding] = action
 	}
 
+	// Проверяем условия when
+	for action, when := range kb.When {
+		if _, err := parseWhen(when); err != nil {
+			return fmt.Errorf("invalid condition for %s: %v", action, err)
+		}
+	}
+
 	return nil
 }
 
@@ -27,6 +27,7 @@ type SidebarWidget struct {
 
 	// UI компоненты
 	mainContainer *fyne.Container
+	viewTabs      *container.AppTabs
 	toolbar       *fyne.Container
 	searchEntry   *widget.Entry
 	filterSelect  *widget.Select
@@ -39,6 +40,7 @@ type SidebarWidget struct {
 	// Состояние
 	rootPath    string
 	currentPath string
+	roots       []string // корни рабочей области; первый совпадает с currentPath
 	isVisible   bool
 	isExpanded  bool
 
@@ -48,6 +50,15 @@ type SidebarWidget struct {
 	searchResults []string
 	selectedFile  string
 
+	// Множественное выделение, буфер обмена и перетаскивание
+	selection       map[string]bool
+	selectionAnchor string
+	clipboardPaths  []string
+	clipboardCut    bool
+	treeNodes       map[*fileTreeNode]bool
+	dragSources     []string
+	dropTarget      string
+
 	// Фильтрация и поиск
 	activeFilter    string
 	searchTerm      string
@@ -55,16 +66,17 @@ type SidebarWidget struct {
 	sortBy          SortType
 	sortAscending   bool
 
-	// File watching
-	watcher       *fsnotify.Watcher
-	watcherCancel context.CancelFunc
-	watchedDirs   map[string]bool
+	// File watching: у каждого корня рабочей области свой watcher
+	watchers map[string]*rootWatcher
 
 	// Производительность
 	loadMutex    sync.RWMutex
 	refreshChan  chan string
 	isRefreshing bool
 
+	// История файловых операций для отмены
+	fileUndo []fileOperation
+
 	// Настройки
 	config *Config
 
@@ -75,6 +87,17 @@ type SidebarWidget struct {
 	onFileSelected func(string)
 	onFileOpened   func(string)
 	onPathChanged  func(string)
+	onPathRenamed  func(oldPath, newPath string)
+	onPathDeleted  func(string)
+	onCompareFiles func(left, right string)
+	onRootsChanged func([]string)
+}
+
+// rootWatcher наблюдает за загруженными директориями одного корня
+type rootWatcher struct {
+	watcher *fsnotify.Watcher
+	cancel  context.CancelFunc
+	dirs    map[string]bool
 }
 
 // FileNode представляет узел в дереве файлов
@@ -187,6 +210,21 @@ func (s *SidebarWidget) setupComponents() {
 
 	// Обработчики событий дерева
 	s.fileTree.OnSelected = func(uid string) {
+		// Выделение рисуем сами, поэтому сбрасываем встроенное - иначе
+		// повторный щелчок по тому же узлу не доходит до обработчика
+		s.fileTree.UnselectAll()
+
+		modifiers := currentKeyModifiers()
+		switch {
+		case modifiers&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0:
+			s.toggleSelection(uid)
+			return
+		case modifiers&fyne.KeyModifierShift != 0 && s.selectionAnchor != "":
+			s.selectRange(s.selectionAnchor, uid)
+			return
+		}
+		s.setSelection(uid)
+
 		now := time.Now()
 		if s.lastTap == uid && now.Sub(s.lastTapTime) < 400*time.Millisecond {
 			// двойной клик
@@ -212,7 +250,7 @@ func (s *SidebarWidget) setupComponents() {
 
 	// Кнопки управления
 	refreshBtn := NewAnimatedButtonWithIcon("", theme.ViewRefreshIcon(), func() {
-		s.RefreshPath(s.currentPath)
+		s.RefreshPath("")
 	})
 	refreshBtn.SetText("Refresh")
 
@@ -241,6 +279,10 @@ func (s *SidebarWidget) setupComponents() {
 	})
 	openBtn.SetText("Open")
 
+	undoBtn := NewAnimatedButtonWithIcon("", theme.ContentUndoIcon(), func() {
+		s.UndoFileOperation()
+	})
+
 	// Настройки отображения
 	settingsBtn := NewAnimatedButtonWithIcon("", theme.SettingsIcon(), func() {
 		s.showSettingsDialog()
@@ -248,7 +290,7 @@ func (s *SidebarWidget) setupComponents() {
 
 	// Toolbar
 	s.toolbar = container.NewHBox(
-		upBtn, homeBtn, refreshBtn, openBtn, settingsBtn,
+		upBtn, homeBtn, refreshBtn, openBtn, undoBtn, settingsBtn,
 	)
 
 	// Статус бар
@@ -259,13 +301,17 @@ func (s *SidebarWidget) setupComponents() {
 	searchContainer := container.NewBorder(nil, nil, nil, s.filterSelect, s.searchEntry)
 
 	// Основной layout
-	s.mainContainer = container.NewBorder(
+	explorer := container.NewBorder(
 		container.NewVBox(s.toolbar, searchContainer), // top
 		s.statusLabel,                   // bottom
 		nil,                             // left
 		nil,                             // right
 		container.NewScroll(s.fileTree), // content (center)
 	)
+
+	// Вкладки представлений: проводник и дополнительные панели
+	s.viewTabs = container.NewAppTabs(container.NewTabItemWithIcon("Explorer", theme.FolderIcon(), explorer))
+	s.mainContainer = container.NewStack(s.viewTabs)
 }
 
 // NewSidebar создает новый файловый менеджер
@@ -275,7 +321,9 @@ func NewSidebar(config *Config, window fyne.Window) *SidebarWidget {
 		window:        window,
 		fileNodes:     make(map[string]*FileNode),
 		filteredNodes: make(map[string]*FileNode),
-		watchedDirs:   make(map[string]bool),
+		watchers:      make(map[string]*rootWatcher),
+		selection:     make(map[string]bool),
+		treeNodes:     make(map[*fileTreeNode]bool),
 		refreshChan:   make(chan string, 100),
 		isVisible:     true,
 		activeFilter:  "All",
@@ -327,11 +375,8 @@ func (s *SidebarWidget) treeChildUIDs(uid string) []string {
 	defer s.loadMutex.RUnlock()
 
 	if uid == "" {
-		// Корневой узел
-		if s.currentPath != "" {
-			return []string{s.currentPath}
-		}
-		return []string{}
+		// Корневые узлы рабочей области
+		return append([]string(nil), s.roots...)
 	}
 
 	node := s.getNodeByPath(uid)
@@ -366,15 +411,12 @@ func (s *SidebarWidget) treeIsBranch(uid string) bool {
 
 // treeCreateNode создает виджет для узла
 func (s *SidebarWidget) treeCreateNode(branch bool) fyne.CanvasObject {
-	icon := widget.NewIcon(theme.DocumentIcon())
-	label := widget.NewLabel("Template")
-
+	node := newFileTreeNode(s)
 	if branch {
-		icon.SetResource(theme.FolderIcon())
+		node.icon.SetResource(theme.FolderIcon())
 	}
-
-	nodeContainer := container.NewHBox(icon, label)
-	return nodeContainer
+	s.treeNodes[node] = true
+	return node
 }
 
 // treeUpdateNode обновляет виджет узла
@@ -384,9 +426,11 @@ func (s *SidebarWidget) treeUpdateNode(uid string, branch bool, node fyne.Canvas
 		return
 	}
 
-	nodeContainer := node.(*fyne.Container) // вместо *container.HBox
-	icon := nodeContainer.Objects[0].(*widget.Icon)
-	label := nodeContainer.Objects[1].(*widget.Label)
+	treeNode := node.(*fileTreeNode)
+	treeNode.uid = uid
+	treeNode.updateBackground()
+	icon := treeNode.icon
+	label := treeNode.label
 
 	// Устанавливаем иконку
 	icon.SetResource(s.getNodeIcon(fileNode))
@@ -410,34 +454,29 @@ func (s *SidebarWidget) treeUpdateNode(uid string, branch bool, node fyne.Canvas
 	label.SetText(displayName)
 }
 
-// SetRootPath устанавливает корневую директорию
-func (s *SidebarWidget) SetRootPath(path string) error {
-	// Проверяем путь и приводим его к директории
+// rootDirectory проверяет путь и приводит его к директории
+func rootDirectory(path string) (string, os.FileInfo, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("cannot access path: %v", err)
+		return "", nil, fmt.Errorf("cannot access path: %v", err)
 	}
 
 	if !info.IsDir() {
 		path = filepath.Dir(path)
 		info, err = os.Stat(path)
 		if err != nil {
-			return fmt.Errorf("cannot access directory: %v", err)
+			return "", nil, fmt.Errorf("cannot access directory: %v", err)
 		}
 		if !info.IsDir() {
-			return fmt.Errorf("path is not a directory: %s", path)
+			return "", nil, fmt.Errorf("path is not a directory: %s", path)
 		}
 	}
+	return path, info, nil
+}
 
-	s.stopWatching()
-
-	s.rootPath = path
-	s.currentPath = path
-	s.fileNodes = make(map[string]*FileNode)
-	s.filteredNodes = make(map[string]*FileNode)
-
-	// Создаем корневой узел
-	rootNode := &FileNode{
+// newRootNode создает узел корня рабочей области
+func newRootNode(path string, info os.FileInfo) *FileNode {
+	return &FileNode{
 		Path:     path,
 		Name:     filepath.Base(path),
 		IsDir:    true,
@@ -445,11 +484,28 @@ func (s *SidebarWidget) SetRootPath(path string) error {
 		Size:     info.Size(),
 		IsLoaded: false,
 	}
+}
+
+// SetRootPath устанавливает корневую директорию, заменяя все корни рабочей области
+func (s *SidebarWidget) SetRootPath(path string) error {
+	path, info, err := rootDirectory(path)
+	if err != nil {
+		return err
+	}
+
+	s.stopWatching()
+
+	s.rootPath = path
+	s.currentPath = path
+	s.roots = []string{path}
+	s.fileNodes = make(map[string]*FileNode)
+	s.filteredNodes = make(map[string]*FileNode)
 
-	s.fileNodes[path] = rootNode
+	// Создаем корневой узел
+	s.fileNodes[path] = newRootNode(path, info)
 
 	// Запускаем watching
-	s.startWatching()
+	s.startWatching(path)
 
 	// Обновляем UI
 	if s.fileTree != nil {
@@ -460,10 +516,129 @@ func (s *SidebarWidget) SetRootPath(path string) error {
 	if s.onPathChanged != nil {
 		s.onPathChanged(path)
 	}
+	s.notifyRootsChanged()
 
 	return nil
 }
 
+// AddRoot добавляет папку в рабочую область как еще один корень
+func (s *SidebarWidget) AddRoot(path string) error {
+	path, info, err := rootDirectory(path)
+	if err != nil {
+		return err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if len(s.roots) == 0 {
+		return s.SetRootPath(path)
+	}
+
+	// Вложенные корни дали бы один узел дереву дважды
+	for _, root := range s.roots {
+		if isSubPath(root, path) || isSubPath(path, root) {
+			return fmt.Errorf("%s overlaps workspace folder %s", path, root)
+		}
+	}
+
+	s.loadMutex.Lock()
+	s.roots = append(s.roots, path)
+	s.fileNodes[path] = newRootNode(path, info)
+	s.loadMutex.Unlock()
+
+	s.startWatching(path)
+	if s.fileTree != nil {
+		s.fileTree.Refresh()
+	}
+	s.updateStatus(fmt.Sprintf("Added to workspace: %s", path))
+	s.notifyRootsChanged()
+	return nil
+}
+
+// RemoveRoot убирает папку из рабочей области; последний корень убрать нельзя
+func (s *SidebarWidget) RemoveRoot(path string) {
+	index := -1
+	for i, root := range s.roots {
+		if root == path {
+			index = i
+		}
+	}
+	if index < 0 || len(s.roots) == 1 {
+		return
+	}
+
+	s.stopWatchingRoot(path)
+	s.roots = append(s.roots[:index], s.roots[index+1:]...)
+	s.forgetNodes(path)
+
+	if s.fileTree != nil {
+		s.fileTree.Refresh()
+	}
+	s.updateStatus(fmt.Sprintf("Removed from workspace: %s", path))
+
+	// Первый корень служит рабочей директорией для терминала, поиска и git
+	if index == 0 {
+		s.rootPath = s.roots[0]
+		s.currentPath = s.roots[0]
+		if s.onPathChanged != nil {
+			s.onPathChanged(s.currentPath)
+		}
+	}
+	s.notifyRootsChanged()
+}
+
+// SetRoots заменяет рабочую область набором корней
+func (s *SidebarWidget) SetRoots(paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("workspace has no folders")
+	}
+	if err := s.SetRootPath(paths[0]); err != nil {
+		return err
+	}
+	var errs []string
+	for _, path := range paths[1:] {
+		if err := s.AddRoot(path); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// Roots возвращает корни рабочей области
+func (s *SidebarWidget) Roots() []string {
+	return append([]string(nil), s.roots...)
+}
+
+// isRoot сообщает, является ли путь корнем рабочей области
+func (s *SidebarWidget) isRoot(path string) bool {
+	for _, root := range s.roots {
+		if root == path {
+			return true
+		}
+	}
+	return false
+}
+
+// rootFor возвращает корень рабочей области, содержащий путь
+func (s *SidebarWidget) rootFor(path string) string {
+	for _, root := range s.roots {
+		if isSubPath(root, path) {
+			return root
+		}
+	}
+	return ""
+}
+
+// notifyRootsChanged сообщает об изменении набора корней
+func (s *SidebarWidget) notifyRootsChanged() {
+	if s.onRootsChanged != nil {
+		s.onRootsChanged(s.Roots())
+	}
+}
+
 // loadDirectoryChildren загружает дочерние элементы директории
 func (s *SidebarWidget) loadDirectoryChildren(node *FileNode) {
 	if !node.IsDir || node.IsLoaded {
@@ -676,74 +851,73 @@ func (s *SidebarWidget) sortPaths(paths []string) {
 
 // File watching methods
 
-// startWatching запускает наблюдение за файловой системой
-func (s *SidebarWidget) startWatching() {
-	if s.watcher != nil {
-		s.stopWatching()
-	}
+// startWatching запускает наблюдение за файловой системой корня
+func (s *SidebarWidget) startWatching(root string) {
+	s.stopWatchingRoot(root)
 
-	var err error
-	s.watcher, err = fsnotify.NewWatcher()
+	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		s.updateStatus(fmt.Sprintf("File watcher error: %v", err))
 		return
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
-	s.watcherCancel = cancel
+	rw := &rootWatcher{watcher: watcher, cancel: cancel, dirs: make(map[string]bool)}
+	s.watchers[root] = rw
 
-	go s.watcherWorker(ctx)
+	go s.watcherWorker(ctx, rw)
 }
 
-// stopWatching останавливает наблюдение
-func (s *SidebarWidget) stopWatching() {
-	if s.watcherCancel != nil {
-		s.watcherCancel()
+// stopWatchingRoot останавливает наблюдение за одним корнем
+func (s *SidebarWidget) stopWatchingRoot(root string) {
+	rw := s.watchers[root]
+	if rw == nil {
+		return
 	}
+	rw.cancel()
+	rw.watcher.Close()
+	delete(s.watchers, root)
+}
 
-	if s.watcher != nil {
-		s.watcher.Close()
-		s.watcher = nil
+// stopWatching останавливает наблюдение за всеми корнями
+func (s *SidebarWidget) stopWatching() {
+	for root := range s.watchers {
+		s.stopWatchingRoot(root)
 	}
-
-	s.watchedDirs = make(map[string]bool)
 }
 
-// addToWatching добавляет директорию в наблюдение
+// addToWatching добавляет директорию в наблюдение ее корня
 func (s *SidebarWidget) addToWatching(path string) {
-	if s.watcher == nil {
+	rw := s.watchers[s.rootFor(path)]
+	if rw == nil {
 		return
 	}
 
-	if s.watchedDirs[path] {
+	if rw.dirs[path] {
 		return
 	}
 
-	err := s.watcher.Add(path)
+	err := rw.watcher.Add(path)
 	if err != nil {
 		s.updateStatus(fmt.Sprintf("Cannot watch directory: %v", err))
 		return
 	}
 
-	s.watchedDirs[path] = true
+	rw.dirs[path] = true
 }
 
 // watcherWorker обрабатывает события файловой системы
-func (s *SidebarWidget) watcherWorker(ctx context.Context) {
-	if s.watcher == nil {
-		return
-	}
-
+func (s *SidebarWidget) watcherWorker(ctx context.Context, rw *rootWatcher) {
 	for {
 		select {
-		case event, ok := <-s.watcher.Events:
+		case event, ok := <-rw.watcher.Events:
 			if !ok {
 				return
 			}
 
 			s.handleFileSystemEvent(event)
 
-		case err, ok := <-s.watcher.Errors:
+		case err, ok := <-rw.watcher.Errors:
 			if !ok {
 				return
 			}
@@ -839,13 +1013,18 @@ func (s *SidebarWidget) NavigateToPath(path string) error {
 	return s.SetRootPath(path)
 }
 
-// RefreshPath обновляет указанный путь
+// RefreshPath обновляет указанный путь; пустой путь обновляет все корни
 func (s *SidebarWidget) RefreshPath(path string) {
 	if path == "" {
-		path = s.currentPath
+		for _, root := range s.roots {
+			s.refreshDirectory(root)
+		}
+	} else {
+		s.refreshDirectory(path)
+	}
+	if s.fileTree != nil {
+		s.fileTree.Refresh()
 	}
-
-	s.refreshDirectory(path)
 	s.updateStatus("Refreshed")
 }
 
@@ -858,6 +1037,13 @@ func (s *SidebarWidget) ShowContextMenu(path string, pos fyne.Position) {
 		return
 	}
 
+	// Операции применяются ко всему выделению, если щелчок пришелся на него
+	targets := s.menuTargets(path)
+	targetDir := path
+	if !node.IsDir {
+		targetDir = filepath.Dir(path)
+	}
+
 	menu := fyne.NewMenu("File Operations")
 
 	// Открыть файл
@@ -882,14 +1068,60 @@ func (s *SidebarWidget) ShowContextMenu(path string, pos fyne.Position) {
 		s.copyPathToClipboard(path)
 	}))
 
-	menu.Items = append(menu.Items, fyne.NewMenuItem("Rename", func() {
-		s.showRenameDialog(node)
+	menu.Items = append(menu.Items, fyne.NewMenuItem("Cut", func() {
+		s.setFileClipboard(targets, true)
+	}))
+
+	menu.Items = append(menu.Items, fyne.NewMenuItem("Copy", func() {
+		s.setFileClipboard(targets, false)
 	}))
 
-	menu.Items = append(menu.Items, fyne.NewMenuItem("Delete", func() {
-		s.showDeleteDialog(node)
+	if len(s.clipboardPaths) > 0 {
+		menu.Items = append(menu.Items, fyne.NewMenuItem("Paste", func() {
+			s.pasteFiles(targetDir)
+		}))
+	}
+
+	menu.Items = append(menu.Items, fyne.NewMenuItem("Duplicate", func() {
+		s.duplicatePaths(targets)
 	}))
 
+	if s.isRoot(path) {
+		// Корень рабочей области убирается из нее, а не переименовывается на диске
+		if len(s.roots) > 1 {
+			menu.Items = append(menu.Items, fyne.NewMenuItem("Remove Folder from Workspace", func() {
+				s.RemoveRoot(path)
+			}))
+		}
+	} else {
+		if len(targets) == 1 {
+			menu.Items = append(menu.Items, fyne.NewMenuItem("Rename", func() {
+				s.showRenameDialog(node)
+			}))
+		}
+
+		menu.Items = append(menu.Items, fyne.NewMenuItem("Move To...", func() {
+			s.showMoveDialog(targets)
+		}))
+
+		menu.Items = append(menu.Items, fyne.NewMenuItem("Delete", func() {
+			s.showDeleteDialog(targets)
+		}))
+	}
+
+	if s.canCompare(targets) {
+		menu.Items = append(menu.Items, fyne.NewMenuItem("Compare Selected", func() {
+			s.onCompareFiles(targets[0], targets[1])
+		}))
+	}
+
+	if len(s.fileUndo) > 0 {
+		last := s.fileUndo[len(s.fileUndo)-1]
+		menu.Items = append(menu.Items, fyne.NewMenuItem("Undo "+last.Description(), func() {
+			s.UndoFileOperation()
+		}))
+	}
+
 	menu.Items = append(menu.Items, fyne.NewMenuItemSeparator())
 
 	// Создание новых файлов/папок
@@ -909,8 +1141,7 @@ func (s *SidebarWidget) ShowContextMenu(path string, pos fyne.Position) {
 		s.showPropertiesDialog(node)
 	}))
 
-	// Показываем меню (в Fyne нет popup menu, используем диалог)
-	s.showContextMenuDialog(menu)
+	widget.ShowPopUpMenuAtPosition(menu, fyne.CurrentApp().Driver().CanvasForObject(s.fileTree), pos)
 }
 
 // File operation implementations
@@ -948,21 +1179,36 @@ func (s *SidebarWidget) showRenameDialog(node *FileNode) {
 }
 
 // showDeleteDialog показывает диалог удаления
-func (s *SidebarWidget) showDeleteDialog(node *FileNode) {
-	fileType := "file"
-	if node.IsDir {
-		fileType = "folder"
+func (s *SidebarWidget) showDeleteDialog(paths []string) {
+	message := fmt.Sprintf("Move %d items to the trash?", len(paths))
+	if len(paths) == 1 {
+		fileType := "file"
+		if node := s.getNodeByPath(paths[0]); node != nil && node.IsDir {
+			fileType = "folder"
+		}
+		message = fmt.Sprintf("Move the %s '%s' to the trash?", fileType, filepath.Base(paths[0]))
 	}
 
-	message := fmt.Sprintf("Are you sure you want to delete the %s '%s'?", fileType, node.Name)
-
 	dialog.ShowConfirm("Delete", message, func(confirmed bool) {
 		if confirmed {
-			s.deleteFile(node)
+			s.deletePaths(paths)
 		}
 	}, fyne.CurrentApp().Driver().AllWindows()[0])
 }
 
+// showMoveDialog предлагает выбрать папку для перемещения
+func (s *SidebarWidget) showMoveDialog(paths []string) {
+	dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			s.updateStatus(fmt.Sprintf("Cannot select folder: %v", err))
+			return
+		}
+		if dir != nil {
+			s.movePaths(paths, dir.Path())
+		}
+	}, fyne.CurrentApp().Driver().AllWindows()[0]).Show()
+}
+
 // showNewFileDialog показывает диалог создания файла
 func (s *SidebarWidget) showNewFileDialog(parentNode *FileNode) {
 	entry := widget.NewEntry()
@@ -1020,51 +1266,87 @@ func (s *SidebarWidget) showPropertiesDialog(node *FileNode) {
 
 // renameFile переименовывает файл
 func (s *SidebarWidget) renameFile(node *FileNode, newName string) {
-	oldPath := node.Path
-	newPath := filepath.Join(filepath.Dir(oldPath), newName)
-
-	err := os.Rename(oldPath, newPath)
-	if err != nil {
-		dialog.ShowError(fmt.Errorf("cannot rename file: %v", err), fyne.CurrentApp().Driver().AllWindows()[0])
+	if strings.ContainsRune(newName, filepath.Separator) {
+		dialog.ShowError(fmt.Errorf("invalid name: %s", newName), fyne.CurrentApp().Driver().AllWindows()[0])
 		return
 	}
+	s.transfer([]pathMove{{from: node.Path, to: filepath.Join(filepath.Dir(node.Path), newName)}}, transferMove)
+}
 
-	// Обновляем узел
-	node.Path = newPath
-	node.Name = newName
-	node.Extension = filepath.Ext(newName)
+// UndoFileOperation отменяет последнюю файловую операцию
+func (s *SidebarWidget) UndoFileOperation() {
+	if len(s.fileUndo) == 0 {
+		s.updateStatus("Nothing to undo")
+		return
+	}
 
-	// Обновляем карту
-	delete(s.fileNodes, oldPath)
-	s.fileNodes[newPath] = node
+	op := s.fileUndo[len(s.fileUndo)-1]
+	err := op.Undo(s)
 
-	s.fileTree.Refresh()
-	s.updateStatus(fmt.Sprintf("Renamed to: %s", newName))
-}
+	var dirs []string
+	for _, path := range op.Paths() {
+		dirs = append(dirs, filepath.Dir(path))
+	}
+	s.refreshAfterChange(dirs...)
 
-// deleteFile удаляет файл
-func (s *SidebarWidget) deleteFile(node *FileNode) {
-	err := os.RemoveAll(node.Path)
 	if err != nil {
-		dialog.ShowError(fmt.Errorf("cannot delete file: %v", err), fyne.CurrentApp().Driver().AllWindows()[0])
+		dialog.ShowError(fmt.Errorf("cannot undo %s: %v", op.Description(), err), fyne.CurrentApp().Driver().AllWindows()[0])
 		return
 	}
+	s.fileUndo = s.fileUndo[:len(s.fileUndo)-1]
+	s.updateStatus("Undone: " + op.Description())
+}
 
-	// Удаляем из parent
-	if node.Parent != nil {
-		for i, child := range node.Parent.Children {
-			if child.Path == node.Path {
-				node.Parent.Children = append(node.Parent.Children[:i], node.Parent.Children[i+1:]...)
-				break
-			}
-		}
+// pushFileOperation добавляет операцию в историю
+func (s *SidebarWidget) pushFileOperation(op fileOperation) {
+	s.fileUndo = append(s.fileUndo, op)
+	if len(s.fileUndo) > maxFileUndo {
+		s.fileUndo = s.fileUndo[len(s.fileUndo)-maxFileUndo:]
+	}
+}
+
+// notifyRenamed сообщает открытым редакторам о переименовании
+func (s *SidebarWidget) notifyRenamed(oldPath, newPath string) {
+	s.forgetNodes(oldPath)
+	if s.onPathRenamed != nil {
+		s.onPathRenamed(oldPath, newPath)
+	}
+}
+
+// notifyDeleted сообщает открытым редакторам об удалении
+func (s *SidebarWidget) notifyDeleted(path string) {
+	s.forgetNodes(path)
+	if s.onPathDeleted != nil {
+		s.onPathDeleted(path)
 	}
+}
 
-	// Удаляем из карты
-	delete(s.fileNodes, node.Path)
+// forgetNodes удаляет из кэша узлы по пути и все вложенные
+func (s *SidebarWidget) forgetNodes(path string) {
+	s.loadMutex.Lock()
+	defer s.loadMutex.Unlock()
+
+	for nodePath := range s.fileNodes {
+		if !s.isRoot(nodePath) && isSubPath(path, nodePath) {
+			delete(s.fileNodes, nodePath)
+		}
+	}
+	for selected := range s.selection {
+		if isSubPath(path, selected) {
+			delete(s.selection, selected)
+		}
+	}
+}
 
-	s.fileTree.Refresh()
-	s.updateStatus(fmt.Sprintf("Deleted: %s", node.Name))
+// refreshAfterChange обновляет затронутые операцией директории
+func (s *SidebarWidget) refreshAfterChange(dirs ...string) {
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if !seen[dir] {
+			seen[dir] = true
+			s.RefreshPath(dir)
+		}
+	}
 }
 
 // createNewFile создает новый файл
@@ -1086,7 +1368,8 @@ func (s *SidebarWidget) createNewFile(parentNode *FileNode, fileName string) {
 	file.Close()
 
 	// Обновляем дерево
-	s.refreshDirectory(parentNode.Path)
+	s.pushFileOperation(&createOperation{path: newPath})
+	s.refreshAfterChange(parentNode.Path)
 	s.updateStatus(fmt.Sprintf("Created file: %s", fileName))
 }
 
@@ -1101,7 +1384,8 @@ func (s *SidebarWidget) createNewFolder(parentNode *FileNode, folderName string)
 	}
 
 	// Обновляем дерево
-	s.refreshDirectory(parentNode.Path)
+	s.pushFileOperation(&createOperation{path: newPath})
+	s.refreshAfterChange(parentNode.Path)
 	s.updateStatus(fmt.Sprintf("Created folder: %s", folderName))
 }
 
@@ -1248,28 +1532,6 @@ func (s *SidebarWidget) updateStatus(message string) {
 	}
 }
 
-// showContextMenuDialog показывает контекстное меню как диалог
-func (s *SidebarWidget) showContextMenuDialog(menu *fyne.Menu) {
-	buttons := []fyne.CanvasObject{}
-
-	for _, item := range menu.Items {
-		if item.Label == "-" { // в fyne разделитель указывают через Label = "-"
-			buttons = append(buttons, widget.NewSeparator())
-		} else {
-			btn := widget.NewButton(item.Label, item.Action)
-			buttons = append(buttons, btn)
-		}
-	}
-
-	content := container.NewVBox(buttons...)
-	dialog.NewCustom(
-		"File Operations",
-		"Close",
-		content,
-		fyne.CurrentApp().Driver().AllWindows()[0],
-	).Show()
-}
-
 // Public API methods
 
 // SetVisible устанавливает видимость сайдбара
@@ -1306,6 +1568,48 @@ func (s *SidebarWidget) SetCallbacks(onFileSelected, onFileOpened func(string),
 	s.onPathChanged = onPathChanged
 }
 
+// SetFileOpCallbacks задает обработчики переименования и удаления файлов,
+// чтобы открытые буферы могли следовать за ними, и сравнения двух файлов
+func (s *SidebarWidget) SetFileOpCallbacks(onPathRenamed func(oldPath, newPath string), onPathDeleted func(string), onCompareFiles func(left, right string)) {
+	s.onPathRenamed = onPathRenamed
+	s.onPathDeleted = onPathDeleted
+	s.onCompareFiles = onCompareFiles
+}
+
+// SetRootsCallback задает обработчик изменения корней рабочей области
+func (s *SidebarWidget) SetRootsCallback(onRootsChanged func([]string)) {
+	s.onRootsChanged = onRootsChanged
+}
+
+// ActiveView возвращает заголовок открытой вкладки боковой панели
+func (s *SidebarWidget) ActiveView() string {
+	if s.viewTabs == nil || s.viewTabs.Selected() == nil {
+		return ""
+	}
+	return s.viewTabs.Selected().Text
+}
+
+// AddView добавляет в боковую панель вкладку с дополнительным представлением
+func (s *SidebarWidget) AddView(title string, icon fyne.Resource, content fyne.CanvasObject) {
+	if s.viewTabs == nil {
+		return
+	}
+	s.viewTabs.Append(container.NewTabItemWithIcon(title, icon, content))
+}
+
+// ShowView переключает боковую панель на вкладку с указанным заголовком
+func (s *SidebarWidget) ShowView(title string) {
+	if s.viewTabs == nil {
+		return
+	}
+	for _, item := range s.viewTabs.Items {
+		if item.Text == title {
+			s.viewTabs.Select(item)
+			return
+		}
+	}
+}
+
 // CreateRenderer реализует интерфейс fyne.Widget
 func (s *SidebarWidget) CreateRenderer() fyne.WidgetRenderer {
 	return widget.NewSimpleRenderer(s.mainContainer)
@@ -0,0 +1,607 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// fileTreeNode - строка дерева файлов: рисует множественное выделение,
+// открывает контекстное меню и служит источником перетаскивания
+type fileTreeNode struct {
+	widget.BaseWidget
+
+	sidebar    *SidebarWidget
+	uid        string
+	background *canvas.Rectangle
+	icon       *widget.Icon
+	label      *widget.Label
+}
+
+func newFileTreeNode(s *SidebarWidget) *fileTreeNode {
+	n := &fileTreeNode{
+		sidebar:    s,
+		background: canvas.NewRectangle(theme.Color(theme.ColorNameSelection)),
+		icon:       widget.NewIcon(theme.DocumentIcon()),
+		label:      widget.NewLabel("Template"),
+	}
+	n.background.Hide()
+	n.ExtendBaseWidget(n)
+	return n
+}
+
+func (n *fileTreeNode) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(n.background, container.NewHBox(n.icon, n.label)))
+}
+
+// updateBackground подсвечивает выделенный узел и цель перетаскивания
+func (n *fileTreeNode) updateBackground() {
+	switch {
+	case n.uid != "" && n.uid == n.sidebar.dropTarget:
+		n.background.FillColor = theme.Color(theme.ColorNameFocus)
+		n.background.Show()
+	case n.sidebar.selection[n.uid]:
+		n.background.FillColor = theme.Color(theme.ColorNameSelection)
+		n.background.Show()
+	default:
+		n.background.Hide()
+	}
+	n.background.Refresh()
+}
+
+func (n *fileTreeNode) TappedSecondary(ev *fyne.PointEvent) {
+	n.sidebar.ShowContextMenu(n.uid, ev.AbsolutePosition)
+}
+
+func (n *fileTreeNode) Dragged(ev *fyne.DragEvent) {
+	n.sidebar.dragOver(n.uid, ev.AbsolutePosition)
+}
+
+func (n *fileTreeNode) DragEnd() {
+	n.sidebar.drop()
+}
+
+// currentKeyModifiers возвращает зажатые модификаторы на десктопе
+func currentKeyModifiers() fyne.KeyModifier {
+	if d, ok := fyne.CurrentApp().Driver().(desktop.Driver); ok {
+		return d.CurrentKeyModifiers()
+	}
+	return 0
+}
+
+// Выделение
+
+// setSelection делает uid единственным выделенным узлом
+func (s *SidebarWidget) setSelection(uid string) {
+	s.selection = map[string]bool{uid: true}
+	s.selectionAnchor = uid
+	s.refreshNodeBackgrounds()
+}
+
+// toggleSelection добавляет узел в выделение или убирает из него (Ctrl+щелчок)
+func (s *SidebarWidget) toggleSelection(uid string) {
+	if s.selection[uid] {
+		delete(s.selection, uid)
+	} else {
+		s.selection[uid] = true
+	}
+	s.selectionAnchor = uid
+	s.refreshNodeBackgrounds()
+	s.updateStatus(fmt.Sprintf("%d selected", len(s.selection)))
+}
+
+// selectRange выделяет видимые узлы между from и to (Shift+щелчок)
+func (s *SidebarWidget) selectRange(from, to string) {
+	order := s.visibleOrder()
+	start, end := -1, -1
+	for i, uid := range order {
+		if uid == from {
+			start = i
+		}
+		if uid == to {
+			end = i
+		}
+	}
+	if start < 0 || end < 0 {
+		s.setSelection(to)
+		return
+	}
+	if start > end {
+		start, end = end, start
+	}
+
+	s.selection = make(map[string]bool)
+	for _, uid := range order[start : end+1] {
+		s.selection[uid] = true
+	}
+	s.refreshNodeBackgrounds()
+	s.updateStatus(fmt.Sprintf("%d selected", len(s.selection)))
+}
+
+// visibleOrder возвращает узлы в порядке отображения с учетом раскрытых папок
+func (s *SidebarWidget) visibleOrder() []string {
+	var order []string
+	var walk func(uid string)
+	walk = func(uid string) {
+		for _, child := range s.treeChildUIDs(uid) {
+			order = append(order, child)
+			if s.treeIsBranch(child) && s.fileTree.IsBranchOpen(child) {
+				walk(child)
+			}
+		}
+	}
+	walk("")
+	return order
+}
+
+// SelectedPaths возвращает выделенные пути в порядке сортировки
+func (s *SidebarWidget) SelectedPaths() []string {
+	paths := make([]string, 0, len(s.selection))
+	for path := range s.selection {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// menuTargets определяет, к чему применять контекстное меню: ко всему
+// выделению, если щелчок пришелся на него, иначе только к узлу под курсором
+func (s *SidebarWidget) menuTargets(uid string) []string {
+	if s.selection[uid] && len(s.selection) > 1 {
+		return s.SelectedPaths()
+	}
+	s.setSelection(uid)
+	return []string{uid}
+}
+
+// canCompare сообщает, можно ли сравнить цели меню: нужны ровно два файла
+func (s *SidebarWidget) canCompare(paths []string) bool {
+	if s.onCompareFiles == nil || len(paths) != 2 {
+		return false
+	}
+	for _, path := range paths {
+		if node := s.getNodeByPath(path); node == nil || node.IsDir {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshNodeBackgrounds перерисовывает подсветку видимых узлов
+func (s *SidebarWidget) refreshNodeBackgrounds() {
+	for node := range s.treeNodes {
+		node.updateBackground()
+	}
+}
+
+// Перетаскивание
+
+// dragOver запоминает перетаскиваемые узлы и подсвечивает папку под курсором
+func (s *SidebarWidget) dragOver(uid string, pos fyne.Position) {
+	if s.dragSources == nil {
+		if s.selection[uid] {
+			s.dragSources = s.SelectedPaths()
+		} else {
+			s.setSelection(uid)
+			s.dragSources = []string{uid}
+		}
+	}
+
+	target := ""
+	if over := s.nodeAt(pos); over != nil {
+		target = over.uid
+		if node := s.getNodeByPath(target); node != nil && !node.IsDir {
+			target = filepath.Dir(target)
+		}
+		for _, source := range s.dragSources {
+			if isSubPath(source, target) {
+				target = ""
+				break
+			}
+		}
+	}
+
+	if target != s.dropTarget {
+		s.dropTarget = target
+		s.refreshNodeBackgrounds()
+		if target != "" {
+			s.updateStatus(fmt.Sprintf("Move %d items to %s", len(s.dragSources), filepath.Base(target)))
+		}
+	}
+}
+
+// drop перемещает перетаскиваемые узлы в папку под курсором
+func (s *SidebarWidget) drop() {
+	sources, target := s.dragSources, s.dropTarget
+	s.dragSources = nil
+	s.dropTarget = ""
+	s.refreshNodeBackgrounds()
+
+	if target != "" && len(sources) > 0 {
+		s.movePaths(sources, target)
+	}
+}
+
+// nodeAt находит отображаемую строку дерева под абсолютной позицией
+func (s *SidebarWidget) nodeAt(pos fyne.Position) *fileTreeNode {
+	driver := fyne.CurrentApp().Driver()
+	for node := range s.treeNodes {
+		if node.uid == "" || !node.Visible() {
+			continue
+		}
+		// Строки из пула дерева не лежат на холсте, и позиция для них нулевая
+		origin := driver.AbsolutePositionForObject(node)
+		if origin.IsZero() {
+			continue
+		}
+		size := node.Size()
+		if pos.X >= origin.X && pos.X < origin.X+size.Width &&
+			pos.Y >= origin.Y && pos.Y < origin.Y+size.Height {
+			return node
+		}
+	}
+	return nil
+}
+
+// Буфер обмена файлов
+
+// setFileClipboard запоминает пути для последующей вставки
+func (s *SidebarWidget) setFileClipboard(paths []string, cut bool) {
+	s.clipboardPaths = append([]string(nil), paths...)
+	s.clipboardCut = cut
+	action := "Copied"
+	if cut {
+		action = "Cut"
+	}
+	s.updateStatus(fmt.Sprintf("%s %d items", action, len(paths)))
+}
+
+// pasteFiles вставляет файлы из буфера в dir
+func (s *SidebarWidget) pasteFiles(dir string) {
+	if len(s.clipboardPaths) == 0 {
+		return
+	}
+	if s.clipboardCut {
+		s.movePaths(s.clipboardPaths, dir)
+		s.clipboardPaths = nil
+		s.clipboardCut = false
+		return
+	}
+	s.copyPaths(s.clipboardPaths, dir)
+}
+
+// Операции над несколькими файлами
+
+// transferMode определяет, перемещать или копировать файлы
+type transferMode int
+
+const (
+	transferMove transferMode = iota
+	transferCopy
+)
+
+// pathMove - пара исходного и целевого пути
+type pathMove struct {
+	from, to string
+}
+
+// movePaths перемещает пути в директорию dir
+func (s *SidebarWidget) movePaths(paths []string, dir string) {
+	var moves []pathMove
+	for _, path := range topLevelPaths(paths) {
+		if filepath.Dir(path) == dir {
+			continue
+		}
+		if s.isRoot(path) {
+			dialog.ShowError(fmt.Errorf("cannot move workspace folder '%s'", filepath.Base(path)), fyne.CurrentApp().Driver().AllWindows()[0])
+			return
+		}
+		if isSubPath(path, dir) {
+			dialog.ShowError(fmt.Errorf("cannot move '%s' into itself", filepath.Base(path)), fyne.CurrentApp().Driver().AllWindows()[0])
+			return
+		}
+		moves = append(moves, pathMove{from: path, to: filepath.Join(dir, filepath.Base(path))})
+	}
+	s.transfer(moves, transferMove)
+}
+
+// copyPaths копирует пути в директорию dir; копия в ту же папку получает новое имя
+func (s *SidebarWidget) copyPaths(paths []string, dir string) {
+	var moves []pathMove
+	for _, path := range topLevelPaths(paths) {
+		if isSubPath(path, dir) && filepath.Dir(path) != dir {
+			dialog.ShowError(fmt.Errorf("cannot copy '%s' into itself", filepath.Base(path)), fyne.CurrentApp().Driver().AllWindows()[0])
+			return
+		}
+		// Цель может совпадать с источником и через символическую ссылку на папку
+		to := filepath.Join(dir, filepath.Base(path))
+		if to == path || samePath(path, to) {
+			to = uniqueCopyName(dir, filepath.Base(path))
+		}
+		moves = append(moves, pathMove{from: path, to: to})
+	}
+	s.transfer(moves, transferCopy)
+}
+
+// duplicatePaths создает копии рядом с оригиналами
+func (s *SidebarWidget) duplicatePaths(paths []string) {
+	var moves []pathMove
+	for _, path := range topLevelPaths(paths) {
+		moves = append(moves, pathMove{from: path, to: uniqueCopyName(filepath.Dir(path), filepath.Base(path))})
+	}
+	s.transfer(moves, transferCopy)
+}
+
+// deletePaths перемещает пути в корзину одним шагом отмены
+func (s *SidebarWidget) deletePaths(paths []string) {
+	var ops []fileOperation
+	var dirs []string
+	var err error
+	for _, path := range topLevelPaths(paths) {
+		var entry *trashEntry
+		entry, err = moveToTrash(path)
+		if err != nil {
+			err = fmt.Errorf("cannot move '%s' to trash: %v", filepath.Base(path), err)
+			break
+		}
+		ops = append(ops, &deleteOperation{entry: entry})
+		dirs = append(dirs, filepath.Dir(path))
+		s.notifyDeleted(path)
+	}
+
+	s.pushFileOperations(fmt.Sprintf("Delete %d items", len(ops)), ops)
+	s.refreshAfterChange(dirs...)
+	if err != nil {
+		dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
+		return
+	}
+	if len(ops) == 1 {
+		s.updateStatus("Moved to trash: " + filepath.Base(ops[0].Paths()[0]))
+	} else {
+		s.updateStatus(fmt.Sprintf("Moved %d items to trash", len(ops)))
+	}
+}
+
+// transfer проверяет конфликты имен, спрашивает о замене и выполняет
+// перемещение или копирование
+func (s *SidebarWidget) transfer(moves []pathMove, mode transferMode) {
+	if len(moves) == 0 {
+		return
+	}
+
+	var conflicts []string
+	for _, m := range moves {
+		targetInfo, err := os.Lstat(m.to)
+		if err != nil {
+			continue
+		}
+		sourceInfo, err := os.Lstat(m.from)
+		if err != nil {
+			dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
+			return
+		}
+		// Смена регистра имени на нечувствительной к регистру ФС - это тот же файл
+		if mode == transferMove && os.SameFile(sourceInfo, targetInfo) {
+			continue
+		}
+		// Копирование файла в самого себя обнулило бы его
+		if os.SameFile(sourceInfo, targetInfo) {
+			dialog.ShowError(fmt.Errorf("cannot copy '%s' onto itself", filepath.Base(m.from)), fyne.CurrentApp().Driver().AllWindows()[0])
+			return
+		}
+		if targetInfo.IsDir() != sourceInfo.IsDir() {
+			kind := "file"
+			if targetInfo.IsDir() {
+				kind = "folder"
+			}
+			dialog.ShowError(fmt.Errorf("cannot replace '%s': a %s with this name exists", filepath.Base(m.to), kind), fyne.CurrentApp().Driver().AllWindows()[0])
+			return
+		}
+		conflicts = append(conflicts, filepath.Base(m.to))
+	}
+
+	run := func() {
+		if mode == transferCopy {
+			s.runCopy(moves)
+		} else {
+			s.runMove(moves)
+		}
+	}
+	if len(conflicts) == 0 {
+		run()
+		return
+	}
+
+	message := fmt.Sprintf("'%s' already exists in %s.", conflicts[0], filepath.Dir(moves[0].to))
+	if len(conflicts) > 1 {
+		message = fmt.Sprintf("%d items already exist: %s.", len(conflicts), strings.Join(conflicts, ", "))
+	}
+	dialog.ShowConfirm("Replace", message+"\nReplace? Existing items will be moved to the trash.", func(confirmed bool) {
+		if confirmed {
+			run()
+		}
+	}, fyne.CurrentApp().Driver().AllWindows()[0])
+}
+
+// replaceTarget отправляет в корзину существующий путь, который будет заменен
+func replaceTarget(m pathMove) (*trashEntry, error) {
+	if _, err := os.Lstat(m.to); err != nil || samePath(m.from, m.to) {
+		return nil, nil
+	}
+	return moveToTrash(m.to)
+}
+
+// samePath сообщает, указывают ли оба пути на один и тот же файл
+func samePath(a, b string) bool {
+	aInfo, err := os.Lstat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Lstat(b)
+	return err == nil && os.SameFile(aInfo, bInfo)
+}
+
+// runMove переименовывает пути; замененные файлы уходят в корзину
+func (s *SidebarWidget) runMove(moves []pathMove) {
+	var ops []fileOperation
+	var dirs []string
+	var err error
+	for _, m := range moves {
+		op := &renameOperation{from: m.from, to: m.to}
+		op.overwritten, err = replaceTarget(m)
+		if err != nil {
+			err = fmt.Errorf("cannot replace '%s': %v", filepath.Base(m.to), err)
+			break
+		}
+		if op.overwritten != nil {
+			s.notifyDeleted(m.to)
+		}
+
+		if err = movePath(m.from, m.to); err != nil {
+			if op.overwritten != nil {
+				if restoreErr := restoreFromTrash(op.overwritten); restoreErr != nil {
+					err = fmt.Errorf("%v (restore failed: %v)", err, restoreErr)
+				}
+			}
+			err = fmt.Errorf("cannot move '%s': %v", filepath.Base(m.from), err)
+			dirs = append(dirs, filepath.Dir(m.to))
+			break
+		}
+
+		ops = append(ops, op)
+		dirs = append(dirs, filepath.Dir(m.from), filepath.Dir(m.to))
+		s.notifyRenamed(m.from, m.to)
+	}
+
+	label := fmt.Sprintf("Move %d items", len(ops))
+	s.pushFileOperations(label, ops)
+	s.refreshAfterChange(dirs...)
+	if err != nil {
+		dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
+		return
+	}
+	if len(ops) == 1 {
+		s.updateStatus(ops[0].Description() + " done")
+	} else {
+		s.updateStatus(label + " done")
+	}
+}
+
+// runCopy копирует пути в фоне, показывая прогресс с возможностью отмены
+func (s *SidebarWidget) runCopy(moves []pathMove) {
+	win := fyne.CurrentApp().Driver().AllWindows()[0]
+
+	status := widget.NewLabel("Preparing...")
+	bar := widget.NewProgressBar()
+	var cancelled atomic.Bool
+	cancelBtn := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), func() {
+		cancelled.Store(true)
+	})
+	progress := dialog.NewCustomWithoutButtons("Copying",
+		container.NewVBox(status, bar, container.NewCenter(cancelBtn)), win)
+	progress.Resize(fyne.NewSize(420, 0))
+	progress.Show()
+
+	go func() {
+		var total int64
+		for _, m := range moves {
+			total += pathSize(m.from)
+		}
+
+		var done int64
+		var lastUpdate time.Time
+		var ops []fileOperation
+		var err error
+		for _, m := range moves {
+			name := filepath.Base(m.from)
+			op := &createOperation{path: m.to, copied: true}
+			op.overwritten, err = replaceTarget(m)
+			if err != nil {
+				err = fmt.Errorf("cannot replace '%s': %v", filepath.Base(m.to), err)
+				break
+			}
+
+			// Частичную копию удаляем, только если цель создана этим копированием
+			_, statErr := os.Lstat(m.to)
+			created := os.IsNotExist(statErr)
+			err = copyPath(m.from, m.to, func(n int64) error {
+				if cancelled.Load() {
+					return errCopyCancelled
+				}
+				done += n
+				if now := time.Now(); now.Sub(lastUpdate) > 100*time.Millisecond {
+					lastUpdate = now
+					value := 1.0
+					if total > 0 {
+						value = float64(done) / float64(total)
+					}
+					fyne.Do(func() {
+						status.SetText("Copying " + name)
+						bar.SetValue(value)
+					})
+				}
+				return nil
+			})
+			if err != nil {
+				if created {
+					os.RemoveAll(m.to)
+				}
+				if op.overwritten != nil {
+					restoreFromTrash(op.overwritten)
+				}
+				break
+			}
+			ops = append(ops, op)
+		}
+
+		fyne.Do(func() {
+			progress.Hide()
+
+			var dirs []string
+			for _, op := range ops {
+				created := op.(*createOperation)
+				if created.overwritten != nil {
+					s.notifyDeleted(created.path)
+				}
+				dirs = append(dirs, filepath.Dir(created.path))
+			}
+			label := fmt.Sprintf("Copy %d items", len(ops))
+			s.pushFileOperations(label, ops)
+			s.refreshAfterChange(dirs...)
+
+			switch {
+			case err == errCopyCancelled:
+				s.updateStatus("Copy cancelled")
+			case err != nil:
+				dialog.ShowError(fmt.Errorf("copy failed: %v", err), win)
+			case len(ops) == 1:
+				s.updateStatus("Copied to: " + ops[0].Paths()[0])
+			default:
+				s.updateStatus(label + " done")
+			}
+		})
+	}()
+}
+
+// pushFileOperations записывает выполненные операции как один шаг отмены
+func (s *SidebarWidget) pushFileOperations(label string, ops []fileOperation) {
+	switch len(ops) {
+	case 0:
+	case 1:
+		s.pushFileOperation(ops[0])
+	default:
+		s.pushFileOperation(&batchOperation{label: label, ops: ops})
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SourceControlPanel - панель управления изменениями git в боковой панели
+type SourceControlPanel struct {
+	widget.BaseWidget
+
+	// UI компоненты
+	mainContainer *fyne.Container
+	branchLabel   *widget.Label
+	messageEntry  *widget.Entry
+	amendCheck    *widget.Check
+	fileList      *widget.List
+	statusLabel   *widget.Label
+
+	// Состояние
+	config  *Config
+	window  fyne.Window
+	workDir string
+	root    string
+	rows    []scmRow
+
+	// Callbacks
+	onOpenDiff func(title, leftName, left, rightName, right string)
+	onOpenFile func(path string)
+	onChanged  func()
+}
+
+// scmRow - строка списка изменений: заголовок группы или файл
+type scmRow struct {
+	header string
+	status GitFileStatus
+	staged bool
+}
+
+// NewSourceControlPanel создает панель управления версиями
+func NewSourceControlPanel(config *Config, window fyne.Window) *SourceControlPanel {
+	p := &SourceControlPanel{
+		config: config,
+		window: window,
+	}
+	p.ExtendBaseWidget(p)
+	p.setupComponents()
+	return p
+}
+
+// setupComponents создает UI компоненты
+func (p *SourceControlPanel) setupComponents() {
+	p.branchLabel = widget.NewLabel("No repository")
+	p.branchLabel.TextStyle.Bold = true
+
+	p.messageEntry = widget.NewMultiLineEntry()
+	p.messageEntry.SetPlaceHolder("Commit message")
+	p.messageEntry.SetMinRowsVisible(3)
+
+	p.amendCheck = widget.NewCheck("Amend", func(checked bool) {
+		if checked && strings.TrimSpace(p.messageEntry.Text) == "" {
+			if msg, err := p.git().LastCommitMessage(); err == nil {
+				p.messageEntry.SetText(msg)
+			}
+		}
+	})
+
+	commitBtn := widget.NewButtonWithIcon("Commit", theme.ConfirmIcon(), p.commit)
+	commitBtn.Importance = widget.HighImportance
+
+	p.fileList = widget.NewList(
+		func() int { return len(p.rows) },
+		p.createRow,
+		p.updateRow,
+	)
+	p.fileList.OnSelected = func(id widget.ListItemID) {
+		if id >= 0 && id < len(p.rows) && p.rows[id].header == "" {
+			p.openDiff(p.rows[id])
+		}
+		p.fileList.UnselectAll()
+	}
+
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), p.Reload)
+	branchBtn := widget.NewButtonWithIcon("", theme.MenuIcon(), p.showBranchDialog)
+	stashBtn := widget.NewButtonWithIcon("", theme.StorageIcon(), p.showStashDialog)
+
+	header := container.NewBorder(nil, nil, nil,
+		container.NewHBox(branchBtn, stashBtn, refreshBtn), p.branchLabel)
+
+	commitBox := container.NewBorder(nil, nil, nil,
+		container.NewHBox(p.amendCheck, commitBtn), nil)
+
+	p.statusLabel = widget.NewLabel("")
+	p.statusLabel.TextStyle.Italic = true
+
+	p.mainContainer = container.NewBorder(
+		container.NewVBox(header, p.messageEntry, commitBox),
+		p.statusLabel,
+		nil,
+		nil,
+		p.fileList,
+	)
+}
+
+// createRow создает шаблон строки списка изменений
+func (p *SourceControlPanel) createRow() fyne.CanvasObject {
+	return container.NewBorder(nil, nil,
+		widget.NewLabel("M"),
+		container.NewHBox(
+			widget.NewButtonWithIcon("", theme.FileIcon(), nil),
+			widget.NewButtonWithIcon("", theme.ContentAddIcon(), nil),
+			widget.NewButtonWithIcon("", theme.ContentUndoIcon(), nil),
+		),
+		widget.NewLabel("file"),
+	)
+}
+
+// updateRow заполняет строку списка изменений
+func (p *SourceControlPanel) updateRow(id widget.ListItemID, o fyne.CanvasObject) {
+	if id < 0 || id >= len(p.rows) {
+		return
+	}
+	row := p.rows[id]
+	box := o.(*fyne.Container)
+	nameLabel := box.Objects[0].(*widget.Label)
+	statusLabel := box.Objects[1].(*widget.Label)
+	buttons := box.Objects[2].(*fyne.Container)
+	openBtn := buttons.Objects[0].(*widget.Button)
+	stageBtn := buttons.Objects[1].(*widget.Button)
+	discardBtn := buttons.Objects[2].(*widget.Button)
+
+	if row.header != "" {
+		nameLabel.SetText(row.header)
+		nameLabel.TextStyle.Bold = true
+		statusLabel.SetText("")
+		buttons.Hide()
+		return
+	}
+
+	nameLabel.TextStyle.Bold = false
+	buttons.Show()
+
+	name := row.status.Path
+	if row.status.OrigPath != "" && row.staged {
+		name = row.status.OrigPath + " → " + row.status.Path
+	}
+	nameLabel.SetText(name)
+
+	code := row.status.WorkTree
+	if row.staged {
+		code = row.status.Index
+	}
+	if row.status.IsUntracked() {
+		code = 'U'
+	}
+	statusLabel.SetText(string(code))
+
+	openBtn.OnTapped = func() {
+		if p.onOpenFile != nil {
+			p.onOpenFile(filepath.Join(p.root, filepath.FromSlash(row.status.Path)))
+		}
+	}
+
+	if row.staged {
+		stageBtn.SetIcon(theme.ContentRemoveIcon())
+		stageBtn.OnTapped = func() { p.runAndRefresh(func(g *GitClient) error { return g.Unstage(row.status.Path) }) }
+		discardBtn.Hide()
+	} else {
+		stageBtn.SetIcon(theme.ContentAddIcon())
+		stageBtn.OnTapped = func() { p.runAndRefresh(func(g *GitClient) error { return g.Stage(row.status.Path) }) }
+		discardBtn.Show()
+		discardBtn.OnTapped = func() { p.confirmDiscard(row.status) }
+	}
+}
+
+// SetWorkDir задает директорию, для которой отображается состояние репозитория
+func (p *SourceControlPanel) SetWorkDir(dir string) {
+	p.workDir = dir
+	p.Reload()
+}
+
+// git возвращает клиент git для корня репозитория
+func (p *SourceControlPanel) git() *GitClient {
+	dir := p.root
+	if dir == "" {
+		dir = p.workDir
+	}
+	gitPath := ""
+	if p.config != nil {
+		gitPath = p.config.ExternalTools.GitPath
+	}
+	return NewGitClient(gitPath, dir)
+}
+
+// Reload перечитывает состояние репозитория
+func (p *SourceControlPanel) Reload() {
+	if p.workDir == "" {
+		return
+	}
+	workDir := p.workDir
+
+	go func() {
+		gitPath := ""
+		if p.config != nil {
+			gitPath = p.config.ExternalTools.GitPath
+		}
+		root, err := NewGitClient(gitPath, workDir).RepoRoot()
+		if err != nil {
+			fyne.Do(func() {
+				p.root = ""
+				p.rows = nil
+				p.branchLabel.SetText("No repository")
+				p.statusLabel.SetText("")
+				p.fileList.Refresh()
+			})
+			return
+		}
+
+		git := NewGitClient(gitPath, root)
+		branch, _ := git.CurrentBranch()
+		statuses, err := git.Status()
+
+		fyne.Do(func() {
+			p.root = root
+			if err != nil {
+				p.statusLabel.SetText(err.Error())
+				return
+			}
+			p.branchLabel.SetText(fmt.Sprintf("%s (%s)", filepath.Base(root), branch))
+			p.setStatuses(statuses)
+		})
+	}()
+}
+
+// setStatuses группирует файлы на проиндексированные и непроиндексированные
+func (p *SourceControlPanel) setStatuses(statuses []GitFileStatus) {
+	var staged, unstaged []scmRow
+	for _, s := range statuses {
+		if s.IsStaged() {
+			staged = append(staged, scmRow{status: s, staged: true})
+		}
+		if s.IsUnstaged() {
+			unstaged = append(unstaged, scmRow{status: s})
+		}
+	}
+
+	p.rows = nil
+	if len(staged) > 0 {
+		p.rows = append(p.rows, scmRow{header: fmt.Sprintf("Staged Changes (%d)", len(staged))})
+		p.rows = append(p.rows, staged...)
+	}
+	if len(unstaged) > 0 {
+		p.rows = append(p.rows, scmRow{header: fmt.Sprintf("Changes (%d)", len(unstaged))})
+		p.rows = append(p.rows, unstaged...)
+	}
+
+	if len(p.rows) == 0 {
+		p.statusLabel.SetText("No changes")
+	} else {
+		p.statusLabel.SetText(fmt.Sprintf("%d staged, %d changed", len(staged), len(unstaged)))
+	}
+	p.fileList.Refresh()
+}
+
+// runAndRefresh выполняет операцию git и обновляет панель
+func (p *SourceControlPanel) runAndRefresh(op func(g *GitClient) error) {
+	git := p.git()
+	go func() {
+		err := op(git)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, p.window)
+			}
+			p.Reload()
+			if p.onChanged != nil {
+				p.onChanged()
+			}
+		})
+	}()
+}
+
+// confirmDiscard запрашивает подтверждение и отменяет изменения файла
+func (p *SourceControlPanel) confirmDiscard(status GitFileStatus) {
+	message := fmt.Sprintf("Discard changes in '%s'?", status.Path)
+	if status.IsUntracked() {
+		message = fmt.Sprintf("Delete untracked file '%s'?", status.Path)
+	}
+	dialog.ShowConfirm("Discard Changes", message, func(confirmed bool) {
+		if confirmed {
+			p.runAndRefresh(func(g *GitClient) error { return g.Discard(status) })
+		}
+	}, p.window)
+}
+
+// commit создает коммит из проиндексированных изменений
+func (p *SourceControlPanel) commit() {
+	message := strings.TrimSpace(p.messageEntry.Text)
+	if message == "" {
+		dialog.ShowInformation("Commit", "Please enter a commit message", p.window)
+		return
+	}
+	amend := p.amendCheck.Checked
+
+	git := p.git()
+	go func() {
+		err := git.Commit(message, amend)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, p.window)
+				return
+			}
+			p.messageEntry.SetText("")
+			p.amendCheck.SetChecked(false)
+			p.Reload()
+			if p.onChanged != nil {
+				p.onChanged()
+			}
+		})
+	}()
+}
+
+// openDiff открывает сравнение изменений файла
+func (p *SourceControlPanel) openDiff(row scmRow) {
+	if p.onOpenDiff == nil {
+		return
+	}
+	git := p.git()
+	root := p.root
+
+	go func() {
+		var leftName, left, rightName, right string
+		if row.staged {
+			origPath := row.status.Path
+			if row.status.OrigPath != "" {
+				origPath = row.status.OrigPath
+			}
+			leftName, rightName = "HEAD", "Index"
+			// Новый файл отсутствует в HEAD
+			left, _ = git.FileAtRevision("HEAD", origPath)
+			right, _ = git.IndexContent(row.status.Path)
+		} else {
+			leftName, rightName = "Index", "Working Copy"
+			if !row.status.IsUntracked() {
+				left, _ = git.IndexContent(row.status.Path)
+			}
+			data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(row.status.Path)))
+			if err == nil {
+				right = string(data)
+			}
+		}
+
+		fyne.Do(func() {
+			p.onOpenDiff(filepath.Base(row.status.Path), leftName, left, rightName, right)
+		})
+	}()
+}
+
+// showBranchDialog показывает диалог создания и переключения веток
+func (p *SourceControlPanel) showBranchDialog() {
+	if p.root == "" {
+		return
+	}
+	git := p.git()
+	branches, err := git.Branches()
+	if err != nil {
+		dialog.ShowError(err, p.window)
+		return
+	}
+	current, _ := git.CurrentBranch()
+
+	branchSelect := widget.NewSelect(branches, nil)
+	branchSelect.SetSelected(current)
+
+	newBranchEntry := widget.NewEntry()
+	newBranchEntry.SetPlaceHolder("new-branch-name")
+
+	dialog.ShowForm("Branches", "Apply", "Cancel", []*widget.FormItem{
+		{Text: "Checkout:", Widget: branchSelect},
+		{Text: "Create:", Widget: newBranchEntry, HintText: "Leave empty to switch to the selected branch"},
+	}, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		name := strings.TrimSpace(newBranchEntry.Text)
+		if name != "" {
+			p.runAndRefresh(func(g *GitClient) error { return g.CreateBranch(name, true) })
+		} else if branchSelect.Selected != "" && branchSelect.Selected != current {
+			selected := branchSelect.Selected
+			p.runAndRefresh(func(g *GitClient) error { return g.Checkout(selected) })
+		}
+	}, p.window)
+}
+
+// showStashDialog показывает операции со stash
+func (p *SourceControlPanel) showStashDialog() {
+	if p.root == "" {
+		return
+	}
+	stashes, err := p.git().StashList()
+	if err != nil {
+		dialog.ShowError(err, p.window)
+		return
+	}
+
+	messageEntry := widget.NewEntry()
+	messageEntry.SetPlaceHolder("Stash message (optional)")
+	untrackedCheck := widget.NewCheck("Include untracked files", nil)
+
+	var stashDialog dialog.Dialog
+	pushBtn := widget.NewButton("Stash Push", func() {
+		message := messageEntry.Text
+		includeUntracked := untrackedCheck.Checked
+		stashDialog.Hide()
+		p.runAndRefresh(func(g *GitClient) error { return g.StashPush(message, includeUntracked) })
+	})
+	popBtn := widget.NewButton("Stash Pop", func() {
+		stashDialog.Hide()
+		p.runAndRefresh(func(g *GitClient) error { return g.StashPop() })
+	})
+	if len(stashes) == 0 {
+		popBtn.Disable()
+	}
+
+	stashText := "No stashes"
+	if len(stashes) > 0 {
+		stashText = strings.Join(stashes, "\n")
+	}
+
+	content := container.NewVBox(
+		messageEntry,
+		untrackedCheck,
+		container.NewHBox(pushBtn, popBtn),
+		widget.NewSeparator(),
+		widget.NewLabel(stashText),
+	)
+	stashDialog = dialog.NewCustom("Stash", "Close", content, p.window)
+	stashDialog.Show()
+}
+
+// SetCallbacks устанавливает callback функции
+func (p *SourceControlPanel) SetCallbacks(onOpenDiff func(title, leftName, left, rightName, right string), onOpenFile func(string), onChanged func()) {
+	p.onOpenDiff = onOpenDiff
+	p.onOpenFile = onOpenFile
+	p.onChanged = onChanged
+}
+
+// CreateRenderer реализует интерфейс fyne.Widget
+func (p *SourceControlPanel) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(p.mainContainer)
+}
+
+// showSourceControl показывает панель управления версиями в боковой панели
+func (a *App) showSourceControl() {
+	if a.sidebar == nil || a.sourceControl == nil {
+		return
+	}
+	if !a.sidebar.IsVisible() {
+		a.toggleSidebar()
+	}
+	a.sidebar.ShowView("Source Control")
+	a.sourceControl.Reload()
+}
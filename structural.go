@@ -0,0 +1,773 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Шаблон структурного поиска - выражение или операторы Go с метапеременными:
+// $x соответствует любому выражению (или оператору), $x... - любому числу
+// аргументов, элементов или операторов. Перед разбором метапеременные
+// заменяются идентификаторами с этими префиксами.
+const (
+	ssrWildcardPrefix = "ssr_wc_"
+	ssrVariadicPrefix = "ssr_wcv_"
+)
+
+var ssrVariablePattern = regexp.MustCompile(`\$(\w+)(\.\.\.)?`)
+
+// ssrPattern - разобранный шаблон структурного поиска
+type ssrPattern struct {
+	expr  ast.Expr   // шаблон-выражение
+	stmts []ast.Stmt // или последовательность операторов
+	names []string   // идентификаторы шаблона кроме метапеременных, для отбора файлов
+}
+
+// ssrBinding - фрагмент кода, сопоставленный метапеременной
+type ssrBinding struct {
+	node       ast.Node // nil для $x...
+	start, end int      // смещения в файле; start == end - пустой список
+}
+
+// ssrMatch - найденное вхождение шаблона
+type ssrMatch struct {
+	Start, End int // смещения в файле
+	Line, Col  int
+	Text       string
+	bindings   map[string]ssrBinding
+}
+
+// ssrFileResult - вхождения в файле и результат замены
+type ssrFileResult struct {
+	Path     string
+	Original string
+	Replaced string // "" - только поиск
+	Matches  []ssrMatch
+	Err      error
+}
+
+// parseSSRPattern разбирает шаблон как выражение, а если не получилось - как операторы
+func parseSSRPattern(src string) (*ssrPattern, error) {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	code := ssrVariablePattern.ReplaceAllStringFunc(src, func(v string) string {
+		m := ssrVariablePattern.FindStringSubmatch(v)
+		if m[2] != "" {
+			return ssrVariadicPrefix + m[1]
+		}
+		return ssrWildcardPrefix + m[1]
+	})
+
+	p := &ssrPattern{}
+	if expr, err := parser.ParseExpr(code); err == nil {
+		p.expr = expr
+	} else {
+		file, stmtErr := parser.ParseFile(token.NewFileSet(), "", "package p\nfunc _() {\n"+code+"\n}", 0)
+		if stmtErr != nil {
+			return nil, fmt.Errorf("pattern is neither an expression nor statements: %v", err)
+		}
+		p.stmts = file.Decls[0].(*ast.FuncDecl).Body.List
+		if len(p.stmts) == 0 {
+			return nil, fmt.Errorf("pattern is empty")
+		}
+		// Один оператор-выражение ищем как выражение
+		if es, ok := p.stmts[0].(*ast.ExprStmt); ok && len(p.stmts) == 1 {
+			p.expr, p.stmts = es.X, nil
+		}
+	}
+
+	var root ast.Node = p.expr
+	if p.expr == nil {
+		root = &ast.BlockStmt{List: p.stmts}
+	}
+	ast.Inspect(root, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && !strings.HasPrefix(id.Name, ssrWildcardPrefix) &&
+			!strings.HasPrefix(id.Name, ssrVariadicPrefix) && id.Name != "_" {
+			p.names = append(p.names, id.Name)
+		}
+		return true
+	})
+	return p, nil
+}
+
+// wildcardName возвращает имя метапеременной узла шаблона
+func ssrWildcardName(v reflect.Value) (name string, variadic bool, ok bool) {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false, false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() {
+		return "", false, false
+	}
+	var id *ast.Ident
+	switch n := v.Interface().(type) {
+	case *ast.Ident:
+		id = n
+	case *ast.ExprStmt:
+		id, _ = n.X.(*ast.Ident)
+	}
+	if id == nil {
+		return "", false, false
+	}
+	if strings.HasPrefix(id.Name, ssrVariadicPrefix) {
+		return strings.TrimPrefix(id.Name, ssrVariadicPrefix), true, true
+	}
+	if strings.HasPrefix(id.Name, ssrWildcardPrefix) {
+		return strings.TrimPrefix(id.Name, ssrWildcardPrefix), false, true
+	}
+	return "", false, false
+}
+
+var (
+	ssrPosType     = reflect.TypeOf(token.NoPos)
+	ssrObjectType  = reflect.TypeOf((*ast.Object)(nil))
+	ssrScopeType   = reflect.TypeOf((*ast.Scope)(nil))
+	ssrCommentType = reflect.TypeOf((*ast.CommentGroup)(nil))
+	ssrExprType    = reflect.TypeOf((*ast.Expr)(nil)).Elem()
+	ssrStmtType    = reflect.TypeOf((*ast.Stmt)(nil)).Elem()
+	ssrNodeType    = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+// ssrMatcher сопоставляет шаблон с узлами одного файла
+type ssrMatcher struct {
+	fset     *token.FileSet
+	src      []byte
+	bindings map[string]ssrBinding
+	seqEnd   int // индекс после последнего оператора совпавшей последовательности
+}
+
+func (m *ssrMatcher) offset(pos token.Pos) int {
+	return m.fset.Position(pos).Offset
+}
+
+func (m *ssrMatcher) snapshot() map[string]ssrBinding {
+	saved := make(map[string]ssrBinding, len(m.bindings))
+	for k, v := range m.bindings {
+		saved[k] = v
+	}
+	return saved
+}
+
+// bind связывает метапеременную с узлом; повторное вхождение должно совпадать с первым
+func (m *ssrMatcher) bind(name string, node ast.Node) bool {
+	b := ssrBinding{node: node, start: m.offset(node.Pos()), end: m.offset(node.End())}
+	if prev, ok := m.bindings[name]; ok {
+		return string(m.src[prev.start:prev.end]) == string(m.src[b.start:b.end]) ||
+			ssrNodeString(m.fset, prev.node) == ssrNodeString(m.fset, node)
+	}
+	m.bindings[name] = b
+	return true
+}
+
+// bindList связывает $x... с элементами list[from:to]
+func (m *ssrMatcher) bindList(name string, list reflect.Value, from, to int) bool {
+	b := ssrBinding{}
+	if from < to {
+		b.start = m.offset(list.Index(from).Interface().(ast.Node).Pos())
+		b.end = m.offset(list.Index(to - 1).Interface().(ast.Node).End())
+	}
+	if prev, ok := m.bindings[name]; ok {
+		return string(m.src[prev.start:prev.end]) == string(m.src[b.start:b.end])
+	}
+	m.bindings[name] = b
+	return true
+}
+
+// match сравнивает узел шаблона с узлом файла без учета позиций и комментариев
+func (m *ssrMatcher) match(p, n reflect.Value) bool {
+	if name, variadic, ok := ssrWildcardName(p); ok && !variadic {
+		if n.Kind() == reflect.Interface {
+			n = n.Elem()
+		}
+		node, isNode := ssrAsNode(n)
+		if !isNode {
+			return false
+		}
+		// $x как оператор соответствует любому оператору, как выражение - любому выражению
+		if _, stmt := p.Interface().(*ast.ExprStmt); !stmt {
+			if _, isExpr := node.(ast.Expr); !isExpr {
+				return false
+			}
+		}
+		return m.bind(name, node)
+	}
+
+	if p.Kind() == reflect.Interface {
+		if p.IsNil() || n.IsNil() {
+			return p.IsNil() && n.IsNil()
+		}
+		p, n = p.Elem(), n.Elem()
+	}
+	if p.Type() != n.Type() {
+		return false
+	}
+
+	switch p.Kind() {
+	case reflect.Ptr:
+		if p.IsNil() || n.IsNil() {
+			return p.IsNil() && n.IsNil()
+		}
+		if call, ok := p.Interface().(*ast.CallExpr); ok {
+			return m.matchCall(call, n.Interface().(*ast.CallExpr))
+		}
+		return m.match(p.Elem(), n.Elem())
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			switch p.Type().Field(i).Type {
+			case ssrPosType, ssrObjectType, ssrScopeType, ssrCommentType:
+				continue
+			}
+			if !m.match(p.Field(i), n.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		elem := p.Type().Elem()
+		if elem == ssrExprType || elem == ssrStmtType {
+			return m.matchList(p, n, 0, 0, true)
+		}
+		if p.Len() != n.Len() {
+			return false
+		}
+		for i := 0; i < p.Len(); i++ {
+			if !m.match(p.Index(i), n.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.String, reflect.Int, reflect.Bool:
+		return p.Interface() == n.Interface()
+	}
+	return reflect.DeepEqual(p.Interface(), n.Interface())
+}
+
+// matchCall сравнивает вызовы; $args... в конце шаблона забирает и "..." вызова
+func (m *ssrMatcher) matchCall(p, n *ast.CallExpr) bool {
+	if !m.match(reflect.ValueOf(p.Fun), reflect.ValueOf(n.Fun)) {
+		return false
+	}
+	variadicTail := false
+	var tailName string
+	if len(p.Args) > 0 {
+		tailName, variadicTail, _ = ssrWildcardName(reflect.ValueOf(p.Args[len(p.Args)-1]))
+	}
+	if !variadicTail && p.Ellipsis.IsValid() != n.Ellipsis.IsValid() {
+		return false
+	}
+	if !m.matchList(reflect.ValueOf(p.Args), reflect.ValueOf(n.Args), 0, 0, true) {
+		return false
+	}
+	if variadicTail && n.Ellipsis.IsValid() && !p.Ellipsis.IsValid() {
+		if b := m.bindings[tailName]; b.end > b.start {
+			b.end = m.offset(n.Ellipsis) + len("...")
+			m.bindings[tailName] = b
+		}
+	}
+	return true
+}
+
+// matchList сопоставляет списки выражений или операторов с учетом $x...;
+// anchored == false допускает лишние элементы в конце списка
+func (m *ssrMatcher) matchList(p, n reflect.Value, pi, ni int, anchored bool) bool {
+	if pi == p.Len() {
+		m.seqEnd = ni
+		return !anchored || ni == n.Len()
+	}
+	if name, variadic, ok := ssrWildcardName(p.Index(pi)); ok && variadic {
+		for k := ni; k <= n.Len(); k++ {
+			saved := m.snapshot()
+			if m.bindList(name, n, ni, k) && m.matchList(p, n, pi+1, k, anchored) {
+				return true
+			}
+			m.bindings = saved
+		}
+		return false
+	}
+	if ni >= n.Len() {
+		return false
+	}
+	saved := m.snapshot()
+	if m.match(p.Index(pi), n.Index(ni)) && m.matchList(p, n, pi+1, ni+1, anchored) {
+		return true
+	}
+	m.bindings = saved
+	return false
+}
+
+// ssrAsNode приводит значение к ast.Node
+func ssrAsNode(v reflect.Value) (ast.Node, bool) {
+	if !v.IsValid() || !v.Type().Implements(ssrNodeType) || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil, false
+	}
+	node, ok := v.Interface().(ast.Node)
+	return node, ok
+}
+
+// ssrNodeString печатает узел для сравнения повторных метапеременных
+func ssrNodeString(fset *token.FileSet, node ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, node)
+	return buf.String()
+}
+
+// findSSRMatches ищет вхождения шаблона в исходном коде файла
+func findSSRMatches(pattern *ssrPattern, filename string, src []byte) ([]ssrMatch, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []ssrMatch
+	add := func(m *ssrMatcher, start, end token.Pos) {
+		s, e := m.offset(start), m.offset(end)
+		pos := fset.Position(start)
+		matches = append(matches, ssrMatch{
+			Start:    s,
+			End:      e,
+			Line:     pos.Line,
+			Col:      pos.Column - 1,
+			Text:     string(src[s:e]),
+			bindings: m.bindings,
+		})
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		if pattern.expr != nil {
+			expr, ok := node.(ast.Expr)
+			if !ok {
+				return true
+			}
+			m := &ssrMatcher{fset: fset, src: src, bindings: make(map[string]ssrBinding)}
+			if m.match(reflect.ValueOf(pattern.expr), reflect.ValueOf(expr)) {
+				add(m, expr.Pos(), expr.End())
+				return false
+			}
+			return true
+		}
+
+		var list []ast.Stmt
+		switch n := node.(type) {
+		case *ast.BlockStmt:
+			list = n.List
+		case *ast.CaseClause:
+			list = n.Body
+		case *ast.CommClause:
+			list = n.Body
+		default:
+			return true
+		}
+		pv, nv := reflect.ValueOf(pattern.stmts), reflect.ValueOf(list)
+		for i := 0; i < len(list); {
+			m := &ssrMatcher{fset: fset, src: src, bindings: make(map[string]ssrBinding)}
+			if m.matchList(pv, nv.Slice(i, len(list)), 0, 0, false) && m.seqEnd > 0 {
+				add(m, list[i].Pos(), list[i+m.seqEnd-1].End())
+				i += m.seqEnd
+				continue
+			}
+			i++
+		}
+		return true
+	})
+	return matches, nil
+}
+
+// expandSSRTemplate подставляет в шаблон замены код, сопоставленный метапеременным
+func expandSSRTemplate(template string, src []byte, bindings map[string]ssrBinding) (string, error) {
+	// Пустой $x... забирает с собой соседнюю запятую
+	for name, b := range bindings {
+		if b.node == nil && b.start == b.end {
+			v := regexp.QuoteMeta("$" + name + "...")
+			template = regexp.MustCompile(`,\s*`+v).ReplaceAllString(template, "")
+			template = regexp.MustCompile(v+`\s*,\s*`).ReplaceAllString(template, "")
+		}
+	}
+
+	var missing []string
+	result := ssrVariablePattern.ReplaceAllStringFunc(template, func(v string) string {
+		name := ssrVariablePattern.FindStringSubmatch(v)[1]
+		b, ok := bindings[name]
+		if !ok {
+			missing = append(missing, "$"+name)
+			return v
+		}
+		return string(src[b.start:b.end])
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("replacement uses unbound variables: %s", strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+// applySSRReplacement заменяет вхождения и форматирует файл через go/format
+func applySSRReplacement(src []byte, matches []ssrMatch, template string, cfg *Config) (string, error) {
+	sorted := append([]ssrMatch(nil), matches...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var buf strings.Builder
+	last := 0
+	for _, m := range sorted {
+		if m.Start < last {
+			continue // вложенное вхождение уже заменено внешним
+		}
+		replacement, err := expandSSRTemplate(template, src, m.bindings)
+		if err != nil {
+			return "", err
+		}
+		buf.Write(src[last:m.Start])
+		buf.WriteString(replacement)
+		last = m.End
+	}
+	buf.Write(src[last:])
+	return formatCode(buf.String(), "go", cfg)
+}
+
+// goFilesForSSR возвращает .go файлы каталога: по индексу поиска, если он есть, иначе обходом
+func goFilesForSSR(root string, index *SearchIndex, pattern *ssrPattern) []string {
+	root, _ = filepath.Abs(root)
+	var files []string
+	if index != nil && index.Ready() && (root == index.Root() || strings.HasPrefix(root, index.Root()+string(filepath.Separator))) {
+		// Файл должен содержать самый длинный идентификатор шаблона
+		longest := ""
+		for _, name := range pattern.names {
+			if len(name) > len(longest) {
+				longest = name
+			}
+		}
+		var candidates []string
+		if longest != "" {
+			candidates = index.Candidates(regexp.MustCompile(regexp.QuoteMeta(longest)))
+		} else {
+			candidates = index.Paths()
+		}
+		prefix := root + string(filepath.Separator)
+		for _, p := range candidates {
+			if strings.HasSuffix(p, ".go") && (root == index.Root() || strings.HasPrefix(p, prefix)) {
+				files = append(files, p)
+			}
+		}
+		return files
+	}
+
+	ignore := newIgnoreMatcher(root)
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel != "." && (searchSkipDirs[d.Name()] || ignore.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			ignore.load(rel)
+			return nil
+		}
+		if strings.HasSuffix(p, ".go") && !ignore.ignored(rel, false) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files
+}
+
+// runStructuralSearch ищет шаблон во всех файлах параллельно; read возвращает текущее
+// содержимое файла (с учетом открытого в редакторе)
+func runStructuralSearch(files []string, pattern *ssrPattern, template string, replace bool, cfg *Config,
+	read func(path string) (string, error)) []ssrFileResult {
+	paths := make(chan string)
+	var mutex sync.Mutex
+	var results []ssrFileResult
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range paths {
+				content, err := read(p)
+				if err != nil {
+					continue
+				}
+				matches, err := findSSRMatches(pattern, p, []byte(content))
+				if err != nil || len(matches) == 0 {
+					continue // файлы с синтаксическими ошибками пропускаем
+				}
+				r := ssrFileResult{Path: p, Original: content, Matches: matches}
+				if replace {
+					r.Replaced, r.Err = applySSRReplacement([]byte(content), matches, template, cfg)
+				}
+				mutex.Lock()
+				results = append(results, r)
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, p := range files {
+		paths <- p
+	}
+	close(paths)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return results
+}
+
+// showStructuralReplace открывает окно структурного поиска и замены по Go-файлам
+func (a *App) showStructuralReplace() {
+	win := a.fyneApp.NewWindow("Structural Search and Replace (Go)")
+
+	patternEntry := widget.NewMultiLineEntry()
+	patternEntry.SetPlaceHolder("fmt.Errorf($msg, $args...)")
+	patternEntry.SetMinRowsVisible(3)
+	if a.editor != nil {
+		patternEntry.SetText(a.editor.GetSelectedText())
+	}
+	replaceEntry := widget.NewMultiLineEntry()
+	replaceEntry.SetPlaceHolder("errors.Wrapf(err, $msg, $args...) - leave empty to search only")
+	replaceEntry.SetMinRowsVisible(3)
+	folderEntry := widget.NewEntry()
+	folderEntry.SetText(a.getCurrentWorkingDir())
+	if a.sidebar != nil && a.sidebar.GetCurrentPath() != "" {
+		folderEntry.SetText(a.sidebar.GetCurrentPath())
+	}
+	status := widget.NewLabel("")
+
+	var results []ssrFileResult
+	byPath := make(map[string]*ssrFileResult)
+	selected := make(map[string]bool)
+	root, current := "", ""
+	rel := func(path string) string {
+		if r, err := filepath.Rel(root, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return path
+	}
+
+	// Узлы дерева: файл - путь, вхождение - путь + "\n" + индекс
+	var tree *widget.Tree
+	tree = widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			if uid == "" {
+				ids := make([]widget.TreeNodeID, len(results))
+				for i, r := range results {
+					ids[i] = r.Path
+				}
+				return ids
+			}
+			r := byPath[uid]
+			if r == nil {
+				return nil
+			}
+			ids := make([]widget.TreeNodeID, len(r.Matches))
+			for i := range r.Matches {
+				ids[i] = uid + "\n" + strconv.Itoa(i)
+			}
+			return ids
+		},
+		func(uid widget.TreeNodeID) bool {
+			return uid == "" || !strings.Contains(uid, "\n")
+		},
+		func(bool) fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), nil, label)
+		},
+		func(uid widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			box := o.(*fyne.Container)
+			label := box.Objects[0].(*widget.Label)
+			check := box.Objects[1].(*widget.Check)
+			check.OnChanged = nil
+
+			if branch {
+				r := byPath[uid]
+				label.TextStyle.Bold = true
+				text := fmt.Sprintf("%s (%d)", rel(r.Path), len(r.Matches))
+				if r.Err != nil {
+					text += " - " + r.Err.Error()
+				}
+				label.SetText(text)
+				check.Show()
+				check.SetChecked(selected[uid])
+				check.OnChanged = func(on bool) { selected[uid] = on }
+				return
+			}
+
+			sep := strings.LastIndex(uid, "\n")
+			r := byPath[uid[:sep]]
+			i, _ := strconv.Atoi(uid[sep+1:])
+			m := r.Matches[i]
+			first, _, _ := strings.Cut(m.Text, "\n")
+			label.TextStyle.Bold = false
+			label.SetText(fmt.Sprintf("%d: %s", m.Line, first))
+			check.Hide()
+		},
+	)
+	tree.OnSelected = func(uid widget.TreeNodeID) {
+		sep := strings.LastIndex(uid, "\n")
+		if sep < 0 {
+			current = uid
+			return
+		}
+		current = uid[:sep]
+		r := byPath[current]
+		i, _ := strconv.Atoi(uid[sep+1:])
+		m := r.Matches[i]
+		first, _, _ := strings.Cut(m.Text, "\n")
+		a.openSearchMatch(r.Path, m.Line, m.Col, len(first))
+	}
+
+	var findButton *widget.Button
+	find := func() {
+		pattern, err := parseSSRPattern(patternEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		root, _ = filepath.Abs(folderEntry.Text)
+		template := strings.TrimSpace(replaceEntry.Text)
+		replace := template != ""
+		findButton.Disable()
+		status.SetText("Searching...")
+
+		go func() {
+			files := goFilesForSSR(root, a.searchIndex, pattern)
+			found := runStructuralSearch(files, pattern, template, replace, a.config, func(path string) (string, error) {
+				var content string
+				var err error
+				fyne.DoAndWait(func() { content, _, err = a.replaceSource(path) })
+				return content, err
+			})
+			fyne.Do(func() {
+				results, current = found, ""
+				byPath = make(map[string]*ssrFileResult)
+				selected = make(map[string]bool)
+				count := 0
+				for i := range results {
+					r := &results[i]
+					byPath[r.Path] = r
+					selected[r.Path] = r.Err == nil
+					count += len(r.Matches)
+				}
+				tree.Refresh()
+				for _, r := range results {
+					tree.OpenBranch(r.Path)
+				}
+				status.SetText(fmt.Sprintf("%d matches in %d files (%d Go files searched)", count, len(results), len(files)))
+				findButton.Enable()
+			})
+		}()
+	}
+	findButton = widget.NewButtonWithIcon("Find", theme.SearchIcon(), find)
+
+	// Дифф открываем для выбранного в дереве файла или для первого отмеченного
+	previewDiff := func() {
+		target := byPath[current]
+		for i := 0; target == nil && i < len(results); i++ {
+			if selected[results[i].Path] {
+				target = &results[i]
+			}
+		}
+		if target == nil {
+			return
+		}
+		if target.Replaced == "" {
+			dialog.ShowInformation("Structural Replace", "Enter a replacement template and run Find first", win)
+			return
+		}
+		if target.Err != nil {
+			dialog.ShowError(target.Err, win)
+			return
+		}
+		a.showDiffContents("Structural Replace: "+rel(target.Path),
+			rel(target.Path)+" (current)", target.Original, rel(target.Path)+" (replaced)", target.Replaced)
+	}
+
+	apply := func() {
+		cmd := &ReplaceInFilesCommand{}
+		for _, r := range results {
+			if !selected[r.Path] || r.Err != nil || r.Replaced == "" || r.Replaced == r.Original {
+				continue
+			}
+			content, open, err := a.replaceSource(r.Path)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			if content != r.Original {
+				dialog.ShowError(fmt.Errorf("%s has changed since the search, run Find again", rel(r.Path)), win)
+				return
+			}
+			if open {
+				cmd.bufferPath, cmd.bufferOld, cmd.bufferNew = r.Path, r.Original, r.Replaced
+			} else {
+				cmd.disk = append(cmd.disk, fileChange{path: r.Path, oldContent: r.Original, newContent: r.Replaced})
+			}
+			cmd.count += len(r.Matches)
+		}
+		if cmd.count == 0 {
+			return
+		}
+		if err := a.commandHistory.Execute(cmd, a.editor); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		win.Close()
+		if a.sidebar != nil {
+			a.sidebar.RefreshPath("")
+		}
+		if a.sourceControl != nil {
+			a.sourceControl.Reload()
+		}
+		dialog.ShowInformation("Structural Replace", cmd.GetDescription()+". Use Undo to revert.", a.mainWin)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Pattern", patternEntry),
+		widget.NewFormItem("Replace with", replaceEntry),
+		widget.NewFormItem("Folder", folderEntry),
+	)
+	hint := widget.NewLabel("$x matches any expression or statement, $x... any number of arguments or statements")
+	hint.Wrapping = fyne.TextWrapWord
+	top := container.NewVBox(form, hint)
+	bottom := container.NewBorder(nil, nil, nil,
+		container.NewHBox(
+			findButton,
+			widget.NewButtonWithIcon("Preview Diff", theme.VisibilityIcon(), previewDiff),
+			widget.NewButtonWithIcon("Replace", theme.ConfirmIcon(), apply),
+			widget.NewButton("Close", func() { win.Close() }),
+		),
+		status,
+	)
+
+	win.SetContent(container.NewBorder(top, bottom, nil, nil, tree))
+	win.Resize(fyne.NewSize(900, 650))
+	win.Show()
+	win.Canvas().Focus(patternEntry)
+}
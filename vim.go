@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -19,7 +19,7 @@ type VimHandler struct {
 	pendingCommand string
 	count          int
 	register       string
-	yankBuffer     string
+	registers      map[string]vimRegister
 	visualStart    TextPosition
 	visualEnd      TextPosition
 	searchPattern  string
@@ -27,38 +27,95 @@ type VimHandler struct {
 	marks          map[string]TextPosition
 	jumpList       []TextPosition
 	jumpIndex      int
-	lastCommand    string
+	lastChange     []string
+	lastInserted   string
+	lastExCommand  string
+	insertKeys     []string
+	commandKeys    []string
+	operator       string
+	operatorCount  int
+	lastFind       vimFind
+	wantCol        int
+	wantPos        int
+	onModeChanged  func(mode VimMode)
 	recording      bool
 	macroRegister  string
 	macroCommands  []string
-	macros         map[string][]string
+	lastMacro      string
+	blockToEnd     bool
+	blockInsert    *vimBlockInsert
+
+	// Воспроизведение: playback - клавиши не с клавиатуры, dotRepeat - повтор точкой
+	playback        bool
+	dotRepeat       bool
+	insertRecording bool
+
+	// Командная строка: история и перебор дополнений по Tab
+	exHistory         []string
+	exHistoryIndex    int
+	exHistoryPrefix   string
+	exCompletions     []string
+	exCompletionBase  string
+	exCompletionIndex int
+
+	// Опции :set, которых нет в настройках редактора
+	ignoreCase bool
+	smartCase  bool
+	hlsearch   bool
+	incsearch  bool
+	hlActive   bool
+
+	// Пользовательские сопоставления клавиш (:map) по режимам n, v, i
+	mappings   map[byte]map[string]vimMapping
+	mapPending []string
+	mapDepth   int
+	noRemap    bool
+	leader     string
+	sourcing   bool
+
+	numberedRow int // строка курсора при последнем пересчете relativenumber
 }
 
 // NewVimHandler создает новый обработчик Vim
 func NewVimHandler(editor *EditorWidget) *VimHandler {
-	return &VimHandler{
-		editor:   editor,
-		mode:     VimNormal,
-		marks:    make(map[string]TextPosition),
-		jumpList: make([]TextPosition, 0),
-		macros:   make(map[string][]string),
-		register: "\"",
+	vh := &VimHandler{
+		editor:    editor,
+		mode:      VimNormal,
+		marks:     make(map[string]TextPosition),
+		jumpList:  make([]TextPosition, 0),
+		registers: make(map[string]vimRegister),
+		wantPos:   -1,
+		mappings:  make(map[byte]map[string]vimMapping),
+		leader:    vimDefaultLeader,
 	}
+	vh.loadRegisters()
+	vh.loadVimrc()
+	return vh
 }
 
 // HandleKey обрабатывает нажатие клавиши в Vim режиме
 func (vh *VimHandler) HandleKey(key string) bool {
-	// Если записываем макрос, добавляем команду
-	if vh.recording && key != "q" {
+	// Если записываем макрос, сохраняем реальный поток клавиш
+	if vh.recording && !vh.playback {
 		vh.macroCommands = append(vh.macroCommands, key)
 	}
 
+	// Пользовательские сопоставления; повтор точкой уже раскрыт
+	if mode := vh.mapMode(); mode != 0 && !vh.noRemap && !vh.dotRepeat &&
+		(len(vh.mappings[mode]) > 0 || len(vh.mapPending) > 0) {
+		return vh.mapKey(mode, key)
+	}
+	return vh.dispatchKey(key)
+}
+
+// dispatchKey передает клавишу обработчику текущего режима
+func (vh *VimHandler) dispatchKey(key string) bool {
 	switch vh.mode {
 	case VimNormal:
 		return vh.handleNormalMode(key)
 	case VimInsert:
 		return vh.handleInsertMode(key)
-	case VimVisual:
+	case VimVisual, VimVisualLine, VimVisualBlock:
 		return vh.handleVisualMode(key)
 	case VimCommand:
 		return vh.handleCommandMode(key)
@@ -69,37 +126,83 @@ func (vh *VimHandler) HandleKey(key string) bool {
 	}
 }
 
-// handleNormalMode обрабатывает Normal режим
+// handleNormalMode обрабатывает Normal режим:
+// [count]["x][count]оператор[count]движение|текстовый объект или простую команду
 func (vh *VimHandler) handleNormalMode(key string) bool {
 	// ESC сбрасывает pending команды
 	if key == "Escape" {
-		vh.pendingCommand = ""
-		vh.count = 0
+		vh.resetPending()
 		return true
 	}
+	vh.commandKeys = append(vh.commandKeys, key)
 
-	// Числовые префиксы
-	if key >= "0" && key <= "9" {
-		if vh.pendingCommand == "" && key == "0" {
-			// 0 - переход к началу строки
-			vh.moveToLineStart()
-		} else {
-			// Накапливаем счетчик
-			vh.count = vh.count*10 + int(key[0]-'0')
-		}
+	// Числовые префиксы (0 без счетчика - движение к началу строки)
+	if vh.pendingCommand == "" && len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || vh.count > 0) {
+		vh.count = vh.count*10 + int(key[0]-'0')
 		return true
 	}
 
 	// Добавляем к pending команде
 	vh.pendingCommand += key
 
-	// Обрабатываем команды
-	handled := vh.executeNormalCommand(vh.pendingCommand)
+	if vh.operator != "" {
+		return vh.handleOperatorPending()
+	}
 
-	if handled {
-		vh.lastCommand = vh.pendingCommand
-		vh.pendingCommand = ""
+	// Выбор регистра
+	if strings.HasPrefix(vh.pendingCommand, "\"") {
+		if len(vh.pendingCommand) > 1 {
+			name := vh.pendingCommand[1:]
+			vh.pendingCommand = ""
+			if !isVimRegisterName(name) {
+				vh.resetPending()
+			} else {
+				vh.register = name
+			}
+		}
+		return true
+	}
+
+	// Короткие формы операторов (x = dl, D = d$ ...)
+	if alias, ok := vimOperatorAliases[vh.pendingCommand]; ok {
+		vh.operator = alias[:1]
+		vh.operatorCount = vh.count
+		vh.count = 0
+		vh.pendingCommand = alias[1:]
+		return vh.handleOperatorPending()
+	}
+
+	// Оператор переходит в ожидание движения, счетчик движения считается отдельно
+	if vimOperators[vh.pendingCommand] {
+		vh.operator = vh.pendingCommand
+		vh.operatorCount = vh.count
 		vh.count = 0
+		vh.pendingCommand = ""
+		return true
+	}
+
+	// Движения
+	if status := parseVimMotion(vh.pendingCommand); status == vimParseComplete {
+		vh.moveByMotion(vh.pendingCommand, vh.count)
+		vh.resetPending()
+		return true
+	}
+
+	// Обрабатываем команды
+	cmd, keys := vh.pendingCommand, vh.commandKeys
+	handled := vh.executeNormalCommand(cmd)
+
+	switch {
+	case handled:
+		// Для повтора точкой запоминаем только изменения текста
+		if strings.ContainsAny(cmd[:1], "rpPiIaAoO") {
+			vh.recordChange(keys)
+		}
+		vh.resetPending()
+	case isPendingPrefix(cmd) || parseVimMotion(cmd) == vimParseIncomplete:
+		return true
+	default:
+		vh.resetPending()
 	}
 
 	return handled
@@ -113,72 +216,6 @@ func (vh *VimHandler) executeNormalCommand(cmd string) bool {
 	}
 
 	switch cmd {
-	// Движение
-	case "h":
-		for i := 0; i < count; i++ {
-			vh.moveLeft()
-		}
-		return true
-	case "j":
-		for i := 0; i < count; i++ {
-			vh.moveDown()
-		}
-		return true
-	case "k":
-		for i := 0; i < count; i++ {
-			vh.moveUp()
-		}
-		return true
-	case "l":
-		for i := 0; i < count; i++ {
-			vh.moveRight()
-		}
-		return true
-
-	// Движение по словам
-	case "w":
-		for i := 0; i < count; i++ {
-			vh.moveWordForward()
-		}
-		return true
-	case "b":
-		for i := 0; i < count; i++ {
-			vh.moveWordBackward()
-		}
-		return true
-	case "e":
-		for i := 0; i < count; i++ {
-			vh.moveWordEnd()
-		}
-		return true
-
-	// Движение по строке
-	case "0":
-		vh.moveToLineStart()
-		return true
-	case "$":
-		vh.moveToLineEnd()
-		return true
-	case "^":
-		vh.moveToLineFirstNonBlank()
-		return true
-
-	// Переход к строке
-	case "G":
-		if vh.count > 0 {
-			vh.goToLine(vh.count)
-		} else {
-			vh.goToLastLine()
-		}
-		return true
-	case "gg":
-		if vh.count > 0 {
-			vh.goToLine(vh.count)
-		} else {
-			vh.goToLine(1)
-		}
-		return true
-
 	// Режимы
 	case "i":
 		vh.mode = VimInsert
@@ -188,11 +225,14 @@ func (vh *VimHandler) executeNormalCommand(cmd string) bool {
 		vh.mode = VimInsert
 		return true
 	case "a":
-		vh.moveRight()
+		text, pos := vh.editor.textContent, vh.editor.cursorIndex()
+		if pos < vimLineEnd(text, pos) {
+			vh.editor.moveCursorToIndex(vimNextRune(text, pos))
+		}
 		vh.mode = VimInsert
 		return true
 	case "A":
-		vh.moveToLineEnd()
+		vh.editor.moveCursorToIndex(vimLineEnd(vh.editor.textContent, vh.editor.cursorIndex()))
 		vh.mode = VimInsert
 		return true
 	case "o":
@@ -209,72 +249,24 @@ func (vh *VimHandler) executeNormalCommand(cmd string) bool {
 	case "V":
 		vh.startVisualLineMode()
 		return true
+	case "Ctrl+v":
+		vh.startVisualBlockMode()
+		return true
 	case "R":
 		vh.mode = VimReplace
 		return true
 
-	// Удаление
-	case "x":
-		for i := 0; i < count; i++ {
-			vh.deleteChar()
-		}
-		return true
-	case "X":
-		for i := 0; i < count; i++ {
-			vh.deleteCharBefore()
-		}
-		return true
-	case "dd":
-		for i := 0; i < count; i++ {
-			vh.deleteLine()
-		}
-		return true
-	case "D":
-		vh.deleteToLineEnd()
-		return true
-	case "dw":
-		for i := 0; i < count; i++ {
-			vh.deleteWord()
-		}
-		return true
-
 	// Изменение
-	case "cc":
-		vh.changeLine()
-		return true
-	case "C":
-		vh.changeToLineEnd()
-		return true
-	case "cw":
-		vh.changeWord()
-		return true
 	case "r":
 		// Ждем следующий символ для замены
 		return false
 
-	// Копирование
-	case "yy":
-		for i := 0; i < count; i++ {
-			vh.yankLine()
-		}
-		return true
-	case "Y":
-		vh.yankLine()
-		return true
-	case "yw":
-		vh.yankWord()
-		return true
-
 	// Вставка
 	case "p":
-		for i := 0; i < count; i++ {
-			vh.pasteAfter()
-		}
+		vh.put(true, count)
 		return true
 	case "P":
-		for i := 0; i < count; i++ {
-			vh.pasteBefore()
-		}
+		vh.put(false, count)
 		return true
 
 	// Отмена/повтор
@@ -295,12 +287,6 @@ func (vh *VimHandler) executeNormalCommand(cmd string) bool {
 	case "?":
 		vh.startSearch(true)
 		return true
-	case "n":
-		vh.searchNext()
-		return true
-	case "N":
-		vh.searchPrevious()
-		return true
 	case "*":
 		vh.searchWordUnderCursor(false)
 		return true
@@ -345,30 +331,10 @@ func (vh *VimHandler) executeNormalCommand(cmd string) bool {
 	case "Ctrl+b":
 		vh.scrollPageUp()
 		return true
-
-	// Скобки
-	case "%":
-		vh.jumpToMatchingBracket()
-		return true
 	}
 
 	// Проверяем составные команды
 	if len(cmd) > 1 {
-		// Команды с регистром
-		if cmd[0] == '"' && len(cmd) == 2 {
-			vh.register = string(cmd[1])
-			vh.pendingCommand = ""
-			return false // Ждем следующую команду
-		}
-
-		// Команды с движением (d, c, y)
-		if cmd[0] == 'd' || cmd[0] == 'c' || cmd[0] == 'y' {
-			// Пока не полная команда
-			if len(cmd) == 1 {
-				return false
-			}
-		}
-
 		// Замена символа
 		if len(cmd) == 2 && cmd[0] == 'r' {
 			vh.replaceChar(rune(cmd[1]))
@@ -391,7 +357,10 @@ func (vh *VimHandler) executeNormalCommand(cmd string) bool {
 			return true
 		}
 		if len(cmd) == 2 && cmd[0] == '@' {
-			vh.playMacro(string(cmd[1]))
+			// Макрос выполняется с чистым состоянием ожидания
+			count := vh.count
+			vh.resetPending()
+			vh.playMacro(string(cmd[1]), count)
 			return true
 		}
 	}
@@ -401,49 +370,91 @@ func (vh *VimHandler) executeNormalCommand(cmd string) bool {
 
 // handleInsertMode обрабатывает Insert режим
 func (vh *VimHandler) handleInsertMode(key string) bool {
+	// Клавиши вставки входят в последнее изменение для повтора точкой
+	if vh.insertRecording && !vh.dotRepeat {
+		vh.lastChange = append(vh.lastChange, key)
+	}
+
+	// Блочная вставка повторяет ввод на всех строках блока
+	if vh.blockInsert != nil && vh.blockInsertKey(key) {
+		vh.insertKeys = append(vh.insertKeys, key)
+		return true
+	}
+
 	if key == "Escape" {
+		vh.insertRecording = false
+		vh.lastInserted = vimInsertedText(vh.insertKeys)
+		vh.insertKeys = nil
 		vh.mode = VimNormal
 		vh.moveLeft() // Vim перемещает курсор влево при выходе из Insert
 		return true
 	}
 
-	// В Insert режиме передаем ввод редактору
+	vh.insertKeys = append(vh.insertKeys, key)
+
+	// При воспроизведении текст вставляет сам обработчик, иначе - редактор
+	if vh.playback {
+		vh.insertKey(key)
+		return true
+	}
 	return false
 }
 
-// handleVisualMode обрабатывает Visual режим
+// handleVisualMode обрабатывает Visual, Visual Line и Visual Block режимы
 func (vh *VimHandler) handleVisualMode(key string) bool {
 	if key == "Escape" {
+		vh.resetPending()
 		vh.mode = VimNormal
 		vh.clearSelection()
 		return true
 	}
 
-	// Движение расширяет выделение
-	switch key {
-	case "h":
-		vh.moveLeft()
-		vh.updateSelection()
+	// Блочные команды (I, A, r, $, o)
+	if vh.mode == VimVisualBlock && vh.handleVisualBlockKey(key) {
 		return true
-	case "j":
-		vh.moveDown()
-		vh.updateSelection()
+	}
+
+	// Счетчик и движения расширяют выделение
+	if vh.pendingCommand == "" && len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || vh.count > 0) {
+		vh.count = vh.count*10 + int(key[0]-'0')
 		return true
-	case "k":
-		vh.moveUp()
+	}
+	vh.pendingCommand += key
+	switch parseVimMotion(vh.pendingCommand) {
+	case vimParseComplete:
+		if !vimVerticalMotions[vh.pendingCommand] {
+			vh.blockToEnd = false
+		}
+		vh.moveByMotion(vh.pendingCommand, vh.count)
+		vh.resetPending()
 		vh.updateSelection()
 		return true
-	case "l":
-		vh.moveRight()
-		vh.updateSelection()
+	case vimParseIncomplete:
 		return true
-	case "w":
-		vh.moveWordForward()
-		vh.updateSelection()
+	}
+	vh.resetPending()
+
+	switch key {
+	// Ex команда для строк выделения: :'<,'>
+	case ":":
+		start, end := vh.visualStart.Row, vh.editor.cursorRow
+		vh.marks["<"] = TextPosition{Row: min(start, end)}
+		vh.marks[">"] = TextPosition{Row: max(start, end)}
+		vh.clearSelection()
+		vh.mode = VimCommand
+		vh.pendingCommand = "'<,'>"
 		return true
-	case "b":
-		vh.moveWordBackward()
-		vh.updateSelection()
+
+	// Смена вида выделения, повторное нажатие выходит из Visual
+	case "v", "V", "Ctrl+v":
+		mode := map[string]VimMode{"v": VimVisual, "V": VimVisualLine, "Ctrl+v": VimVisualBlock}[key]
+		if vh.mode == mode {
+			vh.mode = VimNormal
+			vh.clearSelection()
+		} else {
+			vh.mode = mode
+			vh.updateSelection()
+		}
 		return true
 
 	// Операции с выделением
@@ -464,30 +475,42 @@ func (vh *VimHandler) handleVisualMode(key string) bool {
 	return false
 }
 
-// handleCommandMode обрабатывает Command режим
+// handleCommandMode обрабатывает Command режим (командную строку :)
 func (vh *VimHandler) handleCommandMode(key string) bool {
-	if key == "Escape" {
-		vh.mode = VimNormal
-		vh.pendingCommand = ""
-		return true
+	// Любая клавиша, кроме Tab, завершает перебор дополнений
+	if key != "Tab" {
+		vh.exCompletions = nil
+	}
+	if key != "Up" && key != "Down" {
+		vh.exHistoryIndex = len(vh.exHistory)
 	}
 
-	if key == "Return" || key == "Enter" {
-		vh.executeExCommand(vh.pendingCommand)
+	switch key {
+	case "Escape":
 		vh.mode = VimNormal
 		vh.pendingCommand = ""
-		return true
-	}
-
-	if key == "Backspace" {
-		if len(vh.pendingCommand) > 0 {
-			vh.pendingCommand = vh.pendingCommand[:len(vh.pendingCommand)-1]
+	case "Return", "Enter":
+		cmd := vh.pendingCommand
+		vh.mode = VimNormal
+		vh.pendingCommand = ""
+		vh.lastExCommand = cmd
+		vh.pushExHistory(cmd)
+		vh.executeExCommand(cmd)
+	case "Backspace":
+		if vh.pendingCommand == "" {
+			vh.mode = VimNormal
+		} else {
+			_, size := utf8.DecodeLastRuneInString(vh.pendingCommand)
+			vh.pendingCommand = vh.pendingCommand[:len(vh.pendingCommand)-size]
 		}
-		return true
+	case "Up", "Down":
+		vh.browseExHistory(key == "Up")
+	case "Tab":
+		vh.completeExCommand()
+	default:
+		// Добавляем символ к команде
+		vh.pendingCommand += key
 	}
-
-	// Добавляем символ к команде
-	vh.pendingCommand += key
 	return true
 }
 
@@ -504,35 +527,10 @@ func (vh *VimHandler) handleReplaceMode(key string) bool {
 	return true
 }
 
-// executeExCommand выполняет Ex команду
+// executeExCommand выполняет Ex команду: [диапазон]команда[!] [аргументы]
 func (vh *VimHandler) executeExCommand(cmd string) {
-	cmd = strings.TrimSpace(cmd)
-
-	// Базовые Ex команды
-	switch {
-	case cmd == "w" || cmd == "write":
-		vh.saveFile()
-	case cmd == "q" || cmd == "quit":
-		vh.quit()
-	case cmd == "wq" || cmd == "x":
-		vh.saveFile()
-		vh.quit()
-	case cmd == "q!":
-		vh.forceQuit()
-	case strings.HasPrefix(cmd, "e ") || strings.HasPrefix(cmd, "edit "):
-		filename := strings.TrimSpace(cmd[2:])
-		vh.openFile(filename)
-	case strings.HasPrefix(cmd, "set "):
-		vh.setOption(cmd[4:])
-	case strings.HasPrefix(cmd, "s/"):
-		vh.substitute(cmd)
-	case strings.HasPrefix(cmd, "%s/"):
-		vh.substituteAll(cmd[1:])
-	default:
-		// Проверяем, является ли это номером строки
-		if lineNum := parseLineNumber(cmd); lineNum > 0 {
-			vh.goToLine(lineNum)
-		}
+	if err := vh.runExCommand(strings.TrimLeft(cmd, " \t")); err != nil {
+		vh.exError(err)
 	}
 }
 
@@ -582,98 +580,6 @@ func (vh *VimHandler) adjustCursorColumn() {
 	}
 }
 
-func (vh *VimHandler) moveWordForward() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow >= len(lines) {
-		return
-	}
-
-	line := lines[vh.editor.cursorRow]
-	col := vh.editor.cursorCol
-
-	// Пропускаем текущее слово
-	for col < len(line) && !isWordBoundary(rune(line[col])) {
-		col++
-	}
-
-	// Пропускаем пробелы
-	for col < len(line) && isWordBoundary(rune(line[col])) {
-		col++
-	}
-
-	if col < len(line) {
-		vh.editor.cursorCol = col
-	} else if vh.editor.cursorRow < len(lines)-1 {
-		// Переходим на следующую строку
-		vh.editor.cursorRow++
-		vh.editor.cursorCol = 0
-	}
-}
-
-func (vh *VimHandler) moveWordBackward() {
-	if vh.editor.cursorCol > 0 {
-		lines := strings.Split(vh.editor.textContent, "\n")
-		line := lines[vh.editor.cursorRow]
-		col := vh.editor.cursorCol - 1
-
-		// Пропускаем пробелы
-		for col > 0 && isWordBoundary(rune(line[col])) {
-			col--
-		}
-
-		// Идем до начала слова
-		for col > 0 && !isWordBoundary(rune(line[col-1])) {
-			col--
-		}
-
-		vh.editor.cursorCol = col
-	} else if vh.editor.cursorRow > 0 {
-		// Переходим на предыдущую строку
-		vh.editor.cursorRow--
-		lines := strings.Split(vh.editor.textContent, "\n")
-		vh.editor.cursorCol = len(lines[vh.editor.cursorRow]) - 1
-	}
-}
-
-func (vh *VimHandler) moveWordEnd() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow >= len(lines) {
-		return
-	}
-
-	line := lines[vh.editor.cursorRow]
-	col := vh.editor.cursorCol + 1
-
-	// Пропускаем пробелы
-	for col < len(line) && isWordBoundary(rune(line[col])) {
-		col++
-	}
-
-	// Идем до конца слова
-	for col < len(line) && !isWordBoundary(rune(line[col])) {
-		col++
-	}
-
-	if col > 0 && col <= len(line) {
-		vh.editor.cursorCol = col - 1
-	}
-}
-
-func (vh *VimHandler) moveToLineStart() {
-	vh.editor.cursorCol = 0
-}
-
-func (vh *VimHandler) moveToLineEnd() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < len(lines) {
-		line := lines[vh.editor.cursorRow]
-		vh.editor.cursorCol = len(line) - 1
-		if vh.editor.cursorCol < 0 {
-			vh.editor.cursorCol = 0
-		}
-	}
-}
-
 func (vh *VimHandler) moveToLineFirstNonBlank() {
 	lines := strings.Split(vh.editor.textContent, "\n")
 	if vh.editor.cursorRow < len(lines) {
@@ -697,173 +603,8 @@ func (vh *VimHandler) goToLine(lineNum int) {
 	}
 }
 
-func (vh *VimHandler) goToLastLine() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	vh.addToJumpList()
-	vh.editor.cursorRow = len(lines) - 1
-	vh.editor.cursorCol = 0
-}
-
 // Методы редактирования
 
-func (vh *VimHandler) deleteChar() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < len(lines) {
-		line := lines[vh.editor.cursorRow]
-		if vh.editor.cursorCol < len(line) {
-			vh.yankBuffer = string(line[vh.editor.cursorCol])
-			lines[vh.editor.cursorRow] = line[:vh.editor.cursorCol] + line[vh.editor.cursorCol+1:]
-			vh.editor.textContent = strings.Join(lines, "\n")
-			vh.editor.isDirty = true
-			vh.editor.updateDisplay()
-		}
-	}
-}
-
-func (vh *VimHandler) deleteCharBefore() {
-	if vh.editor.cursorCol > 0 {
-		vh.moveLeft()
-		vh.deleteChar()
-	}
-}
-
-func (vh *VimHandler) deleteLine() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < len(lines) {
-		vh.yankBuffer = lines[vh.editor.cursorRow] + "\n"
-		lines = append(lines[:vh.editor.cursorRow], lines[vh.editor.cursorRow+1:]...)
-		vh.editor.textContent = strings.Join(lines, "\n")
-		vh.editor.isDirty = true
-
-		// Корректируем позицию курсора
-		if vh.editor.cursorRow >= len(lines) && len(lines) > 0 {
-			vh.editor.cursorRow = len(lines) - 1
-		}
-		vh.editor.cursorCol = 0
-		vh.editor.updateDisplay()
-	}
-}
-
-func (vh *VimHandler) deleteToLineEnd() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < len(lines) {
-		line := lines[vh.editor.cursorRow]
-		if vh.editor.cursorCol < len(line) {
-			vh.yankBuffer = line[vh.editor.cursorCol:]
-			lines[vh.editor.cursorRow] = line[:vh.editor.cursorCol]
-			vh.editor.textContent = strings.Join(lines, "\n")
-			vh.editor.isDirty = true
-			vh.editor.updateDisplay()
-		}
-	}
-}
-
-func (vh *VimHandler) deleteWord() {
-	startCol := vh.editor.cursorCol
-	vh.moveWordForward()
-	endCol := vh.editor.cursorCol
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < len(lines) {
-		line := lines[vh.editor.cursorRow]
-		if startCol < len(line) && endCol <= len(line) {
-			vh.yankBuffer = line[startCol:endCol]
-			lines[vh.editor.cursorRow] = line[:startCol] + line[endCol:]
-			vh.editor.cursorCol = startCol
-			vh.editor.textContent = strings.Join(lines, "\n")
-			vh.editor.isDirty = true
-			vh.editor.updateDisplay()
-		}
-	}
-}
-
-func (vh *VimHandler) yankLine() {
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < len(lines) {
-		vh.yankBuffer = lines[vh.editor.cursorRow] + "\n"
-	}
-}
-
-func (vh *VimHandler) yankWord() {
-	startCol := vh.editor.cursorCol
-	vh.moveWordForward()
-	endCol := vh.editor.cursorCol
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < len(lines) {
-		line := lines[vh.editor.cursorRow]
-		if startCol < len(line) && endCol <= len(line) {
-			vh.yankBuffer = line[startCol:endCol]
-			vh.editor.cursorCol = startCol
-		}
-	}
-}
-
-func (vh *VimHandler) pasteAfter() {
-	if vh.yankBuffer == "" {
-		return
-	}
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-
-	if strings.HasSuffix(vh.yankBuffer, "\n") {
-		// Вставка строки
-		newLines := make([]string, 0, len(lines)+1)
-		newLines = append(newLines, lines[:vh.editor.cursorRow+1]...)
-		newLines = append(newLines, strings.TrimSuffix(vh.yankBuffer, "\n"))
-		if vh.editor.cursorRow+1 < len(lines) {
-			newLines = append(newLines, lines[vh.editor.cursorRow+1:]...)
-		}
-		vh.editor.textContent = strings.Join(newLines, "\n")
-		vh.editor.cursorRow++
-		vh.editor.cursorCol = 0
-	} else {
-		// Вставка текста
-		if vh.editor.cursorRow < len(lines) {
-			line := lines[vh.editor.cursorRow]
-			insertPos := vh.editor.cursorCol + 1
-			if insertPos > len(line) {
-				insertPos = len(line)
-			}
-			lines[vh.editor.cursorRow] = line[:insertPos] + vh.yankBuffer + line[insertPos:]
-			vh.editor.textContent = strings.Join(lines, "\n")
-			vh.editor.cursorCol = insertPos + len(vh.yankBuffer) - 1
-		}
-	}
-
-	vh.editor.isDirty = true
-	vh.editor.updateDisplay()
-}
-
-func (vh *VimHandler) pasteBefore() {
-	if vh.yankBuffer == "" {
-		return
-	}
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-
-	if strings.HasSuffix(vh.yankBuffer, "\n") {
-		// Вставка строки
-		newLines := make([]string, 0, len(lines)+1)
-		newLines = append(newLines, lines[:vh.editor.cursorRow]...)
-		newLines = append(newLines, strings.TrimSuffix(vh.yankBuffer, "\n"))
-		newLines = append(newLines, lines[vh.editor.cursorRow:]...)
-		vh.editor.textContent = strings.Join(newLines, "\n")
-		vh.editor.cursorCol = 0
-	} else {
-		// Вставка текста
-		if vh.editor.cursorRow < len(lines) {
-			line := lines[vh.editor.cursorRow]
-			lines[vh.editor.cursorRow] = line[:vh.editor.cursorCol] + vh.yankBuffer + line[vh.editor.cursorCol:]
-			vh.editor.textContent = strings.Join(lines, "\n")
-			vh.editor.cursorCol += len(vh.yankBuffer) - 1
-		}
-	}
-
-	vh.editor.isDirty = true
-	vh.editor.updateDisplay()
-}
-
 // Вспомогательные методы
 
 func (vh *VimHandler) startVisualMode() {
@@ -882,6 +623,11 @@ func (vh *VimHandler) startVisualLineMode() {
 }
 
 func (vh *VimHandler) updateSelection() {
+	if vh.mode == VimVisualBlock {
+		vh.updateBlockSelection()
+		return
+	}
+	vh.editor.blockSelection = nil
 	vh.visualEnd = TextPosition{Row: vh.editor.cursorRow, Col: vh.editor.cursorCol}
 	vh.editor.selectionStart = vh.visualStart
 	vh.editor.selectionEnd = vh.visualEnd
@@ -889,6 +635,8 @@ func (vh *VimHandler) updateSelection() {
 }
 
 func (vh *VimHandler) clearSelection() {
+	vh.blockToEnd = false
+	vh.editor.blockSelection = nil
 	vh.editor.selectionStart = TextPosition{}
 	vh.editor.selectionEnd = TextPosition{}
 	vh.editor.updateDisplay()
@@ -950,11 +698,10 @@ func (vh *VimHandler) deleteSelection() {
 		lines = newLines
 	}
 
-	vh.yankBuffer = builder.String()
-	vh.editor.textContent = strings.Join(lines, "\n")
+	vh.setRegister(builder.String(), vh.mode == VimVisualLine, false)
+	vh.editor.ReplaceContent(strings.Join(lines, "\n"), false)
 	vh.editor.cursorRow = start.Row
 	vh.editor.cursorCol = start.Col
-	vh.editor.isDirty = true
 	vh.editor.selectionStart = TextPosition{}
 	vh.editor.selectionEnd = TextPosition{}
 	vh.editor.updateDisplay()
@@ -1004,7 +751,7 @@ func (vh *VimHandler) yankSelection() {
 		builder.WriteString(endLine[:end.Col])
 	}
 
-	vh.yankBuffer = builder.String()
+	vh.setRegister(builder.String(), vh.mode == VimVisualLine, true)
 	vh.editor.cursorRow = start.Row
 	vh.editor.cursorCol = start.Col
 	vh.clearSelection()
@@ -1015,22 +762,6 @@ func (vh *VimHandler) changeSelection() {
 	vh.mode = VimInsert
 }
 
-func (vh *VimHandler) changeLine() {
-	vh.deleteLine()
-	vh.insertLineAbove()
-	vh.mode = VimInsert
-}
-
-func (vh *VimHandler) changeToLineEnd() {
-	vh.deleteToLineEnd()
-	vh.mode = VimInsert
-}
-
-func (vh *VimHandler) changeWord() {
-	vh.deleteWord()
-	vh.mode = VimInsert
-}
-
 func (vh *VimHandler) insertLineBelow() {
 	lines := strings.Split(vh.editor.textContent, "\n")
 	newLines := make([]string, 0, len(lines)+1)
@@ -1039,10 +770,9 @@ func (vh *VimHandler) insertLineBelow() {
 	if vh.editor.cursorRow+1 < len(lines) {
 		newLines = append(newLines, lines[vh.editor.cursorRow+1:]...)
 	}
-	vh.editor.textContent = strings.Join(newLines, "\n")
+	vh.editor.ReplaceContent(strings.Join(newLines, "\n"), false)
 	vh.editor.cursorRow++
 	vh.editor.cursorCol = 0
-	vh.editor.isDirty = true
 	vh.editor.updateDisplay()
 }
 
@@ -1052,9 +782,8 @@ func (vh *VimHandler) insertLineAbove() {
 	newLines = append(newLines, lines[:vh.editor.cursorRow]...)
 	newLines = append(newLines, "")
 	newLines = append(newLines, lines[vh.editor.cursorRow:]...)
-	vh.editor.textContent = strings.Join(newLines, "\n")
+	vh.editor.ReplaceContent(strings.Join(newLines, "\n"), false)
 	vh.editor.cursorCol = 0
-	vh.editor.isDirty = true
 	vh.editor.updateDisplay()
 }
 
@@ -1066,37 +795,24 @@ func (vh *VimHandler) replaceChar(ch rune) {
 			runes := []rune(line)
 			runes[vh.editor.cursorCol] = ch
 			lines[vh.editor.cursorRow] = string(runes)
-			vh.editor.textContent = strings.Join(lines, "\n")
-			vh.editor.isDirty = true
+			vh.editor.ReplaceContent(strings.Join(lines, "\n"), false)
 			vh.editor.updateDisplay()
 		}
 	}
 }
 
 func (vh *VimHandler) undo() {
-	if vh.editor == nil || vh.editor.content == nil {
+	if vh.editor == nil {
 		return
 	}
-	vh.editor.content.Undo()
-	vh.editor.cursorRow = vh.editor.content.CursorRow
-	vh.editor.cursorCol = vh.editor.content.CursorColumn
-	vh.editor.updateDisplay()
+	vh.editor.Undo()
 }
 
 func (vh *VimHandler) redo() {
-	if vh.editor == nil || vh.editor.content == nil {
+	if vh.editor == nil {
 		return
 	}
-	vh.editor.content.Redo()
-	vh.editor.cursorRow = vh.editor.content.CursorRow
-	vh.editor.cursorCol = vh.editor.content.CursorColumn
-	vh.editor.updateDisplay()
-}
-
-func (vh *VimHandler) repeatLastCommand() {
-	if vh.lastCommand != "" {
-		vh.executeNormalCommand(vh.lastCommand)
-	}
+	vh.editor.Redo()
 }
 
 func (vh *VimHandler) startSearch(backward bool) {
@@ -1105,118 +821,66 @@ func (vh *VimHandler) startSearch(backward bool) {
 	entry := widget.NewEntry()
 	entry.SetPlaceHolder("Enter search pattern")
 
-	var win fyne.Window
-	if app := fyne.CurrentApp(); app != nil {
-		wins := app.Driver().AllWindows()
-		if len(wins) > 0 {
-			win = wins[0]
-		}
-	}
-
+	win := vimWindow()
 	if win == nil {
 		return
 	}
 
+	// incsearch: курсор следует за совпадением, пока шаблон набирается
+	origin := vh.editor.cursorIndex()
+	entry.OnChanged = func(pattern string) {
+		if !vh.incsearch || pattern == "" {
+			return
+		}
+		if re, err := vh.compilePattern(pattern); err == nil {
+			if next := vimSearchFrom(vh.editor.textContent, re, origin, backward); next >= 0 {
+				vh.setCursorNormal(next)
+				vh.syncEditor()
+			}
+		}
+	}
+
 	dialog.ShowCustomConfirm("Search", "OK", "Cancel", entry, func(confirm bool) {
-		if confirm {
+		vh.setCursorNormal(origin)
+		if confirm && entry.Text != "" {
 			vh.searchPattern = entry.Text
-			if vh.searchPattern == "" {
-				return
-			}
 			if vh.searchBackward {
 				vh.searchPrevious()
 			} else {
 				vh.searchNext()
 			}
 		}
+		vh.syncEditor()
 	}, win)
 
 	win.Canvas().Focus(entry)
 }
 
+// searchNext ищет шаблон вперед от курсора с переходом через конец текста
 func (vh *VimHandler) searchNext() {
-	if vh.searchPattern == "" {
-		return
-	}
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-	startRow := vh.editor.cursorRow
-	startCol := vh.editor.cursorCol + 1
-
-	pattern := vh.searchPattern
-
-	// Search forward from current position
-	for r := startRow; r < len(lines); r++ {
-		line := lines[r]
-		col := 0
-		if r == startRow {
-			if startCol > len(line) {
-				startCol = len(line)
-			}
-			col = startCol
-		}
-		if idx := strings.Index(line[col:], pattern); idx != -1 {
-			vh.addToJumpList()
-			vh.editor.cursorRow = r
-			vh.editor.cursorCol = col + idx
-			vh.editor.updateDisplay()
-			return
-		}
-	}
-
-	// Wrap around to beginning
-	for r := 0; r <= startRow; r++ {
-		line := lines[r]
-		if idx := strings.Index(line, pattern); idx != -1 {
-			vh.addToJumpList()
-			vh.editor.cursorRow = r
-			vh.editor.cursorCol = idx
-			vh.editor.updateDisplay()
-			return
-		}
-	}
+	vh.searchFromCursor(false)
 }
 
+// searchPrevious ищет шаблон назад от курсора
 func (vh *VimHandler) searchPrevious() {
+	vh.searchFromCursor(true)
+}
+
+// searchFromCursor переходит к следующему совпадению шаблона поиска
+func (vh *VimHandler) searchFromCursor(backward bool) {
 	if vh.searchPattern == "" {
 		return
 	}
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-	startRow := vh.editor.cursorRow
-	startCol := vh.editor.cursorCol
-
-	pattern := vh.searchPattern
-
-	for r := startRow; r >= 0; r-- {
-		line := lines[r]
-		endCol := len(line)
-		if r == startRow {
-			endCol = startCol
-		}
-		if endCol < 0 {
-			continue
-		}
-		if idx := strings.LastIndex(line[:endCol], pattern); idx != -1 {
-			vh.addToJumpList()
-			vh.editor.cursorRow = r
-			vh.editor.cursorCol = idx
-			vh.editor.updateDisplay()
-			return
-		}
+	re, err := vh.compilePattern(vh.searchPattern)
+	if err != nil {
+		vh.exError(err)
+		return
 	}
-
-	// Wrap around to end
-	for r := len(lines) - 1; r >= startRow; r-- {
-		line := lines[r]
-		if idx := strings.LastIndex(line, pattern); idx != -1 {
-			vh.addToJumpList()
-			vh.editor.cursorRow = r
-			vh.editor.cursorCol = idx
-			vh.editor.updateDisplay()
-			return
-		}
+	if next := vimSearchFrom(vh.editor.textContent, re, vh.editor.cursorIndex(), backward); next >= 0 {
+		vh.addToJumpList()
+		vh.setCursorNormal(next)
 	}
+	vh.updateSearchHighlight()
 }
 
 func (vh *VimHandler) searchWordUnderCursor(backward bool) {
@@ -1256,7 +920,7 @@ func (vh *VimHandler) searchWordUnderCursor(backward bool) {
 		return
 	}
 
-	vh.searchPattern = word
+	vh.searchPattern = `\b` + regexp.QuoteMeta(word) + `\b`
 	vh.searchBackward = backward
 
 	if backward {
@@ -1319,52 +983,6 @@ func (vh *VimHandler) scrollLines(lines int) {
 	vh.editor.updateDisplay()
 }
 
-func (vh *VimHandler) jumpToMatchingBracket() {
-	if vh.editor == nil {
-		return
-	}
-
-	// Ensure bracket mappings are up to date
-	if vh.editor.matchingBrackets == nil || len(vh.editor.matchingBrackets) == 0 {
-		vh.editor.updateBracketMatching()
-	}
-
-	pos := TextPosition{Row: vh.editor.cursorRow, Col: vh.editor.cursorCol}
-	index := vh.editor.positionToIndex(pos)
-
-	matchIndex, ok := vh.editor.matchingBrackets[index]
-	if !ok && index > 0 {
-		matchIndex, ok = vh.editor.matchingBrackets[index-1]
-		if ok {
-			index = index - 1
-		}
-	}
-	if !ok {
-		return
-	}
-
-	// Convert index back to position
-	lines := strings.Split(vh.editor.textContent, "\n")
-	count := 0
-	target := TextPosition{}
-	for row, line := range lines {
-		lineLen := len(line)
-		if matchIndex <= count+lineLen {
-			target.Row = row
-			target.Col = matchIndex - count
-			break
-		}
-		count += lineLen + 1
-	}
-
-	vh.addToJumpList()
-	vh.editor.cursorRow = target.Row
-	vh.editor.cursorCol = target.Col
-	vh.editor.content.CursorRow = target.Row
-	vh.editor.content.CursorColumn = target.Col
-	vh.editor.updateDisplay()
-}
-
 func (vh *VimHandler) scrollHalfPageDown() {
 	if vh.editor == nil || vh.editor.scrollContainer == nil {
 		return
@@ -1409,28 +1027,6 @@ func (vh *VimHandler) scrollPageUp() {
 	vh.scrollLines(-linesPerPage)
 }
 
-func (vh *VimHandler) startRecordingMacro(register string) {
-	vh.recording = true
-	vh.macroRegister = register
-	vh.macroCommands = []string{}
-}
-
-func (vh *VimHandler) stopRecordingMacro() {
-	if vh.recording {
-		vh.recording = false
-		vh.macros[vh.macroRegister] = vh.macroCommands
-		vh.macroCommands = nil
-	}
-}
-
-func (vh *VimHandler) playMacro(register string) {
-	if commands, exists := vh.macros[register]; exists {
-		for _, cmd := range commands {
-			vh.HandleKey(cmd)
-		}
-	}
-}
-
 func (vh *VimHandler) saveFile() {
 	if vh.editor != nil {
 		vh.editor.SaveFile()
@@ -1474,115 +1070,6 @@ func (vh *VimHandler) forceQuit() {
 	}
 }
 
-func (vh *VimHandler) setOption(option string) {
-	option = strings.TrimSpace(option)
-	if option == "" || vh.editor == nil || vh.editor.config == nil {
-		return
-	}
-
-	switch option {
-	case "number":
-		vh.editor.config.Editor.ShowLineNumbers = true
-		vh.editor.updateLineNumbers()
-		editorLayer := container.NewMax(vh.editor.richContent, vh.editor.content)
-		vh.editor.scrollContainer.Content = container.NewBorder(nil, nil, container.NewVBox(vh.editor.lineNumbers), nil, editorLayer)
-		vh.editor.scrollContainer.Refresh()
-	case "nonumber":
-		vh.editor.config.Editor.ShowLineNumbers = false
-		editorLayer := container.NewMax(vh.editor.richContent, vh.editor.content)
-		vh.editor.scrollContainer.Content = editorLayer
-		vh.editor.scrollContainer.Refresh()
-		vh.editor.scrollContainer.Refresh()
-	case "wrap":
-		vh.editor.config.Editor.WordWrap = true
-		vh.editor.content.Wrapping = fyne.TextWrapWord
-	case "nowrap":
-		vh.editor.config.Editor.WordWrap = false
-		vh.editor.content.Wrapping = fyne.TextWrapOff
-	default:
-		dialog.ShowInformation("Unknown option", fmt.Sprintf("Unknown option: %s", option), fyne.CurrentApp().Driver().AllWindows()[0])
-	}
-
-	vh.editor.updateDisplay()
-}
-
-func (vh *VimHandler) substitute(cmd string) {
-	pattern, replacement, flags, err := parseSubstituteCommand(cmd)
-	if err != nil {
-		dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
-		return
-	}
-
-	if pattern == "" {
-		pattern = vh.searchPattern
-	} else {
-		vh.searchPattern = pattern
-	}
-
-	regexPattern := pattern
-	if strings.Contains(flags, "i") {
-		regexPattern = "(?i)" + regexPattern
-	}
-	re, err := regexp.Compile(regexPattern)
-	if err != nil {
-		dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
-		return
-	}
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-	if vh.editor.cursorRow < 0 || vh.editor.cursorRow >= len(lines) {
-		return
-	}
-
-	newLine, replaced := applySubstitution(lines[vh.editor.cursorRow], re, replacement, strings.Contains(flags, "g"))
-	if replaced {
-		lines[vh.editor.cursorRow] = newLine
-		vh.editor.textContent = strings.Join(lines, "\n")
-		vh.editor.isDirty = true
-		vh.editor.updateDisplay()
-	}
-}
-
-func (vh *VimHandler) substituteAll(cmd string) {
-	pattern, replacement, flags, err := parseSubstituteCommand(cmd)
-	if err != nil {
-		dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
-		return
-	}
-
-	if pattern == "" {
-		pattern = vh.searchPattern
-	} else {
-		vh.searchPattern = pattern
-	}
-
-	regexPattern := pattern
-	if strings.Contains(flags, "i") {
-		regexPattern = "(?i)" + regexPattern
-	}
-	re, err := regexp.Compile(regexPattern)
-	if err != nil {
-		dialog.ShowError(err, fyne.CurrentApp().Driver().AllWindows()[0])
-		return
-	}
-
-	lines := strings.Split(vh.editor.textContent, "\n")
-	changed := false
-	for i, line := range lines {
-		newLine, replaced := applySubstitution(line, re, replacement, strings.Contains(flags, "g"))
-		if replaced {
-			lines[i] = newLine
-			changed = true
-		}
-	}
-
-	if changed {
-		vh.editor.textContent = strings.Join(lines, "\n")
-		vh.editor.isDirty = true
-		vh.editor.updateDisplay()
-	}
-}
-
 func parseSubstituteCommand(cmd string) (string, string, string, error) {
 	if !strings.HasPrefix(cmd, "s/") {
 		return "", "", "", fmt.Errorf("invalid substitute command")
@@ -1640,6 +1127,46 @@ func (vh *VimHandler) GetMode() VimMode {
 	return vh.mode
 }
 
+// syncEditor переносит курсор и режим обработчика в Entry редактора
+func (vh *VimHandler) syncEditor() {
+	e := vh.editor
+	if vh.mode != VimInsert {
+		vh.adjustCursorColumn()
+	}
+	row, col := e.cursorRow, e.cursorCol
+	if e.config.Editor.ShowRelativeNumbers && row != vh.numberedRow {
+		vh.numberedRow = row
+		e.updateLineNumbers()
+	}
+	fyne.Do(func() {
+		e.content.CursorRow = row
+		e.content.CursorColumn = col
+		e.content.Refresh()
+	})
+	if e.vimMode != vh.mode {
+		e.SetVimMode(vh.mode)
+		if vh.onModeChanged != nil {
+			vh.onModeChanged(vh.mode)
+		}
+	}
+	// Строка состояния показывает режим и набираемую команду
+	if e.onCursorChanged != nil {
+		e.onCursorChanged(row, col)
+	}
+}
+
+// StatusText возвращает текст для строки состояния: командную строку или режим
+func (vh *VimHandler) StatusText() string {
+	if vh.mode == VimCommand {
+		return ":" + vh.pendingCommand
+	}
+	text := vh.GetModeString()
+	if vh.recording {
+		text += " recording @" + vh.macroRegister
+	}
+	return text
+}
+
 // GetModeString возвращает строковое представление режима
 func (vh *VimHandler) GetModeString() string {
 	switch vh.mode {
@@ -1671,9 +1198,3 @@ func isWordBoundary(ch rune) bool {
 		ch == '(' || ch == ')' || ch == '[' || ch == ']' ||
 		ch == '{' || ch == '}' || ch == '<' || ch == '>'
 }
-
-func parseLineNumber(s string) int {
-	var num int
-	fmt.Sscanf(s, "%d", &num)
-	return num
-}
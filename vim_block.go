@@ -0,0 +1,353 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// vimVerticalMotions - движения, после которых блок остается продленным до конца строк ($)
+var vimVerticalMotions = map[string]bool{
+	"j": true, "k": true, "+": true, "-": true, "_": true,
+	"G": true, "gg": true, "{": true, "}": true,
+}
+
+// vimBlock - прямоугольник Visual Block: строки и экранные столбцы включительно
+type vimBlock struct {
+	top, bottom int
+	left, right int
+	tab         int  // ширина табуляции, по которой считаются столбцы
+	toEnd       bool // блок продлен до конца каждой строки ($)
+}
+
+// span возвращает байтовые границы части строки, попадающей в блок;
+// символы, лишь частично попавшие в блок (табуляция), входят целиком
+func (b vimBlock) span(line string) (int, int) {
+	from := columnOffset(line, b.left, b.tab)
+	if b.toEnd {
+		return from, len(line)
+	}
+	to := columnOffset(line, b.right, b.tab)
+	if to < len(line) {
+		_, size := utf8.DecodeRuneInString(line[to:])
+		to += size
+	}
+	return from, to
+}
+
+// vimBlockInsert - вставка I/A/c, повторяемая на всех строках блока
+type vimBlockInsert struct {
+	start int // столбец начала вставки, дальше Backspace не повторяется
+}
+
+// startVisualBlockMode включает блочное выделение (Ctrl-V)
+func (vh *VimHandler) startVisualBlockMode() {
+	vh.mode = VimVisualBlock
+	vh.blockToEnd = false
+	vh.visualStart = TextPosition{Row: vh.editor.cursorRow, Col: vh.editor.cursorCol}
+	vh.updateBlockSelection()
+}
+
+// currentBlock возвращает блок между началом выделения и курсором
+func (vh *VimHandler) currentBlock() vimBlock {
+	e := vh.editor
+	tab := e.tabSize()
+	lines := strings.Split(e.textContent, "\n")
+	// columns возвращает первый и последний экранный столбец символа в позиции
+	columns := func(p TextPosition) (int, int) {
+		if p.Row >= len(lines) {
+			return 0, 0
+		}
+		line := lines[p.Row]
+		at := min(p.Col, len(line))
+		first := displayColumn(line, at, tab)
+		if at == len(line) {
+			return first, first
+		}
+		_, size := utf8.DecodeRuneInString(line[at:])
+		return first, displayColumn(line, at+size, tab) - 1
+	}
+
+	a, c := vh.visualStart, TextPosition{Row: e.cursorRow, Col: e.cursorCol}
+	aFirst, aLast := columns(a)
+	cFirst, cLast := columns(c)
+	return vimBlock{
+		top:    min(a.Row, c.Row),
+		bottom: max(a.Row, c.Row),
+		left:   min(aFirst, cFirst),
+		right:  max(aLast, cLast),
+		tab:    tab,
+		toEnd:  vh.blockToEnd,
+	}
+}
+
+// updateBlockSelection передает редактору прямоугольники выделения по строкам
+func (vh *VimHandler) updateBlockSelection() {
+	e := vh.editor
+	b := vh.currentBlock()
+	lines := strings.Split(e.textContent, "\n")
+
+	var ranges []TextRange
+	for row := b.top; row <= b.bottom && row < len(lines); row++ {
+		if from, to := b.span(lines[row]); from < to {
+			ranges = append(ranges, TextRange{
+				Start: TextPosition{Row: row, Col: from},
+				End:   TextPosition{Row: row, Col: to},
+				Text:  lines[row][from:to],
+			})
+		}
+	}
+	e.blockSelection = ranges
+	from, _ := b.span(lines[b.top])
+	_, to := b.span(lines[b.bottom])
+	e.selectionStart = TextPosition{Row: b.top, Col: from}
+	e.selectionEnd = TextPosition{Row: b.bottom, Col: to}
+	e.updateDisplay()
+}
+
+// handleVisualBlockKey выполняет команды, которые есть только у блочного выделения
+func (vh *VimHandler) handleVisualBlockKey(key string) bool {
+	if vh.pendingCommand == "r" {
+		vh.resetPending()
+		if utf8.RuneCountInString(key) == 1 {
+			vh.replaceBlock(key)
+		}
+		return true
+	}
+	// Недописанное движение (f, t, g ...) обрабатывается как обычно
+	if vh.pendingCommand != "" {
+		return false
+	}
+
+	b := vh.currentBlock()
+	switch key {
+	case "r":
+		vh.pendingCommand = "r"
+	case "$":
+		vh.blockToEnd = true
+		text := vh.editor.textContent
+		vh.setCursorNormal(vimLineEnd(text, vh.editor.cursorIndex()))
+		vh.updateBlockSelection()
+	case "o":
+		cur := TextPosition{Row: vh.editor.cursorRow, Col: vh.editor.cursorCol}
+		vh.setCursorAt(vh.visualStart.Row, vh.visualStart.Col)
+		vh.visualStart = cur
+		vh.updateBlockSelection()
+	case "O":
+		// Угол переносится по экранному столбцу: байтовые смещения строк не совпадают
+		e := vh.editor
+		lines := strings.Split(e.textContent, "\n")
+		tab := e.tabSize()
+		row, start := e.cursorRow, vh.visualStart
+		col := displayColumn(lines[row], e.cursorCol, tab)
+		startCol := displayColumn(lines[start.Row], start.Col, tab)
+		vh.setCursorAt(row, columnOffset(lines[row], startCol, tab))
+		vh.visualStart.Col = columnOffset(lines[start.Row], col, tab)
+		vh.updateBlockSelection()
+	case "d", "x":
+		vh.storeRegister(vimRegister{Text: strings.Join(vh.cutBlock(b), "\n"), Blockwise: true}, false)
+		vh.leaveVisualBlock(b)
+	case "y":
+		vh.storeRegister(vimRegister{Text: strings.Join(vh.blockLines(b), "\n"), Blockwise: true}, true)
+		vh.leaveVisualBlock(b)
+	case "c", "s":
+		vh.storeRegister(vimRegister{Text: strings.Join(vh.cutBlock(b), "\n"), Blockwise: true}, false)
+		b.toEnd = false
+		vh.startBlockInsert(b, 'c')
+	case "I", "A":
+		vh.startBlockInsert(b, key[0])
+	default:
+		return false
+	}
+	return true
+}
+
+// blockLines возвращает текст блока построчно
+func (vh *VimHandler) blockLines(b vimBlock) []string {
+	lines := strings.Split(vh.editor.textContent, "\n")
+	var out []string
+	for row := b.top; row <= b.bottom && row < len(lines); row++ {
+		from, to := b.span(lines[row])
+		out = append(out, lines[row][from:to])
+	}
+	return out
+}
+
+// cutBlock удаляет блок из текста и возвращает удаленные части строк
+func (vh *VimHandler) cutBlock(b vimBlock) []string {
+	e := vh.editor
+	lines := strings.Split(e.textContent, "\n")
+	var removed []string
+	for row := b.top; row <= b.bottom && row < len(lines); row++ {
+		from, to := b.span(lines[row])
+		removed = append(removed, lines[row][from:to])
+		lines[row] = lines[row][:from] + lines[row][to:]
+	}
+	e.ReplaceContent(strings.Join(lines, "\n"), false)
+	return removed
+}
+
+// replaceBlock заменяет каждый символ блока на ch (r в Visual Block)
+func (vh *VimHandler) replaceBlock(ch string) {
+	e := vh.editor
+	b := vh.currentBlock()
+	lines := strings.Split(e.textContent, "\n")
+	for row := b.top; row <= b.bottom && row < len(lines); row++ {
+		from, to := b.span(lines[row])
+		n := utf8.RuneCountInString(lines[row][from:to])
+		lines[row] = lines[row][:from] + strings.Repeat(ch, n) + lines[row][to:]
+	}
+	e.ReplaceContent(strings.Join(lines, "\n"), false)
+	vh.leaveVisualBlock(b)
+}
+
+// leaveVisualBlock возвращает в Normal с курсором в левом верхнем углу блока
+func (vh *VimHandler) leaveVisualBlock(b vimBlock) {
+	vh.mode = VimNormal
+	text := vh.editor.textContent
+	start := vimRowOffset(text, b.top)
+	vh.setCursorAt(b.top, columnOffset(text[start:vimLineEnd(text, start)], b.left, b.tab))
+	vh.clearSelection()
+}
+
+// setCursorAt ставит курсор в строку row и столбец col (не дальше конца строки)
+func (vh *VimHandler) setCursorAt(row, col int) {
+	text := vh.editor.textContent
+	start := vimRowOffset(text, row)
+	vh.editor.moveCursorToIndex(min(start+col, vimLineEnd(text, start)))
+}
+
+// startBlockInsert переходит во вставку (I, A или c) с курсором на каждой строке блока.
+// I и c пропускают строки, не доходящие до блока, A дополняет их пробелами.
+func (vh *VimHandler) startBlockInsert(b vimBlock, cmd byte) {
+	e := vh.editor
+	lines := strings.Split(e.textContent, "\n")
+
+	var cursors []TextPosition
+	for row := b.top; row <= b.bottom && row < len(lines); row++ {
+		line := lines[row]
+		width := displayColumn(line, len(line), b.tab)
+		at, end := b.span(line)
+		if cmd == 'A' {
+			at = end
+			if !b.toEnd && width <= b.right {
+				lines[row] = line + strings.Repeat(" ", b.right+1-width)
+				at = len(lines[row])
+			}
+		} else if width < b.left || cmd == 'I' && width == b.left && row != b.top {
+			// I не трогает строки, кончающиеся перед блоком; после c такие строки входили в блок
+			continue
+		}
+		cursors = append(cursors, TextPosition{Row: row, Col: at})
+	}
+	if len(cursors) == 0 {
+		from, _ := b.span(lines[b.top])
+		cursors = []TextPosition{{Row: b.top, Col: from}}
+	}
+
+	e.ReplaceContent(strings.Join(lines, "\n"), false)
+	e.cursors = cursors[1:]
+	vh.setCursorAt(cursors[0].Row, cursors[0].Col)
+	vh.blockInsert = &vimBlockInsert{start: e.cursorCol}
+	vh.mode = VimInsert
+	vh.clearSelection()
+}
+
+// blockInsertKey повторяет клавишу вставки на всех курсорах блока;
+// false - блочная вставка закончена и клавиша обрабатывается обычным образом
+func (vh *VimHandler) blockInsertKey(key string) bool {
+	switch {
+	case key == "Backspace" && vh.editor.cursorCol > vh.blockInsert.start:
+		vh.editBlockCursors(func(line string, col int) (string, int) {
+			if col == 0 {
+				return line, col
+			}
+			prev := vimPrevRune(line, col)
+			return line[:prev] + line[col:], prev
+		})
+	case key == "Tab":
+		vh.insertAtBlockCursors("\t")
+	case key != "Backspace" && utf8.RuneCountInString(key) == 1:
+		vh.insertAtBlockCursors(key)
+	default:
+		// Escape, Enter, Delete и Backspace за началом вставки завершают блок
+		vh.finishBlockInsert()
+		return false
+	}
+	return true
+}
+
+// insertAtBlockCursors вставляет текст перед каждым курсором блока
+func (vh *VimHandler) insertAtBlockCursors(s string) {
+	vh.editBlockCursors(func(line string, col int) (string, int) {
+		return line[:col] + s + line[col:], col + len(s)
+	})
+}
+
+// editBlockCursors применяет правку к строке основного и каждого дополнительного курсора
+func (vh *VimHandler) editBlockCursors(edit func(line string, col int) (string, int)) {
+	e := vh.editor
+	lines := strings.Split(e.textContent, "\n")
+	positions := append([]TextPosition{{Row: e.cursorRow, Col: e.cursorCol}}, e.cursors...)
+	for i, p := range positions {
+		if p.Row >= len(lines) {
+			continue
+		}
+		lines[p.Row], positions[i].Col = edit(lines[p.Row], min(p.Col, len(lines[p.Row])))
+	}
+
+	// Ввод в блок сливается в один шаг отмены
+	e.ReplaceContent(strings.Join(lines, "\n"), true)
+	e.cursors = positions[1:]
+	vh.setCursorAt(positions[0].Row, positions[0].Col)
+	e.updateDisplay()
+}
+
+// finishBlockInsert убирает дополнительные курсоры блочной вставки
+func (vh *VimHandler) finishBlockInsert() {
+	vh.blockInsert = nil
+	vh.editor.cursors = nil
+	vh.editor.updateCursorOverlay()
+}
+
+// putBlock вставляет блочный регистр столбцом начиная со строки курсора
+func (vh *VimHandler) putBlock(reg vimRegister, after bool, count int) {
+	e := vh.editor
+	tab := e.tabSize()
+	rows := strings.Split(reg.Text, "\n")
+	width := 0
+	for _, r := range rows {
+		width = max(width, displayColumn(r, len(r), tab))
+	}
+
+	lines := strings.Split(e.textContent, "\n")
+	row, at := e.cursorRow, min(e.cursorCol, len(lines[e.cursorRow]))
+	if after && at < len(lines[row]) {
+		_, size := utf8.DecodeRuneInString(lines[row][at:])
+		at += size
+	}
+	// Столбец вставки экранный, на каждой строке он переводится в свое смещение
+	col := displayColumn(lines[row], at, tab)
+
+	for i, piece := range rows {
+		r := row + i
+		for r >= len(lines) {
+			lines = append(lines, "")
+		}
+		line := lines[r]
+		if lineWidth := displayColumn(line, len(line), tab); lineWidth < col {
+			line += strings.Repeat(" ", col-lineWidth)
+		}
+		off := columnOffset(line, col, tab)
+		padded := piece + strings.Repeat(" ", width-displayColumn(piece, len(piece), tab))
+		block := strings.Repeat(padded, count)
+		if off == len(line) {
+			// В конце строки выравнивающие пробелы последнего повтора не нужны
+			block = strings.Repeat(padded, count-1) + piece
+		}
+		lines[r] = line[:off] + block + line[off:]
+	}
+
+	e.ReplaceContent(strings.Join(lines, "\n"), false)
+	vh.setCursorAt(row, at)
+	e.updateDisplay()
+}
@@ -0,0 +1,854 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// vimExRange - диапазон строк Ex команды (номера с 0, включительно);
+// строка -1 допустима как адрес 0 для :0r, :m0 и :t0
+type vimExRange struct {
+	start, end int
+	given      bool
+}
+
+// vimExCommands - полные имена Ex команд и минимальная длина сокращения
+var vimExCommands = []struct {
+	name string
+	min  int
+}{
+	{"substitute", 1}, {"set", 2}, {"sort", 3},
+	{"write", 1}, {"wq", 2}, {"xit", 1}, {"quit", 1},
+	{"edit", 1}, {"read", 1}, {"registers", 3},
+	{"delete", 1}, {"display", 2}, {"yank", 1},
+	{"move", 1}, {"copy", 2}, {"t", 1},
+	{"global", 1}, {"vglobal", 1}, {"normal", 4},
+	{"nohlsearch", 3}, {"source", 2}, {"let", 3},
+	{"map", 3}, {"nmap", 2}, {"vmap", 2}, {"xmap", 2}, {"imap", 2},
+	{"noremap", 2}, {"nnoremap", 2}, {"vnoremap", 2}, {"xnoremap", 2}, {"inoremap", 3},
+	{"unmap", 3}, {"nunmap", 3}, {"vunmap", 2}, {"xunmap", 2}, {"iunmap", 2},
+}
+
+// vimExFileCommands - команды, аргумент которых дополняется именами файлов
+var vimExFileCommands = map[string]bool{"edit": true, "read": true, "write": true}
+
+// vimExCommandName разворачивает сокращение Ex команды (sor -> sort)
+func vimExCommandName(word string) string {
+	for _, c := range vimExCommands {
+		if len(word) >= c.min && strings.HasPrefix(c.name, word) {
+			return c.name
+		}
+	}
+	return word
+}
+
+// splitExCommand делит команду после диапазона на имя, ! и аргумент
+func splitExCommand(cmd string) (name string, bang bool, arg string) {
+	cmd = strings.TrimLeft(cmd, " ")
+	if cmd == "" {
+		return "", false, ""
+	}
+	if cmd[0] == '!' {
+		return "!", false, strings.TrimSpace(cmd[1:])
+	}
+	i := 0
+	for i < len(cmd) && unicode.IsLetter(rune(cmd[i])) {
+		i++
+	}
+	if i == 0 {
+		return cmd, false, ""
+	}
+	name, rest := vimExCommandName(cmd[:i]), cmd[i:]
+	if strings.HasPrefix(rest, "!") {
+		bang, rest = true, rest[1:]
+	}
+	return name, bang, strings.TrimLeft(rest, " ")
+}
+
+// splitExDelimited читает текст до неэкранированного разделителя
+func splitExDelimited(s string, delim byte) (string, string) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == delim:
+			sb.WriteByte(delim)
+			i++
+		case s[i] == delim:
+			return sb.String(), s[i+1:]
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), ""
+}
+
+// exLines возвращает строки буфера
+func (vh *VimHandler) exLines() []string {
+	return strings.Split(vh.editor.textContent, "\n")
+}
+
+// setExLines заменяет буфер строками lines
+func (vh *VimHandler) setExLines(lines []string) {
+	if len(lines) == 0 {
+		lines = []string{""}
+	}
+	vh.editor.ReplaceContent(strings.Join(lines, "\n"), false)
+	vh.editor.updateDisplay()
+}
+
+// exCursorLine ставит курсор на первый непробельный символ строки row
+func (vh *VimHandler) exCursorLine(row int) {
+	text := vh.editor.textContent
+	row = max(0, min(row, strings.Count(text, "\n")))
+	vh.setCursorNormal(vimFirstNonBlank(text, vimRowOffset(text, row)))
+}
+
+// parseExAddress разбирает один адрес: N . $ 'x /pat/ ?pat? и смещения +N -N
+func (vh *VimHandler) parseExAddress(s string, lines []string, cur int) (int, string, bool, error) {
+	n := cur
+	switch {
+	case s == "":
+		return 0, s, false, nil
+	case s[0] == '.':
+		s = s[1:]
+	case s[0] == '$':
+		n, s = len(lines)-1, s[1:]
+	case s[0] >= '0' && s[0] <= '9':
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		num, _ := strconv.Atoi(s[:i])
+		n, s = num-1, s[i:]
+	case s[0] == '\'' && len(s) > 1:
+		pos, ok := vh.marks[s[1:2]]
+		if !ok {
+			return 0, s, false, fmt.Errorf("E20: Mark not set")
+		}
+		n, s = pos.Row, s[2:]
+	case s[0] == '/' || s[0] == '?':
+		backward := s[0] == '?'
+		var pattern string
+		pattern, s = splitExDelimited(s[1:], s[0])
+		row, err := vh.searchExLine(lines, cur, pattern, backward)
+		if err != nil {
+			return 0, s, false, err
+		}
+		n = row
+	case s[0] == '+' || s[0] == '-':
+		// Смещение без адреса отсчитывается от текущей строки
+	default:
+		return 0, s, false, nil
+	}
+
+	for len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		step := 1
+		if i > 0 {
+			step, _ = strconv.Atoi(s[:i])
+		}
+		n, s = n+sign*step, s[i:]
+	}
+	return n, s, true, nil
+}
+
+// searchExLine ищет строку с шаблоном после (или до) строки cur с переходом через край
+func (vh *VimHandler) searchExLine(lines []string, cur int, pattern string, backward bool) (int, error) {
+	if pattern == "" {
+		pattern = vh.searchPattern
+	}
+	if pattern == "" {
+		return 0, fmt.Errorf("E35: No previous regular expression")
+	}
+	vh.searchPattern = pattern
+	re, err := vh.compilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+	for i := 1; i <= len(lines); i++ {
+		row := cur + i
+		if backward {
+			row = cur - i
+		}
+		row = (row%len(lines) + len(lines)) % len(lines)
+		if re.MatchString(lines[row]) {
+			return row, nil
+		}
+	}
+	return 0, fmt.Errorf("E486: Pattern not found: %s", pattern)
+}
+
+// parseExRange разбирает диапазон в начале команды: %, адреса через , и ;
+func (vh *VimHandler) parseExRange(cmd string) (vimExRange, string, error) {
+	lines := vh.exLines()
+	cur := vh.editor.cursorRow
+	r := vimExRange{start: cur, end: cur}
+
+	cmd = strings.TrimLeft(cmd, " :")
+	if strings.HasPrefix(cmd, "%") {
+		return vimExRange{start: 0, end: len(lines) - 1, given: true}, cmd[1:], nil
+	}
+
+	var addrs []int
+	afterSep := false
+	for {
+		n, rest, ok, err := vh.parseExAddress(cmd, lines, cur)
+		if err != nil {
+			return r, cmd, err
+		}
+		if !ok {
+			// Пропущенный адрес рядом с разделителем - текущая строка
+			if !afterSep && (cmd == "" || cmd[0] != ',' && cmd[0] != ';') {
+				break
+			}
+			n = cur
+		}
+		addrs = append(addrs, n)
+		cmd = rest
+		if cmd == "" || cmd[0] != ',' && cmd[0] != ';' {
+			break
+		}
+		if cmd[0] == ';' {
+			cur = n
+		}
+		cmd, afterSep = cmd[1:], true
+	}
+
+	switch len(addrs) {
+	case 0:
+		return r, cmd, nil
+	case 1:
+		r.start, r.end = addrs[0], addrs[0]
+	default:
+		r.start, r.end = addrs[len(addrs)-2], addrs[len(addrs)-1]
+	}
+	r.given = true
+	if r.start > r.end {
+		r.start, r.end = r.end, r.start
+	}
+	if r.start < -1 || r.end >= len(lines) {
+		return r, cmd, fmt.Errorf("E16: Invalid range")
+	}
+	return r, cmd, nil
+}
+
+// runExCommand выполняет Ex команду с диапазоном строк
+func (vh *VimHandler) runExCommand(cmd string) error {
+	r, rest, err := vh.parseExRange(cmd)
+	if err != nil {
+		return err
+	}
+	name, bang, arg := splitExCommand(rest)
+	lines := vh.exLines()
+
+	// Строка 0 означает "перед первой" только для :read, :move и :copy
+	lineRange := r
+	lineRange.start, lineRange.end = max(r.start, 0), max(r.end, 0)
+	whole := lineRange
+	if !r.given {
+		whole = vimExRange{start: 0, end: len(lines) - 1}
+	}
+
+	switch name {
+	case "":
+		if r.given {
+			vh.goToLine(lineRange.end + 1)
+			vh.exCursorLine(lineRange.end)
+		}
+	case "write":
+		vh.saveFile()
+	case "quit":
+		if bang {
+			vh.forceQuit()
+		} else {
+			vh.quit()
+		}
+	case "wq", "xit":
+		vh.saveFile()
+		vh.quit()
+	case "edit":
+		vh.openFile(arg)
+	case "registers", "display":
+		vh.showRegisters()
+	case "set":
+		return vh.setOptions(arg)
+	case "nohlsearch":
+		vh.clearSearchHighlight()
+	case "source":
+		return vh.sourceFile(arg)
+	case "let":
+		return vh.letCommand(arg)
+	case "map", "nmap", "vmap", "xmap", "imap", "noremap", "nnoremap", "vnoremap", "xnoremap", "inoremap",
+		"unmap", "nunmap", "vunmap", "xunmap", "iunmap":
+		return vh.mapCommand(name, arg)
+	case "substitute":
+		return vh.substituteLines(lineRange, "s"+arg)
+	case "global", "vglobal":
+		return vh.globalCommand(whole, arg, bang || name == "vglobal")
+	case "sort":
+		return vh.sortLines(whole, bang, arg)
+	case "normal":
+		vh.normalCommand(lineRange, arg, bang)
+	case "read":
+		return vh.readLines(r.end, bang || strings.HasPrefix(arg, "!"), strings.TrimPrefix(arg, "!"))
+	case "!":
+		if r.given {
+			return vh.filterLines(lineRange, arg)
+		}
+		return vh.runShell(arg)
+	case "move", "copy", "t":
+		dest, rest, ok, err := vh.parseExAddress(strings.TrimSpace(arg), lines, vh.editor.cursorRow)
+		if err != nil {
+			return err
+		}
+		if !ok || strings.TrimSpace(rest) != "" || dest < -1 || dest >= len(lines) {
+			return fmt.Errorf("E14: Invalid address")
+		}
+		if name == "move" {
+			return vh.moveLines(lineRange, dest)
+		}
+		vh.copyLines(lineRange, dest)
+	case "delete", "yank":
+		prev := vh.register
+		if arg != "" && isVimRegisterName(arg[:1]) {
+			vh.register = arg[:1]
+		}
+		vh.deleteOrYankLines(lineRange, name == "delete")
+		vh.register = prev
+	default:
+		return fmt.Errorf("E492: Not an editor command: %s", strings.TrimSpace(cmd))
+	}
+	return nil
+}
+
+// substituteLines выполняет :s/pat/rep/flags в строках диапазона
+func (vh *VimHandler) substituteLines(r vimExRange, cmd string) error {
+	pattern, replacement, flags, err := parseSubstituteCommand(cmd)
+	if err != nil {
+		return err
+	}
+	if pattern == "" {
+		pattern = vh.searchPattern
+	} else {
+		vh.searchPattern = pattern
+	}
+	if strings.Contains(flags, "i") {
+		pattern = "(?i)" + pattern
+	}
+	re, err := vh.compilePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	lines := vh.exLines()
+	last := -1
+	for i := r.start; i <= r.end && i < len(lines); i++ {
+		if newLine, replaced := applySubstitution(lines[i], re, replacement, strings.Contains(flags, "g")); replaced {
+			lines[i] = newLine
+			last = i
+		}
+	}
+	if last < 0 {
+		return fmt.Errorf("E486: Pattern not found: %s", vh.searchPattern)
+	}
+	vh.setExLines(lines)
+	vh.exCursorLine(last)
+	return nil
+}
+
+// globalCommand выполняет :g/pat/cmd (или :v) для каждой подходящей строки
+func (vh *VimHandler) globalCommand(r vimExRange, arg string, invert bool) error {
+	if arg == "" {
+		return fmt.Errorf("E35: No previous regular expression")
+	}
+	pattern, command := splitExDelimited(arg[1:], arg[0])
+	if pattern == "" {
+		pattern = vh.searchPattern
+	} else {
+		vh.searchPattern = pattern
+	}
+	re, err := vh.compilePattern(pattern)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(command) == "" {
+		command = "p"
+	}
+
+	lines := vh.exLines()
+	var rows []int
+	for i := r.start; i <= r.end && i < len(lines); i++ {
+		if re.MatchString(lines[i]) != invert {
+			rows = append(rows, i)
+		}
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("E486: Pattern not found: %s", pattern)
+	}
+
+	var firstErr error
+	vh.forEachExLine(rows, func(int) {
+		// :p просто переходит к строке
+		if command == "p" || command == "print" {
+			return
+		}
+		if err := vh.runExCommand(command); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	})
+	return firstErr
+}
+
+// forEachExLine вызывает fn для строк rows, пересчитывая номера еще не
+// обработанных строк после изменений, сделанных предыдущими вызовами
+func (vh *VimHandler) forEachExLine(rows []int, fn func(row int)) {
+	for len(rows) > 0 {
+		row := rows[0]
+		rows = rows[1:]
+		before := vh.exLines()
+		vh.setCursorAt(row, 0)
+		fn(row)
+		rows = vimRemapRows(before, vh.exLines(), rows)
+	}
+}
+
+// vimRemapRows переводит номера строк буфера before в номера буфера after;
+// строки, которых больше нет, отбрасываются
+func vimRemapRows(before, after []string, rows []int) []int {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	// Строки в измененной середине ищем по содержимому
+	middle := after[prefix : len(after)-suffix]
+	used := make([]bool, len(middle))
+	var out []int
+	for _, row := range rows {
+		switch {
+		case row < prefix:
+			out = append(out, row)
+		case row >= len(before)-suffix:
+			out = append(out, row+len(after)-len(before))
+		default:
+			for i, line := range middle {
+				if !used[i] && line == before[row] {
+					used[i] = true
+					out = append(out, prefix+i)
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// vimSortNumberRe - числа для :sort n и :sort x
+var (
+	vimSortNumberRe = regexp.MustCompile(`-?\d+`)
+	vimSortHexRe    = regexp.MustCompile(`-?(0[xX])?[0-9a-fA-F]+`)
+)
+
+// sortLines выполняет :sort[!] [i] [n] [x] [u] [r] [/pat/]
+func (vh *VimHandler) sortLines(r vimExRange, reverse bool, arg string) error {
+	var re *regexp.Regexp
+	var flags string
+	for arg != "" {
+		if c := arg[0]; c == ' ' || unicode.IsLetter(rune(c)) {
+			flags += arg[:1]
+			arg = arg[1:]
+			continue
+		}
+		pattern, rest := splitExDelimited(arg[1:], arg[0])
+		if pattern == "" {
+			pattern = vh.searchPattern
+		}
+		var err error
+		if re, err = vh.compilePattern(pattern); err != nil {
+			return err
+		}
+		arg = rest
+	}
+	ignoreCase := strings.Contains(flags, "i")
+	numeric := strings.Contains(flags, "n") || strings.Contains(flags, "x")
+	hex := strings.Contains(flags, "x")
+	unique := strings.Contains(flags, "u")
+	useMatch := strings.Contains(flags, "r")
+
+	type sortLine struct {
+		text   string
+		key    string
+		num    int64
+		hasKey bool
+	}
+
+	lines := vh.exLines()
+	var keyed, plain []sortLine
+	for _, line := range lines[r.start : r.end+1] {
+		l := sortLine{text: line, key: line, hasKey: true}
+		if re != nil {
+			loc := re.FindStringIndex(line)
+			switch {
+			case loc == nil:
+				l.hasKey = false
+			case useMatch:
+				l.key = line[loc[0]:loc[1]]
+			default:
+				l.key = line[loc[1]:]
+			}
+		}
+		if l.hasKey && numeric {
+			numRe := vimSortNumberRe
+			if hex {
+				numRe = vimSortHexRe
+			}
+			if m := numRe.FindString(l.key); m != "" {
+				base := 10
+				if hex {
+					base = 16
+					m = strings.Replace(strings.Replace(m, "0x", "", 1), "0X", "", 1)
+				}
+				l.num, _ = strconv.ParseInt(m, base, 64)
+			} else {
+				// Строки без числа идут перед числами в исходном порядке
+				l.hasKey = false
+			}
+		}
+		if ignoreCase {
+			l.key = strings.ToLower(l.key)
+		}
+		if l.hasKey {
+			keyed = append(keyed, l)
+		} else {
+			plain = append(plain, l)
+		}
+	}
+
+	compare := func(a, b sortLine) int {
+		if numeric {
+			switch {
+			case a.num < b.num:
+				return -1
+			case a.num > b.num:
+				return 1
+			}
+			return 0
+		}
+		return strings.Compare(a.key, b.key)
+	}
+	sort.SliceStable(keyed, func(i, j int) bool {
+		if reverse {
+			return compare(keyed[i], keyed[j]) > 0
+		}
+		return compare(keyed[i], keyed[j]) < 0
+	})
+
+	// Строки без ключа остаются перед отсортированными (после - при обратной сортировке)
+	var sorted []sortLine
+	if reverse {
+		for i := len(plain) - 1; i >= 0; i-- {
+			keyed = append(keyed, plain[i])
+		}
+		sorted = keyed
+	} else {
+		sorted = append(plain, keyed...)
+	}
+
+	result := make([]string, 0, len(sorted))
+	for i, l := range sorted {
+		if unique && i > 0 && l.hasKey && sorted[i-1].hasKey && compare(l, sorted[i-1]) == 0 {
+			continue
+		}
+		result = append(result, l.text)
+	}
+
+	lines = append(append(append([]string{}, lines[:r.start]...), result...), lines[r.end+1:]...)
+	vh.setExLines(lines)
+	vh.exCursorLine(r.start)
+	return nil
+}
+
+// normalCommand выполняет :normal - клавиши Normal режима для каждой строки диапазона;
+// :normal! не применяет пользовательские сопоставления
+func (vh *VimHandler) normalCommand(r vimExRange, arg string, noremap bool) {
+	keys := parseVimKeys(arg)
+	if len(keys) == 0 {
+		return
+	}
+	prev := vh.noRemap
+	vh.noRemap = noremap
+	defer func() { vh.noRemap = prev }()
+	var rows []int
+	for i := r.start; i <= r.end; i++ {
+		rows = append(rows, i)
+	}
+	vh.forEachExLine(rows, func(int) {
+		vh.mode = VimNormal
+		vh.resetPending()
+		vh.feedKeys(keys)
+		// Незаконченная вставка или команда завершается как по Esc
+		if vh.mode != VimNormal {
+			vh.feedKeys([]string{"Escape"})
+		}
+		vh.resetPending()
+	})
+}
+
+// vimShellCommand создает команду оболочки, как и runCommand редактора
+func vimShellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// shellCommand создает команду оболочки в каталоге текущего файла
+func (vh *VimHandler) shellCommand(command string) *exec.Cmd {
+	cmd := vimShellCommand(command)
+	if vh.editor.filePath != "" {
+		cmd.Dir = filepath.Dir(vh.editor.filePath)
+	}
+	return cmd
+}
+
+// vimOutputLines делит вывод файла или команды на строки буфера
+func vimOutputLines(data []byte) []string {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// readLines выполняет :r file и :r !cmd - вставку после строки after
+func (vh *VimHandler) readLines(after int, shell bool, arg string) error {
+	arg = strings.TrimSpace(arg)
+	var data []byte
+	var err error
+	if shell {
+		data, err = vh.shellCommand(arg).Output()
+	} else {
+		if arg == "" {
+			arg = vh.editor.filePath
+		}
+		data, err = os.ReadFile(vimExpandPath(arg))
+	}
+	if err != nil {
+		return err
+	}
+
+	lines := vh.exLines()
+	inserted := vimOutputLines(data)
+	lines = append(append(append([]string{}, lines[:after+1]...), inserted...), lines[after+1:]...)
+	vh.setExLines(lines)
+	vh.exCursorLine(after + 1)
+	return nil
+}
+
+// filterLines выполняет :{range}!cmd - пропускает строки через команду оболочки
+func (vh *VimHandler) filterLines(r vimExRange, command string) error {
+	lines := vh.exLines()
+	cmd := vh.shellCommand(command)
+	cmd.Stdin = strings.NewReader(strings.Join(lines[r.start:r.end+1], "\n") + "\n")
+	data, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%s: %v", command, err)
+	}
+
+	lines = append(append(append([]string{}, lines[:r.start]...), vimOutputLines(data)...), lines[r.end+1:]...)
+	vh.setExLines(lines)
+	vh.exCursorLine(r.start)
+	return nil
+}
+
+// runShell выполняет :!cmd и показывает вывод
+func (vh *VimHandler) runShell(command string) error {
+	data, err := vh.shellCommand(command).CombinedOutput()
+	if err != nil && len(data) == 0 {
+		return fmt.Errorf("%s: %v", command, err)
+	}
+	if win := vimWindow(); win != nil {
+		dialog.ShowInformation("!"+command, string(data), win)
+	}
+	return nil
+}
+
+// moveLines выполняет :m - переносит строки диапазона после строки dest
+func (vh *VimHandler) moveLines(r vimExRange, dest int) error {
+	if dest >= r.start && dest < r.end {
+		return fmt.Errorf("E134: Cannot move a range of lines into itself")
+	}
+	lines := vh.exLines()
+	block := append([]string{}, lines[r.start:r.end+1]...)
+	rest := append(append([]string{}, lines[:r.start]...), lines[r.end+1:]...)
+	at := dest + 1
+	if dest > r.end {
+		at -= len(block)
+	}
+	lines = append(append(append([]string{}, rest[:at]...), block...), rest[at:]...)
+	vh.setExLines(lines)
+	vh.exCursorLine(at + len(block) - 1)
+	return nil
+}
+
+// copyLines выполняет :t (:co) - копирует строки диапазона после строки dest
+func (vh *VimHandler) copyLines(r vimExRange, dest int) {
+	lines := vh.exLines()
+	block := append([]string{}, lines[r.start:r.end+1]...)
+	lines = append(append(append([]string{}, lines[:dest+1]...), block...), lines[dest+1:]...)
+	vh.setExLines(lines)
+	vh.exCursorLine(dest + len(block))
+}
+
+// deleteOrYankLines выполняет :d и :y в регистр, выбранный в vh.register
+func (vh *VimHandler) deleteOrYankLines(r vimExRange, del bool) {
+	lines := vh.exLines()
+	vh.setRegister(strings.Join(lines[r.start:r.end+1], "\n"), true, !del)
+	if !del {
+		return
+	}
+	lines = append(append([]string{}, lines[:r.start]...), lines[r.end+1:]...)
+	vh.setExLines(lines)
+	vh.exCursorLine(r.start)
+}
+
+// vimExpandPath раскрывает ~ в начале пути
+func vimExpandPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// vimWindow возвращает главное окно для диалогов
+func vimWindow() fyne.Window {
+	if app := fyne.CurrentApp(); app != nil {
+		if wins := app.Driver().AllWindows(); len(wins) > 0 {
+			return wins[0]
+		}
+	}
+	return nil
+}
+
+// exError сообщает об ошибке Ex команды
+func (vh *VimHandler) exError(err error) {
+	log.Printf("vim: %v", err)
+	if win := vimWindow(); win != nil {
+		dialog.ShowError(err, win)
+	}
+}
+
+// Командная строка: история и дополнение
+
+// pushExHistory добавляет команду в историю без повторов подряд
+func (vh *VimHandler) pushExHistory(cmd string) {
+	if cmd != "" && (len(vh.exHistory) == 0 || vh.exHistory[len(vh.exHistory)-1] != cmd) {
+		vh.exHistory = append(vh.exHistory, cmd)
+	}
+	vh.exHistoryIndex = len(vh.exHistory)
+}
+
+// browseExHistory листает историю среди команд, начинающихся с набранного текста
+func (vh *VimHandler) browseExHistory(older bool) {
+	if vh.exHistoryIndex >= len(vh.exHistory) {
+		vh.exHistoryIndex = len(vh.exHistory)
+		vh.exHistoryPrefix = vh.pendingCommand
+	}
+	step := 1
+	if older {
+		step = -1
+	}
+	for i := vh.exHistoryIndex + step; i >= 0 && i < len(vh.exHistory); i += step {
+		if strings.HasPrefix(vh.exHistory[i], vh.exHistoryPrefix) {
+			vh.exHistoryIndex = i
+			vh.pendingCommand = vh.exHistory[i]
+			return
+		}
+	}
+	if !older {
+		// Ниже самой новой команды - снова набранный текст
+		vh.exHistoryIndex = len(vh.exHistory)
+		vh.pendingCommand = vh.exHistoryPrefix
+	}
+}
+
+// completeExCommand дополняет последнее слово командной строки, повторный Tab
+// перебирает варианты
+func (vh *VimHandler) completeExCommand() {
+	if vh.exCompletions == nil {
+		vh.exCompletionBase, vh.exCompletions = vimExCompletions(vh.pendingCommand)
+		vh.exCompletionIndex = -1
+		if len(vh.exCompletions) == 0 {
+			vh.exCompletions = nil
+			return
+		}
+	}
+	vh.exCompletionIndex = (vh.exCompletionIndex + 1) % len(vh.exCompletions)
+	vh.pendingCommand = vh.exCompletionBase + vh.exCompletions[vh.exCompletionIndex]
+}
+
+// vimExRangePrefix - диапазон перед именем команды
+var vimExRangePrefix = regexp.MustCompile(`^[\s\d.,;$%+\-]*('[a-z<>][\s\d.,;$%+\-]*)*`)
+
+// vimExCompletions возвращает неизменяемую часть строки и варианты для последнего слова
+func vimExCompletions(line string) (string, []string) {
+	prefix := vimExRangePrefix.FindString(line)
+	body := line[len(prefix):]
+
+	space := strings.LastIndex(body, " ")
+	if space < 0 {
+		var names []string
+		for _, c := range vimExCommands {
+			if strings.HasPrefix(c.name, body) && len(c.name) > 1 {
+				names = append(names, c.name)
+			}
+		}
+		sort.Strings(names)
+		return prefix, names
+	}
+
+	name, _, _ := splitExCommand(body)
+	base, word := line[:len(prefix)+space+1], body[space+1:]
+	var out []string
+	switch {
+	case name == "set":
+		for _, opt := range vimOptionNames() {
+			if strings.HasPrefix(opt, word) {
+				out = append(out, opt)
+			}
+		}
+	case vimExFileCommands[name]:
+		matches, _ := filepath.Glob(vimExpandPath(word) + "*")
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.IsDir() {
+				m += string(filepath.Separator)
+			}
+			out = append(out, m)
+		}
+	}
+	return base, out
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// vimMapping - правая часть :map; noremap - без повторного сопоставления
+type vimMapping struct {
+	rhs     string
+	noremap bool
+}
+
+// vimrcFile - стартовый файл Vim в каталоге настроек
+const vimrcFile = "vimrc"
+
+// vimDefaultLeader - <leader> по умолчанию, меняется через let mapleader
+const vimDefaultLeader = "\\"
+
+// vimMaxMapDepth ограничивает рекурсивные сопоставления (maxmapdepth)
+const vimMaxMapDepth = 100
+
+// vimMapCommands - команды сопоставления: режимы и признак noremap/unmap
+var vimMapCommands = map[string]struct {
+	modes   string
+	noremap bool
+	unmap   bool
+}{
+	"map":      {modes: "nv"},
+	"nmap":     {modes: "n"},
+	"vmap":     {modes: "v"},
+	"xmap":     {modes: "v"},
+	"imap":     {modes: "i"},
+	"noremap":  {modes: "nv", noremap: true},
+	"nnoremap": {modes: "n", noremap: true},
+	"vnoremap": {modes: "v", noremap: true},
+	"xnoremap": {modes: "v", noremap: true},
+	"inoremap": {modes: "i", noremap: true},
+	"unmap":    {modes: "nv", unmap: true},
+	"nunmap":   {modes: "n", unmap: true},
+	"vunmap":   {modes: "v", unmap: true},
+	"xunmap":   {modes: "v", unmap: true},
+	"iunmap":   {modes: "i", unmap: true},
+}
+
+// vimKeyNotation - запись клавиш в :map без учета регистра
+var vimKeyNotation = map[string]string{
+	"<cr>":     "<CR>",
+	"<enter>":  "<CR>",
+	"<return>": "<CR>",
+	"<esc>":    "<Esc>",
+	"<bs>":     "<BS>",
+	"<tab>":    "<Tab>",
+	"<del>":    "<Del>",
+	"<up>":     "<Up>",
+	"<down>":   "<Down>",
+	"<space>":  " ",
+	"<lt>":     "<lt>",
+	"<bar>":    "|",
+	"<bslash>": "\\",
+}
+
+var (
+	vimKeyTokenRe  = regexp.MustCompile(`<[^<>\s]+>`)
+	vimMapLeaderRe = regexp.MustCompile(`^(?:g:)?mapleader\s*=\s*(["'])(.*)["']$`)
+)
+
+// vimMapArgs - специальные аргументы :map, которые не входят в {lhs}
+var vimMapArgs = map[string]bool{"<silent>": true, "<buffer>": true, "<nowait>": true, "<unique>": true}
+
+// normalizeKeys приводит запись клавиш к виду vimKeysToString с подстановкой <leader>
+func (vh *VimHandler) normalizeKeys(s string) string {
+	s = vimKeyTokenRe.ReplaceAllStringFunc(s, func(token string) string {
+		lower := strings.ToLower(token)
+		switch {
+		case lower == "<leader>":
+			return vimKeysToString(parseVimKeys(vh.leader))
+		case vimKeyNotation[lower] != "":
+			return vimKeyNotation[lower]
+		case len(lower) == 5 && strings.HasPrefix(lower, "<c-"):
+			return "<C-" + lower[3:4] + ">"
+		}
+		return token
+	})
+	return vimKeysToString(parseVimKeys(s))
+}
+
+// mapCommand выполняет :map, :nnoremap, :iunmap и подобные
+func (vh *VimHandler) mapCommand(name, arg string) error {
+	def := vimMapCommands[name]
+	fields := strings.Fields(arg)
+	for len(fields) > 0 && vimMapArgs[strings.ToLower(fields[0])] {
+		arg = strings.TrimLeft(strings.TrimPrefix(arg, fields[0]), " ")
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		vh.listMappings(def.modes, "")
+		return nil
+	}
+
+	lhs := vh.normalizeKeys(fields[0])
+	rhs := strings.TrimLeft(strings.TrimPrefix(arg, fields[0]), " ")
+	if def.unmap {
+		found := false
+		for _, mode := range []byte(def.modes) {
+			if _, ok := vh.mappings[mode][lhs]; ok {
+				delete(vh.mappings[mode], lhs)
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("E31: No such mapping")
+		}
+		return nil
+	}
+	if rhs == "" {
+		vh.listMappings(def.modes, lhs)
+		return nil
+	}
+
+	for _, mode := range []byte(def.modes) {
+		if vh.mappings[mode] == nil {
+			vh.mappings[mode] = make(map[string]vimMapping)
+		}
+		vh.mappings[mode][lhs] = vimMapping{rhs: vh.normalizeKeys(rhs), noremap: def.noremap}
+	}
+	return nil
+}
+
+// listMappings показывает сопоставления режимов modes (с префиксом lhs)
+func (vh *VimHandler) listMappings(modes, lhs string) {
+	var lines []string
+	for _, mode := range []byte(modes) {
+		for key, m := range vh.mappings[mode] {
+			if !strings.HasPrefix(key, lhs) {
+				continue
+			}
+			star := " "
+			if m.noremap {
+				star = "*"
+			}
+			lines = append(lines, fmt.Sprintf("%c  %-10s %s %s", mode, key, star, m.rhs))
+		}
+	}
+	if len(lines) == 0 {
+		vh.exMessage("map", "No mapping found")
+		return
+	}
+	sort.Strings(lines)
+	vh.exMessage("map", strings.Join(lines, "\n"))
+}
+
+// letCommand выполняет :let; поддерживается только mapleader
+func (vh *VimHandler) letCommand(arg string) error {
+	m := vimMapLeaderRe.FindStringSubmatch(strings.TrimSpace(arg))
+	if m == nil {
+		return fmt.Errorf("E121: Undefined variable: %s", strings.TrimSpace(arg))
+	}
+	value := m[2]
+	if m[1] == `"` {
+		value = strings.ReplaceAll(value, `\<Space>`, " ")
+		value = strings.ReplaceAll(value, `\\`, `\`)
+	}
+	vh.leader = value
+	return nil
+}
+
+// mapMode возвращает режим сопоставлений для следующей клавиши (0 - без сопоставлений)
+func (vh *VimHandler) mapMode() byte {
+	switch vh.mode {
+	case VimNormal:
+		if vh.pendingCommand == "" && vh.operator == "" {
+			return 'n'
+		}
+	case VimVisual, VimVisualLine, VimVisualBlock:
+		if vh.pendingCommand == "" {
+			return 'v'
+		}
+	case VimInsert:
+		return 'i'
+	}
+	return 0
+}
+
+// mapKey копит клавиши, пока они начинают какой-либо {lhs}, и раскрывает сопоставления
+func (vh *VimHandler) mapKey(mode byte, key string) bool {
+	table := vh.mappings[mode]
+	buf := append(vh.mapPending, key)
+	lhs := vimKeysToString(buf)
+	for k := range table {
+		if len(k) > len(lhs) && strings.HasPrefix(k, lhs) {
+			vh.mapPending = buf
+			return true
+		}
+	}
+
+	vh.mapPending = nil
+	if m, ok := table[lhs]; ok {
+		vh.expandMapping(lhs, m)
+		return true
+	}
+	if len(buf) == 1 {
+		return vh.dispatchKey(key)
+	}
+
+	// Отложенные клавиши не сложились в {lhs}: ищем самое длинное сопоставление в начале
+	for i := len(buf) - 1; i > 0; i-- {
+		if m, ok := table[vimKeysToString(buf[:i])]; ok {
+			vh.expandMapping(vimKeysToString(buf[:i]), m)
+			vh.replayKeys(buf[i:], true)
+			return true
+		}
+	}
+	vh.replayKeys(buf[:1], false)
+	vh.replayKeys(buf[1:], true)
+	return true
+}
+
+// expandMapping выполняет {rhs}; {rhs}, начинающийся с {lhs}, в начале не переотображается
+func (vh *VimHandler) expandMapping(lhs string, m vimMapping) {
+	if vh.mapDepth >= vimMaxMapDepth {
+		vh.exError(fmt.Errorf("E223: recursive mapping"))
+		return
+	}
+	vh.mapDepth++
+	defer func() { vh.mapDepth-- }()
+
+	keys := parseVimKeys(m.rhs)
+	if m.noremap {
+		vh.replayKeys(keys, false)
+		return
+	}
+	if lhsKeys := parseVimKeys(lhs); strings.HasPrefix(m.rhs, lhs) {
+		vh.replayKeys(keys[:len(lhsKeys)], false)
+		keys = keys[len(lhsKeys):]
+	}
+	vh.replayKeys(keys, true)
+}
+
+// replayKeys передает клавиши обработчику с сопоставлениями или без них
+func (vh *VimHandler) replayKeys(keys []string, remap bool) {
+	prev := vh.noRemap
+	vh.noRemap = !remap
+	vh.feedKeys(keys)
+	vh.noRemap = prev
+}
+
+// loadVimrc выполняет стартовый файл vimrc, если он есть
+func (vh *VimHandler) loadVimrc() {
+	path := filepath.Join(getConfigDirectory(), vimrcFile)
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	if err := vh.sourceFile(path); err != nil {
+		log.Printf("vimrc: %v", err)
+	}
+}
+
+// sourceFile выполняет Ex команды из файла (:source); возвращает первую ошибку
+func (vh *VimHandler) sourceFile(path string) error {
+	f, err := os.Open(vimExpandPath(path))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	prev := vh.sourcing
+	vh.sourcing = true
+	defer func() { vh.sourcing = prev }()
+
+	var firstErr error
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimLeft(scanner.Text(), " \t:")
+		if line == "" || strings.HasPrefix(line, `"`) {
+			continue
+		}
+		if err := vh.runExCommand(line); err != nil {
+			err = fmt.Errorf("%s:%d: %v", filepath.Base(path), n, err)
+			log.Print(err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
@@ -0,0 +1,1315 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// vimParseStatus - результат разбора последовательности клавиш
+type vimParseStatus int
+
+const (
+	vimParseInvalid vimParseStatus = iota
+	vimParseIncomplete
+	vimParseComplete
+)
+
+// vimMotionKind - тип движения для операторов
+type vimMotionKind int
+
+const (
+	vimExclusive vimMotionKind = iota
+	vimInclusive
+	vimLinewise
+)
+
+// vimOperators - операторы, ожидающие движения или текстового объекта
+var vimOperators = map[string]bool{
+	"d": true, "c": true, "y": true, ">": true, "<": true, "=": true,
+	"g~": true, "gu": true, "gU": true,
+}
+
+// vimOperatorAliases - короткие команды, эквивалентные оператору с движением
+var vimOperatorAliases = map[string]string{
+	"x": "dl", "X": "dh", "D": "d$", "C": "c$", "s": "cl", "S": "cc", "Y": "yy",
+}
+
+// vimSimpleMotions - движения без аргумента
+var vimSimpleMotions = map[string]vimMotionKind{
+	"h": vimExclusive, "l": vimExclusive,
+	"w": vimExclusive, "W": vimExclusive, "b": vimExclusive, "B": vimExclusive,
+	"e": vimInclusive, "E": vimInclusive, "ge": vimInclusive, "gE": vimInclusive,
+	"0": vimExclusive, "^": vimExclusive, "$": vimInclusive,
+	"j": vimLinewise, "k": vimLinewise, "+": vimLinewise, "-": vimLinewise, "_": vimLinewise,
+	"G": vimLinewise, "gg": vimLinewise,
+	"{": vimExclusive, "}": vimExclusive,
+	"%": vimInclusive,
+	"n": vimExclusive, "N": vimExclusive,
+	";": vimInclusive, ",": vimInclusive,
+}
+
+// vimRange - диапазон текста для оператора; для построчного end - конец последней строки
+type vimRange struct {
+	start    int
+	end      int
+	linewise bool
+}
+
+// vimFind - последний поиск символа f/F/t/T для ; и ,
+type vimFind struct {
+	cmd  byte
+	char string
+}
+
+// parseVimMotion проверяет, является ли последовательность движением
+func parseVimMotion(cmd string) vimParseStatus {
+	if _, ok := vimSimpleMotions[cmd]; ok {
+		return vimParseComplete
+	}
+	if cmd == "g" {
+		return vimParseIncomplete
+	}
+	switch cmd[0] {
+	case 'f', 'F', 't', 'T':
+		if len(cmd) == 1 {
+			return vimParseIncomplete
+		}
+		if utf8.RuneCountInString(cmd[1:]) == 1 {
+			return vimParseComplete
+		}
+	}
+	return vimParseInvalid
+}
+
+// vimCharClass возвращает класс символа: 0 - пробел, 1 - пунктуация, 2 - слово
+func vimCharClass(b byte, bigWord bool) int {
+	switch {
+	case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+		return 0
+	case bigWord:
+		return 2
+	case b == '_' || b >= 0x80 || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z'):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// vimLineStart возвращает смещение начала строки, содержащей pos
+func vimLineStart(text string, pos int) int {
+	if pos > len(text) {
+		pos = len(text)
+	}
+	return strings.LastIndexByte(text[:pos], '\n') + 1
+}
+
+// vimLineEnd возвращает смещение перевода строки (или конца текста) для строки с pos
+func vimLineEnd(text string, pos int) int {
+	if pos >= len(text) {
+		return len(text)
+	}
+	if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(text)
+}
+
+// vimFirstNonBlank возвращает первый непробельный символ строки
+func vimFirstNonBlank(text string, lineStart int) int {
+	end := vimLineEnd(text, lineStart)
+	i := lineStart
+	for i < end && (text[i] == ' ' || text[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// vimRowOffset возвращает смещение начала строки row (с ограничением)
+func vimRowOffset(text string, row int) int {
+	off := 0
+	for r := 0; r < row; r++ {
+		i := strings.IndexByte(text[off:], '\n')
+		if i < 0 {
+			return off
+		}
+		off += i + 1
+	}
+	return off
+}
+
+// vimRowOf возвращает номер строки для смещения
+func vimRowOf(text string, pos int) int {
+	if pos > len(text) {
+		pos = len(text)
+	}
+	return strings.Count(text[:pos], "\n")
+}
+
+// vimIsEmptyLine проверяет, что pos - начало пустой строки
+func vimIsEmptyLine(text string, pos int) bool {
+	return pos < len(text) && text[pos] == '\n' && (pos == 0 || text[pos-1] == '\n') ||
+		pos == len(text) && (pos == 0 || text[pos-1] == '\n')
+}
+
+// vimWordForward - движение w/W
+func vimWordForward(text string, pos int, big bool) int {
+	n := len(text)
+	if pos >= n {
+		return n
+	}
+	i := pos
+	if c := vimCharClass(text[i], big); c != 0 {
+		for i < n && vimCharClass(text[i], big) == c {
+			i++
+		}
+	}
+	for i < n && vimCharClass(text[i], big) == 0 {
+		// Пустая строка считается словом
+		if i > pos && vimIsEmptyLine(text, i) {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+// vimWordEnd - движение e/E
+func vimWordEnd(text string, pos int, big bool) int {
+	n := len(text)
+	i := pos + 1
+	for i < n && vimCharClass(text[i], big) == 0 {
+		i++
+	}
+	if i >= n {
+		return n - 1
+	}
+	c := vimCharClass(text[i], big)
+	for i+1 < n && vimCharClass(text[i+1], big) == c {
+		i++
+	}
+	return i
+}
+
+// vimWordBackward - движение b/B
+func vimWordBackward(text string, pos int, big bool) int {
+	i := pos - 1
+	for i >= 0 && vimCharClass(text[i], big) == 0 {
+		if vimIsEmptyLine(text, i) && i < pos-1 {
+			return i
+		}
+		i--
+	}
+	if i < 0 {
+		return 0
+	}
+	c := vimCharClass(text[i], big)
+	for i > 0 && vimCharClass(text[i-1], big) == c {
+		i--
+	}
+	return i
+}
+
+// vimWordEndBackward - движение ge/gE
+func vimWordEndBackward(text string, pos int, big bool) int {
+	i := pos
+	if i >= len(text) {
+		i = len(text) - 1
+	}
+	if i < 0 {
+		return 0
+	}
+	if c := vimCharClass(text[i], big); c != 0 {
+		for i >= 0 && vimCharClass(text[i], big) == c {
+			i--
+		}
+	}
+	for i >= 0 && vimCharClass(text[i], big) == 0 {
+		if vimIsEmptyLine(text, i) {
+			return i
+		}
+		i--
+	}
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+// vimParagraphForward - движение }
+func vimParagraphForward(text string, pos int) int {
+	i := vimLineStart(text, pos)
+	// Пропускаем пустые строки, затем абзац
+	for i < len(text) && vimIsEmptyLine(text, i) {
+		i++
+	}
+	for i < len(text) {
+		if vimIsEmptyLine(text, i) {
+			return i
+		}
+		i = vimLineEnd(text, i) + 1
+	}
+	return len(text)
+}
+
+// vimParagraphBackward - движение {
+func vimParagraphBackward(text string, pos int) int {
+	i := vimLineStart(text, pos)
+	for i > 0 && vimIsEmptyLine(text, vimLineStart(text, i-1)) {
+		i = vimLineStart(text, i-1)
+	}
+	for i > 0 {
+		prev := vimLineStart(text, i-1)
+		if vimIsEmptyLine(text, prev) {
+			return prev
+		}
+		i = prev
+	}
+	return 0
+}
+
+// vimMatchBracket - движение %
+func vimMatchBracket(text string, pos int) int {
+	const brackets = "(){}[]"
+	end := vimLineEnd(text, pos)
+	i := pos
+	for i < end && !strings.ContainsRune(brackets, rune(text[i])) {
+		i++
+	}
+	if i >= end {
+		return -1
+	}
+	idx := strings.IndexByte(brackets, text[i])
+	open, close := brackets[idx&^1], brackets[idx|1]
+	if text[i] == open {
+		return vimMatchForward(text, i, open, close)
+	}
+	return vimFindUnmatchedOpen(text, i-1, open, close)
+}
+
+// vimFindUnmatchedOpen ищет незакрытую открывающую скобку назад от from
+func vimFindUnmatchedOpen(text string, from int, open, close byte) int {
+	depth := 0
+	for i := from; i >= 0; i-- {
+		switch text[i] {
+		case close:
+			depth++
+		case open:
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// vimMatchForward ищет закрывающую скобку для открывающей в позиции o
+func vimMatchForward(text string, o int, open, close byte) int {
+	depth := 0
+	for i := o + 1; i < len(text); i++ {
+		switch text[i] {
+		case open:
+			depth++
+		case close:
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// vimFindChar - движения f/F/t/T в пределах строки: t/T ищут count-е вхождение
+// как f/F и останавливаются на символ раньше
+func vimFindChar(text string, pos int, cmd byte, char string, count int) int {
+	ls := vimLineStart(text, pos)
+	le := vimLineEnd(text, pos)
+	i := pos
+	for n := 0; n < count; n++ {
+		switch cmd {
+		case 'f', 't':
+			start := vimNextRune(text, i)
+			if start > le {
+				return -1
+			}
+			j := strings.Index(text[start:le], char)
+			if j < 0 {
+				return -1
+			}
+			i = start + j
+		case 'F', 'T':
+			j := strings.LastIndex(text[ls:i], char)
+			if j < 0 {
+				return -1
+			}
+			i = ls + j
+		}
+	}
+	switch cmd {
+	case 't':
+		i = vimPrevRune(text, i)
+	case 'T':
+		i += len(char)
+	}
+	return i
+}
+
+// vimNextRune возвращает смещение следующего символа
+func vimNextRune(text string, pos int) int {
+	if pos >= len(text) {
+		return len(text)
+	}
+	_, size := utf8.DecodeRuneInString(text[pos:])
+	return pos + size
+}
+
+// vimPrevRune возвращает смещение предыдущего символа
+func vimPrevRune(text string, pos int) int {
+	if pos <= 0 {
+		return 0
+	}
+	_, size := utf8.DecodeLastRuneInString(text[:pos])
+	return pos - size
+}
+
+// vimWordObject - текстовые объекты iw/aw/iW/aW
+func vimWordObject(text string, pos, count int, around, big bool) (int, int, bool) {
+	if len(text) == 0 {
+		return 0, 0, false
+	}
+	if pos >= len(text) {
+		pos = len(text) - 1
+	}
+	ls, le := vimLineStart(text, pos), vimLineEnd(text, pos)
+	if ls == le {
+		return 0, 0, false
+	}
+	if pos >= le {
+		pos = le - 1
+	}
+
+	runEnd := func(i int) int {
+		c := vimCharClass(text[i], big)
+		for i < le && vimCharClass(text[i], big) == c {
+			i++
+		}
+		return i
+	}
+
+	c := vimCharClass(text[pos], big)
+	start := pos
+	for start > ls && vimCharClass(text[start-1], big) == c {
+		start--
+	}
+	end := runEnd(pos)
+
+	if !around {
+		for k := 1; k < count && end < le; k++ {
+			end = runEnd(end)
+		}
+		return start, end, true
+	}
+
+	if c == 0 {
+		// На пробелах: пробелы и следующее слово
+		for k := 0; k < count && end < le; k++ {
+			end = runEnd(end)
+			if k+1 < count && end < le {
+				end = runEnd(end)
+			}
+		}
+		return start, end, true
+	}
+
+	for k := 1; k < count && end < le; k++ {
+		if vimCharClass(text[end], big) == 0 {
+			end = runEnd(end)
+		}
+		if end < le {
+			end = runEnd(end)
+		}
+	}
+	if end < le && vimCharClass(text[end], big) == 0 {
+		end = runEnd(end)
+	} else {
+		// Нет пробелов после слова - берем пробелы перед ним, но не отступ
+		s := start
+		for s > ls && vimCharClass(text[s-1], big) == 0 {
+			s--
+		}
+		if s > ls {
+			start = s
+		}
+	}
+	return start, end, true
+}
+
+// vimQuoteObject - текстовые объекты i" a" i' a' i` a`
+func vimQuoteObject(text string, pos int, q byte, around bool) (int, int, bool) {
+	ls, le := vimLineStart(text, pos), vimLineEnd(text, pos)
+
+	var quotes []int
+	for i := ls; i < le; i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] == q {
+			quotes = append(quotes, i)
+		}
+	}
+
+	// На кавычке пары определяются от начала строки, иначе берутся ближайшие кавычки
+	open, close := -1, -1
+	for k := 0; k < len(quotes); k++ {
+		if quotes[k] == pos {
+			if k%2 == 0 && k+1 < len(quotes) {
+				open, close = quotes[k], quotes[k+1]
+			} else if k%2 == 1 {
+				open, close = quotes[k-1], quotes[k]
+			}
+			break
+		}
+		if quotes[k] > pos {
+			if k > 0 {
+				open, close = quotes[k-1], quotes[k]
+			} else if len(quotes) > 1 {
+				open, close = quotes[0], quotes[1]
+			}
+			break
+		}
+	}
+	if open < 0 {
+		return 0, 0, false
+	}
+
+	if !around {
+		return open + 1, close, true
+	}
+	start, end := open, close+1
+	if end < le && (text[end] == ' ' || text[end] == '\t') {
+		for end < le && (text[end] == ' ' || text[end] == '\t') {
+			end++
+		}
+	} else {
+		for start > ls && (text[start-1] == ' ' || text[start-1] == '\t') {
+			start--
+		}
+	}
+	return start, end, true
+}
+
+// vimBracketObject - текстовые объекты i( a( i{ a{ i[ a[ i< a<
+func vimBracketObject(text string, pos int, open, close byte, count int, around bool) (int, int, bool) {
+	o := -1
+	switch {
+	case pos < len(text) && text[pos] == open:
+		o = pos
+	case pos < len(text) && text[pos] == close:
+		o = vimFindUnmatchedOpen(text, pos-1, open, close)
+	default:
+		o = vimFindUnmatchedOpen(text, pos-1, open, close)
+	}
+	for level := 1; level < count && o >= 0; level++ {
+		o = vimFindUnmatchedOpen(text, o-1, open, close)
+	}
+	if o < 0 {
+		return 0, 0, false
+	}
+	c := vimMatchForward(text, o, open, close)
+	if c < 0 {
+		return 0, 0, false
+	}
+	if around {
+		return o, c + 1, true
+	}
+
+	start, end := o+1, c
+	// Многострочный блок: внутренность - целые строки между скобками
+	if start < len(text) && text[start] == '\n' {
+		start++
+	}
+	if ls := vimLineStart(text, c); ls > start && strings.TrimSpace(text[ls:c]) == "" {
+		end = ls
+	}
+	if end < start {
+		end = start
+	}
+	return start, end, true
+}
+
+var vimTagRe = regexp.MustCompile(`<(/?)([A-Za-z][\w:.-]*)[^<>]*?(/?)>`)
+
+// vimTagObject - текстовые объекты it/at
+func vimTagObject(text string, pos, count int, around bool) (int, int, bool) {
+	type openTag struct {
+		name       string
+		start, end int
+	}
+	type tagPair struct {
+		openStart, openEnd, closeStart, closeEnd int
+	}
+
+	var stack []openTag
+	var pairs []tagPair
+	for _, m := range vimTagRe.FindAllStringSubmatchIndex(text, -1) {
+		closing := m[3] > m[2]
+		name := text[m[4]:m[5]]
+		if m[7] > m[6] {
+			continue
+		}
+		if !closing {
+			stack = append(stack, openTag{name: name, start: m[0], end: m[1]})
+			continue
+		}
+		for i := len(stack) - 1; i >= 0; i-- {
+			if strings.EqualFold(stack[i].name, name) {
+				pairs = append(pairs, tagPair{stack[i].start, stack[i].end, m[0], m[1]})
+				stack = stack[:i]
+				break
+			}
+		}
+	}
+
+	var enclosing []tagPair
+	for _, p := range pairs {
+		if p.openStart <= pos && pos < p.closeEnd {
+			enclosing = append(enclosing, p)
+		}
+	}
+	if len(enclosing) < count {
+		return 0, 0, false
+	}
+	sort.Slice(enclosing, func(i, j int) bool {
+		return enclosing[i].closeEnd-enclosing[i].openStart < enclosing[j].closeEnd-enclosing[j].openStart
+	})
+	p := enclosing[count-1]
+	if around {
+		return p.openStart, p.closeEnd, true
+	}
+	return p.openEnd, p.closeStart, true
+}
+
+// vimParagraphObject - текстовые объекты ip/ap (возвращает номера строк)
+func vimParagraphObject(text string, pos, count int, around bool) (int, int, bool) {
+	lines := strings.Split(text, "\n")
+	row := vimRowOf(text, pos)
+	if row >= len(lines) {
+		return 0, 0, false
+	}
+	blank := func(i int) bool { return strings.TrimSpace(lines[i]) == "" }
+	runEnd := func(i int) int {
+		b := blank(i)
+		for i+1 < len(lines) && blank(i+1) == b {
+			i++
+		}
+		return i
+	}
+
+	start := row
+	for start > 0 && blank(start-1) == blank(row) {
+		start--
+	}
+	end := runEnd(row)
+
+	if !around {
+		for k := 1; k < count && end+1 < len(lines); k++ {
+			end = runEnd(end + 1)
+		}
+		return start, end, true
+	}
+
+	startBlank := blank(row)
+	for k := 0; k < count; k++ {
+		if k > 0 {
+			if end+1 >= len(lines) {
+				break
+			}
+			end = runEnd(end + 1)
+		}
+		if end+1 < len(lines) {
+			end = runEnd(end + 1)
+		} else if !startBlank && k == 0 {
+			// Нет пустых строк после абзаца - берем пустые строки перед ним
+			for start > 0 && blank(start-1) {
+				start--
+			}
+		}
+	}
+	return start, end, true
+}
+
+// vimSentenceObject - текстовые объекты is/as
+func vimSentenceObject(text string, pos, count int, around bool) (int, int, bool) {
+	startRow, endRow, ok := vimParagraphObject(text, pos, 1, false)
+	if !ok {
+		return 0, 0, false
+	}
+	ps := vimRowOffset(text, startRow)
+	pe := vimLineEnd(text, vimRowOffset(text, endRow))
+
+	isSpace := func(b byte) bool { return b == ' ' || b == '\t' || b == '\n' || b == '\r' }
+	type span struct{ start, end int }
+	var sentences []span
+	for i := ps; i < pe; {
+		for i < pe && isSpace(text[i]) {
+			i++
+		}
+		if i >= pe {
+			break
+		}
+		s, e := i, pe
+		for j := i; j < pe; j++ {
+			if text[j] != '.' && text[j] != '!' && text[j] != '?' {
+				continue
+			}
+			k := j + 1
+			for k < pe && strings.IndexByte(`)]"'`, text[k]) >= 0 {
+				k++
+			}
+			if k >= pe || isSpace(text[k]) {
+				e = k
+				break
+			}
+		}
+		sentences = append(sentences, span{s, e})
+		i = e
+	}
+
+	idx := -1
+	for i, s := range sentences {
+		if pos < s.end {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return 0, 0, false
+	}
+
+	last := idx + count - 1
+	if last >= len(sentences) {
+		last = len(sentences) - 1
+	}
+	start, end := sentences[idx].start, sentences[last].end
+	if around {
+		e := end
+		for e < pe && isSpace(text[e]) {
+			e++
+		}
+		if e > end {
+			end = e
+		} else {
+			for start > ps && isSpace(text[start-1]) {
+				start--
+			}
+		}
+	}
+	return start, end, true
+}
+
+// vimTextObject вычисляет текстовый объект по его символу
+func vimTextObject(text string, pos int, obj string, count int, around bool) (vimRange, bool) {
+	if count < 1 {
+		count = 1
+	}
+
+	var start, end int
+	var ok bool
+	switch obj {
+	case "w":
+		start, end, ok = vimWordObject(text, pos, count, around, false)
+	case "W":
+		start, end, ok = vimWordObject(text, pos, count, around, true)
+	case "s":
+		start, end, ok = vimSentenceObject(text, pos, count, around)
+	case "p":
+		startRow, endRow, found := vimParagraphObject(text, pos, count, around)
+		if !found {
+			return vimRange{}, false
+		}
+		s := vimRowOffset(text, startRow)
+		return vimRange{start: s, end: vimLineEnd(text, vimRowOffset(text, endRow)), linewise: true}, true
+	case "\"", "'", "`":
+		start, end, ok = vimQuoteObject(text, pos, obj[0], around)
+	case "(", ")", "b":
+		start, end, ok = vimBracketObject(text, pos, '(', ')', count, around)
+	case "{", "}", "B":
+		start, end, ok = vimBracketObject(text, pos, '{', '}', count, around)
+	case "[", "]":
+		start, end, ok = vimBracketObject(text, pos, '[', ']', count, around)
+	case "<", ">":
+		start, end, ok = vimBracketObject(text, pos, '<', '>', count, around)
+	case "t":
+		start, end, ok = vimTagObject(text, pos, count, around)
+	}
+	return vimRange{start: start, end: end}, ok
+}
+
+// vimShiftLines сдвигает строки на уровень отступа
+func vimShiftLines(lines []string, unit string, width int, right bool) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		if right {
+			if strings.TrimSpace(line) == "" {
+				out[i] = line
+			} else {
+				out[i] = unit + line
+			}
+			continue
+		}
+		// Удаляем не больше одного уровня отступа
+		removed, j := 0, 0
+		for j < len(line) && removed < width {
+			if line[j] == '\t' {
+				removed = width
+			} else if line[j] == ' ' {
+				removed++
+			} else {
+				break
+			}
+			j++
+		}
+		out[i] = line[j:]
+	}
+	return out
+}
+
+// vimReindentLines выравнивает отступы строк по вложенности скобок
+func vimReindentLines(lines []string, prevLine, unit string, width int) []string {
+	indentWidth := func(line string) int {
+		w := 0
+		for _, r := range line {
+			switch r {
+			case ' ':
+				w++
+			case '\t':
+				w += width
+			default:
+				return w
+			}
+		}
+		return w
+	}
+	netBrackets := func(line string) (int, int) {
+		opens, leadingCloses := 0, 0
+		trimmed := strings.TrimSpace(line)
+		for i := 0; i < len(trimmed) && strings.IndexByte(")}]", trimmed[i]) >= 0; i++ {
+			leadingCloses++
+		}
+		for _, r := range trimmed {
+			switch r {
+			case '(', '{', '[':
+				opens++
+			case ')', '}', ']':
+				opens--
+			}
+		}
+		return opens, leadingCloses
+	}
+
+	level := 0
+	if strings.TrimSpace(prevLine) != "" && width > 0 {
+		level = indentWidth(prevLine) / width
+		if opens, leading := netBrackets(prevLine); opens+leading > 0 {
+			level++
+		}
+	}
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			out[i] = ""
+			continue
+		}
+		opens, leading := netBrackets(line)
+		lineLevel := level - leading
+		if lineLevel < 0 {
+			lineLevel = 0
+		}
+		out[i] = strings.Repeat(unit, lineLevel) + trimmed
+		level = lineLevel + opens + leading
+		if level < 0 {
+			level = 0
+		}
+	}
+	return out
+}
+
+// vimToggleCase меняет регистр символов
+func vimToggleCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// Операторы и движения VimHandler
+
+// totalCount возвращает произведение счетчиков оператора и движения (0 - счетчик не задан)
+func (vh *VimHandler) totalCount() int {
+	switch {
+	case vh.operatorCount == 0:
+		return vh.count
+	case vh.count == 0:
+		return vh.operatorCount
+	default:
+		return vh.operatorCount * vh.count
+	}
+}
+
+// resetPending сбрасывает состояние ожидания команды
+func (vh *VimHandler) resetPending() {
+	vh.pendingCommand = ""
+	vh.count = 0
+	vh.operator = ""
+	vh.operatorCount = 0
+	vh.commandKeys = nil
+	vh.register = ""
+}
+
+// isPendingPrefix проверяет, может ли команда быть дополнена следующими клавишами
+func isPendingPrefix(cmd string) bool {
+	switch cmd {
+	case "r", "m", "q", "@", "'", "`", "\"", "g", "z":
+		return true
+	}
+	return false
+}
+
+// indentUnit возвращает строку одного уровня отступа и его ширину
+func (vh *VimHandler) indentUnit() (string, int) {
+	width, useSpaces := 4, true
+	if cfg := vh.editor.config; cfg != nil {
+		if cfg.Editor.IndentSize > 0 {
+			width = cfg.Editor.IndentSize
+		} else if cfg.Editor.TabSize > 0 {
+			width = cfg.Editor.TabSize
+		}
+		useSpaces = cfg.Editor.UseSpaces
+	}
+	if useSpaces {
+		return strings.Repeat(" ", width), width
+	}
+	return "\t", width
+}
+
+// motionTarget вычисляет позицию после движения
+func (vh *VimHandler) motionTarget(cmd string, count int, forOperator bool) (int, vimMotionKind, bool) {
+	text := vh.editor.textContent
+	pos := vh.editor.cursorIndex()
+	if pos > len(text) {
+		pos = len(text)
+	}
+	hasCount := count > 0
+	if count < 1 {
+		count = 1
+	}
+	kind := vimSimpleMotions[cmd]
+
+	switch cmd {
+	case "h":
+		ls := vimLineStart(text, pos)
+		for i := 0; i < count && pos > ls; i++ {
+			pos = vimPrevRune(text, pos)
+		}
+		return pos, kind, true
+	case "l":
+		le := vimLineEnd(text, pos)
+		for i := 0; i < count && pos < le; i++ {
+			pos = vimNextRune(text, pos)
+		}
+		return pos, kind, true
+	case "w", "W":
+		big := cmd == "W"
+		for i := 0; i < count; i++ {
+			next := vimWordForward(text, pos, big)
+			// Последнее слово строки: оператор не захватывает перевод строки
+			if forOperator && i == count-1 && vimRowOf(text, next) > vimRowOf(text, pos) {
+				next = vimLineEnd(text, pos)
+				if next == vimLineStart(text, pos) && next < len(text) {
+					next++
+				}
+			}
+			pos = next
+		}
+		return pos, kind, true
+	case "b", "B":
+		for i := 0; i < count; i++ {
+			pos = vimWordBackward(text, pos, cmd == "B")
+		}
+		return pos, kind, true
+	case "e", "E":
+		for i := 0; i < count; i++ {
+			pos = vimWordEnd(text, pos, cmd == "E")
+		}
+		return pos, kind, pos >= 0
+	case "ge", "gE":
+		for i := 0; i < count; i++ {
+			pos = vimWordEndBackward(text, pos, cmd == "gE")
+		}
+		return pos, kind, true
+	case "0":
+		return vimLineStart(text, pos), kind, true
+	case "^":
+		return vimFirstNonBlank(text, vimLineStart(text, pos)), kind, true
+	case "$":
+		ls := vimRowOffset(text, vimRowOf(text, pos)+count-1)
+		le := vimLineEnd(text, ls)
+		if le == ls {
+			return ls, vimExclusive, true
+		}
+		return vimPrevRune(text, le), kind, true
+	case "j", "k", "+", "-", "_":
+		row := vimRowOf(text, pos)
+		col := pos - vimLineStart(text, pos)
+		// Столбец, обрезанный короткой строкой, восстанавливается на следующих j/k
+		if !forOperator && (cmd == "j" || cmd == "k") {
+			if pos == vh.wantPos {
+				col = vh.wantCol
+			}
+			vh.wantCol = col
+		}
+		target := row
+		switch cmd {
+		case "j", "+":
+			target += count
+		case "k", "-":
+			target -= count
+		case "_":
+			target += count - 1
+		}
+		last := strings.Count(text, "\n")
+		if target < 0 || target > last {
+			if forOperator {
+				return 0, kind, false
+			}
+			target = max(0, min(target, last))
+		}
+		ls := vimRowOffset(text, target)
+		if cmd == "j" || cmd == "k" {
+			return min(ls+col, vimLineEnd(text, ls)), kind, true
+		}
+		return vimFirstNonBlank(text, ls), kind, true
+	case "G", "gg":
+		row := 0
+		switch {
+		case hasCount:
+			row = count - 1
+		case cmd == "G":
+			row = strings.Count(text, "\n")
+		}
+		return vimFirstNonBlank(text, vimRowOffset(text, row)), kind, true
+	case "}":
+		for i := 0; i < count; i++ {
+			pos = vimParagraphForward(text, pos)
+		}
+		return pos, kind, true
+	case "{":
+		for i := 0; i < count; i++ {
+			pos = vimParagraphBackward(text, pos)
+		}
+		return pos, kind, true
+	case "%":
+		target := vimMatchBracket(text, pos)
+		return target, kind, target >= 0
+	case "n", "N":
+		re, err := vh.compilePattern(vh.searchPattern)
+		if vh.searchPattern == "" || err != nil {
+			return 0, kind, false
+		}
+		backward := vh.searchBackward != (cmd == "N")
+		for i := 0; i < count; i++ {
+			next := vimSearchFrom(text, re, pos, backward)
+			if next < 0 {
+				return 0, kind, false
+			}
+			pos = next
+		}
+		return pos, kind, true
+	case ";", ",":
+		if vh.lastFind.cmd == 0 {
+			return 0, kind, false
+		}
+		find := vh.lastFind.cmd
+		if cmd == "," {
+			find = map[byte]byte{'f': 'F', 'F': 'f', 't': 'T', 'T': 't'}[find]
+		}
+		target := vimFindChar(text, pos, find, vh.lastFind.char, count)
+		if find == 'F' || find == 'T' {
+			kind = vimExclusive
+		}
+		return target, kind, target >= 0
+	}
+
+	switch cmd[0] {
+	case 'f', 'F', 't', 'T':
+		vh.lastFind = vimFind{cmd: cmd[0], char: cmd[1:]}
+		target := vimFindChar(text, pos, cmd[0], cmd[1:], count)
+		kind = vimInclusive
+		if cmd[0] == 'F' || cmd[0] == 'T' {
+			kind = vimExclusive
+		}
+		return target, kind, target >= 0
+	}
+	return 0, kind, false
+}
+
+// vimSearchFrom ищет следующее совпадение регулярного выражения от позиции
+func vimSearchFrom(text string, re *regexp.Regexp, pos int, backward bool) int {
+	matches := re.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	if backward {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i][0] < pos {
+				return matches[i][0]
+			}
+		}
+		return matches[len(matches)-1][0]
+	}
+	for _, m := range matches {
+		if m[0] > pos {
+			return m[0]
+		}
+	}
+	return matches[0][0]
+}
+
+// moveByMotion перемещает курсор в Normal режиме
+func (vh *VimHandler) moveByMotion(cmd string, count int) {
+	target, _, ok := vh.motionTarget(cmd, count, false)
+	if !ok {
+		return
+	}
+	switch cmd {
+	case "G", "gg", "n", "N", "%", "{", "}":
+		vh.addToJumpList()
+	}
+	vh.setCursorNormal(target)
+	if cmd == "n" || cmd == "N" {
+		vh.updateSearchHighlight()
+	}
+	if cmd == "j" || cmd == "k" {
+		vh.wantPos = vh.editor.cursorIndex()
+	}
+}
+
+// setCursorNormal ставит курсор, не допуская позиции на переводе строки
+func (vh *VimHandler) setCursorNormal(pos int) {
+	text := vh.editor.textContent
+	if pos > len(text) {
+		pos = len(text)
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	if ls := vimLineStart(text, pos); pos == vimLineEnd(text, pos) && pos > ls {
+		pos = vimPrevRune(text, pos)
+	}
+	vh.editor.moveCursorToIndex(pos)
+	vh.editor.updateDisplay()
+}
+
+// motionRange преобразует движение от курсора в диапазон для оператора
+func (vh *VimHandler) motionRange(cmd string, count int) (vimRange, bool) {
+	text := vh.editor.textContent
+	pos := vh.editor.cursorIndex()
+
+	// cw/cW на слове работает как ce/cE
+	if vh.operator == "c" && (cmd == "w" || cmd == "W") && pos < len(text) && vimCharClass(text[pos], false) != 0 {
+		end := pos
+		big := cmd == "W"
+		for i := 0; i < max(count, 1); i++ {
+			if i == 0 && (end+1 >= len(text) || vimCharClass(text[end+1], big) != vimCharClass(text[end], big)) {
+				continue
+			}
+			end = vimWordEnd(text, end, big)
+		}
+		return vimRange{start: pos, end: vimNextRune(text, end)}, true
+	}
+
+	target, kind, ok := vh.motionTarget(cmd, count, true)
+	if !ok {
+		return vimRange{}, false
+	}
+	start, end := pos, target
+	if start > end {
+		start, end = end, start
+	}
+
+	switch kind {
+	case vimLinewise:
+		return vimRange{start: vimLineStart(text, start), end: vimLineEnd(text, end), linewise: true}, true
+	case vimInclusive:
+		end = vimNextRune(text, end)
+	default:
+		// Исключающее движение, заканчивающееся в начале строки
+		if end > start && end == vimLineStart(text, end) && vimRowOf(text, end) > vimRowOf(text, start) {
+			end--
+			if start <= vimFirstNonBlank(text, vimLineStart(text, start)) {
+				return vimRange{start: vimLineStart(text, start), end: vimLineEnd(text, end), linewise: true}, true
+			}
+		}
+	}
+	return vimRange{start: start, end: min(end, len(text))}, true
+}
+
+// handleOperatorPending разбирает движение или текстовый объект после оператора
+func (vh *VimHandler) handleOperatorPending() bool {
+	cmd := vh.pendingCommand
+	op := vh.operator
+	count := vh.totalCount()
+	text := vh.editor.textContent
+	pos := vh.editor.cursorIndex()
+
+	var r vimRange
+	ok := false
+
+	switch {
+	case cmd == op || (len(op) == 2 && cmd == op[1:]):
+		// Повтор оператора: построчная операция над count строками
+		row := vimRowOf(text, pos)
+		last := min(row+max(count, 1)-1, strings.Count(text, "\n"))
+		r = vimRange{start: vimRowOffset(text, row), end: vimLineEnd(text, vimRowOffset(text, last)), linewise: true}
+		ok = true
+	case cmd == "i" || cmd == "a":
+		return true
+	case (cmd[0] == 'i' || cmd[0] == 'a') && len(cmd) > 1:
+		r, ok = vimTextObject(text, pos, cmd[1:], count, cmd[0] == 'a')
+	default:
+		switch parseVimMotion(cmd) {
+		case vimParseIncomplete:
+			return true
+		case vimParseComplete:
+			r, ok = vh.motionRange(cmd, count)
+		}
+		// g - префикс для повтора операторов gu/gU/g~
+		if len(op) == 2 && strings.HasPrefix(op, cmd) {
+			return true
+		}
+	}
+
+	keys, register := vh.commandKeys, vh.register
+	vh.resetPending()
+	if !ok {
+		return true
+	}
+	vh.register = register
+	vh.applyOperator(op, r)
+	vh.register = ""
+	if op != "y" {
+		vh.recordChange(keys)
+	}
+	return true
+}
+
+// applyOperator применяет оператор к диапазону
+func (vh *VimHandler) applyOperator(op string, r vimRange) {
+	e := vh.editor
+	text := e.textContent
+	if r.start > r.end || r.end > len(text) {
+		return
+	}
+	selected := text[r.start:r.end]
+
+	switch op {
+	case "y":
+		vh.setRegister(selected, r.linewise, op == "y")
+		if r.linewise {
+			row := vimRowOf(text, r.start)
+			if row < e.cursorRow {
+				e.cursorRow = row
+				vh.adjustCursorColumn()
+				e.moveCursorToIndex(e.cursorIndex())
+			}
+			return
+		}
+		vh.setCursorNormal(r.start)
+
+	case "d":
+		vh.setRegister(selected, r.linewise, op == "y")
+		if !r.linewise {
+			vh.replaceText(r.start, r.end, "")
+			vh.setCursorNormal(r.start)
+			return
+		}
+		start, end := r.start, r.end
+		if end < len(text) {
+			end++
+		} else if start > 0 {
+			start--
+		}
+		vh.replaceText(start, end, "")
+		ls := vimLineStart(e.textContent, min(r.start, len(e.textContent)))
+		vh.setCursorNormal(vimFirstNonBlank(e.textContent, ls))
+
+	case "c":
+		vh.setRegister(selected, r.linewise, op == "y")
+		if r.linewise {
+			indent := text[r.start:vimFirstNonBlank(text, r.start)]
+			vh.replaceText(r.start, r.end, indent)
+			e.moveCursorToIndex(r.start + len(indent))
+		} else {
+			vh.replaceText(r.start, r.end, "")
+			e.moveCursorToIndex(r.start)
+		}
+		vh.mode = VimInsert
+		e.updateDisplay()
+
+	case ">", "<", "=":
+		ls := vimLineStart(text, r.start)
+		le := vimLineEnd(text, r.end)
+		// Построчный оператор: пустой символьный диапазон в конце строки не расширяем
+		if !r.linewise && r.end > r.start && r.end == vimLineStart(text, r.end) {
+			le = vimLineEnd(text, r.end-1)
+		}
+		lines := strings.Split(text[ls:le], "\n")
+		unit, width := vh.indentUnit()
+		if op == "=" {
+			prev := ""
+			if ls > 0 {
+				prev = text[vimLineStart(text, ls-1) : ls-1]
+			}
+			lines = vimReindentLines(lines, prev, unit, width)
+		} else {
+			lines = vimShiftLines(lines, unit, width, op == ">")
+		}
+		vh.replaceText(ls, le, strings.Join(lines, "\n"))
+		vh.setCursorNormal(vimFirstNonBlank(e.textContent, ls))
+
+	case "gu", "gU", "g~":
+		var changed string
+		switch op {
+		case "gu":
+			changed = strings.ToLower(selected)
+		case "gU":
+			changed = strings.ToUpper(selected)
+		default:
+			changed = vimToggleCase(selected)
+		}
+		vh.replaceText(r.start, r.end, changed)
+		vh.setCursorNormal(r.start)
+	}
+}
+
+// replaceText заменяет диапазон текста редактора отдельным шагом отмены
+func (vh *VimHandler) replaceText(start, end int, repl string) {
+	vh.editor.ReplaceRange(start, end, repl, false)
+	vh.editor.updateDisplay()
+}
+
+// insertText заменяет диапазон, сливая правку с предыдущей: вставка при повторе
+// изменения отменяется вместе с ним одним шагом
+func (vh *VimHandler) insertText(start, end int, repl string) {
+	vh.editor.ReplaceRange(start, end, repl, true)
+	vh.editor.updateDisplay()
+}
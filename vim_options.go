@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// vimOptionDef - опция :set: логическая (flag) или числовая (num)
+type vimOptionDef struct {
+	name, short string
+	flag        func(vh *VimHandler) *bool
+	num         func(vh *VimHandler) *int
+	apply       func(vh *VimHandler)
+}
+
+// vimOptionDefs - опции :set; часть из них хранится в настройках редактора
+var vimOptionDefs = []vimOptionDef{
+	{name: "number", short: "nu", flag: func(vh *VimHandler) *bool { return &vh.editor.config.Editor.ShowLineNumbers }, apply: (*VimHandler).applyLineNumbers},
+	{name: "relativenumber", short: "rnu", flag: func(vh *VimHandler) *bool { return &vh.editor.config.Editor.ShowRelativeNumbers }, apply: (*VimHandler).applyLineNumbers},
+	{name: "wrap", flag: func(vh *VimHandler) *bool { return &vh.editor.config.Editor.WordWrap }, apply: (*VimHandler).applyWrap},
+	{name: "tabstop", short: "ts", num: func(vh *VimHandler) *int { return &vh.editor.config.Editor.TabSize }},
+	{name: "shiftwidth", short: "sw", num: func(vh *VimHandler) *int { return &vh.editor.config.Editor.IndentSize }},
+	{name: "expandtab", short: "et", flag: func(vh *VimHandler) *bool { return &vh.editor.config.Editor.UseSpaces }},
+	{name: "ignorecase", short: "ic", flag: func(vh *VimHandler) *bool { return &vh.ignoreCase }, apply: (*VimHandler).updateSearchHighlight},
+	{name: "smartcase", short: "scs", flag: func(vh *VimHandler) *bool { return &vh.smartCase }, apply: (*VimHandler).updateSearchHighlight},
+	{name: "hlsearch", short: "hls", flag: func(vh *VimHandler) *bool { return &vh.hlsearch }, apply: (*VimHandler).updateSearchHighlight},
+	{name: "incsearch", short: "is", flag: func(vh *VimHandler) *bool { return &vh.incsearch }},
+}
+
+// vimOptionNames возвращает имена опций и их отрицаний для дополнения :set
+func vimOptionNames() []string {
+	var names []string
+	for _, def := range vimOptionDefs {
+		names = append(names, def.name)
+		if def.flag != nil {
+			names = append(names, "no"+def.name, "inv"+def.name)
+		}
+	}
+	return names
+}
+
+// vimFindOption ищет опцию по полному или короткому имени
+func vimFindOption(name string) (vimOptionDef, bool) {
+	for _, def := range vimOptionDefs {
+		if name == def.name || def.short != "" && name == def.short {
+			return def, true
+		}
+	}
+	return vimOptionDef{}, false
+}
+
+// vimSetArgRe - один аргумент :set: [no|inv]имя[!|?] или имя[+-^]=значение
+var vimSetArgRe = regexp.MustCompile(`^(no|inv)?([a-z]+)([!?]|[+\-^]?[=:](.*))?$`)
+
+// setOptions выполняет :set со списком опций через пробел
+func (vh *VimHandler) setOptions(arg string) error {
+	if vh.editor == nil || vh.editor.config == nil {
+		return fmt.Errorf("E518: Unknown option: %s", arg)
+	}
+	var shown []string
+	for _, item := range strings.Fields(arg) {
+		value, err := vh.setOption(item)
+		if err != nil {
+			return err
+		}
+		if value != "" {
+			shown = append(shown, value)
+		}
+	}
+	if len(shown) > 0 {
+		vh.exMessage("set", strings.Join(shown, "  "))
+	}
+	return nil
+}
+
+// setOption применяет один аргумент :set; для запроса (имя?) возвращает значение
+func (vh *VimHandler) setOption(item string) (string, error) {
+	m := vimSetArgRe.FindStringSubmatch(item)
+	if m == nil {
+		return "", fmt.Errorf("E518: Unknown option: %s", item)
+	}
+	prefix, name, op, value := m[1], m[2], m[3], m[4]
+	def, ok := vimFindOption(name)
+	if !ok {
+		return "", fmt.Errorf("E518: Unknown option: %s", item)
+	}
+
+	if def.flag != nil {
+		flag := def.flag(vh)
+		switch {
+		case op == "?":
+			return vimFlagString(def.name, *flag), nil
+		case strings.ContainsAny(op, "=:"):
+			return "", fmt.Errorf("E474: Invalid argument: %s", item)
+		case op == "!" || prefix == "inv":
+			*flag = !*flag
+		default:
+			*flag = prefix != "no"
+		}
+	} else {
+		num := def.num(vh)
+		if op == "" || op == "?" {
+			return fmt.Sprintf("%s=%d", def.name, *num), nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || prefix != "" {
+			return "", fmt.Errorf("E521: Number required after =: %s", item)
+		}
+		switch op[0] {
+		case '+':
+			n = *num + n
+		case '-':
+			n = *num - n
+		case '^':
+			n = *num * n
+		}
+		if n < 1 {
+			return "", fmt.Errorf("E487: Argument must be positive: %s", item)
+		}
+		*num = n
+	}
+
+	if def.apply != nil {
+		def.apply(vh)
+	}
+	vh.editor.updateDisplay()
+	return "", nil
+}
+
+// vimFlagString показывает логическую опцию, как :set имя?
+func vimFlagString(name string, on bool) string {
+	if on {
+		return "  " + name
+	}
+	return "no" + name
+}
+
+// applyLineNumbers показывает или скрывает колонку номеров строк
+func (vh *VimHandler) applyLineNumbers() {
+	e := vh.editor
+	editorLayer := container.NewStack(e.richContent, e.cursorContainer, e.entryWidget, e.indentContainer)
+	if e.config.Editor.ShowLineNumbers || e.config.Editor.ShowRelativeNumbers {
+		e.updateLineNumbers()
+		e.scrollContainer.Content = container.NewBorder(nil, nil, container.NewVBox(e.lineNumbers), nil, editorLayer)
+	} else {
+		e.scrollContainer.Content = editorLayer
+	}
+	e.scrollContainer.Refresh()
+}
+
+// applyWrap переключает перенос строк
+func (vh *VimHandler) applyWrap() {
+	if vh.editor.config.Editor.WordWrap {
+		vh.editor.content.Wrapping = fyne.TextWrapWord
+	} else {
+		vh.editor.content.Wrapping = fyne.TextWrapOff
+	}
+}
+
+// compilePattern компилирует шаблон поиска с учетом ignorecase и smartcase
+func (vh *VimHandler) compilePattern(pattern string) (*regexp.Regexp, error) {
+	if vh.ignoreCase && !(vh.smartCase && strings.IndexFunc(pattern, unicode.IsUpper) >= 0) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// updateSearchHighlight подсвечивает все совпадения последнего поиска (hlsearch)
+func (vh *VimHandler) updateSearchHighlight() {
+	e := vh.editor
+	if !vh.hlsearch || vh.searchPattern == "" {
+		if vh.hlActive {
+			vh.hlActive = false
+			e.searchResults = nil
+			e.applyTokensToRichText()
+		}
+		return
+	}
+	re, err := vh.compilePattern(vh.searchPattern)
+	if err != nil {
+		return
+	}
+	var results []TextRange
+	for _, m := range re.FindAllStringIndex(e.textContent, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+		results = append(results, TextRange{
+			Start: e.indexToPosition(m[0]),
+			End:   e.indexToPosition(m[1]),
+			Text:  e.textContent[m[0]:m[1]],
+		})
+	}
+	vh.hlActive = true
+	e.searchResults = results
+	e.applyTokensToRichText()
+}
+
+// clearSearchHighlight выполняет :nohlsearch - гасит подсветку до следующего поиска
+func (vh *VimHandler) clearSearchHighlight() {
+	if vh.hlActive {
+		vh.hlActive = false
+		vh.editor.searchResults = nil
+		vh.editor.applyTokensToRichText()
+	}
+}
+
+// exMessage показывает сообщение Ex команды (при чтении vimrc - только в журнал)
+func (vh *VimHandler) exMessage(title, text string) {
+	if vh.sourcing {
+		log.Printf("vim: %s", text)
+		return
+	}
+	if win := vimWindow(); win != nil {
+		dialog.ShowInformation(title, text, win)
+	}
+}
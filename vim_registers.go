@@ -0,0 +1,478 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// vimRegister - содержимое регистра Vim; построчный текст хранится без завершающего \n,
+// блочный - строками блока через \n
+type vimRegister struct {
+	Text      string `json:"text"`
+	Linewise  bool   `json:"linewise,omitempty"`
+	Blockwise bool   `json:"blockwise,omitempty"`
+}
+
+// vimUnnamedRegister - безымянный регистр, указывает на последний использованный
+const vimUnnamedRegister = "\""
+
+// vimRegistersFile - файл с именованными регистрами и макросами между сессиями
+const vimRegistersFile = "vim_registers.json"
+
+// vimSpecialKeys - запись специальных клавиш в макросах
+var vimSpecialKeys = map[string]string{
+	"Escape":    "<Esc>",
+	"Return":    "<CR>",
+	"Backspace": "<BS>",
+	"Tab":       "<Tab>",
+	"Delete":    "<Del>",
+	"Up":        "<Up>",
+	"Down":      "<Down>",
+}
+
+// isVimNamedRegister проверяет регистры a-z
+func isVimNamedRegister(name string) bool {
+	return len(name) == 1 && name[0] >= 'a' && name[0] <= 'z'
+}
+
+// isVimAppendRegister проверяет регистры A-Z (дописывание в a-z)
+func isVimAppendRegister(name string) bool {
+	return len(name) == 1 && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// isVimRegisterName проверяет, можно ли выбрать регистр через "x
+func isVimRegisterName(name string) bool {
+	if isVimNamedRegister(name) || isVimAppendRegister(name) {
+		return true
+	}
+	return len(name) == 1 && strings.Contains("0123456789\"-+*_.:%/", name)
+}
+
+// vimKeysToString кодирует последовательность клавиш в текст регистра
+func vimKeysToString(keys []string) string {
+	var sb strings.Builder
+	for _, key := range keys {
+		switch {
+		case vimSpecialKeys[key] != "":
+			sb.WriteString(vimSpecialKeys[key])
+		case strings.HasPrefix(key, "Ctrl+"):
+			sb.WriteString("<C-" + strings.TrimPrefix(key, "Ctrl+") + ">")
+		case key == "<":
+			sb.WriteString("<lt>")
+		default:
+			sb.WriteString(key)
+		}
+	}
+	return sb.String()
+}
+
+// parseVimKeys разбирает текст регистра обратно в клавиши
+func parseVimKeys(text string) []string {
+	var keys []string
+	for i := 0; i < len(text); {
+		if text[i] == '<' {
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				token := text[i : i+end+1]
+				key := ""
+				switch {
+				case token == "<lt>":
+					key = "<"
+				case strings.HasPrefix(token, "<C-") && len(token) > 4:
+					key = "Ctrl+" + token[3:len(token)-1]
+				default:
+					for name, code := range vimSpecialKeys {
+						if code == token {
+							key = name
+						}
+					}
+				}
+				if key != "" {
+					keys = append(keys, key)
+					i += len(token)
+					continue
+				}
+			}
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		keys = append(keys, text[i:i+size])
+		i += size
+	}
+	return keys
+}
+
+// vimInsertedText восстанавливает вставленный текст по клавишам режима вставки
+func vimInsertedText(keys []string) string {
+	var out []rune
+	for _, key := range keys {
+		switch key {
+		case "Return":
+			out = append(out, '\n')
+		case "Tab":
+			out = append(out, '\t')
+		case "Backspace":
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				out = append(out, []rune(key)...)
+			}
+		}
+	}
+	return string(out)
+}
+
+// vimClipboard возвращает системный буфер обмена
+func vimClipboard() fyne.Clipboard {
+	if app := fyne.CurrentApp(); app != nil {
+		return app.Clipboard()
+	}
+	return nil
+}
+
+// setRegister сохраняет текст удаления или копирования в выбранный регистр
+func (vh *VimHandler) setRegister(text string, linewise, yank bool) {
+	vh.storeRegister(vimRegister{Text: text, Linewise: linewise}, yank)
+}
+
+// storeRegister раскладывает регистр по выбранному, нумерованным и безымянному регистрам
+func (vh *VimHandler) storeRegister(reg vimRegister, yank bool) {
+	name := vh.register
+	text, linewise := reg.Text, reg.Linewise
+
+	switch {
+	case name == "_":
+		return
+	case name == "+" || name == "*":
+		if cb := vimClipboard(); cb != nil {
+			if linewise {
+				cb.SetContent(text + "\n")
+			} else {
+				cb.SetContent(text)
+			}
+		}
+	case isVimAppendRegister(name):
+		lower := strings.ToLower(name)
+		if prev, ok := vh.registers[lower]; ok {
+			if prev.Linewise || linewise {
+				reg = vimRegister{Text: prev.Text + "\n" + text, Linewise: true}
+			} else if prev.Blockwise || reg.Blockwise {
+				reg = vimRegister{Text: prev.Text + "\n" + text, Blockwise: true}
+			} else {
+				reg = vimRegister{Text: prev.Text + text}
+			}
+		}
+		vh.registers[lower] = reg
+		vh.saveRegisters()
+	case isVimNamedRegister(name):
+		vh.registers[name] = reg
+		vh.saveRegisters()
+	default:
+		// Безымянный регистр: копирование в "0, удаление строк в "1-"9, мелкое удаление в "-
+		switch {
+		case yank:
+			vh.registers["0"] = reg
+		case linewise || strings.Contains(text, "\n"):
+			for i := 9; i > 1; i-- {
+				if prev, ok := vh.registers[strconv.Itoa(i-1)]; ok {
+					vh.registers[strconv.Itoa(i)] = prev
+				}
+			}
+			vh.registers["1"] = reg
+		default:
+			vh.registers["-"] = reg
+		}
+	}
+	vh.registers[vimUnnamedRegister] = reg
+}
+
+// getRegister возвращает содержимое регистра для вставки
+func (vh *VimHandler) getRegister(name string) (vimRegister, bool) {
+	switch name {
+	case "", vimUnnamedRegister:
+		reg, ok := vh.registers[vimUnnamedRegister]
+		return reg, ok
+	case "+", "*":
+		cb := vimClipboard()
+		if cb == nil {
+			return vimRegister{}, false
+		}
+		text := cb.Content()
+		if strings.HasSuffix(text, "\n") {
+			return vimRegister{Text: strings.TrimSuffix(text, "\n"), Linewise: true}, text != ""
+		}
+		return vimRegister{Text: text}, text != ""
+	case ".":
+		return vimRegister{Text: vh.lastInserted}, vh.lastInserted != ""
+	case ":":
+		return vimRegister{Text: vh.lastExCommand}, vh.lastExCommand != ""
+	case "/":
+		return vimRegister{Text: vh.searchPattern}, vh.searchPattern != ""
+	case "%":
+		return vimRegister{Text: vh.editor.filePath}, vh.editor.filePath != ""
+	case "_":
+		return vimRegister{}, false
+	}
+	reg, ok := vh.registers[strings.ToLower(name)]
+	return reg, ok
+}
+
+// put вставляет регистр после или перед курсором count раз
+func (vh *VimHandler) put(after bool, count int) {
+	reg, ok := vh.getRegister(vh.register)
+	if !ok || (reg.Text == "" && !reg.Linewise) {
+		return
+	}
+	if count < 1 {
+		count = 1
+	}
+	if reg.Blockwise {
+		vh.putBlock(reg, after, count)
+		return
+	}
+
+	text := vh.editor.textContent
+	pos := min(vh.editor.cursorIndex(), len(text))
+
+	if reg.Linewise {
+		block := strings.Repeat(reg.Text+"\n", count)
+		at := vimLineStart(text, pos)
+		if after {
+			at = vimLineEnd(text, pos)
+			if at == len(text) {
+				// Последняя строка без перевода строки
+				block = "\n" + strings.TrimSuffix(block, "\n")
+				vh.replaceText(at, at, block)
+				vh.setCursorNormal(vimFirstNonBlank(vh.editor.textContent, at+1))
+				return
+			}
+			at++
+		}
+		vh.replaceText(at, at, block)
+		vh.setCursorNormal(vimFirstNonBlank(vh.editor.textContent, at))
+		return
+	}
+
+	block := strings.Repeat(reg.Text, count)
+	at := pos
+	if after && pos < vimLineEnd(text, pos) {
+		at = vimNextRune(text, pos)
+	}
+	vh.replaceText(at, at, block)
+	vh.setCursorNormal(vimPrevRune(vh.editor.textContent, at+len(block)))
+}
+
+// recordChange запоминает клавиши изменения для повтора точкой
+func (vh *VimHandler) recordChange(keys []string) {
+	if vh.dotRepeat {
+		return
+	}
+	vh.lastChange = append([]string(nil), keys...)
+	vh.insertRecording = vh.mode == VimInsert
+}
+
+// insertKey применяет клавишу режима вставки при воспроизведении
+func (vh *VimHandler) insertKey(key string) {
+	text := vh.editor.textContent
+	pos := min(vh.editor.cursorIndex(), len(text))
+
+	switch key {
+	case "Return":
+		vh.insertText(pos, pos, "\n")
+		pos++
+	case "Tab":
+		vh.insertText(pos, pos, "\t")
+		pos++
+	case "Backspace":
+		if pos > 0 {
+			prev := vimPrevRune(text, pos)
+			vh.insertText(prev, pos, "")
+			pos = prev
+		}
+	case "Delete":
+		if pos < len(text) {
+			vh.insertText(pos, vimNextRune(text, pos), "")
+		}
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			vh.insertText(pos, pos, key)
+			pos += len(key)
+		}
+	}
+	vh.editor.moveCursorToIndex(pos)
+}
+
+// feedKeys передает обработчику синтетические клавиши (повтор или макрос)
+func (vh *VimHandler) feedKeys(keys []string) {
+	prev := vh.playback
+	vh.playback = true
+	for _, key := range keys {
+		vh.HandleKey(key)
+	}
+	vh.playback = prev
+}
+
+// repeatLastCommand повторяет последнее изменение вместе со вставленным текстом
+func (vh *VimHandler) repeatLastCommand() {
+	keys := vh.lastChange
+	if len(keys) == 0 {
+		return
+	}
+
+	// Счетчик перед точкой заменяет исходный счетчик команды
+	if count := vh.count; count > 0 {
+		i := 0
+		for i < len(keys) && len(keys[i]) == 1 && keys[i][0] >= '0' && keys[i][0] <= '9' && (i > 0 || keys[i] != "0") {
+			i++
+		}
+		var withCount []string
+		for _, d := range strconv.Itoa(count) {
+			withCount = append(withCount, string(d))
+		}
+		keys = append(withCount, keys[i:]...)
+		vh.lastChange = keys
+	}
+
+	vh.resetPending()
+	vh.dotRepeat = true
+	vh.feedKeys(keys)
+	vh.dotRepeat = false
+}
+
+// startRecordingMacro начинает запись клавиш в регистр; нумерованные регистры
+// заняты yank и delete, поэтому макросы пишутся только в a-z (A-Z дописывает)
+func (vh *VimHandler) startRecordingMacro(register string) {
+	if !isVimNamedRegister(register) && !isVimAppendRegister(register) {
+		return
+	}
+	vh.recording = true
+	vh.macroRegister = register
+	vh.macroCommands = []string{}
+}
+
+// stopRecordingMacro сохраняет записанные клавиши в регистр
+func (vh *VimHandler) stopRecordingMacro() {
+	if !vh.recording {
+		return
+	}
+	vh.recording = false
+
+	// Последняя клавиша - q, завершившая запись
+	keys := vh.macroCommands
+	if n := len(keys); n > 0 && keys[n-1] == "q" {
+		keys = keys[:n-1]
+	}
+	vh.macroCommands = nil
+
+	name := vh.macroRegister
+	text := vimKeysToString(keys)
+	if isVimAppendRegister(name) {
+		name = strings.ToLower(name)
+		text = vh.registers[name].Text + text
+	}
+	vh.registers[name] = vimRegister{Text: text}
+	vh.saveRegisters()
+}
+
+// playMacro выполняет содержимое регистра как последовательность клавиш
+func (vh *VimHandler) playMacro(register string, count int) {
+	if register == "@" {
+		register = vh.lastMacro
+	}
+	reg, ok := vh.getRegister(register)
+	if !ok {
+		return
+	}
+	vh.lastMacro = register
+
+	keys := parseVimKeys(reg.Text)
+	if reg.Linewise {
+		keys = append(keys, "Return")
+	}
+	for i := 0; i < max(count, 1); i++ {
+		vh.feedKeys(keys)
+	}
+}
+
+// registersPath возвращает путь к файлу регистров
+func (vh *VimHandler) registersPath() string {
+	return filepath.Join(getConfigDirectory(), vimRegistersFile)
+}
+
+// loadRegisters загружает именованные регистры предыдущей сессии
+func (vh *VimHandler) loadRegisters() {
+	data, err := os.ReadFile(vh.registersPath())
+	if err != nil {
+		return
+	}
+	saved := make(map[string]vimRegister)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Printf("Error loading vim registers: %v", err)
+		return
+	}
+	for name, reg := range saved {
+		if isVimNamedRegister(name) {
+			vh.registers[name] = reg
+		}
+	}
+}
+
+// saveRegisters сохраняет именованные регистры и макросы
+func (vh *VimHandler) saveRegisters() {
+	saved := make(map[string]vimRegister)
+	for name, reg := range vh.registers {
+		if isVimNamedRegister(name) {
+			saved[name] = reg
+		}
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return
+	}
+	path := vh.registersPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Printf("Error saving vim registers: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("Error saving vim registers: %v", err)
+	}
+}
+
+// showRegisters показывает содержимое регистров (:registers)
+func (vh *VimHandler) showRegisters() {
+	names := []string{vimUnnamedRegister}
+	for _, name := range strings.Split("0123456789-abcdefghijklmnopqrstuvwxyz.:%/+", "") {
+		names = append(names, name)
+	}
+
+	var lines []string
+	for _, name := range names {
+		reg, ok := vh.getRegister(name)
+		if !ok {
+			continue
+		}
+		text := strings.ReplaceAll(reg.Text, "\n", "^J")
+		if reg.Linewise {
+			text += "^J"
+		}
+		if len(text) > 60 {
+			text = text[:60] + "..."
+		}
+		lines = append(lines, fmt.Sprintf("\"%s  %s", name, text))
+	}
+	wins := fyne.CurrentApp().Driver().AllWindows()
+	if len(wins) == 0 {
+		return
+	}
+	dialog.ShowInformation("Registers", strings.Join(lines, "\n"), wins[0])
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// whenExpr - разобранное условие привязки ("editorHasSelection && language == go && !vimInsert")
+type whenExpr interface {
+	eval(ck *ContextKeys) bool
+	String() string
+}
+
+// whenConst - литерал true/false
+type whenConst bool
+
+// whenKey - истинность ключа контекста
+type whenKey string
+
+// whenNot - отрицание
+type whenNot struct{ x whenExpr }
+
+// whenAnd, whenOr - цепочки && и ||
+type whenAnd []whenExpr
+type whenOr []whenExpr
+
+// whenCompare - сравнение ключа со значением: ==, !=, =~, <, <=, >, >=
+type whenCompare struct {
+	key   string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (c whenConst) eval(*ContextKeys) bool { return bool(c) }
+func (c whenConst) String() string         { return strconv.FormatBool(bool(c)) }
+
+func (k whenKey) eval(ck *ContextKeys) bool { return whenTruthy(ck.Get(string(k))) }
+func (k whenKey) String() string            { return string(k) }
+
+func (n whenNot) eval(ck *ContextKeys) bool { return !n.x.eval(ck) }
+func (n whenNot) String() string            { return "!" + n.x.String() }
+
+func (a whenAnd) eval(ck *ContextKeys) bool {
+	for _, x := range a {
+		if !x.eval(ck) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a whenAnd) String() string { return joinWhen(a, " && ") }
+
+func (o whenOr) eval(ck *ContextKeys) bool {
+	for _, x := range o {
+		if x.eval(ck) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o whenOr) String() string { return "(" + joinWhen(o, " || ") + ")" }
+
+func joinWhen(list []whenExpr, sep string) string {
+	parts := make([]string, len(list))
+	for i, x := range list {
+		parts[i] = x.String()
+	}
+	return strings.Join(parts, sep)
+}
+
+func (c whenCompare) eval(ck *ContextKeys) bool {
+	value := ck.Get(c.key)
+	switch c.op {
+	case "==":
+		return whenString(value) == c.value
+	case "!=":
+		return whenString(value) != c.value
+	case "=~":
+		return value != nil && c.re.MatchString(whenString(value))
+	}
+
+	// Числовые сравнения; нечисловое значение условие не выполняет
+	left, err1 := strconv.ParseFloat(whenString(value), 64)
+	right, err2 := strconv.ParseFloat(c.value, 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	default:
+		return left >= right
+	}
+}
+
+func (c whenCompare) String() string {
+	if c.op == "=~" {
+		return fmt.Sprintf("%s =~ /%s/", c.key, c.re.String())
+	}
+	return fmt.Sprintf("%s %s %s", c.key, c.op, strconv.Quote(c.value))
+}
+
+// whenTruthy - истинность значения ключа: отсутствующий, false, "" и 0 ложны
+func whenTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	}
+	return true
+}
+
+// whenString приводит значение ключа к строке для сравнения
+func whenString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// parseWhen разбирает условие; пустая строка означает "всегда"
+func parseWhen(src string) (whenExpr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, nil
+	}
+	p := &whenParser{src: src}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("when %q: unexpected %q at %d", src, p.src[p.pos:], p.pos)
+	}
+	return expr, nil
+}
+
+// whenParser - рекурсивный спуск: or := and ('||' and)*, and := unary ('&&' unary)*,
+// unary := '!' unary | '(' or ')' | operand [op operand]
+type whenParser struct {
+	src string
+	pos int
+}
+
+func (p *whenParser) skipSpace() {
+	for p.pos < len(p.src) && strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept пропускает token, если он следующий
+func (p *whenParser) accept(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *whenParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("when %q: %s at %d", p.src, fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *whenParser) parseOr() (whenExpr, error) {
+	var list whenOr
+	for {
+		x, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, x)
+		if !p.accept("||") {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *whenParser) parseAnd() (whenExpr, error) {
+	var list whenAnd
+	for {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, x)
+		if !p.accept("&&") {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *whenParser) parseUnary() (whenExpr, error) {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == '!' && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return whenNot{x}, nil
+	}
+	if p.accept("(") {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf("missing )")
+		}
+		return x, nil
+	}
+
+	key := p.word()
+	if key == "" {
+		return nil, p.errorf("expected context key")
+	}
+	switch key {
+	case "true":
+		return whenConst(true), nil
+	case "false":
+		return whenConst(false), nil
+	}
+
+	for _, op := range []string{"==", "!=", "=~", "<=", ">=", "<", ">"} {
+		if !p.accept(op) {
+			continue
+		}
+		if op == "=~" {
+			re, err := p.regex()
+			if err != nil {
+				return nil, err
+			}
+			return whenCompare{key: key, op: op, re: re}, nil
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return whenCompare{key: key, op: op, value: value}, nil
+	}
+	return whenKey(key), nil
+}
+
+// word читает имя ключа или значение без кавычек
+func (p *whenParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '_' || c == '.' || c == ':' || c == '-' || c == '/' || c == '+' || c == '#' ||
+			c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// value читает правую часть сравнения: 'строку', "строку" или слово
+func (p *whenParser) value() (string, error) {
+	p.skipSpace()
+	if p.pos < len(p.src) && (p.src[p.pos] == '\'' || p.src[p.pos] == '"') {
+		quote := p.src[p.pos]
+		end := strings.IndexByte(p.src[p.pos+1:], quote)
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		value := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	}
+	if value := p.word(); value != "" {
+		return value, nil
+	}
+	return "", p.errorf("expected value")
+}
+
+// regex читает /шаблон/флаги для =~
+func (p *whenParser) regex() (*regexp.Regexp, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '/' {
+		return nil, p.errorf("expected /regex/")
+	}
+	var pattern strings.Builder
+	i := p.pos + 1
+	for ; i < len(p.src) && p.src[i] != '/'; i++ {
+		if p.src[i] == '\\' && i+1 < len(p.src) && p.src[i+1] == '/' {
+			i++
+		}
+		pattern.WriteByte(p.src[i])
+	}
+	if i >= len(p.src) {
+		return nil, p.errorf("unterminated regex")
+	}
+	p.pos = i + 1
+
+	flags := ""
+	for p.pos < len(p.src) && strings.IndexByte("imsu", p.src[p.pos]) >= 0 {
+		if p.src[p.pos] != 'u' {
+			flags += string(p.src[p.pos])
+		}
+		p.pos++
+	}
+	src := pattern.String()
+	if flags != "" {
+		src = "(?" + flags + ")" + src
+	}
+	re, err := regexp.Compile(src)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return re, nil
+}
+
+// Пересечение условий для поиска конфликтов
+
+// whenLiteral - элементарное условие дизъюнктивной нормальной формы
+type whenLiteral struct {
+	key   string
+	op    string // "truthy", "==", "!=" или "opaque" для остальных сравнений
+	value string // для opaque - текст сравнения
+	neg   bool   // только для truthy и opaque
+}
+
+// whenDNFLimit ограничивает размер ДНФ; при переполнении условия считаются пересекающимися
+const whenDNFLimit = 256
+
+// whenDNF раскладывает условие в дизъюнкцию конъюнкций; nil - условие слишком сложное
+func whenDNF(x whenExpr, neg bool) [][]whenLiteral {
+	switch x := x.(type) {
+	case nil:
+		return [][]whenLiteral{{}}
+	case whenConst:
+		if bool(x) != neg {
+			return [][]whenLiteral{{}}
+		}
+		return [][]whenLiteral{}
+	case whenKey:
+		return [][]whenLiteral{{{key: string(x), op: "truthy", neg: neg}}}
+	case whenNot:
+		return whenDNF(x.x, !neg)
+	case whenCompare:
+		switch {
+		case x.op == "==" || x.op == "!=":
+			op := x.op
+			if neg {
+				op = map[string]string{"==": "!=", "!=": "=="}[op]
+			}
+			return [][]whenLiteral{{{key: x.key, op: op, value: x.value}}}
+		default:
+			return [][]whenLiteral{{{key: x.key, op: "opaque", value: x.String(), neg: neg}}}
+		}
+	case whenAnd, whenOr:
+		var list []whenExpr
+		isAnd := false
+		if and, ok := x.(whenAnd); ok {
+			list, isAnd = and, true
+		} else {
+			list = x.(whenOr)
+		}
+		// По закону де Моргана отрицание меняет && и || местами
+		if neg {
+			isAnd = !isAnd
+		}
+		if !isAnd {
+			var out [][]whenLiteral
+			for _, item := range list {
+				terms := whenDNF(item, neg)
+				if terms == nil || len(out)+len(terms) > whenDNFLimit {
+					return nil
+				}
+				out = append(out, terms...)
+			}
+			return out
+		}
+		out := [][]whenLiteral{{}}
+		for _, item := range list {
+			terms := whenDNF(item, neg)
+			if terms == nil || len(out)*len(terms) > whenDNFLimit {
+				return nil
+			}
+			var next [][]whenLiteral
+			for _, a := range out {
+				for _, b := range terms {
+					next = append(next, append(append([]whenLiteral{}, a...), b...))
+				}
+			}
+			out = next
+		}
+		return out
+	}
+	return nil
+}
+
+// whenContradicts сообщает, что два литерала не могут выполняться одновременно
+func whenContradicts(a, b whenLiteral) bool {
+	if a.key != b.key {
+		return false
+	}
+	// Упорядочиваем пару: ==, затем !=, затем truthy
+	rank := map[string]int{"==": 0, "!=": 1, "truthy": 2, "opaque": 3}
+	if rank[a.op] > rank[b.op] {
+		a, b = b, a
+	}
+	switch {
+	case a.op == "truthy" && b.op == "truthy":
+		return a.neg != b.neg
+	case a.op == "opaque" && b.op == "opaque":
+		return a.value == b.value && a.neg != b.neg
+	case a.op == "==" && b.op == "==":
+		return a.value != b.value
+	case a.op == "==" && b.op == "!=":
+		return a.value == b.value
+	case a.op == "==" && b.op == "truthy":
+		// key == '' или key == false несовместимо с истинным ключом, непустое значение - с ложным
+		falsy := a.value == "" || a.value == "false" || a.value == "0"
+		return falsy != b.neg
+	}
+	return false
+}
+
+// whenOverlaps проверяет, могут ли оба условия выполняться одновременно
+func whenOverlaps(a, b whenExpr) bool {
+	left, right := whenDNF(a, false), whenDNF(b, false)
+	if left == nil || right == nil {
+		return true
+	}
+	for _, x := range left {
+		for _, y := range right {
+			if whenSatisfiable(append(append([]whenLiteral{}, x...), y...)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// whenSatisfiable проверяет конъюнкцию на противоречия между литералами
+func whenSatisfiable(terms []whenLiteral) bool {
+	for i := range terms {
+		for j := i + 1; j < len(terms); j++ {
+			if whenContradicts(terms[i], terms[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+)
+
+// workspaceFileExt - расширение файлов рабочей области
+const workspaceFileExt = ".pnws"
+
+// maxRecentProjects ограничивает список недавних рабочих областей
+const maxRecentProjects = 10
+
+// Workspace - рабочая область: несколько корневых папок, переопределения
+// настроек, открытые редакторы и раскладка окна
+type Workspace struct {
+	Folders []WorkspaceFolder `json:"folders"`
+	// Settings имеет ту же структуру, что и config.json, например
+	// {"editor": {"tab_size": 2}}, и накладывается поверх пользовательских настроек
+	Settings json.RawMessage   `json:"settings,omitempty"`
+	Editors  []WorkspaceEditor `json:"editors,omitempty"`
+	Layout   WorkspaceLayout   `json:"layout"`
+
+	path string // файл рабочей области; пусто, пока она не сохранена
+}
+
+// WorkspaceFolder - корневая папка; относительный путь отсчитывается от файла рабочей области
+type WorkspaceFolder struct {
+	Path string `json:"path"`
+}
+
+// WorkspaceEditor - открытый в рабочей области файл и позиция курсора в нем
+type WorkspaceEditor struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Active bool   `json:"active,omitempty"`
+}
+
+// WorkspaceLayout - раскладка окна рабочей области
+type WorkspaceLayout struct {
+	SidebarVisible bool   `json:"sidebar_visible"`
+	MinimapVisible bool   `json:"minimap_visible"`
+	SidebarView    string `json:"sidebar_view,omitempty"`
+	WindowWidth    int    `json:"window_width,omitempty"`
+	WindowHeight   int    `json:"window_height,omitempty"`
+}
+
+// loadWorkspace читает файл рабочей области
+func loadWorkspace(path string) (*Workspace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &Workspace{}
+	if err := json.Unmarshal(data, ws); err != nil {
+		return nil, fmt.Errorf("cannot parse workspace %s: %v", filepath.Base(path), err)
+	}
+	if len(ws.Folders) == 0 {
+		return nil, fmt.Errorf("workspace %s has no folders", filepath.Base(path))
+	}
+	ws.path, _ = filepath.Abs(path)
+	return ws, nil
+}
+
+// save записывает рабочую область; пути внутри ее папки сохраняются относительными
+func (ws *Workspace) save() error {
+	out := *ws
+	out.Folders = make([]WorkspaceFolder, len(ws.Folders))
+	for i, folder := range ws.Folders {
+		out.Folders[i] = WorkspaceFolder{Path: ws.relative(folder.Path)}
+	}
+	out.Editors = make([]WorkspaceEditor, len(ws.Editors))
+	for i, editor := range ws.Editors {
+		editor.Path = ws.relative(editor.Path)
+		out.Editors[i] = editor
+	}
+
+	data, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Пишем через временный файл, чтобы не оставить рабочую область пустой при сбое
+	tmp := ws.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ws.path)
+}
+
+// Name возвращает отображаемое имя рабочей области
+func (ws *Workspace) Name() string {
+	if ws.path == "" {
+		return "Untitled Workspace"
+	}
+	return strings.TrimSuffix(filepath.Base(ws.path), workspaceFileExt)
+}
+
+// resolve приводит путь из файла рабочей области к абсолютному
+func (ws *Workspace) resolve(path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) || ws.path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(ws.path), path)
+}
+
+// relative делает путь относительным, если он лежит рядом с файлом рабочей области
+func (ws *Workspace) relative(path string) string {
+	if ws.path == "" {
+		return path
+	}
+	dir := filepath.Dir(ws.path)
+	if isSubPath(dir, path) {
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// folderPaths возвращает абсолютные пути корневых папок
+func (ws *Workspace) folderPaths() []string {
+	paths := make([]string, 0, len(ws.Folders))
+	for _, folder := range ws.Folders {
+		paths = append(paths, ws.resolve(folder.Path))
+	}
+	return paths
+}
+
+// openWorkspace открывает файл рабочей области
+func (a *App) openWorkspace(path string) {
+	ws, err := loadWorkspace(path)
+	if err != nil {
+		dialog.ShowError(err, a.mainWin)
+		a.removeRecentProject(path)
+		return
+	}
+	a.saveWorkspaceState()
+	a.applyWorkspace(ws)
+	a.addRecentProject(ws.path)
+}
+
+// applyWorkspace восстанавливает корни, настройки, раскладку и редакторы рабочей области
+func (a *App) applyWorkspace(ws *Workspace) {
+	if err := a.configManager.SetOverrides(ws.Settings); err != nil {
+		dialog.ShowError(err, a.mainWin)
+	}
+	a.applyConfigChanges()
+
+	if err := a.sidebar.SetRoots(ws.folderPaths()); err != nil {
+		dialog.ShowError(err, a.mainWin)
+	}
+	a.workspace = ws
+
+	// Раскладка
+	layout := ws.Layout
+	a.sidebar.SetVisible(layout.SidebarVisible)
+	if a.minimap != nil {
+		a.minimap.SetVisible(layout.MinimapVisible)
+	}
+	a.createMainLayout()
+	if layout.SidebarView != "" {
+		a.sidebar.ShowView(layout.SidebarView)
+	}
+	if layout.WindowWidth > 0 && layout.WindowHeight > 0 {
+		a.mainWin.Resize(fyne.NewSize(float32(layout.WindowWidth), float32(layout.WindowHeight)))
+	}
+
+	// Открываем активный редактор, а если он не отмечен - первый
+	var active *WorkspaceEditor
+	for i := range ws.Editors {
+		if active == nil || ws.Editors[i].Active {
+			active = &ws.Editors[i]
+		}
+	}
+	if active != nil {
+		path := ws.resolve(active.Path)
+		if _, err := os.Stat(path); err == nil {
+			a.openSearchMatch(path, active.Line, active.Column, 0)
+		}
+	}
+
+	a.updateTitle()
+}
+
+// captureWorkspace записывает в рабочую область текущие корни, редактор и раскладку
+func (a *App) captureWorkspace(ws *Workspace) {
+	ws.Folders = ws.Folders[:0]
+	for _, root := range a.sidebar.Roots() {
+		ws.Folders = append(ws.Folders, WorkspaceFolder{Path: root})
+	}
+
+	ws.Editors = nil
+	if a.editor != nil && a.editor.filePath != "" {
+		path, _ := filepath.Abs(a.editor.filePath)
+		ws.Editors = append(ws.Editors, WorkspaceEditor{
+			Path:   path,
+			Line:   a.editor.cursorRow + 1,
+			Column: a.editor.cursorCol,
+			Active: true,
+		})
+	}
+
+	size := a.mainWin.Canvas().Size()
+	ws.Layout = WorkspaceLayout{
+		SidebarVisible: a.sidebar.IsVisible(),
+		MinimapVisible: a.minimap != nil && a.minimap.IsVisible(),
+		SidebarView:    a.sidebar.ActiveView(),
+		WindowWidth:    int(size.Width),
+		WindowHeight:   int(size.Height),
+	}
+}
+
+// saveWorkspaceState сохраняет состояние открытой рабочей области в ее файл
+func (a *App) saveWorkspaceState() {
+	if a.workspace == nil || a.workspace.path == "" {
+		return
+	}
+	a.captureWorkspace(a.workspace)
+	if err := a.workspace.save(); err != nil {
+		log.Printf("Failed to save workspace %s: %v", a.workspace.path, err)
+	}
+}
+
+// showOpenWorkspace предлагает выбрать файл рабочей области
+func (a *App) showOpenWorkspace() {
+	a.dialogManager.ShowOpenFileDialogWithFilter([]string{workspaceFileExt}, func(path string) {
+		a.openWorkspace(path)
+	})
+}
+
+// saveWorkspaceAs сохраняет текущие корни и раскладку в новый файл рабочей области
+func (a *App) saveWorkspaceAs() {
+	name := filepath.Base(a.sidebar.GetCurrentPath()) + workspaceFileExt
+	if a.workspace != nil && a.workspace.path != "" {
+		name = filepath.Base(a.workspace.path)
+	}
+
+	a.dialogManager.ShowSaveFileDialogWithName(name, func(path string) {
+		if !strings.HasSuffix(path, workspaceFileExt) {
+			path += workspaceFileExt
+		}
+
+		ws := &Workspace{}
+		if a.workspace != nil {
+			ws.Settings = a.workspace.Settings
+		}
+		ws.path, _ = filepath.Abs(path)
+		a.captureWorkspace(ws)
+		if err := ws.save(); err != nil {
+			dialog.ShowError(fmt.Errorf("cannot save workspace: %v", err), a.mainWin)
+			return
+		}
+
+		a.workspace = ws
+		a.addRecentProject(ws.path)
+		a.updateTitle()
+	})
+}
+
+// addFolderToWorkspace добавляет папку как еще один корень рабочей области
+func (a *App) addFolderToWorkspace() {
+	a.dialogManager.ShowOpenFolderDialog(func(path string) {
+		if err := a.sidebar.AddRoot(path); err != nil {
+			dialog.ShowError(err, a.mainWin)
+			return
+		}
+		if a.workspace == nil {
+			a.workspace = &Workspace{}
+		}
+		a.saveWorkspaceState()
+		a.updateTitle()
+	})
+}
+
+// closeWorkspace сохраняет и закрывает рабочую область, оставляя ее первую папку
+func (a *App) closeWorkspace() {
+	if a.workspace == nil {
+		return
+	}
+	a.saveWorkspaceState()
+	a.workspace = nil
+
+	if err := a.configManager.SetOverrides(nil); err != nil {
+		log.Printf("Failed to clear workspace settings: %v", err)
+	}
+	a.applyConfigChanges()
+	a.sidebar.SetRootPath(a.sidebar.GetCurrentPath())
+	a.updateTitle()
+}
+
+// showRecentWorkspaces показывает недавние рабочие области и папки
+func (a *App) showRecentWorkspaces() {
+	var commands []Command
+	for _, path := range a.config.App.RecentProjects {
+		path := path
+		icon := theme.FolderIcon()
+		name := filepath.Base(path)
+		if strings.HasSuffix(path, workspaceFileExt) {
+			icon = theme.StorageIcon()
+			name = strings.TrimSuffix(name, workspaceFileExt)
+		}
+		commands = append(commands, Command{
+			Name:     name,
+			Shortcut: filepath.Dir(path),
+			Icon:     icon,
+			Action:   func() { a.openRecentProject(path) },
+		})
+	}
+	if len(commands) == 0 {
+		dialog.ShowInformation("Recent Workspaces", "No recent workspaces", a.mainWin)
+		return
+	}
+
+	a.dialogManager.ShowCommandPaletteDialog(commands, func(cmd Command) {
+		cmd.Action()
+	})
+}
+
+// openRecentProject открывает недавнюю рабочую область или папку
+func (a *App) openRecentProject(path string) {
+	if strings.HasSuffix(path, workspaceFileExt) {
+		a.openWorkspace(path)
+		return
+	}
+
+	a.closeWorkspace()
+	if err := a.sidebar.SetRootPath(path); err != nil {
+		dialog.ShowError(err, a.mainWin)
+		a.removeRecentProject(path)
+		return
+	}
+	a.addRecentProject(path)
+}
+
+// addRecentProject поднимает путь в начало списка недавних рабочих областей
+func (a *App) addRecentProject(path string) {
+	projects := []string{path}
+	for _, p := range a.config.App.RecentProjects {
+		if p != path {
+			projects = append(projects, p)
+		}
+	}
+	if len(projects) > maxRecentProjects {
+		projects = projects[:maxRecentProjects]
+	}
+	a.config.App.RecentProjects = projects
+	a.configManager.SaveConfigAsync()
+}
+
+// removeRecentProject убирает недоступный путь из недавних
+func (a *App) removeRecentProject(path string) {
+	projects := a.config.App.RecentProjects[:0]
+	for _, p := range a.config.App.RecentProjects {
+		if p != path {
+			projects = append(projects, p)
+		}
+	}
+	a.config.App.RecentProjects = projects
+	a.configManager.SaveConfigAsync()
+}